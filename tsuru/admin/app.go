@@ -25,7 +25,10 @@ func (c *AppRoutesRebuild) Info() *cmd.Info {
 		Usage:   "app-routes-rebuild <app-name>",
 		Desc: `Rebuild routes for an application.
 This can be used to recover from some failure in the router that caused
-existing routes to be lost.`,
+existing routes to be lost.
+
+The tsuru API doesn't report which routes were added or removed by the
+rebuild yet, so only its success is displayed.`,
 	}
 }
 
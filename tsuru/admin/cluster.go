@@ -103,7 +103,7 @@ func (c *ClusterAdd) Run(ctx *cmd.Context) error {
 		}
 		clus.Clientkey = data
 	}
-	response, err := apiClient.ClusterApi.ClusterCreate(context.TODO(), clus)
+	response, err := apiClient.ClusterApi.ClusterCreate(tsuruHTTP.StreamingContext(context.TODO()), clus)
 	if err != nil {
 		return err
 	}
@@ -466,7 +466,7 @@ func (c *ClusterRemove) Run(ctx *cmd.Context) error {
 	if err != nil {
 		return err
 	}
-	response, err := apiClient.ClusterApi.ClusterDelete(context.TODO(), name)
+	response, err := apiClient.ClusterApi.ClusterDelete(tsuruHTTP.StreamingContext(context.TODO()), name)
 	if err != nil {
 		return err
 	}
@@ -154,7 +154,7 @@ func (p *PlatformAdd) Run(context *cmd.Context) error {
 		return err
 	}
 	request.Header.Add("Content-Type", writer.FormDataContentType())
-	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	response, err := tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
 	if err != nil {
 		return err
 	}
@@ -251,7 +251,7 @@ func (p *PlatformUpdate) Run(context *cmd.Context) error {
 		return err
 	}
 	request.Header.Add("Content-Type", writer.FormDataContentType())
-	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	response, err := tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
 	if err != nil {
 		return err
 	}
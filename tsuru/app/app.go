@@ -26,15 +26,21 @@ func (cmd *AppNameMixIn) AppNameByArgsAndFlag(args []string) (string, error) {
 	return cmd.AppNameByFlag()
 }
 
+// AppNameByFlag returns the app given in the --app flag. Without the flag,
+// the app is guessed from the working directory, as described in GuessName.
 func (cmd *AppNameMixIn) AppNameByFlag() (string, error) {
-	if cmd.appName == "" {
-		return "", errors.Errorf(`The name of the app is required.
+	if cmd.appName != "" {
+		return cmd.appName, nil
+	}
+	if name := GuessName(); name != "" {
+		return name, nil
+	}
+	return "", errors.Errorf(`The name of the app is required.
 
-Use the --app flag to specify it.
+Use the --app flag to specify it, or run the command in a directory with a
+.tsuru/app file or a tsuru git remote.
 
 `)
-	}
-	return cmd.appName, nil
 }
 
 func (cmd *AppNameMixIn) Flags() *gnuflag.FlagSet {
@@ -5,6 +5,9 @@
 package app
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/tsuru/gnuflag"
@@ -27,10 +30,31 @@ var appshortflag = &gnuflag.Flag{
 
 func Test(t *testing.T) { check.TestingT(t) }
 
-type S struct{}
+type S struct {
+	wd           string
+	gitRemoteURL func() (string, error)
+}
 
 var _ = check.Suite(&S{})
 
+func (s *S) SetUpTest(c *check.C) {
+	var err error
+	s.wd, err = os.Getwd()
+	c.Assert(err, check.IsNil)
+	err = os.Chdir(c.MkDir())
+	c.Assert(err, check.IsNil)
+	s.gitRemoteURL = GitRemoteURL
+	GitRemoteURL = func() (string, error) {
+		return "", errors.New("no such remote")
+	}
+}
+
+func (s *S) TearDownTest(c *check.C) {
+	GitRemoteURL = s.gitRemoteURL
+	err := os.Chdir(s.wd)
+	c.Assert(err, check.IsNil)
+}
+
 func (s *S) TestAppNameMixInWithFlagDefined(c *check.C) {
 	g := AppNameMixIn{}
 	g.Flags().Parse(true, []string{"--app", "myapp"})
@@ -70,7 +94,8 @@ func (s *S) TestAppNameMixInWithoutFlagDefinedFails(c *check.C) {
 	c.Assert(err, check.NotNil)
 	c.Assert(err.Error(), check.Equals, `The name of the app is required.
 
-Use the --app flag to specify it.
+Use the --app flag to specify it, or run the command in a directory with a
+.tsuru/app file or a tsuru git remote.
 
 `)
 }
@@ -86,3 +111,63 @@ func (s *S) TestAppNameMixInFlags(c *check.C) {
 	})
 	c.Assert(flags, check.DeepEquals, expected)
 }
+
+func (s *S) TestAppNameMixInFromFile(c *check.C) {
+	err := os.MkdirAll(".tsuru", 0755)
+	c.Assert(err, check.IsNil)
+	err = os.WriteFile(NameFile, []byte("myapp\n"), 0644)
+	c.Assert(err, check.IsNil)
+	g := AppNameMixIn{}
+	g.Flags().Parse(true, []string{})
+	name, err := g.AppNameByFlag()
+	c.Assert(err, check.IsNil)
+	c.Assert(name, check.Equals, "myapp")
+}
+
+func (s *S) TestAppNameMixInFlagOverridesFile(c *check.C) {
+	err := os.MkdirAll(".tsuru", 0755)
+	c.Assert(err, check.IsNil)
+	err = os.WriteFile(NameFile, []byte("myapp"), 0644)
+	c.Assert(err, check.IsNil)
+	g := AppNameMixIn{}
+	g.Flags().Parse(true, []string{"-a", "otherapp"})
+	name, err := g.AppNameByFlag()
+	c.Assert(err, check.IsNil)
+	c.Assert(name, check.Equals, "otherapp")
+}
+
+func (s *S) TestGuessNameFromParentDirectory(c *check.C) {
+	err := os.MkdirAll(filepath.Join(".tsuru"), 0755)
+	c.Assert(err, check.IsNil)
+	err = os.WriteFile(NameFile, []byte("myapp"), 0644)
+	c.Assert(err, check.IsNil)
+	err = os.MkdirAll(filepath.Join("src", "pkg"), 0755)
+	c.Assert(err, check.IsNil)
+	err = os.Chdir(filepath.Join("src", "pkg"))
+	c.Assert(err, check.IsNil)
+	c.Assert(GuessName(), check.Equals, "myapp")
+}
+
+func (s *S) TestGuessNameFileOverridesGitRemote(c *check.C) {
+	GitRemoteURL = func() (string, error) {
+		return "git@tsuru.example.com:remoteapp.git", nil
+	}
+	c.Assert(GuessName(), check.Equals, "remoteapp")
+	err := os.MkdirAll(".tsuru", 0755)
+	c.Assert(err, check.IsNil)
+	err = os.WriteFile(NameFile, []byte("myapp"), 0644)
+	c.Assert(err, check.IsNil)
+	c.Assert(GuessName(), check.Equals, "myapp")
+}
+
+func (s *S) TestNameFromGitURL(c *check.C) {
+	tests := map[string]string{
+		"git@tsuru.example.com:myapp.git":            "myapp",
+		"ssh://git@tsuru.example.com:2222/myapp.git": "myapp",
+		"https://tsuru.example.com/git/myapp.git":    "myapp",
+		"https://tsuru.example.com/git/myapp/":       "myapp",
+	}
+	for remote, expected := range tests {
+		c.Check(nameFromGitURL(remote), check.Equals, expected, check.Commentf("remote %s", remote))
+	}
+}
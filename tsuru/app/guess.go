@@ -0,0 +1,72 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// NameFile is the file, relative to the root of a project, that holds the
+// name of the app deployed from it.
+var NameFile = filepath.Join(".tsuru", "app")
+
+// GitRemoteURL returns the URL of the tsuru git remote of the working
+// directory. It's a variable so tests can replace it.
+var GitRemoteURL = func() (string, error) {
+	out, err := exec.Command("git", "config", "--get", "remote.tsuru.url").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// GuessName returns the name of the app used when the --app flag is not
+// given. The name is looked up, in order:
+//
+//  1. in the .tsuru/app file of the working directory, or of the closest
+//     parent directory that has one;
+//  2. in the URL of the git remote named tsuru, like
+//     git@tsuru.example.com:myapp.git.
+//
+// An empty string is returned when the name can't be found.
+func GuessName() string {
+	if name := nameFromFile(); name != "" {
+		return name
+	}
+	remote, err := GitRemoteURL()
+	if err != nil {
+		return ""
+	}
+	return nameFromGitURL(remote)
+}
+
+func nameFromFile() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, NameFile))
+		if err == nil {
+			return strings.TrimSpace(string(data))
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func nameFromGitURL(remote string) string {
+	remote = strings.TrimSuffix(strings.TrimSpace(remote), "/")
+	if i := strings.LastIndexAny(remote, "/:"); i >= 0 {
+		remote = remote[i+1:]
+	}
+	return strings.TrimSuffix(remote, ".git")
+}
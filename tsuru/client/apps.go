@@ -14,10 +14,14 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"text/template"
 	"time"
 
@@ -31,9 +35,13 @@ import (
 	"github.com/tsuru/tsuru-client/tsuru/formatter"
 	tsuruHTTP "github.com/tsuru/tsuru-client/tsuru/http"
 	"github.com/tsuru/tsuru/cmd"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/exec"
 	apptypes "github.com/tsuru/tsuru/types/app"
+	provTypes "github.com/tsuru/tsuru/types/provision"
 	quotaTypes "github.com/tsuru/tsuru/types/quota"
 	volumeTypes "github.com/tsuru/tsuru/types/volume"
+	terminal "golang.org/x/term"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/duration"
 )
@@ -52,9 +60,18 @@ type AppCreate struct {
 	description string
 	tags        cmd.StringSliceFlag
 	routerOpts  cmd.MapFlag
+	gitRemote   bool
+	skipCheck   bool
 	fs          *gnuflag.FlagSet
 }
 
+// appCreateResult is the response of the API to the creation of an app.
+type appCreateResult struct {
+	Status        string `json:"status"`
+	RepositoryURL string `json:"repository_url"`
+	IP            string `json:"ip"`
+}
+
 type unitSorter struct {
 	Statuses []string
 	Counts   []int
@@ -94,7 +111,7 @@ func newUnitSorter(m map[string]int) *unitSorter {
 func (c *AppCreate) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:  "app-create",
-		Usage: "app create <appname> [platform] [--plan/-p plan name] [--router/-r router name] [--team/-t team owner] [--pool/-o pool name] [--description/-d description] [--tag/-g tag]... [--router-opts key=value]...",
+		Usage: "app create <appname> [platform] [--plan/-p plan name] [--router/-r router name] [--team/-t team owner] [--pool/-o pool name] [--description/-d description] [--tag/-g tag]... [--router-opt key=value]... [--set-git-remote] [--skip-validation]",
 		Desc: `Creates a new app using the given name and platform. For tsuru,
 a platform is provisioner dependent. To check the available platforms, use the
 command [[tsuru platform list]] and to add a platform use the command [[tsuru platform add]].
@@ -134,9 +151,18 @@ description associated.
 
 The [[--tag]] parameter sets a tag to your app. You can set multiple [[--tag]] parameters.
 
-The [[--router-opts]] parameter allow passing custom parameters to the router
-used by the application's plan. The key and values used depends on the router
-implementation.`,
+The [[--router-opt]] parameter allow passing custom parameters to the router
+used by the application's plan, like timeouts. It must be in the key=value
+format and can be used multiple times. The key and values used depends on the
+router implementation. [[--router-opts]] is an alias for it.
+
+The plan and the pool are checked against the ones available before creating
+the app, and the valid names are displayed when they're not found. Use
+[[--skip-validation]] to skip this check.
+
+The repository URL and the address of the app are displayed after it's
+created. The [[--set-git-remote]] flag also adds the repository as the "tsuru"
+git remote of the current directory, which must be a git repository.`,
 		MinArgs: 1,
 	}
 }
@@ -162,7 +188,10 @@ func (c *AppCreate) Flags() *gnuflag.FlagSet {
 		tagMessage := "App tag"
 		c.fs.Var(&c.tags, "tag", tagMessage)
 		c.fs.Var(&c.tags, "g", tagMessage)
+		c.fs.Var(&c.routerOpts, "router-opt", "Router options")
 		c.fs.Var(&c.routerOpts, "router-opts", "Router options")
+		c.fs.BoolVar(&c.gitRemote, "set-git-remote", false, "Add the repository of the app as the tsuru git remote of the current directory")
+		c.fs.BoolVar(&c.skipCheck, "skip-validation", false, "Don't check the plan and the pool before creating the app")
 	}
 	return c.fs
 }
@@ -173,6 +202,15 @@ func (c *AppCreate) Run(context *cmd.Context) error {
 	if len(context.Args) > 1 {
 		platform = context.Args[1]
 	}
+	if c.gitRemote && !insideGitRepository() {
+		return errors.New("the --set-git-remote flag must be used inside a git repository, the app was not created")
+	}
+	if !c.skipCheck {
+		err := c.validate()
+		if err != nil {
+			return err
+		}
+	}
 	v, err := form.EncodeToValues(map[string]interface{}{"routeropts": c.routerOpts})
 	if err != nil {
 		return err
@@ -187,33 +225,117 @@ func (c *AppCreate) Run(context *cmd.Context) error {
 		v.Add("tag", tag)
 	}
 	v.Set("router", c.router)
-	b := strings.NewReader(v.Encode())
-	u, err := config.GetURL("/apps")
+	result, err := createApp(v)
 	if err != nil {
 		return err
 	}
-	request, err := http.NewRequest("POST", u, b)
+	fmt.Fprintf(context.Stdout, "App %q has been created!\n", appName)
+	if result.RepositoryURL != "" {
+		fmt.Fprintf(context.Stdout, "Repository: %s\n", result.RepositoryURL)
+	}
+	if result.IP != "" {
+		fmt.Fprintf(context.Stdout, "Address: %s\n", result.IP)
+	}
+	fmt.Fprintln(context.Stdout, "Use app info to check the status of the app and its units.")
+	if !c.gitRemote {
+		return nil
+	}
+	if result.RepositoryURL == "" {
+		return errors.New("the git remote was not set, the API didn't return the repository of the app")
+	}
+	err = Executor().Execute(exec.ExecuteOptions{
+		Cmd:    "git",
+		Args:   []string{"remote", "add", "tsuru", result.RepositoryURL},
+		Stdout: context.Stdout,
+		Stderr: context.Stderr,
+	})
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to add the tsuru git remote: %w", err)
+	}
+	fmt.Fprintln(context.Stdout, `Git remote "tsuru" added.`)
+	return nil
+}
+
+// validate checks that the plan and the pool of the app exist, so typos are
+// reported with the valid names.
+func (c *AppCreate) validate() error {
+	if c.plan != "" {
+		plans, err := listPlans()
+		if err != nil {
+			return err
+		}
+		names := make([]string, len(plans))
+		for i, p := range plans {
+			names[i] = p.Name
+		}
+		if err := checkNameExists("plan", c.plan, names); err != nil {
+			return err
+		}
+	}
+	if c.pool != "" {
+		pools, err := listPools()
+		if err != nil {
+			return err
+		}
+		names := make([]string, len(pools))
+		for i, p := range pools {
+			names[i] = p.Name
+		}
+		if err := checkNameExists("pool", c.pool, names); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkNameExists(kind, name string, names []string) error {
+	if sliceContains(names, name) {
+		return nil
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("%s %q not found, there are no %ss available", kind, name, kind)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("%s %q not found, the available %ss are: %s", kind, name, kind, strings.Join(names, ", "))
+}
+
+// insideGitRepository reports whether the current directory is inside a git
+// working tree.
+func insideGitRepository() bool {
+	err := Executor().Execute(exec.ExecuteOptions{
+		Cmd:    "git",
+		Args:   []string{"rev-parse", "--is-inside-work-tree"},
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+	})
+	return err == nil
+}
+
+func createApp(v url.Values) (*appCreateResult, error) {
+	u, err := config.GetURL("/apps")
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequest("POST", u, strings.NewReader(v.Encode()))
+	if err != nil {
+		return nil, err
 	}
 	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer response.Body.Close()
 	result, err := io.ReadAll(response.Body)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	out := make(map[string]string)
+	var out appCreateResult
 	err = json.Unmarshal(result, &out)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	fmt.Fprintf(context.Stdout, "App %q has been created!\n", appName)
-	fmt.Fprintln(context.Stdout, "Use app info to check the status of the app and its units.")
-	return nil
+	return &out, nil
 }
 
 type AppUpdate struct {
@@ -229,7 +351,12 @@ func (c *AppUpdate) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:  "app-update",
 		Usage: "app update [-a/--app appname] [--description/-d description] [--plan/-p plan name] [--pool/-o pool] [--team-owner/-t team owner] [--platform/-l platform] [-i/--image-reset] [--cpu cpu] [--memory memory] [--cpu-burst-factor cpu-burst-factor] [--tag/-g tag]...",
-		Desc:  `Updates an app, changing its description, tags, plan or pool information.`,
+		Desc: `Updates an app, changing its description, tags, plan or pool information.
+Several fields can be changed at once, and the progress of the update is
+displayed as it happens.
+
+At least one field must be changed. Fields can't be cleared, so flags like
+[[--description]] and [[--team-owner]] can't be given an empty value.`,
 	}
 }
 
@@ -314,11 +441,18 @@ func (c *AppUpdate) Run(ctx *cmd.Context) error {
 	}
 
 	appName := c.Flags().Lookup("app").Value.String()
+	if appName == "" {
+		appName = tsuruClientApp.GuessName()
+	}
 	if appName == "" {
 		return errors.New("Please use the -a/--app flag to specify which app you want to update.")
 	}
+	err = c.validateChanges()
+	if err != nil {
+		return err
+	}
 
-	response, err := apiClient.AppApi.AppUpdate(context.TODO(), appName, c.args)
+	response, err := apiClient.AppApi.AppUpdate(tsuruHTTP.StreamingContext(context.TODO()), appName, c.args)
 	if err != nil {
 		return err
 	}
@@ -331,6 +465,41 @@ func (c *AppUpdate) Run(ctx *cmd.Context) error {
 	return nil
 }
 
+// appUpdateFieldFlags are the flags of app-update that change a field of
+// the app, by their aliases.
+var appUpdateFieldFlags = map[string]string{
+	"d":           "description",
+	"description": "description",
+	"p":           "plan",
+	"plan":        "plan",
+	"o":           "pool",
+	"pool":        "pool",
+	"t":           "team-owner",
+	"team-owner":  "team-owner",
+	"l":           "platform",
+	"platform":    "platform",
+}
+
+// validateChanges checks the flags before sending the update, as the API
+// ignores empty fields and fails when no field is changed.
+func (c *AppUpdate) validateChanges() error {
+	var emptyFlag string
+	c.Flags().Visit(func(f *gnuflag.Flag) {
+		if name, ok := appUpdateFieldFlags[f.Name]; ok && emptyFlag == "" && f.Value.String() == "" {
+			emptyFlag = name
+		}
+	})
+	if emptyFlag != "" {
+		return fmt.Errorf("the --%s flag can't be empty, the API doesn't support clearing the %s of an app", emptyFlag, strings.ReplaceAll(emptyFlag, "-", " "))
+	}
+	a := c.args
+	if a.Description == "" && a.Plan == "" && a.Pool == "" && a.TeamOwner == "" && a.Platform == "" &&
+		len(a.Tags) == 0 && !a.ImageReset && c.cpu == "" && c.memory == "" && c.cpuBurst == "" {
+		return errors.New("nothing to update, use at least one of the --description, --plan, --pool, --team-owner, --platform, --tag, --image-reset, --cpu, --memory or --cpu-burst-factor flags")
+	}
+	return nil
+}
+
 type AppRemove struct {
 	tsuruClientApp.AppNameMixIn
 	cmd.ConfirmationCommand
@@ -352,6 +521,9 @@ remove it (you are able to remove any app that you see in [[tsuru app list]]).`,
 
 func (c *AppRemove) Run(context *cmd.Context) error {
 	appName := c.Flags().Lookup("app").Value.String()
+	if appName == "" {
+		appName = tsuruClientApp.GuessName()
+	}
 	if appName == "" {
 		return errors.New("Please use the -a/--app flag to specify which app you want to remove.")
 	}
@@ -369,7 +541,7 @@ func (c *AppRemove) Run(context *cmd.Context) error {
 	if err != nil {
 		return err
 	}
-	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	response, err := tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
 	if err != nil {
 		return err
 	}
@@ -386,21 +558,113 @@ func (c *AppRemove) Flags() *gnuflag.FlagSet {
 	return c.fs
 }
 
+type AppUnlock struct {
+	tsuruClientApp.AppNameMixIn
+	cmd.ConfirmationCommand
+	fs *gnuflag.FlagSet
+}
+
+func (c *AppUnlock) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "app-unlock",
+		Usage: "app unlock [-a/--app appname] [-y/--assume-yes]",
+		Desc: `Forcefully removes the lock of an application. Removing the lock of an app
+with an operation in progress, like a deploy, may leave the app in an
+inconsistent state.
+
+The current lock owner, the operation holding the lock and how long it has
+been held are displayed, and the removal must be confirmed, unless
+[[--assume-yes]] is used. Nothing is done when the app is not locked.`,
+		MinArgs: 0,
+		MaxArgs: 0,
+	}
+}
+
+func (c *AppUnlock) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = mergeFlagSet(
+			c.AppNameMixIn.Flags(),
+			c.ConfirmationCommand.Flags(),
+		)
+	}
+	return c.fs
+}
+
+func (c *AppUnlock) Run(context *cmd.Context) error {
+	appName, err := c.AppNameByFlag()
+	if err != nil {
+		return err
+	}
+	a, err := getApp(appName)
+	if err != nil {
+		return err
+	}
+	if !a.Lock.Locked {
+		fmt.Fprintf(context.Stdout, "App %q is not locked.\n", appName)
+		return nil
+	}
+	fmt.Fprintln(context.Stdout, a.Lock.String())
+	if !c.Confirm(context, fmt.Sprintf("Are you sure you want to remove the lock of app %q?", appName)) {
+		return nil
+	}
+	u, err := config.GetURL(fmt.Sprintf("/apps/%s/lock", appName))
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(http.MethodDelete, u, nil)
+	if err != nil {
+		return err
+	}
+	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	fmt.Fprintf(context.Stdout, "Lock of app %q successfully removed.\n", appName)
+	return nil
+}
+
 type AppInfo struct {
 	tsuruClientApp.AppNameMixIn
 
 	json         bool
 	simplified   bool
+	noColor      bool
+	myPerms      bool
+	unitsOnly    bool
+	flat         bool
+	watch        bool
+	interval     time.Duration
 	flagsApplied bool
 }
 
 func (c *AppInfo) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:  "app-info",
-		Usage: "app info [appname]",
+		Usage: "app info [appname] [--units-only] [--flat] [-w/--watch [--interval duration]]",
 		Desc: `Shows information about a specific app. Its state, platform, git repository,
 etc. You need to be a member of a team that has access to the app to be able to
-see information about it.`,
+see information about it.
+
+When the output is a terminal, unit statuses are colored. Use [[--no-color]] or
+set the NO_COLOR environment variable to disable colors.
+
+The [[--my-perms]] flag also shows which common operations, like deploy,
+env-set and scaling, you're allowed to perform on the app.
+
+Units are grouped by process and version. When the app has more than one
+process, the units of each group are counted by status, like
+"Units [process worker]: 3 (2 started, 1 error)". Use [[--flat]] to list all
+units in a single table, with their process in a column.
+
+The [[--units-only]] flag shows only the name, status and host of the units,
+which is handy for health checks in scripts. It can be combined with
+[[--json]], [[--no-color]] and [[--watch]].
+
+The [[--watch]] flag refreshes the information every [[--interval]], clearing
+the screen between refreshes, and lists the latest changes to the status of
+the units. It's useful to follow a deploy, press Ctrl+C to exit. It requires
+the output to be a terminal.`,
 		MinArgs: 0,
 	}
 }
@@ -411,6 +675,13 @@ func (cmd *AppInfo) Flags() *gnuflag.FlagSet {
 		fs.BoolVar(&cmd.simplified, "simplified", false, "Show simplified view of app")
 		fs.BoolVar(&cmd.simplified, "s", false, "Show simplified view of app")
 		fs.BoolVar(&cmd.json, "json", false, "Show JSON view of app")
+		fs.BoolVar(&cmd.noColor, "no-color", false, "No colors in the output")
+		fs.BoolVar(&cmd.myPerms, "my-perms", false, "Show the operations you're allowed to perform on the app")
+		fs.BoolVar(&cmd.unitsOnly, "units-only", false, "Show only the name, status and host of the units")
+		fs.BoolVar(&cmd.flat, "flat", false, "List all units in a single table, instead of grouping them by process")
+		fs.BoolVar(&cmd.watch, "watch", false, "Refresh the information until Ctrl+C is pressed")
+		fs.BoolVar(&cmd.watch, "w", false, "Refresh the information until Ctrl+C is pressed")
+		fs.DurationVar(&cmd.interval, "interval", 5*time.Second, "How often the information is refreshed in watch mode")
 
 		cmd.flagsApplied = true
 	}
@@ -422,28 +693,149 @@ func (c *AppInfo) Run(context *cmd.Context) error {
 	if err != nil {
 		return err
 	}
+	if c.watch {
+		return c.watchApp(context, appName)
+	}
+	a, err := c.fetchApp(appName)
+	if err != nil || a == nil {
+		return err
+	}
+	return c.Show(a, context, c.simplified)
+}
+
+// fetchApp returns the app with the information displayed by app-info, or
+// nil when the API has no content for it.
+func (c *AppInfo) fetchApp(appName string) (*app, error) {
 	u, err := config.GetURL(fmt.Sprintf("/apps/%s", appName))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	request, err := http.NewRequest("GET", u, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if response.StatusCode == http.StatusNoContent {
-		return nil
+		return nil, nil
 	}
 	defer response.Body.Close()
 	var a app
 	err = json.NewDecoder(response.Body).Decode(&a)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if !c.simplified && !c.unitsOnly && a.Deploys > 0 {
+		// The last deploy is only informative, so app-info doesn't fail
+		// when it can't be fetched.
+		a.LastDeploy, _ = lastDeploy(appName)
+	}
+	if c.myPerms {
+		a.MyPermissions, err = appOperationPermissions(&a)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &a, nil
+}
+
+const (
+	clearScreen       = "\033[H\033[2J"
+	maxWatchedChanges = 10
+)
+
+// isTerminalWriter reports whether w is a terminal.
+var isTerminalWriter = func(w io.Writer) bool {
+	f, ok := w.(descriptable)
+	return ok && terminal.IsTerminal(int(f.Fd()))
+}
+
+// waitAppInfoRefresh blocks for the interval, returning false if the user
+// pressed Ctrl+C.
+var waitAppInfoRefresh = func(sigChan <-chan os.Signal, interval time.Duration) bool {
+	select {
+	case <-sigChan:
+		return false
+	case <-time.After(interval):
+		return true
+	}
+}
+
+// watchApp displays the app info every interval, along with the latest
+// changes to the status of the units, until the user presses Ctrl+C.
+func (c *AppInfo) watchApp(context *cmd.Context, appName string) error {
+	if c.json {
+		return errors.New("the --watch flag can't be used with --json")
+	}
+	if c.interval <= 0 {
+		return errors.New("the interval must be greater than zero")
+	}
+	if !isTerminalWriter(context.Stdout) {
+		return errors.New("the --watch flag requires the output to be a terminal, run app-info without it to pipe or redirect the output")
+	}
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	color := useColors(context.Stdout, c.noColor)
+	var previous map[string]string
+	var changes []string
+	for {
+		a, err := c.fetchApp(appName)
+		if err != nil || a == nil {
+			return err
+		}
+		current := unitStatuses(a)
+		if previous != nil {
+			var buf bytes.Buffer
+			printUnitTransitions(&buf, previous, current, color)
+			now := time.Now().Format("15:04:05")
+			for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+				if line != "" {
+					changes = append(changes, now+" "+line)
+				}
+			}
+			if len(changes) > maxWatchedChanges {
+				changes = changes[len(changes)-maxWatchedChanges:]
+			}
+		}
+		previous = current
+		fmt.Fprint(context.Stdout, clearScreen)
+		fmt.Fprintf(context.Stdout, "Every %s: app-info %s (press Ctrl+C to exit)\n\n", c.interval, appName)
+		err = c.Show(a, context, c.simplified)
+		if err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			fmt.Fprintf(context.Stdout, "\nUnit status changes:\n%s\n", strings.Join(changes, "\n"))
+		}
+		if !waitAppInfoRefresh(sigChan, c.interval) {
+			return nil
+		}
+	}
+}
+
+func getApp(appName string) (*app, error) {
+	u, err := config.GetURL(fmt.Sprintf("/apps/%s", appName))
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	var a app
+	err = json.NewDecoder(response.Body).Decode(&a)
+	if err != nil {
+		return nil, err
 	}
-	return c.Show(&a, context, c.simplified)
+	return &a, nil
 }
 
 type unit struct {
@@ -492,6 +884,40 @@ func (u *unit) ReadyAndStatus() string {
 	return u.Status
 }
 
+var unitStatusColors = map[string]string{
+	"ready":    "green",
+	"started":  "green",
+	"created":  "yellow",
+	"building": "yellow",
+	"starting": "yellow",
+	"stopped":  "yellow",
+	"error":    "red",
+	"down":     "red",
+}
+
+// colorUnitStatus paints a unit status, like "started" or
+// "error (CrashLoopBackOff)", with the color of the status.
+func colorUnitStatus(status string, color bool) string {
+	if !color {
+		return status
+	}
+	name := strings.SplitN(status, " ", 2)[0]
+	if c, ok := unitStatusColors[name]; ok {
+		return cmd.Colorfy(status, c, "", "")
+	}
+	return status
+}
+
+// useColors reports whether the output to w may be colored: it must be a
+// terminal and colors must not be disabled by the --no-color flag or by the
+// NO_COLOR environment variable.
+func useColors(w io.Writer, noColor bool) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminalWriter(w)
+}
+
 func (u *unit) Port() string {
 	if len(u.Addresses) == 0 {
 		if u.Address == nil {
@@ -522,12 +948,18 @@ type lock struct {
 	AcquireDate time.Time
 }
 
+var lockNow = time.Now
+
 func (l *lock) String() string {
+	if !l.Locked {
+		return "Lock: unlocked"
+	}
+	age := lockNow().Sub(l.AcquireDate).Round(time.Second)
 	format := `Lock:
- Acquired in: %s
+ Reason: %s
  Owner: %s
- Running: %s`
-	return fmt.Sprintf(format, l.AcquireDate, l.Owner, l.Reason)
+ Acquired at: %s (held for %s)`
+	return fmt.Sprintf(format, l.Reason, l.Owner, formatter.FormatDate(l.AcquireDate), age)
 }
 
 type app struct {
@@ -554,6 +986,12 @@ type app struct {
 	Error       string
 	Routers     []apptypes.AppRouter
 	AutoScale   []tsuru.AutoScaleSpec
+	LastDeploy  *deployInfo `json:",omitempty"`
+
+	MyPermissions []appOperationPermission `json:",omitempty"`
+
+	colorStatus bool
+	flatUnits   bool
 
 	DashboardURL         string
 	InternalAddresses    []appInternalAddress
@@ -634,6 +1072,32 @@ func (a *app) Addr() string {
 	return strings.Join(allAddrs, ", ")
 }
 
+// UnitCount returns the number of units of the app, ignoring placeholder
+// units without an ID.
+func (a *app) UnitCount() int {
+	count := 0
+	for _, u := range a.Units {
+		if u.ID != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// appsByUnits sorts apps by their number of units, the ones with more units
+// first. Apps with the same number of units are sorted by name.
+type appsByUnits []app
+
+func (l appsByUnits) Len() int      { return len(l) }
+func (l appsByUnits) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l appsByUnits) Less(i, j int) bool {
+	ci, cj := l[i].UnitCount(), l[j].UnitCount()
+	if ci == cj {
+		return l[i].Name < l[j].Name
+	}
+	return ci > cj
+}
+
 func (a *app) TagList() string {
 	return strings.Join(a.Tags, ", ")
 }
@@ -709,12 +1173,12 @@ Router:{{if .Router}} {{.Router}}{{if .RouterOpts}} ({{.GetRouterOpts}}){{end}}{
 Teams: {{.TeamList}}
 External Addresses: {{.Addr}}
 Created by: {{.Owner}}
-Deploys: {{.Deploys}}
+Deploys: {{.Deploys}}{{ if .LastDeploy }} (last via {{ .LastDeploy }}){{ end }}
 {{if .Cluster -}}
 Cluster: {{ .Cluster }}
 {{ end -}}
-Pool:{{if .Pool}} {{.Pool}}{{end}}{{if .Lock.Locked}}
-{{.Lock.String}}{{end}}
+Pool:{{if .Pool}} {{.Pool}}{{end}}
+{{.Lock.String}}
 Quota: {{ .QuotaString }}
 `
 
@@ -732,8 +1196,10 @@ func (a *app) String(simplified bool) string {
 
 	if simplified {
 		renderUnitsSummary(&buf, a.Units, a.UnitsMetrics, a.Provisioner)
+	} else if a.flatUnits {
+		renderFlatUnits(&buf, a.Units, a.UnitsMetrics, a.Provisioner, a.colorStatus)
 	} else {
-		renderUnits(&buf, a.Units, a.UnitsMetrics, a.Provisioner)
+		renderUnits(&buf, a.Units, a.UnitsMetrics, a.Provisioner, a.colorStatus)
 	}
 
 	internalAddressesTable := tablecli.NewTable()
@@ -996,13 +1462,14 @@ func renderUnitsSummary(buf *bytes.Buffer, units []unit, metrics []unitMetrics,
 	buf.WriteString(unitsTable.String())
 }
 
-func renderUnits(buf *bytes.Buffer, units []unit, metrics []unitMetrics, provisioner string) {
+func renderUnits(buf *bytes.Buffer, units []unit, metrics []unitMetrics, provisioner string, color bool) {
 	type unitsKey struct {
 		process  string
 		version  int
 		routable bool
 	}
 	groupedUnits := map[unitsKey][]unit{}
+	processes := map[string]bool{}
 	for _, u := range units {
 		routable := false
 		if u.Routable != nil {
@@ -1010,6 +1477,7 @@ func renderUnits(buf *bytes.Buffer, units []unit, metrics []unitMetrics, provisi
 		}
 		key := unitsKey{process: u.ProcessName, version: u.Version, routable: routable}
 		groupedUnits[key] = append(groupedUnits[key], u)
+		processes[u.ProcessName] = true
 	}
 	keys := make([]unitsKey, 0, len(groupedUnits))
 	for key := range groupedUnits {
@@ -1022,12 +1490,7 @@ func renderUnits(buf *bytes.Buffer, units []unit, metrics []unitMetrics, provisi
 		return keys[i].version < keys[j].version
 	})
 
-	var titles []string
-	if provisioner == "kubernetes" {
-		titles = []string{"Name", "Host", "Status", "Restarts", "Age", "CPU", "Memory"}
-	} else {
-		titles = []string{"Name", "Status", "Host", "Port"}
-	}
+	titles := unitTitles(provisioner)
 	mapUnitMetrics := map[string]unitMetrics{}
 	for _, unitMetric := range metrics {
 		mapUnitMetrics[unitMetric.ID] = unitMetric
@@ -1041,31 +1504,14 @@ func renderUnits(buf *bytes.Buffer, units []unit, metrics []unitMetrics, provisi
 		sort.Slice(units, func(i, j int) bool {
 			return units[i].ID < units[j].ID
 		})
+		var statuses []string
 		for _, unit := range units {
 			if unit.ID == "" {
 				continue
 			}
-			var row tablecli.Row
-			if provisioner == "kubernetes" {
-				row = tablecli.Row{
-					unit.ID,
-					unit.Host(),
-					unit.ReadyAndStatus(),
-					countValue(unit.Restarts),
-					translateTimestampSince(unit.CreatedAt),
-					cpuValue(mapUnitMetrics[unit.ID].CPU),
-					memoryValue(mapUnitMetrics[unit.ID].Memory),
-				}
-			} else {
-				row = tablecli.Row{
-					ShortID(unit.ID),
-					unit.Status,
-					unit.Host(),
-					unit.Port(),
-				}
-			}
-
+			row := unitRow(&unit, mapUnitMetrics[unit.ID], provisioner, color)
 			unitsTable.AddRow(row)
+			statuses = append(statuses, unitStatus(&unit, provisioner))
 		}
 		if unitsTable.Rows() > 0 {
 			unitsTable.SortByColumn(2)
@@ -1080,12 +1526,109 @@ func renderUnits(buf *bytes.Buffer, units []unit, metrics []unitMetrics, provisi
 			if key.routable {
 				groupLabel = fmt.Sprintf("%s [routable]", groupLabel)
 			}
-			buf.WriteString(fmt.Sprintf("Units%s: %d\n", groupLabel, unitsTable.Rows()))
+			count := fmt.Sprintf("%d", unitsTable.Rows())
+			// Apps with a single process have a single group, whose
+			// statuses are already easy to read from the table.
+			if len(processes) > 1 {
+				count = fmt.Sprintf("%s (%s)", count, statusRollup(statuses))
+			}
+			buf.WriteString(fmt.Sprintf("Units%s: %s\n", groupLabel, count))
 			buf.WriteString(unitsTable.String())
 		}
 	}
 }
 
+// renderFlatUnits renders all the units in a single table, with their
+// process in a column, instead of grouping them by process and version.
+func renderFlatUnits(buf *bytes.Buffer, units []unit, metrics []unitMetrics, provisioner string, color bool) {
+	mapUnitMetrics := map[string]unitMetrics{}
+	for _, unitMetric := range metrics {
+		mapUnitMetrics[unitMetric.ID] = unitMetric
+	}
+	sorted := make([]unit, len(units))
+	copy(sorted, units)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+	titles := unitTitles(provisioner)
+	unitsTable := tablecli.NewTable()
+	tablecli.TableConfig.ForceWrap = false
+	unitsTable.Headers = append(tablecli.Row{titles[0], "Process"}, titles[1:]...)
+	for _, unit := range sorted {
+		if unit.ID == "" {
+			continue
+		}
+		row := unitRow(&unit, mapUnitMetrics[unit.ID], provisioner, color)
+		unitsTable.AddRow(append(tablecli.Row{row[0], unit.ProcessName}, row[1:]...))
+	}
+	if unitsTable.Rows() > 0 {
+		buf.WriteString("\n")
+		buf.WriteString(fmt.Sprintf("Units: %d\n", unitsTable.Rows()))
+		buf.WriteString(unitsTable.String())
+	}
+}
+
+func unitTitles(provisioner string) []string {
+	if provisioner == "kubernetes" {
+		return []string{"Name", "Host", "Status", "Restarts", "Age", "CPU", "Memory"}
+	}
+	return []string{"Name", "Status", "Host", "Port"}
+}
+
+func unitRow(unit *unit, metrics unitMetrics, provisioner string, color bool) tablecli.Row {
+	if provisioner == "kubernetes" {
+		return tablecli.Row{
+			unit.ID,
+			unit.Host(),
+			colorUnitStatus(unit.ReadyAndStatus(), color),
+			countValue(unit.Restarts),
+			translateTimestampSince(unit.CreatedAt),
+			cpuValue(metrics.CPU),
+			memoryValue(metrics.Memory),
+		}
+	}
+	return tablecli.Row{
+		ShortID(unit.ID),
+		colorUnitStatus(unit.Status, color),
+		unit.Host(),
+		unit.Port(),
+	}
+}
+
+// unitStatus returns the status of the unit as displayed in app-info,
+// without the reason, like "error" for "error (CrashLoopBackOff)".
+func unitStatus(unit *unit, provisioner string) string {
+	status := unit.Status
+	if provisioner == "kubernetes" {
+		status = unit.ReadyAndStatus()
+	}
+	return strings.SplitN(status, " ", 2)[0]
+}
+
+// statusRollup summarizes the given unit statuses, like "2 ready, 1 error",
+// with the most common statuses first.
+func statusRollup(statuses []string) string {
+	counts := map[string]int{}
+	var names []string
+	for _, status := range statuses {
+		if counts[status] == 0 {
+			names = append(names, status)
+		}
+		counts[status]++
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] == counts[names[j]] {
+			return names[i] < names[j]
+		}
+		return counts[names[i]] > counts[names[j]]
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%d %s", counts[name], name)
+	}
+	return strings.Join(parts, ", ")
+}
+
 func renderServiceInstanceBinds(w io.Writer, binds []tsuru.AppServiceInstanceBinds) {
 	sibs := make([]tsuru.AppServiceInstanceBinds, len(binds))
 	copy(sibs, binds)
@@ -1202,24 +1745,72 @@ func translateTimestampSince(timestamp *time.Time) string {
 }
 
 func (c *AppInfo) Show(a *app, context *cmd.Context, simplified bool) error {
+	if c.unitsOnly {
+		return c.showUnits(a, context)
+	}
 	if c.json {
 		return formatter.JSON(context.Stdout, a)
 	}
+	a.colorStatus = useColors(context.Stdout, c.noColor)
+	a.flatUnits = c.flat
 	fmt.Fprintln(context.Stdout, a.String(simplified))
+	if len(a.MyPermissions) > 0 {
+		table := tablecli.NewTable()
+		table.Headers = tablecli.Row{"Operation", "Permission", "Allowed"}
+		for _, p := range a.MyPermissions {
+			allowed := "no"
+			if p.Allowed {
+				allowed = "yes"
+			}
+			table.AddRow(tablecli.Row{p.Operation, p.Permission, allowed})
+		}
+		fmt.Fprintf(context.Stdout, "My permissions:\n%s", table.String())
+	}
 	return nil
 }
 
-type AppGrant struct {
-	tsuruClientApp.AppNameMixIn
+type unitSummary struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Host   string `json:"host"`
 }
 
-func (c *AppGrant) Info() *cmd.Info {
-	return &cmd.Info{
-		Name:  "app-grant",
-		Usage: "app grant <teamname> [-a/--app appname]",
-		Desc: `Allows a team to access an application. You need to be a member of a team that
-has access to the app to allow another team to access it. grants access to an
-app to a team.`,
+// showUnits prints only the name, status and host of the units of the app.
+func (c *AppInfo) showUnits(a *app, context *cmd.Context) error {
+	units := make([]unitSummary, 0, len(a.Units))
+	for i := range a.Units {
+		u := &a.Units[i]
+		units = append(units, unitSummary{Name: u.ID, Status: u.ReadyAndStatus(), Host: u.Host()})
+	}
+	sort.Slice(units, func(i, j int) bool { return units[i].Name < units[j].Name })
+	if c.json {
+		return formatter.JSON(context.Stdout, units)
+	}
+	if len(units) == 0 {
+		fmt.Fprintf(context.Stdout, "App %q has no units.\n", a.Name)
+		return nil
+	}
+	color := useColors(context.Stdout, c.noColor)
+	table := tablecli.NewTable()
+	table.Headers = tablecli.Row{"Name", "Status", "Host"}
+	for _, u := range units {
+		table.AddRow(tablecli.Row{u.Name, colorUnitStatus(u.Status, color), u.Host})
+	}
+	fmt.Fprint(context.Stdout, table.String())
+	return nil
+}
+
+type AppGrant struct {
+	tsuruClientApp.AppNameMixIn
+}
+
+func (c *AppGrant) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "app-grant",
+		Usage: "app grant <teamname> [-a/--app appname]",
+		Desc: `Allows a team to access an application. You need to be a member of a team that
+has access to the app to allow another team to access it. grants access to an
+app to a team.`,
 		MinArgs: 1,
 	}
 }
@@ -1230,20 +1821,25 @@ func (c *AppGrant) Run(context *cmd.Context) error {
 		return err
 	}
 	teamName := context.Args[0]
-	u, err := config.GetURL(fmt.Sprintf("/apps/%s/teams/%s", appName, teamName))
+	err = grantAppAccess(appName, teamName)
 	if err != nil {
 		return err
 	}
-	request, err := http.NewRequest("PUT", u, nil)
+	fmt.Fprintf(context.Stdout, `Team "%s" was added to the "%s" app`+"\n", teamName, appName)
+	return nil
+}
+
+func grantAppAccess(appName, teamName string) error {
+	u, err := config.GetURL(fmt.Sprintf("/apps/%s/teams/%s", appName, teamName))
 	if err != nil {
 		return err
 	}
-	_, err = tsuruHTTP.AuthenticatedClient.Do(request)
+	request, err := http.NewRequest("PUT", u, nil)
 	if err != nil {
 		return err
 	}
-	fmt.Fprintf(context.Stdout, `Team "%s" was added to the "%s" app`+"\n", teamName, appName)
-	return nil
+	_, err = tsuruHTTP.AuthenticatedClient.Do(request)
+	return err
 }
 
 type AppRevoke struct {
@@ -1292,13 +1888,18 @@ type appFilter struct {
 	owner     string
 	pool      string
 	locked    bool
-	status    string
+	status    cmd.StringSliceFlag
 	tags      cmd.StringSliceFlag
 }
 
 func (f *appFilter) queryString() (url.Values, error) {
 	result := make(url.Values)
 	if f.name != "" {
+		// The API matches the name as a regular expression, so invalid
+		// patterns are rejected before the request is sent.
+		if _, err := regexp.Compile(f.name); err != nil {
+			return nil, fmt.Errorf("invalid --name pattern %q: %w", f.name, err)
+		}
 		result.Set("name", f.name)
 	}
 	if f.platform != "" {
@@ -1324,8 +1925,12 @@ func (f *appFilter) queryString() (url.Values, error) {
 	if f.pool != "" {
 		result.Set("pool", f.pool)
 	}
-	if f.status != "" {
-		result.Set("status", f.status)
+	statuses, err := f.statuses()
+	if err != nil {
+		return nil, err
+	}
+	if len(statuses) > 0 {
+		result.Set("status", strings.Join(statuses, ","))
 	}
 	for _, tag := range f.tags {
 		result.Add("tag", tag)
@@ -1333,6 +1938,31 @@ func (f *appFilter) queryString() (url.Values, error) {
 	return result, nil
 }
 
+// appListStatuses are the values accepted by the --status filter of app-list,
+// as parsed by provTypes.ParseUnitStatus. They don't always match the status
+// names, the "succeeded" status is given as "success".
+var appListStatuses = []string{"created", "building", "error", "starting", "started", "stopped", "success"}
+
+// statuses returns the unit statuses given in the --status flags, which can
+// be repeated or have values separated by commas, checking that they're
+// valid.
+func (f *appFilter) statuses() ([]string, error) {
+	var result []string
+	for _, value := range f.status {
+		for _, status := range strings.Split(value, ",") {
+			status = strings.TrimSpace(status)
+			if status == "" {
+				continue
+			}
+			if _, err := provTypes.ParseUnitStatus(status); err != nil {
+				return nil, fmt.Errorf("invalid --status value %q, use one of: %s", status, strings.Join(appListStatuses, ", "))
+			}
+			result = append(result, status)
+		}
+	}
+	return result, nil
+}
+
 func currentUserEmail() (string, error) {
 	apiClient, err := tsuruHTTP.TsuruClientFromEnvironment()
 	if err != nil {
@@ -1346,17 +1976,50 @@ func currentUserEmail() (string, error) {
 }
 
 type AppList struct {
+	outputFlag
 	fs         *gnuflag.FlagSet
 	filter     appFilter
 	simplified bool
+	namesOnly  bool
 	json       bool
+	sortBy     string
+	reverse    bool
+	noColor    bool
+	noSummary  bool
 }
 
 func (c *AppList) Run(context *cmd.Context) error {
+	output, err := c.format(c.json)
+	if err != nil {
+		return err
+	}
+	tmpl, err := c.parseTemplate(output)
+	if err != nil {
+		return err
+	}
+	if tmpl != nil && (c.simplified || c.namesOnly) {
+		return errors.New("the --format flag can't be used with -q or --names-only")
+	}
+	if err = c.checkColumns(output); err != nil {
+		return err
+	}
+	if _, err = c.tableColumns(false); err != nil {
+		return err
+	}
 	qs, err := c.filter.queryString()
 	if err != nil {
 		return err
 	}
+	if c.namesOnly {
+		names, err := appNames(qs)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			fmt.Fprintln(context.Stdout, name)
+		}
+		return nil
+	}
 	if c.simplified {
 		qs.Set("simplified", "true")
 	}
@@ -1373,6 +2036,9 @@ func (c *AppList) Run(context *cmd.Context) error {
 		return err
 	}
 	if response.StatusCode == http.StatusNoContent {
+		if output != outputTable && !c.simplified {
+			return renderOutput(context.Stdout, output, []json.RawMessage{})
+		}
 		return nil
 	}
 	defer response.Body.Close()
@@ -1384,8 +2050,29 @@ func (c *AppList) Run(context *cmd.Context) error {
 }
 
 func (c *AppList) Show(result []byte, context *cmd.Context) error {
+	output, err := c.format(c.json)
+	if err != nil {
+		return err
+	}
+	if output != outputTable && !c.simplified {
+		// The apps are printed as returned by the API, so no field, like
+		// the lock details, is lost when decoding them.
+		var rawApps []json.RawMessage
+		err := json.Unmarshal(result, &rawApps)
+		if err != nil {
+			return err
+		}
+		if rawApps == nil {
+			rawApps = []json.RawMessage{}
+		}
+		return renderOutput(context.Stdout, output, rawApps)
+	}
+	tmpl, err := c.parseTemplate(output)
+	if err != nil {
+		return err
+	}
 	var apps []app
-	err := json.Unmarshal(result, &apps)
+	err = json.Unmarshal(result, &apps)
 	if err != nil {
 		return err
 	}
@@ -1396,65 +2083,141 @@ func (c *AppList) Show(result []byte, context *cmd.Context) error {
 		}
 		return nil
 	}
-	if c.json {
-		return formatter.JSON(context.Stdout, apps)
-	}
-	table.Headers = tablecli.Row([]string{"Application", "Units", "Address"})
-	for _, app := range apps {
-		var summary string
-		if app.Error == "" {
-			unitsStatus := make(map[string]int)
-			for _, unit := range app.Units {
-				if unit.ID != "" {
-					if unit.Ready != nil && *unit.Ready {
-						unitsStatus["ready"]++
-					} else if unit.StatusReason != "" {
-						unitsStatus[unit.Status+" ("+unit.StatusReason+")"]++
-					} else {
-						unitsStatus[unit.Status]++
-					}
-				}
-			}
-			statusText := make([]string, len(unitsStatus))
-			i := 0
-			us := newUnitSorter(unitsStatus)
-			sort.Sort(us)
-			for _, status := range us.Statuses {
-				statusText[i] = fmt.Sprintf("%d %s", unitsStatus[status], status)
-				i++
-			}
-			summary = strings.Join(statusText, "\n")
-		} else {
-			summary = "error fetching units: " + app.Error
+	color := useColors(context.Stdout, c.noColor)
+	sortByUnits := c.sortBy == "units"
+	if c.sortBy != "" && !sortByUnits {
+		return fmt.Errorf("invalid sort option %q, the only supported value is \"units\"", c.sortBy)
+	}
+	if sortByUnits {
+		var sorter sort.Interface = appsByUnits(apps)
+		if c.reverse {
+			sorter = sort.Reverse(sorter)
 		}
-		addrs := strings.Replace(app.Addr(), ", ", "\n", -1)
-		table.AddRow(tablecli.Row([]string{app.Name, summary, addrs}))
+		sort.Sort(sorter)
+	} else if tmpl != nil {
+		sort.SliceStable(apps, func(i, j int) bool {
+			return apps[i].Name < apps[j].Name
+		})
+	}
+	if tmpl != nil {
+		return renderTemplate(context.Stdout, tmpl, apps)
+	}
+	columns, err := c.tableColumns(color)
+	if err != nil {
+		return err
+	}
+	if !sortByUnits {
+		sort.SliceStable(apps, func(i, j int) bool {
+			return apps[i].Name < apps[j].Name
+		})
+	}
+	table.Headers = tablecli.Row(columnHeaders(columns))
+	for i := range apps {
+		table.AddRow(tablecli.Row(columnValues(columns, &apps[i])))
 	}
 	table.LineSeparator = true
-	table.Sort()
 	context.Stdout.Write(table.Bytes())
+	if !c.noSummary && len(apps) > 0 {
+		writeAppListSummary(context.Stdout, apps)
+	}
 	return nil
 }
 
+// tableColumns returns the columns of the app-list table chosen by --columns.
+// By default, the name, units and address of the apps are displayed, with the
+// unit count when sorting by units.
+func (c *AppList) tableColumns(color bool) ([]tableColumn[app], error) {
+	columns := []tableColumn[app]{
+		{name: "name", header: "Application", value: func(a *app) string { return a.Name }},
+		{name: "count", header: "Count", value: func(a *app) string { return strconv.Itoa(a.UnitCount()) }},
+		{name: "units", header: "Units", value: func(a *app) string { return unitsSummary(a, color) }},
+		{name: "address", header: "Address", value: func(a *app) string { return strings.Replace(a.Addr(), ", ", "\n", -1) }},
+		{name: "pool", header: "Pool", value: func(a *app) string { return a.Pool }},
+		{name: "platform", header: "Platform", value: func(a *app) string { return a.Platform }},
+		{name: "team-owner", header: "Team owner", value: func(a *app) string { return a.TeamOwner }},
+		{name: "plan", header: "Plan", value: func(a *app) string { return a.Plan.Name }},
+		{name: "tags", header: "Tags", value: func(a *app) string { return strings.Join(a.Tags, "\n") }},
+	}
+	defaults := []string{"name", "units", "address"}
+	if c.sortBy == "units" {
+		defaults = []string{"name", "count", "units", "address"}
+	}
+	return selectColumns(c.columns, columns, defaults)
+}
+
+// unitsSummary counts the units of the app by status, one status per line.
+func unitsSummary(a *app, color bool) string {
+	if a.Error != "" {
+		return "error fetching units: " + a.Error
+	}
+	unitsStatus := make(map[string]int)
+	for _, unit := range a.Units {
+		if unit.ID != "" {
+			status := unit.ReadyAndStatus()
+			unitsStatus[status]++
+		}
+	}
+	statusText := make([]string, len(unitsStatus))
+	i := 0
+	us := newUnitSorter(unitsStatus)
+	sort.Sort(us)
+	for _, status := range us.Statuses {
+		statusText[i] = fmt.Sprintf("%d %s", unitsStatus[status], colorUnitStatus(status, color))
+		i++
+	}
+	return strings.Join(statusText, "\n")
+}
+
+// writeAppListSummary writes the total of apps and units listed, after the
+// table.
+func writeAppListSummary(w io.Writer, apps []app) {
+	units := 0
+	for i := range apps {
+		units += apps[i].UnitCount()
+	}
+	appsLabel := "apps"
+	if len(apps) == 1 {
+		appsLabel = "app"
+	}
+	unitsLabel := "units"
+	if units == 1 {
+		unitsLabel = "unit"
+	}
+	fmt.Fprintf(w, "Total: %d %s, %d %s\n", len(apps), appsLabel, units, unitsLabel)
+}
+
 func (c *AppList) Flags() *gnuflag.FlagSet {
 	if c.fs == nil {
 		c.fs = gnuflag.NewFlagSet("app-list", gnuflag.ExitOnError)
-		c.fs.StringVar(&c.filter.name, "name", "", "Filter applications by name")
-		c.fs.StringVar(&c.filter.name, "n", "", "Filter applications by name")
+		nameMessage := "Filter applications by name. The value is a substring or regular expression matched against the app name"
+		c.fs.StringVar(&c.filter.name, "name", "", nameMessage)
+		c.fs.StringVar(&c.filter.name, "n", "", nameMessage)
 		c.fs.StringVar(&c.filter.pool, "pool", "", "Filter applications by pool")
 		c.fs.StringVar(&c.filter.pool, "o", "", "Filter applications by pool")
-		c.fs.StringVar(&c.filter.status, "status", "", "Filter applications by unit status. Accepts multiple values separated by commas. Possible values can be: building, created, starting, error, started, stopped, asleep")
-		c.fs.StringVar(&c.filter.status, "s", "", "Filter applications by unit status. Accepts multiple values separated by commas. Possible values can be: building, created, starting, error, started, stopped, asleep")
+		statusMessage := "Filter applications by unit status. Can be used multiple times or with values separated by commas. Possible values are: " + strings.Join(appListStatuses, ", ")
+		c.fs.Var(&c.filter.status, "status", statusMessage)
+		c.fs.Var(&c.filter.status, "s", statusMessage)
 		c.fs.StringVar(&c.filter.platform, "platform", "", "Filter applications by platform")
 		c.fs.StringVar(&c.filter.platform, "p", "", "Filter applications by platform")
 		c.fs.StringVar(&c.filter.teamOwner, "team", "", "Filter applications by team owner")
+		c.fs.StringVar(&c.filter.teamOwner, "team-owner", "", "Filter applications by team owner")
 		c.fs.StringVar(&c.filter.teamOwner, "t", "", "Filter applications by team owner")
-		c.fs.StringVar(&c.filter.owner, "user", "", "Filter applications by owner")
-		c.fs.StringVar(&c.filter.owner, "u", "", "Filter applications by owner")
+		ownerMessage := `Filter applications by owner. Use "me" for the current user`
+		c.fs.StringVar(&c.filter.owner, "user", "", ownerMessage)
+		c.fs.StringVar(&c.filter.owner, "owner", "", ownerMessage)
+		c.fs.StringVar(&c.filter.owner, "u", "", ownerMessage)
 		c.fs.BoolVar(&c.filter.locked, "locked", false, "Filter applications by lock status")
 		c.fs.BoolVar(&c.filter.locked, "l", false, "Filter applications by lock status")
 		c.fs.BoolVar(&c.simplified, "q", false, "Display only applications name")
+		c.fs.BoolVar(&c.namesOnly, "names-only", false, "Display only the applications names, cached for a short time, for shell completion")
 		c.fs.BoolVar(&c.json, "json", false, "Display applications in JSON format")
+		c.addOutputFlag(c.fs)
+		c.addFormatFlag(c.fs)
+		c.addColumnsFlag(c.fs)
+		c.fs.StringVar(&c.sortBy, "sort", "", "Sort applications by the given field. Currently only \"units\" is supported, which lists the apps with more units first")
+		c.fs.BoolVar(&c.reverse, "reverse", false, "Reverse the order defined by --sort")
+		c.fs.BoolVar(&c.noColor, "no-color", false, "No colors in the output")
+		c.fs.BoolVar(&c.noSummary, "no-summary", false, "Don't display the total of apps and units after the table")
 		tagMessage := "Filter applications by tag. Can be used multiple times"
 		c.fs.Var(&c.filter.tags, "tag", tagMessage)
 		c.fs.Var(&c.filter.tags, "g", tagMessage)
@@ -1469,12 +2232,59 @@ func (c *AppList) Info() *cmd.Info {
 		Desc: `Lists all apps that you have access to. App access is controlled by teams. If
 your team has access to an app, then you have access to it.
 
-Flags can be used to filter the list of applications.`,
+Flags can be used to filter the list of applications. The [[--locked]] flag
+lists only the locked apps, and [[--status]] lists only the apps with units in
+the given status, like [[--status error]] to find broken apps. [[--status]]
+can be used multiple times, and the statuses are checked before the request
+is sent.
+
+The [[--team-owner]] (or [[--team]]) and [[--owner]] (or [[--user]]) flags
+filter the apps by the team and the user that own them, [[--platform]] by
+platform and [[--name]] by a substring or regular expression matched against
+the app name. When several filters are given, only the apps matching all of
+them are listed.
+
+The [[--output]] flag prints the applications as JSON or YAML, as returned by
+the tsuru API, including their units, cnames, addresses and lock information,
+which is useful to detect locked apps in scripts. [[--json]] is the same as
+[[--output json]].
+
+The [[--sort]] flag orders the list by the given field. Use [[--sort units]] to
+list the apps with more units first, alongside their unit count, and
+[[--reverse]] to invert that order. Without [[--sort]], apps are listed by
+name.
+
+The [[--columns]] flag selects which columns of the table are displayed, and in
+which order, like [[--columns name,pool,units]]. The valid columns are name,
+count, units, address, pool, platform, team-owner, plan and tags.
+
+The table is followed by the total of apps and units listed. Use
+[[--no-summary]] to hide it.
+
+When the output is a terminal, unit statuses are colored. Use [[--no-color]] or
+set the NO_COLOR environment variable to disable colors.
+
+The [[--names-only]] flag prints only the sorted app names, one per line, for
+shell completion scripts. The names are cached in [[$HOME/.tsuru/cache]] for
+30 seconds, like the ones used to complete app names, so completing them again
+doesn't send a new request. Commands that change anything clear the cache.
+Use the global [[--no-cache]] flag or set the TSURU_NO_CACHE environment
+variable to always request them. The other listings always request the apps.
+
+The [[--format]] flag renders each app with the given Go template instead of
+the table, like [[--format '{{.Name}} {{.Pool}}']]. The template gets the
+fields of the app, like Name, Pool, Platform, TeamOwner, Plan, Tags and Units,
+and methods like UnitCount and Addr. The join function joins a list, like
+[[{{join .Tags ","}}]]. Apps are rendered in name order, or in the order
+defined by [[--sort]].`,
 	}
 }
 
 type AppStop struct {
 	tsuruClientApp.AppNameMixIn
+	cmd.ConfirmationCommand
+	ciOutput
+	poolFlags
 	process string
 	version string
 	fs      *gnuflag.FlagSet
@@ -1482,37 +2292,43 @@ type AppStop struct {
 
 func (c *AppStop) Info() *cmd.Info {
 	return &cmd.Info{
-		Name:    "app-stop",
-		Usage:   "app stop [appname] [-p/--process processname] [--version version]",
-		Desc:    "Stops an application, or one of the processes of the application.",
+		Name:  "app-stop",
+		Usage: "app stop [appname] [-p/--process processname] [--version version] [--all-in-pool pool [--concurrency N] [--continue-on-error] [-y/--assume-yes]] [--ci/--quiet]",
+		Desc: `Stops an application, or one of the processes of the application.
+
+The [[--all-in-pool]] flag stops all applications running in the given pool,
+which is useful during maintenance windows. The apps are stopped one at a
+time by default, use [[--concurrency]] to stop more apps at the same time. A
+line is displayed as each app finishes, and the command stops on the first
+failure unless [[--continue-on-error]] is used. The number of affected apps
+must be confirmed before proceeding, unless [[--assume-yes]] is used.`,
 		MinArgs: 0,
 	}
 }
 
 func (c *AppStop) Run(context *cmd.Context) error {
 	context.RawOutput()
+	if c.allInPool != "" {
+		if err := c.checkNoApp(context, &c.AppNameMixIn); err != nil {
+			return err
+		}
+		return c.batch("stop", "stopped").run(context, c.allInPool, &c.ConfirmationCommand, func(appName string) error {
+			return runAppAction(appName, "stop", c.process, c.version)
+		})
+	}
 	appName, err := c.AppNameByArgsAndFlag(context.Args)
 	if err != nil {
 		return err
 	}
-	u, err := config.GetURL(fmt.Sprintf("/apps/%s/stop", appName))
+	err = announceProcess(c.progressWriter(context.Stdout), appName, c.process, "Stopping")
 	if err != nil {
 		return err
 	}
-	qs := url.Values{}
-	qs.Set("process", c.process)
-	qs.Set("version", c.version)
-	body := strings.NewReader(qs.Encode())
-	request, err := http.NewRequest("POST", u, body)
+	response, err := requestAppAction(appName, "stop", c.process, c.version)
 	if err != nil {
 		return err
 	}
-	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
-	if err != nil {
-		return err
-	}
-	return formatter.StreamJSONResponse(context.Stdout, response)
+	return c.stream(context.Stdout, response)
 }
 
 func (c *AppStop) Flags() *gnuflag.FlagSet {
@@ -1521,12 +2337,100 @@ func (c *AppStop) Flags() *gnuflag.FlagSet {
 		c.fs.StringVar(&c.process, "process", "", "Process name")
 		c.fs.StringVar(&c.process, "p", "", "Process name")
 		c.fs.StringVar(&c.version, "version", "", "Version number")
+		c.addFlags(c.fs)
+		c.addPoolFlags(c.fs, "stopped")
+		c.fs = mergeFlagSet(c.fs, c.ConfirmationCommand.Flags())
 	}
 	return c.fs
 }
 
+// poolFlags are the flags of commands that can act on all the apps of a pool,
+// like app-stop and app-start.
+type poolFlags struct {
+	allInPool       string
+	concurrency     int
+	continueOnError bool
+}
+
+func (f *poolFlags) addPoolFlags(fs *gnuflag.FlagSet, past string) {
+	fs.StringVar(&f.allInPool, "all-in-pool", "", "The pool whose apps will all be "+past)
+	fs.IntVar(&f.concurrency, "concurrency", 1, "How many apps of the pool are "+past+" at the same time")
+	fs.BoolVar(&f.continueOnError, "continue-on-error", false, "Keep going with the remaining apps of the pool when an app fails")
+}
+
+func (f *poolFlags) batch(verb, past string) *poolBatch {
+	return &poolBatch{
+		concurrency:     f.concurrency,
+		continueOnError: f.continueOnError,
+		verb:            verb,
+		past:            past,
+	}
+}
+
+// checkNoApp returns an error if an app was given along with --all-in-pool.
+func (f *poolFlags) checkNoApp(context *cmd.Context, mixin *tsuruClientApp.AppNameMixIn) error {
+	if len(context.Args) > 0 || mixin.Flags().Lookup("app").Value.String() != "" {
+		return errors.New("the --all-in-pool flag can't be used with an app name")
+	}
+	return nil
+}
+
+// runAppAction runs an action on the processes of the app, discarding the
+// streamed messages.
+func runAppAction(appName, action, process, version string) error {
+	response, err := requestAppAction(appName, action, process, version)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	return formatter.StreamJSONResponse(io.Discard, response)
+}
+
+// announceProcess prints which processes of the app the action applies to.
+// When a process is given, it must be one of the processes of the app.
+func announceProcess(w io.Writer, appName, process, action string) error {
+	if process != "" {
+		if err := checkAppProcess(appName, process); err != nil {
+			return err
+		}
+	}
+	printProcessAction(w, process, action)
+	return nil
+}
+
+func printProcessAction(w io.Writer, process, action string) {
+	if process == "" {
+		fmt.Fprintf(w, "%s all processes.\n", action)
+		return
+	}
+	fmt.Fprintf(w, "%s process %s.\n", action, process)
+}
+
+// checkAppProcess returns an error when the app doesn't have the process.
+func checkAppProcess(appName, process string) error {
+	a, err := getApp(appName)
+	if err != nil {
+		return err
+	}
+	return checkProcess(a, process)
+}
+
+// checkProcess returns an error when the app doesn't have the process.
+func checkProcess(a *app, process string) error {
+	processes := appProcessNames(a)
+	if sliceContains(processes, process) {
+		return nil
+	}
+	if len(processes) == 0 {
+		return fmt.Errorf("process %q not found, app %q has no processes", process, a.Name)
+	}
+	return fmt.Errorf("process %q not found in app %q, the available processes are: %s", process, a.Name, strings.Join(processes, ", "))
+}
+
 type AppStart struct {
 	tsuruClientApp.AppNameMixIn
+	ciOutput
+	poolFlags
 	process string
 	version string
 	fs      *gnuflag.FlagSet
@@ -1534,37 +2438,42 @@ type AppStart struct {
 
 func (c *AppStart) Info() *cmd.Info {
 	return &cmd.Info{
-		Name:    "app-start",
-		Usage:   "app start [appname] [-p/--process processname] [--version version]",
-		Desc:    "Starts an application, or one of the processes of the application.",
+		Name:  "app-start",
+		Usage: "app start [appname] [-p/--process processname] [--version version] [--all-in-pool pool [--concurrency N] [--continue-on-error]] [--ci/--quiet]",
+		Desc: `Starts an application, or one of the processes of the application.
+
+The [[--all-in-pool]] flag starts all applications running in the given pool,
+like after a maintenance window in which they were stopped with app stop. The
+apps are started one at a time by default, use [[--concurrency]] to start more
+apps at the same time. A line is displayed as each app finishes, and the
+command stops on the first failure unless [[--continue-on-error]] is used.`,
 		MinArgs: 0,
 	}
 }
 
 func (c *AppStart) Run(context *cmd.Context) error {
 	context.RawOutput()
-	appName, err := c.AppNameByArgsAndFlag(context.Args)
-	if err != nil {
-		return err
+	if c.allInPool != "" {
+		if err := c.checkNoApp(context, &c.AppNameMixIn); err != nil {
+			return err
+		}
+		return c.batch("start", "started").run(context, c.allInPool, nil, func(appName string) error {
+			return runAppAction(appName, "start", c.process, c.version)
+		})
 	}
-	u, err := config.GetURL(fmt.Sprintf("/apps/%s/start", appName))
+	appName, err := c.AppNameByArgsAndFlag(context.Args)
 	if err != nil {
 		return err
 	}
-	qs := url.Values{}
-	qs.Set("process", c.process)
-	qs.Set("version", c.version)
-	body := strings.NewReader(qs.Encode())
-	request, err := http.NewRequest("POST", u, body)
+	err = announceProcess(c.progressWriter(context.Stdout), appName, c.process, "Starting")
 	if err != nil {
 		return err
 	}
-	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	response, err := requestAppAction(appName, "start", c.process, c.version)
 	if err != nil {
 		return err
 	}
-	return formatter.StreamJSONResponse(context.Stdout, response)
+	return c.stream(context.Stdout, response)
 }
 
 func (c *AppStart) Flags() *gnuflag.FlagSet {
@@ -1573,14 +2482,19 @@ func (c *AppStart) Flags() *gnuflag.FlagSet {
 		c.fs.StringVar(&c.process, "process", "", "Process name")
 		c.fs.StringVar(&c.process, "p", "", "Process name")
 		c.fs.StringVar(&c.version, "version", "", "Version number")
+		c.addFlags(c.fs)
+		c.addPoolFlags(c.fs, "started")
 	}
 	return c.fs
 }
 
 type AppRestart struct {
 	tsuruClientApp.AppNameMixIn
+	ciOutput
 	process string
 	version string
+	at      string
+	in      time.Duration
 	fs      *gnuflag.FlagSet
 }
 
@@ -1590,31 +2504,63 @@ func (c *AppRestart) Run(context *cmd.Context) error {
 	if err != nil {
 		return err
 	}
-	u, err := config.GetURL(fmt.Sprintf("/apps/%s/restart", appName))
+	target, err := c.scheduledTime(time.Now())
+	if err != nil {
+		return err
+	}
+	if c.process != "" {
+		err = checkAppProcess(appName, c.process)
+		if err != nil {
+			return err
+		}
+	}
+	if !target.IsZero() {
+		err = waitRestart(context.Stdout, appName, target)
+		if err != nil {
+			return err
+		}
+	}
+	printProcessAction(c.progressWriter(context.Stdout), c.process, "Restarting")
+	response, err := requestAppRestart(appName, c.process, c.version)
 	if err != nil {
 		return err
 	}
+	return c.stream(context.Stdout, response)
+}
+
+func requestAppRestart(appName, process, version string) (*http.Response, error) {
+	return requestAppAction(appName, "restart", process, version)
+}
+
+// requestAppAction sends a streaming request for an action on the processes
+// of the app, like "stop", "start" or "restart".
+func requestAppAction(appName, action, process, version string) (*http.Response, error) {
+	u, err := config.GetURL(fmt.Sprintf("/apps/%s/%s", appName, action))
+	if err != nil {
+		return nil, err
+	}
 	qs := url.Values{}
-	qs.Set("process", c.process)
-	qs.Set("version", c.version)
+	qs.Set("process", process)
+	qs.Set("version", version)
 	body := strings.NewReader(qs.Encode())
 	request, err := http.NewRequest("POST", u, body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
-	if err != nil {
-		return err
-	}
-	return formatter.StreamJSONResponse(context.Stdout, response)
+	return tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
 }
 
 func (c *AppRestart) Info() *cmd.Info {
 	return &cmd.Info{
-		Name:    "app-restart",
-		Usage:   "app restart [appname] [-p/--process processname] [--version version]",
-		Desc:    `Restarts an application, or one of the processes of the application.`,
+		Name:  "app-restart",
+		Usage: "app restart [appname] [-p/--process processname] [--version version] [--at time | --in duration] [--ci/--quiet]",
+		Desc: `Restarts an application, or one of the processes of the application.
+
+The restart can be scheduled with [[--at]], which takes a time in the RFC 3339
+format, like 2024-01-01T02:00:00Z, or with [[--in]], which takes a duration,
+like 2h. The command waits until the given time, displaying a countdown, and
+then restarts the app. Press Ctrl+C to cancel the scheduled restart.`,
 		MinArgs: 0,
 	}
 }
@@ -1625,16 +2571,109 @@ func (c *AppRestart) Flags() *gnuflag.FlagSet {
 		c.fs.StringVar(&c.process, "process", "", "Process name")
 		c.fs.StringVar(&c.process, "p", "", "Process name")
 		c.fs.StringVar(&c.version, "version", "", "Version number")
+		c.fs.StringVar(&c.at, "at", "", "Restart the app at the given time, in the RFC 3339 format")
+		c.fs.DurationVar(&c.in, "in", 0, "Restart the app after the given duration")
+		c.addFlags(c.fs)
 	}
 	return c.fs
 }
 
+// scheduledTime returns the time the restart is scheduled to, or the zero
+// time if it should happen right away.
+func (c *AppRestart) scheduledTime(now time.Time) (time.Time, error) {
+	if c.at != "" && c.in != 0 {
+		return time.Time{}, errors.New("the --at and --in flags are mutually exclusive")
+	}
+	if c.in < 0 {
+		return time.Time{}, errors.New("the duration of --in must not be negative")
+	}
+	if c.in > 0 {
+		return now.Add(c.in), nil
+	}
+	if c.at == "" {
+		return time.Time{}, nil
+	}
+	target, err := time.Parse(time.RFC3339, c.at)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid value %q for --at, use a time like 2006-01-02T15:04:05Z", c.at)
+	}
+	if target.Before(now) {
+		return time.Time{}, fmt.Errorf("the time %s is in the past", c.at)
+	}
+	return target, nil
+}
+
+// waitRestart blocks until the target time, displaying a countdown. It
+// returns an error if the user cancels the restart.
+var waitRestart = func(w io.Writer, appName string, target time.Time) error {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		remaining := time.Until(target)
+		if remaining <= 0 {
+			fmt.Fprintln(w)
+			return nil
+		}
+		fmt.Fprintf(w, "\rRestarting app %q in %s (press Ctrl+C to cancel)... ", appName, remaining.Round(time.Second))
+		select {
+		case <-sigChan:
+			fmt.Fprintln(w)
+			return errors.New("scheduled restart canceled")
+		case <-ticker.C:
+		}
+	}
+}
+
 type CnameAdd struct {
 	tsuruClientApp.AppNameMixIn
+	fs             *gnuflag.FlagSet
+	ignoreExisting bool
 }
 
 func (c *CnameAdd) Run(context *cmd.Context) error {
-	err := addCName(context.Args, c.AppNameMixIn)
+	if !c.ignoreExisting {
+		err := addCName(context.Args, c.AppNameMixIn)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(context.Stdout, "cname successfully defined.")
+		return nil
+	}
+	appName, err := c.AppNameByFlag()
+	if err != nil {
+		return err
+	}
+	err = addAppCNames(appName, context.Args)
+	if err == nil {
+		fmt.Fprintln(context.Stdout, "cname successfully defined.")
+		return nil
+	}
+	if !isCNameError(err, cnameExistsRE) {
+		return err
+	}
+	current, currentErr := appCNames(appName)
+	if currentErr != nil {
+		return err
+	}
+	defined := map[string]bool{}
+	for _, cname := range current {
+		defined[cname] = true
+	}
+	var missing []string
+	for _, cname := range context.Args {
+		if defined[cname] {
+			fmt.Fprintf(context.Stdout, "cname %s is already defined in app %q, skipping.\n", cname, appName)
+			continue
+		}
+		missing = append(missing, cname)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	err = addAppCNames(appName, missing)
 	if err != nil {
 		return err
 	}
@@ -1642,24 +2681,86 @@ func (c *CnameAdd) Run(context *cmd.Context) error {
 	return nil
 }
 
+func (c *CnameAdd) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = c.AppNameMixIn.Flags()
+		c.fs.BoolVar(&c.ignoreExisting, "ignore-existing", false, "Succeed when the cnames are already defined in the app")
+	}
+	return c.fs
+}
+
+// cnameExistsRE and cnameMissingRE match the errors returned by the API when
+// a cname is already defined in the app and when it isn't defined, whose text
+// differs between tsuru versions. Cnames of other apps are not matched, they
+// are real conflicts.
+var (
+	cnameExistsRE  = regexp.MustCompile(`(?i)cname (\S+ )?already exists( for this app|$| \()`)
+	cnameMissingRE = regexp.MustCompile(`(?i)cname (\S+ )?(not exists|does not exist)`)
+)
+
+// isCNameError reports whether err is an error of the API about cnames whose
+// message matches re.
+func isCNameError(err error, re *regexp.Regexp) bool {
+	httpErr, ok := tsuruHTTP.UnwrapErr(err).(*tsuruErrors.HTTP)
+	if !ok {
+		return false
+	}
+	switch httpErr.Code {
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError:
+		return re.MatchString(strings.TrimSpace(httpErr.Message))
+	}
+	return false
+}
+
 func (c *CnameAdd) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:  "cname-add",
-		Usage: "cname add <cname> [<cname> ...] [-a/--app appname]",
+		Usage: "cname add <cname> [<cname> ...] [-a/--app appname] [--ignore-existing]",
 		Desc: `Adds a new CNAME to the application.
 
 It will not manage any DNS register, it's up to the user to create the DNS
-register. Once the app contains a custom CNAME, it will be displayed by "app list" and "app info".`,
+register. Once the app contains a custom CNAME, it will be displayed by "app list" and "app info".
+
+Malformed CNAMEs, like the ones with spaces, without a dot or with empty
+labels, are rejected before any change is made.
+
+The [[--ignore-existing]] flag makes the command succeed when CNAMEs are
+already defined in the app, adding only the missing ones, so it can be run
+repeatedly, like in infrastructure as code. CNAMEs defined in other apps are
+still reported as errors.`,
 		MinArgs: 1,
 	}
 }
 
 type CnameRemove struct {
 	tsuruClientApp.AppNameMixIn
+	cmd.ConfirmationCommand
+	fs            *gnuflag.FlagSet
+	checkDNS      bool
+	ignoreMissing bool
 }
 
 func (c *CnameRemove) Run(context *cmd.Context) error {
+	if c.checkDNS {
+		appName, err := c.AppNameByFlag()
+		if err != nil {
+			return err
+		}
+		live, err := liveCNames(appName, context.Args)
+		if err != nil {
+			return err
+		}
+		if len(live) > 0 {
+			fmt.Fprintf(context.Stdout, "WARNING: the following cnames currently resolve to app %q, removing them will affect live traffic: %s\n", appName, strings.Join(live, ", "))
+			if c.Flags().Lookup("assume-yes").Value.String() != "true" && !confirmDomains(context, live) {
+				return nil
+			}
+		}
+	}
 	err := unsetCName(context.Args, c.AppNameMixIn)
+	if err != nil && c.ignoreMissing && isCNameError(err, cnameMissingRE) {
+		err = c.removePresent(context)
+	}
 	if err != nil {
 		return err
 	}
@@ -1667,18 +2768,131 @@ func (c *CnameRemove) Run(context *cmd.Context) error {
 	return nil
 }
 
+// removePresent removes the cnames that are defined in the app, skipping the
+// ones that aren't, as enabled by --ignore-missing.
+func (c *CnameRemove) removePresent(context *cmd.Context) error {
+	appName, err := c.AppNameByFlag()
+	if err != nil {
+		return err
+	}
+	current, err := appCNames(appName)
+	if err != nil {
+		return err
+	}
+	defined := map[string]bool{}
+	for _, cname := range current {
+		defined[cname] = true
+	}
+	var present []string
+	for _, cname := range context.Args {
+		if !defined[cname] {
+			fmt.Fprintf(context.Stdout, "cname %s is not defined in app %q, skipping.\n", cname, appName)
+			continue
+		}
+		present = append(present, cname)
+	}
+	if len(present) == 0 {
+		return nil
+	}
+	return unsetCName(present, c.AppNameMixIn)
+}
+
 func (c *CnameRemove) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:  "cname-remove",
-		Usage: "cname remove <cname> [<cname> ...] [-a/--app appname]",
+		Usage: "cname remove <cname> [<cname> ...] [-a/--app appname] [--check-dns] [--ignore-missing] [-y/--assume-yes]",
 		Desc: `Removes a CNAME from the application. This undoes the change that cname-add
 does.
 
-After unsetting the CNAME from the app, [[tsuru app list]] and [[tsuru app info]] will display the internal, unfriendly address that tsuru uses.`,
+After unsetting the CNAME from the app, [[tsuru app list]] and [[tsuru app info]] will display the internal, unfriendly address that tsuru uses.
+
+The [[--check-dns]] flag resolves each CNAME before removing it. When a CNAME
+currently resolves to the app, removing it will affect live traffic, so the
+domain must be typed again to confirm the removal, unless [[--assume-yes]] is
+used.
+
+The [[--ignore-missing]] flag makes the command succeed when CNAMEs are not
+defined in the app, removing only the ones that are, so it can be run
+repeatedly, like in infrastructure as code.`,
 		MinArgs: 1,
 	}
 }
 
+func (c *CnameRemove) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = mergeFlagSet(
+			c.AppNameMixIn.Flags(),
+			c.ConfirmationCommand.Flags(),
+		)
+		c.fs.BoolVar(&c.checkDNS, "check-dns", false, "Check whether the cnames resolve to the app before removing them")
+		c.fs.BoolVar(&c.ignoreMissing, "ignore-missing", false, "Succeed when the cnames are not defined in the app")
+	}
+	return c.fs
+}
+
+var lookupHost = net.LookupHost
+
+// liveCNames returns the cnames that currently resolve to one of the
+// addresses of the app.
+func liveCNames(appName string, cnames []string) ([]string, error) {
+	a, err := getApp(appName)
+	if err != nil {
+		return nil, err
+	}
+	var appHosts []string
+	if a.IP != "" {
+		appHosts = append(appHosts, a.IP)
+	}
+	for _, r := range a.Routers {
+		appHosts = append(appHosts, r.Addresses...)
+		if r.Address != "" {
+			appHosts = append(appHosts, r.Address)
+		}
+	}
+	appAddrs := map[string]bool{}
+	for _, host := range appHosts {
+		if h, _, splitErr := net.SplitHostPort(host); splitErr == nil {
+			host = h
+		}
+		addrs, lookupErr := lookupHost(host)
+		if lookupErr != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			appAddrs[addr] = true
+		}
+	}
+	var live []string
+	for _, cname := range cnames {
+		addrs, lookupErr := lookupHost(cname)
+		if lookupErr != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if appAddrs[addr] {
+				live = append(live, cname)
+				break
+			}
+		}
+	}
+	return live, nil
+}
+
+func confirmDomains(context *cmd.Context, domains []string) bool {
+	for _, domain := range domains {
+		fmt.Fprintf(context.Stdout, "Type the domain %q to confirm its removal: ", domain)
+		var answer string
+		if context.Stdin != nil {
+			fmt.Fscanln(context.Stdin, &answer)
+		}
+		if answer != domain {
+			fmt.Fprintln(context.Stdout, "Abort.")
+			return false
+		}
+	}
+	return true
+}
+
 func unsetCName(cnames []string, g tsuruClientApp.AppNameMixIn) error {
 	appName, err := g.AppNameByFlag()
 	if err != nil {
@@ -1705,6 +2919,13 @@ func addCName(cnames []string, g tsuruClientApp.AppNameMixIn) error {
 	if err != nil {
 		return err
 	}
+	return addAppCNames(appName, cnames)
+}
+
+func addAppCNames(appName string, cnames []string) error {
+	if err := validateCNames(cnames); err != nil {
+		return err
+	}
 	u, err := config.GetURL(fmt.Sprintf("/apps/%s/cname", appName))
 	if err != nil {
 		return err
@@ -1776,7 +2997,7 @@ func (c *AppProcessUpdate) Run(ctx *cmd.Context) error {
 		return err
 	}
 
-	resp, err := apiClient.AppApi.AppUpdate(context.Background(), ctx.Args[0], a)
+	resp, err := apiClient.AppApi.AppUpdate(tsuruHTTP.StreamingContext(context.Background()), ctx.Args[0], a)
 	if err != nil {
 		return err
 	}
@@ -1788,3 +3009,74 @@ func (c *AppProcessUpdate) Run(ctx *cmd.Context) error {
 
 	return nil
 }
+
+type AppUse struct {
+	fs        *gnuflag.FlagSet
+	unset     bool
+	skipCheck bool
+}
+
+func (c *AppUse) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "app-use",
+		Usage: "app use <appname> | --unset",
+		Desc: `Sets the app used by commands run in the current directory.
+
+The name of the app is written to the .tsuru/app file, so commands that take
+an app can be used without the [[--app]] flag. When the flag is not given, the
+app is looked up, in order:
+
+  1. in the .tsuru/app file of the current directory, or of the closest parent
+     directory that has one;
+  2. in the URL of the "tsuru" git remote of the current directory, like
+     git@tsuru.example.com:myapp.git, which is set by [[app create
+     --set-git-remote]].
+
+The [[--app]] flag always takes precedence over both. The app is checked
+before the file is written, use [[--skip-validation]] to skip this check. The
+[[--unset]] flag removes the file.`,
+		MaxArgs: 1,
+	}
+}
+
+func (c *AppUse) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = gnuflag.NewFlagSet("", gnuflag.ExitOnError)
+		c.fs.BoolVar(&c.unset, "unset", false, "Remove the app set for the current directory")
+		c.fs.BoolVar(&c.skipCheck, "skip-validation", false, "Don't check that the app exists")
+	}
+	return c.fs
+}
+
+func (c *AppUse) Run(ctx *cmd.Context) error {
+	if c.unset {
+		if len(ctx.Args) > 0 {
+			return errors.New("the --unset flag doesn't take an app name")
+		}
+		err := os.Remove(tsuruClientApp.NameFile)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		fmt.Fprintf(ctx.Stdout, "Removed %s.\n", tsuruClientApp.NameFile)
+		return nil
+	}
+	if len(ctx.Args) == 0 {
+		return errors.New("the name of the app is required, or --unset to remove the current one")
+	}
+	appName := ctx.Args[0]
+	if !c.skipCheck {
+		if _, err := getApp(appName); err != nil {
+			return err
+		}
+	}
+	err := os.MkdirAll(filepath.Dir(tsuruClientApp.NameFile), 0755)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(tsuruClientApp.NameFile, []byte(appName+"\n"), 0644)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(ctx.Stdout, "Commands run in this directory will use app %q, set in %s.\n", appName, tsuruClientApp.NameFile)
+	return nil
+}
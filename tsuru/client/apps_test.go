@@ -7,17 +7,27 @@ package client
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/tsuru/gnuflag"
+	tsuruClientApp "github.com/tsuru/tsuru-client/tsuru/app"
+	"github.com/tsuru/tsuru-client/tsuru/formatter"
 	"github.com/tsuru/tsuru/cmd"
 	"github.com/tsuru/tsuru/cmd/cmdtest"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/exec/exectest"
 	tsuruIo "github.com/tsuru/tsuru/io"
+	provTypes "github.com/tsuru/tsuru/types/provision"
 	check "gopkg.in/check.v1"
 )
 
@@ -36,6 +46,7 @@ func (s *S) TestAppCreate(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	result := `{"status":"success", "repository_url":"git@tsuru.plataformas.glb.com:ble.git"}`
 	expected := `App "ble" has been created!
+Repository: git@tsuru.plataformas.glb.com:ble.git
 Use app info to check the status of the app and its units.` + "\n"
 	context := cmd.Context{
 		Args:   []string{"ble", "django"},
@@ -71,6 +82,7 @@ func (s *S) TestAppCreateEmptyPlatform(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	result := `{"status":"success", "repository_url":"git@tsuru.plataformas.glb.com:ble.git"}`
 	expected := `App "ble" has been created!
+Repository: git@tsuru.plataformas.glb.com:ble.git
 Use app info to check the status of the app and its units.` + "\n"
 	context := cmd.Context{
 		Args:   []string{"ble"},
@@ -106,6 +118,7 @@ func (s *S) TestAppCreateTeamOwner(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	result := `{"status":"success", "repository_url":"git@tsuru.plataformas.glb.com:ble.git"}`
 	expected := `App "ble" has been created!
+Repository: git@tsuru.plataformas.glb.com:ble.git
 Use app info to check the status of the app and its units.` + "\n"
 	context := cmd.Context{
 		Args:   []string{"ble", "django"},
@@ -142,28 +155,39 @@ func (s *S) TestAppCreatePlan(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	result := `{"status":"success", "repository_url":"git@tsuru.plataformas.glb.com:ble.git"}`
 	expected := `App "ble" has been created!
+Repository: git@tsuru.plataformas.glb.com:ble.git
 Use app info to check the status of the app and its units.` + "\n"
 	context := cmd.Context{
 		Args:   []string{"ble", "django"},
 		Stdout: &stdout,
 		Stderr: &stderr,
 	}
-	trans := cmdtest.ConditionalTransport{
-		Transport: cmdtest.Transport{Message: result, Status: http.StatusOK},
-		CondFunc: func(r *http.Request) bool {
-			name := r.FormValue("name") == "ble"
-			platform := r.FormValue("platform") == "django"
-			teamOwner := r.FormValue("teamOwner") == ""
-			plan := r.FormValue("plan") == "myplan"
-			pool := r.FormValue("pool") == ""
-			router := r.FormValue("router") == ""
-			description := r.FormValue("description") == ""
-			r.ParseForm()
-			tags := r.Form["tag"] == nil
-			method := r.Method == "POST"
-			url := strings.HasSuffix(r.URL.Path, "/apps")
-			contentType := r.Header.Get("Content-Type") == "application/x-www-form-urlencoded"
-			return method && url && name && platform && teamOwner && plan && pool && description && tags && contentType && router
+	trans := cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: `[{"name": "small"}, {"name": "myplan"}]`, Status: http.StatusOK},
+				CondFunc: func(r *http.Request) bool {
+					return r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/plans")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: result, Status: http.StatusOK},
+				CondFunc: func(r *http.Request) bool {
+					name := r.FormValue("name") == "ble"
+					platform := r.FormValue("platform") == "django"
+					teamOwner := r.FormValue("teamOwner") == ""
+					plan := r.FormValue("plan") == "myplan"
+					pool := r.FormValue("pool") == ""
+					router := r.FormValue("router") == ""
+					description := r.FormValue("description") == ""
+					r.ParseForm()
+					tags := r.Form["tag"] == nil
+					method := r.Method == "POST"
+					url := strings.HasSuffix(r.URL.Path, "/apps")
+					contentType := r.Header.Get("Content-Type") == "application/x-www-form-urlencoded"
+					return method && url && name && platform && teamOwner && plan && pool && description && tags && contentType && router
+				},
+			},
 		},
 	}
 	s.setupFakeTransport(&trans)
@@ -178,28 +202,39 @@ func (s *S) TestAppCreatePool(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	result := `{"status":"success", "repository_url":"git@tsuru.plataformas.glb.com:ble.git"}`
 	expected := `App "ble" has been created!
+Repository: git@tsuru.plataformas.glb.com:ble.git
 Use app info to check the status of the app and its units.` + "\n"
 	context := cmd.Context{
 		Args:   []string{"ble", "django"},
 		Stdout: &stdout,
 		Stderr: &stderr,
 	}
-	trans := cmdtest.ConditionalTransport{
-		Transport: cmdtest.Transport{Message: result, Status: http.StatusOK},
-		CondFunc: func(r *http.Request) bool {
-			name := r.FormValue("name") == "ble"
-			platform := r.FormValue("platform") == "django"
-			teamowner := r.FormValue("teamowner") == ""
-			plan := r.FormValue("plan") == ""
-			pool := r.FormValue("pool") == "mypool"
-			router := r.FormValue("router") == ""
-			description := r.FormValue("description") == ""
-			r.ParseForm()
-			tags := r.Form["tag"] == nil
-			method := r.Method == "POST"
-			url := strings.HasSuffix(r.URL.Path, "/apps")
-			contentType := r.Header.Get("Content-Type") == "application/x-www-form-urlencoded"
-			return method && url && name && platform && teamowner && plan && pool && description && tags && contentType && router
+	trans := cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: `[{"name": "mypool"}, {"name": "other"}]`, Status: http.StatusOK},
+				CondFunc: func(r *http.Request) bool {
+					return r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/pools")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: result, Status: http.StatusOK},
+				CondFunc: func(r *http.Request) bool {
+					name := r.FormValue("name") == "ble"
+					platform := r.FormValue("platform") == "django"
+					teamowner := r.FormValue("teamowner") == ""
+					plan := r.FormValue("plan") == ""
+					pool := r.FormValue("pool") == "mypool"
+					router := r.FormValue("router") == ""
+					description := r.FormValue("description") == ""
+					r.ParseForm()
+					tags := r.Form["tag"] == nil
+					method := r.Method == "POST"
+					url := strings.HasSuffix(r.URL.Path, "/apps")
+					contentType := r.Header.Get("Content-Type") == "application/x-www-form-urlencoded"
+					return method && url && name && platform && teamowner && plan && pool && description && tags && contentType && router
+				},
+			},
 		},
 	}
 	s.setupFakeTransport(&trans)
@@ -214,6 +249,7 @@ func (s *S) TestAppCreateRouterOpts(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	result := `{"status":"success", "repository_url":"git@tsuru.plataformas.glb.com:ble.git"}`
 	expected := `App "ble" has been created!
+Repository: git@tsuru.plataformas.glb.com:ble.git
 Use app info to check the status of the app and its units.` + "\n"
 	context := cmd.Context{
 		Args:   []string{"ble", "django"},
@@ -242,10 +278,132 @@ Use app info to check the status of the app and its units.` + "\n"
 	}
 	s.setupFakeTransport(&trans)
 	command := AppCreate{}
-	command.Flags().Parse(true, []string{"--router-opts", "a=1", "--router-opts", "b=2"})
+	command.Flags().Parse(true, []string{"--router-opt", "a=1", "--router-opts", "b=2"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestAppCreateSetGitRemote(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	result := `{"status":"success", "repository_url":"git@tsuru.io:ble.git", "ip":"ble.tsuru.io"}`
+	expected := `App "ble" has been created!
+Repository: git@tsuru.io:ble.git
+Address: ble.tsuru.io
+Use app info to check the status of the app and its units.
+Git remote "tsuru" added.
+`
+	context := cmd.Context{
+		Args:   []string{"ble", "django"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	fexec := exectest.FakeExecutor{}
+	Execut = &fexec
+	defer func() {
+		Execut = nil
+	}()
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := AppCreate{}
+	command.Flags().Parse(true, []string{"--set-git-remote"})
 	err := command.Run(&context)
 	c.Assert(err, check.IsNil)
 	c.Assert(stdout.String(), check.Equals, expected)
+	c.Assert(fexec.ExecutedCmd("git", []string{"rev-parse", "--is-inside-work-tree"}), check.Equals, true)
+	c.Assert(fexec.ExecutedCmd("git", []string{"remote", "add", "tsuru", "git@tsuru.io:ble.git"}), check.Equals, true)
+}
+
+func (s *S) TestAppCreateSetGitRemoteOutsideRepository(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"ble", "django"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	fexec := exectest.ErrorExecutor{}
+	Execut = &fexec
+	defer func() {
+		Execut = nil
+	}()
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			c.Errorf("unexpected request to %s", req.URL)
+			return false
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppCreate{}
+	command.Flags().Parse(true, []string{"--set-git-remote"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "the --set-git-remote flag must be used inside a git repository, the app was not created")
+	c.Assert(stdout.String(), check.Equals, "")
+}
+
+func (s *S) TestAppCreateInvalidPlan(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"ble", "django"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: `[{"name": "small"}, {"name": "large"}]`, Status: http.StatusOK},
+		CondFunc: func(r *http.Request) bool {
+			if r.Method != "GET" {
+				c.Errorf("unexpected request to %s", r.URL)
+			}
+			return strings.HasSuffix(r.URL.Path, "/plans")
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppCreate{}
+	command.Flags().Parse(true, []string{"-p", "smal"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `plan "smal" not found, the available plans are: large, small`)
+}
+
+func (s *S) TestAppCreateInvalidPool(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"ble", "django"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Status: http.StatusNoContent},
+		CondFunc: func(r *http.Request) bool {
+			if r.Method != "GET" {
+				c.Errorf("unexpected request to %s", r.URL)
+			}
+			return strings.HasSuffix(r.URL.Path, "/pools")
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppCreate{}
+	command.Flags().Parse(true, []string{"-o", "prod"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `pool "prod" not found, there are no pools available`)
+}
+
+func (s *S) TestAppCreateSkipValidation(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"ble", "django"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: `{"status":"success"}`, Status: http.StatusOK},
+		CondFunc: func(r *http.Request) bool {
+			return r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/apps") && r.FormValue("plan") == "custom"
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppCreate{}
+	command.Flags().Parse(true, []string{"-p", "custom", "--skip-validation"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
 }
 
 func (s *S) TestAppCreateNoRepository(c *check.C) {
@@ -301,6 +459,7 @@ func (s *S) TestAppCreateWithTags(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	result := `{"status":"success", "repository_url":"git@tsuru.plataformas.glb.com:ble.git"}`
 	expected := `App "ble" has been created!
+Repository: git@tsuru.plataformas.glb.com:ble.git
 Use app info to check the status of the app and its units.` + "\n"
 	context := cmd.Context{
 		Args:   []string{"ble", "django"},
@@ -337,6 +496,7 @@ func (s *S) TestAppCreateWithEmptyTag(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	result := `{"status":"success", "repository_url":"git@tsuru.plataformas.glb.com:ble.git"}`
 	expected := `App "ble" has been created!
+Repository: git@tsuru.plataformas.glb.com:ble.git
 Use app info to check the status of the app and its units.` + "\n"
 	context := cmd.Context{
 		Args:   []string{"ble", "django"},
@@ -436,6 +596,18 @@ func (s *S) TestAppCreateFlags(c *check.C) {
 	c.Check(routerOpts.Usage, check.Equals, "Router options")
 	c.Check(routerOpts.Value.String(), check.Equals, "{\"opt1\":\"val1\",\"opt2\":\"val2\"}")
 	c.Check(routerOpts.DefValue, check.Equals, "{}")
+	err := flagset.Parse(true, []string{"--router-opt", "opt3=val3"})
+	c.Check(err, check.IsNil)
+	c.Check(flagset.Lookup("router-opt").Value.String(), check.Equals, "{\"opt1\":\"val1\",\"opt2\":\"val2\",\"opt3\":\"val3\"}")
+}
+
+func (s *S) TestAppCreateInvalidRouterOpt(c *check.C) {
+	command := AppCreate{}
+	flagset := command.Flags()
+	flagset.Init("app-create", gnuflag.ContinueOnError)
+	flagset.SetOutput(io.Discard)
+	err := flagset.Parse(true, []string{"--router-opt", "timeout"})
+	c.Assert(err, check.ErrorMatches, `invalid value "timeout" for flag --router-opt: must be on the form "key=value"`)
 }
 
 func (s *S) TestAppUpdateInfo(c *check.C) {
@@ -706,6 +878,58 @@ func (s *S) TestAppUpdateWithoutArgs(c *check.C) {
 	c.Assert(err.Error(), check.Equals, expected)
 }
 
+func (s *S) TestAppUpdateGuessesAppName(c *check.C) {
+	tsuruClientApp.GitRemoteURL = func() (string, error) {
+		return "git@tsuru.example.com:secret.git", nil
+	}
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return req.Method == http.MethodPut && strings.HasSuffix(req.URL.Path, "/apps/secret")
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppUpdate{}
+	command.Flags().Parse(true, []string{"-d", "description of my app"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "App \"secret\" has been updated!\n")
+}
+
+func (s *S) TestAppUpdateEmptyField(c *check.C) {
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			c.Errorf("unexpected request to %s", req.URL)
+			return false
+		},
+	}
+	s.setupFakeTransport(trans)
+	context := cmd.Context{Stdout: io.Discard, Stderr: io.Discard}
+	command := AppUpdate{}
+	command.Flags().Parse(true, []string{"-a", "ble", "-d", "new description", "-t", ""})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "the --team-owner flag can't be empty, the API doesn't support clearing the team owner of an app")
+}
+
+func (s *S) TestAppUpdateNothingToUpdate(c *check.C) {
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			c.Errorf("unexpected request to %s", req.URL)
+			return false
+		},
+	}
+	s.setupFakeTransport(trans)
+	context := cmd.Context{Stdout: io.Discard, Stderr: io.Discard}
+	command := AppUpdate{}
+	command.Flags().Parse(true, []string{"-a", "ble", "--no-restart"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "nothing to update, use at least one of .*")
+}
+
 func (s *S) TestAppUpdateFlags(c *check.C) {
 	command := AppUpdate{}
 	flagset := command.Flags()
@@ -892,6 +1116,7 @@ External Addresses: myapp.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
 Units: 3
@@ -916,6 +1141,68 @@ Units: 3
 	c.Assert(stdout.String(), check.Equals, expected)
 }
 
+func (s *S) TestAppInfoUnitsOnly(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	result := `{"name":"app1","deploys":3,"units":[{"ID":"app1/1","Status":"error","StatusReason":"CrashLoopBackOff","Address":{"Host":"10.8.7.7:3333"}},{"ID":"app1/0","Status":"started","Address":{"Host":"10.8.7.6:3333"},"ready":true}]}`
+	expected := `+--------+--------------------------+----------+
+| Name   | Status                   | Host     |
++--------+--------------------------+----------+
+| app1/0 | ready                    | 10.8.7.6 |
+| app1/1 | error (CrashLoopBackOff) | 10.8.7.7 |
++--------+--------------------------+----------+
+`
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: result, Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return req.URL.Path == "/1.0/apps/app1"
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppInfo{}
+	command.Flags().Parse(true, []string{"--app", "app1", "--units-only"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestAppInfoUnitsOnlyJSON(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	result := `{"name":"app1","units":[{"ID":"app1/0","Status":"started","Address":{"Host":"10.8.7.6:3333"}}]}`
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := AppInfo{}
+	command.Flags().Parse(true, []string{"--app", "app1", "--units-only", "--json"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	var units []map[string]interface{}
+	err = json.Unmarshal(stdout.Bytes(), &units)
+	c.Assert(err, check.IsNil)
+	c.Assert(units, check.DeepEquals, []map[string]interface{}{
+		{"name": "app1/0", "status": "started", "host": "10.8.7.6"},
+	})
+}
+
+func (s *S) TestAppInfoUnitsOnlyNoUnits(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	s.setupFakeTransport(&cmdtest.Transport{Message: `{"name":"app1","units":[]}`, Status: http.StatusOK})
+	command := AppInfo{}
+	command.Flags().Parse(true, []string{"--app", "app1", "--units-only"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "App \"app1\" has no units.\n")
+}
+
 func (s *S) TestAppInfoSimplified(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	result := `{"name":"app1","pool": "dev-a", "provisioner": "kubernetes", "cluster": "mycluster", "teamowner":"myteam","cname":[""],"ip":"myapp.tsuru.io","platform":"php","repository":"git@git.com:php.git","state":"dead", "units":[{"Ip":"10.10.10.10","ID":"app1/0","Status":"started","ProcessName": "web","Address":{"Host": "10.8.7.6:3333"}, "ready": true, "routable": true}, {"Ip":"9.9.9.9","ID":"app1/1","Status":"started","ProcessName": "web","Address":{"Host": "10.8.7.6:3323"}, "ready": true, "routable": true}],"teams":["tsuruteam","crane"], "owner": "myapp_owner", "deploys": 7, "router": "planb", "plan":{"name": "test",  "memory": 536870912, "cpumilli": 100, "default": false}}`
@@ -947,6 +1234,62 @@ Units: 2
 	c.Assert(stdout.String(), check.Equals, expected)
 }
 
+func (s *S) TestAppInfoWatch(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	oldTerminal, oldWait := isTerminalWriter, waitAppInfoRefresh
+	defer func() { isTerminalWriter, waitAppInfoRefresh = oldTerminal, oldWait }()
+	isTerminalWriter = func(io.Writer) bool { return true }
+	var waits []time.Duration
+	waitAppInfoRefresh = func(sigChan <-chan os.Signal, interval time.Duration) bool {
+		waits = append(waits, interval)
+		return len(waits) < 2
+	}
+	appInfo := func(status string) cmdtest.ConditionalTransport {
+		return cmdtest.ConditionalTransport{
+			Transport: cmdtest.Transport{
+				Message: `{"name":"app1","units":[{"ID":"app1/0","Status":"` + status + `","ProcessName":"web"}]}`,
+				Status:  http.StatusOK,
+			},
+			CondFunc: func(req *http.Request) bool {
+				return strings.HasSuffix(req.URL.Path, "/apps/app1")
+			},
+		}
+	}
+	s.setupFakeTransport(&cmdtest.MultiConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{appInfo("starting"), appInfo("started")},
+	})
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	command := AppInfo{}
+	command.Flags().Parse(true, []string{"--app", "app1", "-s", "--watch", "--interval", "2s", "--no-color"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(waits, check.DeepEquals, []time.Duration{2 * time.Second, 2 * time.Second})
+	screens := strings.Split(stdout.String(), clearScreen)
+	c.Assert(screens, check.HasLen, 3)
+	c.Assert(screens[1], check.Matches, `(?s)Every 2s: app-info app1 \(press Ctrl\+C to exit\)\n\nApplication: app1\n.*`)
+	c.Assert(strings.Contains(screens[1], "Unit status changes"), check.Equals, false)
+	c.Assert(screens[2], check.Matches, `(?s).*\nUnit status changes:\n\d\d:\d\d:\d\d app1/0: starting -> started\n`)
+}
+
+func (s *S) TestAppInfoWatchNotTerminal(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	command := AppInfo{}
+	command.Flags().Parse(true, []string{"--app", "app1", "--watch"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "the --watch flag requires the output to be a terminal, run app-info without it to pipe or redirect the output")
+	command = AppInfo{}
+	command.Flags().Parse(true, []string{"--app", "app1", "--watch", "--json"})
+	err = command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "the --watch flag can't be used with --json")
+}
+
 func (s *S) TestAppInfoKubernetes(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	t0 := time.Now().UTC().Format(time.RFC3339)
@@ -1000,6 +1343,7 @@ Created by: myapp_owner
 Deploys: 7
 Cluster: kube-cluster-dev
 Pool: dev-a
+Lock: unlocked
 Quota: 0/0 units
 
 Units: 3
@@ -1035,6 +1379,7 @@ External Addresses: myapp.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
 Units: 3
@@ -1115,6 +1460,7 @@ External Addresses: cname1 (cname), addr1, addr2, addr9, addr3
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
 Units: 3
@@ -1164,6 +1510,7 @@ External Addresses: myapp.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
 Units: 3
@@ -1188,36 +1535,88 @@ Units: 3
 	c.Assert(stdout.String(), check.Equals, expected)
 }
 
-func (s *S) TestAppInfoWithTags(c *check.C) {
+func (s *S) TestAppInfoWithLastDeploy(c *check.C) {
 	var stdout, stderr bytes.Buffer
-	result := `{"name":"app1","teamowner":"myteam","cname":[""],"ip":"myapp.tsuru.io","platform":"php","repository":"git@git.com:php.git","state":"dead", "units":[{"Ip":"10.10.10.10","ID":"app1/0","Status":"started"}, {"Ip":"9.9.9.9","ID":"app1/1","Status":"started"}, {"Ip":"","ID":"app1/2","Status":"pending"}],"teams":["tsuruteam","crane"], "owner": "myapp_owner", "deploys": 7, "tags": ["tag 1", "tag 2", "tag 3"], "router": "planb"}`
+	result := `{"name":"app1","teamowner":"myteam","ip":"myapp.tsuru.io","platform":"php","units":[{"ID":"app1/0","Status":"started"}],"teams":["tsuruteam"], "owner": "myapp_owner", "deploys": 7, "router": "planb"}`
+	deploys := `[{"App": "app1", "Timestamp": "2026-10-16T10:00:00Z", "Commit": "54c92d91a46ec0e78501d86b", "Image": "tsuru/app-app1:v7", "User": "admin@example.com", "Origin": "git"}]`
 	expected := `Application: app1
-Tags: tag 1, tag 2, tag 3
 Platform: php
 Router: planb
-Teams: myteam (owner), tsuruteam, crane
+Teams: myteam (owner), tsuruteam
 External Addresses: myapp.tsuru.io
 Created by: myapp_owner
-Deploys: 7
+Deploys: 7 (last via git push (54c92d9))
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
-Units: 3
-+--------+---------+-------------+------+
-| Name   | Status  | Host        | Port |
-+--------+---------+-------------+------+
-| app1/2 | pending |             |      |
-| app1/0 | started | 10.10.10.10 |      |
-| app1/1 | started | 9.9.9.9     |      |
-+--------+---------+-------------+------+
+Units: 1
++--------+---------+------+------+
+| Name   | Status  | Host | Port |
++--------+---------+------+------+
+| app1/0 | started |      |      |
++--------+---------+------+------+
 
 `
 	context := cmd.Context{
 		Stdout: &stdout,
 		Stderr: &stderr,
 	}
-	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
-	command := AppInfo{}
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: deploys, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return strings.HasSuffix(req.URL.Path, "/deploys") && req.URL.Query().Get("app") == "app1"
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: result, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return strings.HasSuffix(req.URL.Path, "/apps/app1")
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppInfo{}
+	command.Flags().Parse(true, []string{"--app", "app1"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestAppInfoWithTags(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	result := `{"name":"app1","teamowner":"myteam","cname":[""],"ip":"myapp.tsuru.io","platform":"php","repository":"git@git.com:php.git","state":"dead", "units":[{"Ip":"10.10.10.10","ID":"app1/0","Status":"started"}, {"Ip":"9.9.9.9","ID":"app1/1","Status":"started"}, {"Ip":"","ID":"app1/2","Status":"pending"}],"teams":["tsuruteam","crane"], "owner": "myapp_owner", "deploys": 7, "tags": ["tag 1", "tag 2", "tag 3"], "router": "planb"}`
+	expected := `Application: app1
+Tags: tag 1, tag 2, tag 3
+Platform: php
+Router: planb
+Teams: myteam (owner), tsuruteam, crane
+External Addresses: myapp.tsuru.io
+Created by: myapp_owner
+Deploys: 7
+Pool:
+Lock: unlocked
+Quota: 0/0 units
+
+Units: 3
++--------+---------+-------------+------+
+| Name   | Status  | Host        | Port |
++--------+---------+-------------+------+
+| app1/2 | pending |             |      |
+| app1/0 | started | 10.10.10.10 |      |
+| app1/1 | started | 9.9.9.9     |      |
++--------+---------+-------------+------+
+
+`
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := AppInfo{}
 	command.Flags().Parse(true, []string{"--app", "app1"})
 	err := command.Run(&context)
 	c.Assert(err, check.IsNil)
@@ -1235,6 +1634,7 @@ External Addresses: myapp.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
 Units: 3
@@ -1275,6 +1675,7 @@ External Addresses: myapp.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 3/40 units
 
 Units: 3
@@ -1306,6 +1707,67 @@ Units: 3
 	c.Assert(stdout.String(), check.Equals, expected)
 }
 
+func (s *S) TestAppUnlockInfo(c *check.C) {
+	c.Assert((&AppUnlock{}).Info(), check.NotNil)
+}
+
+func (s *S) TestAppUnlock(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr, Stdin: strings.NewReader("y\n")}
+	oldTZ, oldNow := formatter.LocalTZ, lockNow
+	defer func() { formatter.LocalTZ, lockNow = oldTZ, oldNow }()
+	formatter.LocalTZ = time.UTC
+	lockNow = func() time.Time { return time.Date(2012, time.April, 1, 11, 32, 0, 0, time.UTC) }
+	var unlocked bool
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: `{"name": "app1", "lock": {"locked": true, "owner": "admin@example.com", "reason": "POST /apps/app1/deploy", "acquiredate": "2012-04-01T10:32:00Z"}}`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/apps/app1")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					unlocked = req.Method == http.MethodDelete && strings.HasSuffix(req.URL.Path, "/apps/app1/lock")
+					return unlocked
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppUnlock{}
+	command.Flags().Parse(true, []string{"-a", "app1"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(unlocked, check.Equals, true)
+	expected := `Lock:
+ Reason: POST /apps/app1/deploy
+ Owner: admin@example.com
+ Acquired at: 01 Apr 12 10:32 UTC (held for 1h0m0s)
+Are you sure you want to remove the lock of app "app1"? (y/n) Lock of app "app1" successfully removed.
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestAppUnlockNotLocked(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: `{"name": "app1", "lock": {"locked": false}}`, Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return req.Method == http.MethodGet
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppUnlock{}
+	command.Flags().Parse(true, []string{"-a", "app1"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "App \"app1\" is not locked.\n")
+}
+
 func (s *S) TestAppInfoLock(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	result := `{"name":"app1","teamowner":"myteam","cname":[""],"ip":"myapp.tsuru.io","platform":"php","repository":"git@git.com:php.git","state":"dead", "units":[{"ID":"app1/0","Status":"started"}, {"ID":"app1/1","Status":"started"}, {"Ip":"","ID":"app1/2","Status":"pending"}],"teams":["tsuruteam","crane"], "owner": "myapp_owner", "deploys": 7, "lock": {"locked": true, "owner": "admin@example.com", "reason": "DELETE /apps/rbsample/units", "acquiredate": "2012-04-01T10:32:00Z"}, "router": "planb"}`
@@ -1318,9 +1780,9 @@ Created by: myapp_owner
 Deploys: 7
 Pool:
 Lock:
- Acquired in: %s
+ Reason: DELETE /apps/rbsample/units
  Owner: admin@example.com
- Running: DELETE /apps/rbsample/units
+ Acquired at: 01 Apr 12 10:32 UTC (held for 3m12s)
 Quota: 0/0 units
 
 Units: 3
@@ -1333,7 +1795,10 @@ Units: 3
 +--------+---------+------+------+
 
 `
-	expected = fmt.Sprintf(expected, time.Date(2012, time.April, 1, 10, 32, 0, 0, time.UTC))
+	oldTZ, oldNow := formatter.LocalTZ, lockNow
+	defer func() { formatter.LocalTZ, lockNow = oldTZ, oldNow }()
+	formatter.LocalTZ = time.UTC
+	lockNow = func() time.Time { return time.Date(2012, time.April, 1, 10, 35, 12, 0, time.UTC) }
 	context := cmd.Context{
 		Stdout: &stdout,
 		Stderr: &stderr,
@@ -1394,16 +1859,17 @@ External Addresses: myapp.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
-Units [process web]: 1
+Units [process web]: 1 (1 started)
 +--------+---------+-------------+------+
 | Name   | Status  | Host        | Port |
 +--------+---------+-------------+------+
 | app1/0 | started | 10.10.10.10 |      |
 +--------+---------+-------------+------+
 
-Units [process worker]: 2
+Units [process worker]: 2 (1 pending, 1 started)
 +--------+---------+---------+------+
 | Name   | Status  | Host    | Port |
 +--------+---------+---------+------+
@@ -1424,6 +1890,50 @@ Units [process worker]: 2
 	c.Assert(stdout.String(), check.Equals, expected)
 }
 
+func (s *S) TestAppInfoFlat(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	result := `{"name":"app1","teamowner":"myteam","owner":"myapp_owner","ip":"myapp.tsuru.io","platform":"php","router":"planb","units":[
+{"Ip":"9.9.9.9","ID":"app1/1","Status":"started","ProcessName":"worker"},
+{"Ip":"10.10.10.10","ID":"app1/0","Status":"started","ProcessName":"web"},
+{"Ip":"","ID":"app1/2","Status":"pending","ProcessName":"worker","Version":2}]}`
+	expected := `Application: app1
+Platform: php
+Router: planb
+Teams: myteam (owner)
+External Addresses: myapp.tsuru.io
+Created by: myapp_owner
+Deploys: 0
+Pool:
+Lock: unlocked
+Quota: 0/0 units
+
+Units: 3
++--------+---------+---------+-------------+------+
+| Name   | Process | Status  | Host        | Port |
++--------+---------+---------+-------------+------+
+| app1/0 | web     | started | 10.10.10.10 |      |
+| app1/1 | worker  | started | 9.9.9.9     |      |
+| app1/2 | worker  | pending |             |      |
++--------+---------+---------+-------------+------+
+
+`
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := AppInfo{}
+	command.Flags().Parse(true, []string{"--app", "app1", "--flat"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestStatusRollup(c *check.C) {
+	c.Assert(statusRollup([]string{"started"}), check.Equals, "1 started")
+	c.Assert(statusRollup([]string{"error", "ready", "ready", "building"}), check.Equals, "2 ready, 1 building, 1 error")
+}
+
 func (s *S) TestAppInfoManyVersions(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	result := `{
@@ -1489,16 +1999,17 @@ External Addresses: myapp.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
-Units [process web] [version 1]: 1
+Units [process web] [version 1]: 1 (1 started)
 +--------+---------+------+------+
 | Name   | Status  | Host | Port |
 +--------+---------+------+------+
 | app1/0 | started |      |      |
 +--------+---------+------+------+
 
-Units [process worker] [version 1]: 2
+Units [process worker] [version 1]: 2 (1 pending, 1 started)
 +--------+---------+------+------+
 | Name   | Status  | Host | Port |
 +--------+---------+------+------+
@@ -1506,14 +2017,14 @@ Units [process worker] [version 1]: 2
 | app1/2 | pending |      |      |
 +--------+---------+------+------+
 
-Units [process web] [version 2] [routable]: 1
+Units [process web] [version 2] [routable]: 1 (1 started)
 +--------+---------+------+------+
 | Name   | Status  | Host | Port |
 +--------+---------+------+------+
 | app1/3 | started |      |      |
 +--------+---------+------+------+
 
-Units [process worker] [version 2] [routable]: 1
+Units [process worker] [version 2] [routable]: 1 (1 started)
 +--------+---------+------+------+
 | Name   | Status  | Host | Port |
 +--------+---------+------+------+
@@ -1603,16 +2114,17 @@ External Addresses: myapp.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
-Units [process web]: 1
+Units [process web]: 1 (1 started)
 +--------+---------+------+------+
 | Name   | Status  | Host | Port |
 +--------+---------+------+------+
 | app1/0 | started |      |      |
 +--------+---------+------+------+
 
-Units [process worker]: 1
+Units [process worker]: 1 (1 started)
 +--------+---------+------+------+
 | Name   | Status  | Host | Port |
 +--------+---------+------+------+
@@ -1760,16 +2272,17 @@ External Addresses: myapp.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
-Units [process web]: 1
+Units [process web]: 1 (1 started)
 +--------+---------+------+------+
 | Name   | Status  | Host | Port |
 +--------+---------+------+------+
 | app1/0 | started |      |      |
 +--------+---------+------+------+
 
-Units [process worker]: 1
+Units [process worker]: 1 (1 started)
 +--------+---------+------+------+
 | Name   | Status  | Host | Port |
 +--------+---------+------+------+
@@ -1849,6 +2362,7 @@ External Addresses: app1.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
 `
@@ -1875,6 +2389,7 @@ External Addresses: myapp.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
 `
@@ -1901,6 +2416,7 @@ External Addresses: secret.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/unlimited
 
 Units: 2
@@ -1941,6 +2457,7 @@ External Addresses: yourapp.tsuru.io (cname), myapp.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
 Units: 3
@@ -1977,6 +2494,7 @@ External Addresses: yourapp.tsuru.io (cname), myapp.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
 Units: 3
@@ -2011,6 +2529,7 @@ External Addresses: myapp.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
 Units: 3
@@ -2059,6 +2578,7 @@ External Addresses: myapp.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
 Units: 3
@@ -2109,6 +2629,7 @@ External Addresses: myapp.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
 Units: 3
@@ -2150,6 +2671,7 @@ External Addresses: myapp.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
 Units: 3
@@ -2205,6 +2727,7 @@ External Addresses: myapp.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
 Units: 3
@@ -2260,6 +2783,7 @@ External Addresses: app1.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
 Units: 3
@@ -2329,6 +2853,7 @@ External Addresses: monster.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 0/0 units
 
 Units: 1
@@ -2369,6 +2894,7 @@ External Addresses: myapp.tsuru.io
 Created by: myapp_owner
 Deploys: 7
 Pool:
+Lock: unlocked
 Quota: 3/40 units
 
 Units: 3
@@ -2474,6 +3000,110 @@ func (s *S) TestAppList(c *check.C) {
 +-------------+-----------+-------------+
 | app1        | 1 started | 10.10.10.10 |
 +-------------+-----------+-------------+
+Total: 1 app, 1 unit
+`
+	context := cmd.Context{
+		Args:   []string{},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := AppList{}
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestAppListColumns(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	result := `[{"ip":"10.10.10.11","name":"app2","pool":"pool2","units":[]},{"ip":"10.10.10.10","name":"app1","pool":"pool1","units":[{"ID":"app1/0","Status":"started"}]}]`
+	expected := `+-------+-------------+-----------+
+| Pool  | Application | Units     |
++-------+-------------+-----------+
+| pool1 | app1        | 1 started |
++-------+-------------+-----------+
+| pool2 | app2        |           |
++-------+-------------+-----------+
+Total: 2 apps, 1 unit
+`
+	context := cmd.Context{
+		Args:   []string{},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := AppList{}
+	command.Flags().Parse(true, []string{"--columns", "pool, name,units"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestAppListInvalidColumns(c *check.C) {
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--columns", "name,owner"}, `unknown column "owner", valid columns are: name, count, units, address, pool, platform, team-owner, plan, tags`},
+		{[]string{"--columns", "name,name"}, `column "name" given more than once`},
+		{[]string{"--columns", ","}, "the --columns flag requires at least one column"},
+		{[]string{"--columns", "name", "--output", "yaml"}, "the --columns flag can't be used with --output yaml"},
+		{[]string{"--columns", "name", "--format", "{{.Name}}"}, "the --columns flag can't be used with --format"},
+	}
+	for _, tt := range tests {
+		command := AppList{}
+		command.Flags().Parse(true, tt.args)
+		err := command.Run(&cmd.Context{Stdout: io.Discard})
+		c.Check(err, check.ErrorMatches, tt.err, check.Commentf("%v", tt.args))
+	}
+}
+
+func (s *S) TestAppListNoSummary(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	result := `[{"ip":"10.10.10.10","name":"app1","units":[{"ID":"app1/0","Status":"started"}]}]`
+	expected := `+-------------+-----------+-------------+
+| Application | Units     | Address     |
++-------------+-----------+-------------+
+| app1        | 1 started | 10.10.10.10 |
++-------------+-----------+-------------+
+`
+	context := cmd.Context{
+		Args:   []string{},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := AppList{}
+	command.Flags().Parse(true, []string{"--no-summary"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestAppListJSON(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	result := `[{"ip":"10.10.10.10","name":"app1","cname":["app1.example.com"],"units":[{"ID":"app1/0","Status":"started"}],"lock":{"Locked":true,"Reason":"POST /apps/app1/deploy","Owner":"admin@example.com","AcquireDate":"2026-10-16T10:00:00Z"}}]`
+	expected := `[
+  {
+    "ip": "10.10.10.10",
+    "name": "app1",
+    "cname": [
+      "app1.example.com"
+    ],
+    "units": [
+      {
+        "ID": "app1/0",
+        "Status": "started"
+      }
+    ],
+    "lock": {
+      "Locked": true,
+      "Reason": "POST /apps/app1/deploy",
+      "Owner": "admin@example.com",
+      "AcquireDate": "2026-10-16T10:00:00Z"
+    }
+  }
+]
 `
 	context := cmd.Context{
 		Args:   []string{},
@@ -2482,11 +3112,52 @@ func (s *S) TestAppList(c *check.C) {
 	}
 	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
 	command := AppList{}
+	command.Flags().Parse(true, []string{"--json"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestAppListYAML(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	result := `[{"name":"app1","units":[{"ID":"app1/0","Status":"started"}]}]`
+	expected := `- name: app1
+  units:
+  - ID: app1/0
+    Status: started
+`
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := AppList{}
+	command.Flags().Parse(true, []string{"--output", "yaml"})
 	err := command.Run(&context)
 	c.Assert(err, check.IsNil)
 	c.Assert(stdout.String(), check.Equals, expected)
 }
 
+func (s *S) TestAppListInvalidOutput(c *check.C) {
+	context := cmd.Context{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	command := AppList{}
+	command.Flags().Parse(true, []string{"--output", "xml"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `invalid output format "xml", supported formats are table, json and yaml`)
+}
+
+func (s *S) TestAppListJSONNoApps(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	s.setupFakeTransport(&cmdtest.Transport{Status: http.StatusNoContent})
+	command := AppList{}
+	command.Flags().Parse(true, []string{"--json"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "[]\n")
+}
+
 func (s *S) TestAppListDisplayAppsInAlphabeticalOrder(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	result := `[{"ip":"10.10.10.11","name":"sapp","units":[{"ID":"sapp1/0","Status":"started"}]},{"ip":"10.10.10.10","name":"app1","units":[{"ID":"app1/0","Status":"started"}]}]`
@@ -2497,6 +3168,7 @@ func (s *S) TestAppListDisplayAppsInAlphabeticalOrder(c *check.C) {
 +-------------+-----------+-------------+
 | sapp        | 1 started | 10.10.10.11 |
 +-------------+-----------+-------------+
+Total: 2 apps, 2 units
 `
 	context := cmd.Context{
 		Args:   []string{},
@@ -2518,6 +3190,7 @@ func (s *S) TestAppListUnitIsntAvailable(c *check.C) {
 +-------------+-----------+-------------+
 | app1        | 1 pending | 10.10.10.10 |
 +-------------+-----------+-------------+
+Total: 1 app, 1 unit
 `
 	context := cmd.Context{
 		Args:   []string{},
@@ -2539,6 +3212,7 @@ func (s *S) TestAppListErrorFetchingUnits(c *check.C) {
 +-------------+-------------------------------+-------------+
 | app1        | error fetching units: timeout | 10.10.10.10 |
 +-------------+-------------------------------+-------------+
+Total: 1 app, 0 units
 `
 	context := cmd.Context{
 		Args:   []string{},
@@ -2560,6 +3234,7 @@ func (s *S) TestAppListUnitWithoutID(c *check.C) {
 +-------------+-----------+-------------+
 | app1        | 1 stopped | 10.10.10.10 |
 +-------------+-----------+-------------+
+Total: 1 app, 1 unit
 `
 	context := cmd.Context{
 		Args:   []string{},
@@ -2582,6 +3257,7 @@ func (s *S) TestAppListCName(c *check.C) {
 | app1        | 1 started | app1.tsuru.io (cname) |
 |             |           | 10.10.10.10           |
 +-------------+-----------+-----------------------+
+Total: 1 app, 1 unit
 `
 	context := cmd.Context{
 		Args:   []string{},
@@ -2604,6 +3280,7 @@ func (s *S) TestAppListFiltering(c *check.C) {
 | app1        | 1 started | app1.tsuru.io (cname) |
 |             |           | 10.10.10.10           |
 +-------------+-----------+-----------------------+
+Total: 1 app, 1 unit
 `
 	context := cmd.Context{
 		Args:   []string{},
@@ -2637,6 +3314,75 @@ func (s *S) TestAppListFiltering(c *check.C) {
 	c.Assert(request.URL.Query(), check.DeepEquals, queryString)
 }
 
+func (s *S) TestAppListFilteringMultipleStatuses(c *check.C) {
+	var request *http.Request
+	transport := cmdtest.ConditionalTransport{
+		CondFunc: func(r *http.Request) bool {
+			request = r
+			return true
+		},
+		Transport: cmdtest.Transport{Message: "[]", Status: http.StatusOK},
+	}
+	s.setupFakeTransport(&transport)
+	context := cmd.Context{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	command := AppList{}
+	command.Flags().Parse(true, []string{"--status", "error", "-s", "stopped,starting", "--locked"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(request.URL.Query(), check.DeepEquals, url.Values{
+		"status": {"error,stopped,starting"},
+		"locked": {"true"},
+	})
+}
+
+func (s *S) TestAppListFilteringCombined(c *check.C) {
+	var request *http.Request
+	transport := cmdtest.ConditionalTransport{
+		CondFunc: func(r *http.Request) bool {
+			request = r
+			return true
+		},
+		Transport: cmdtest.Transport{Message: "[]", Status: http.StatusOK},
+	}
+	s.setupFakeTransport(&transport)
+	context := cmd.Context{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	command := AppList{}
+	command.Flags().Parse(true, []string{"--team-owner", "team1", "--owner", "someone@example.com", "--platform", "go", "--name", "^api-"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(request.URL.Query(), check.DeepEquals, url.Values{
+		"teamOwner": {"team1"},
+		"owner":     {"someone@example.com"},
+		"platform":  {"go"},
+		"name":      {"^api-"},
+	})
+}
+
+func (s *S) TestAppListFilteringInvalidName(c *check.C) {
+	s.setupFakeTransport(&cmdtest.Transport{Message: "[]", Status: http.StatusOK})
+	context := cmd.Context{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	command := AppList{}
+	command.Flags().Parse(true, []string{"--name", "api-("})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `invalid --name pattern "api-\(": .*`)
+}
+
+func (s *S) TestAppListFilteringInvalidStatus(c *check.C) {
+	s.setupFakeTransport(&cmdtest.Transport{Message: "[]", Status: http.StatusOK})
+	context := cmd.Context{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	command := AppList{}
+	command.Flags().Parse(true, []string{"--status", "error", "--status", "broken"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `invalid --status value "broken", use one of: created, building, error, starting, started, stopped, success`)
+}
+
+func (s *S) TestAppListStatusesAreParsed(c *check.C) {
+	for _, status := range appListStatuses {
+		_, err := provTypes.ParseUnitStatus(status)
+		c.Check(err, check.IsNil, check.Commentf("status %q", status))
+	}
+}
+
 func (s *S) TestAppListFilteringMe(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	result := `[{"ip":"10.10.10.10","cname":["app1.tsuru.io"],"name":"app1","units":[{"ID":"app1/0","Status":"started"}]}]`
@@ -2646,6 +3392,7 @@ func (s *S) TestAppListFilteringMe(c *check.C) {
 | app1        | 1 started | app1.tsuru.io (cname) |
 |             |           | 10.10.10.10           |
 +-------------+-----------+-----------------------+
+Total: 1 app, 1 unit
 `
 	context := cmd.Context{
 		Args:   []string{},
@@ -2691,6 +3438,7 @@ func (s *S) TestAppListSortByCountAndStatus(c *check.C) {
 |             | 1 asleep   |                       |
 |             | 1 starting |                       |
 +-------------+------------+-----------------------+
+Total: 1 app, 6 units
 `
 	context := cmd.Context{
 		Args:   []string{},
@@ -2725,47 +3473,162 @@ func (s *S) TestAppListSortByCountAndStatus(c *check.C) {
 	c.Assert(request.URL.Query(), check.DeepEquals, queryString)
 }
 
-func (s *S) TestAppListWithFlagQ(c *check.C) {
+func (s *S) TestAppListSortByUnits(c *check.C) {
 	var stdout, stderr bytes.Buffer
-	result := `[{"ip":"10.10.10.10","name":"app1","units":[{"ID":"app1/0","Status":"started"}]},{"ip":"10.10.10.11","name":"app2","units":[{"ID":"app2/0","Status":"started"}]},{"ip":"10.10.10.12","cname":["app3.tsuru.io"],"name":"app3","units":[{"ID":"app3/0","Status":"started"}]}]`
-	expected := `app1
-app2
-app3
+	result := `[{"ip":"10.10.10.10","name":"app1","units":[{"ID":"app1/0","Status":"started"}]},{"ip":"10.10.10.11","name":"app2","units":[{"ID":"app2/0","Status":"started"},{"ID":"app2/1","Status":"started"}]},{"ip":"10.10.10.12","name":"app3","units":[]}]`
+	expected := `+-------------+-------+-----------+-------------+
+| Application | Count | Units     | Address     |
++-------------+-------+-----------+-------------+
+| app2        | 2     | 2 started | 10.10.10.11 |
++-------------+-------+-----------+-------------+
+| app1        | 1     | 1 started | 10.10.10.10 |
++-------------+-------+-----------+-------------+
+| app3        | 0     |           | 10.10.10.12 |
++-------------+-------+-----------+-------------+
+Total: 3 apps, 3 units
 `
 	context := cmd.Context{
 		Args:   []string{},
 		Stdout: &stdout,
 		Stderr: &stderr,
 	}
-	transport := cmdtest.ConditionalTransport{
-		CondFunc: func(r *http.Request) bool {
-			return true
-		},
-		Transport: cmdtest.Transport{Message: result, Status: http.StatusOK},
-	}
-	s.setupFakeTransport(&transport)
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
 	command := AppList{}
-	command.Flags().Parse(true, []string{"-q"})
+	command.Flags().Parse(true, []string{"--sort", "units"})
 	err := command.Run(&context)
 	c.Assert(err, check.IsNil)
 	c.Assert(stdout.String(), check.Equals, expected)
+
+	stdout.Reset()
+	command = AppList{}
+	command.Flags().Parse(true, []string{"--sort", "units", "--reverse"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Index(stdout.String(), "app3") < strings.Index(stdout.String(), "app2"), check.Equals, true)
 }
 
-func (s *S) TestAppListWithFlags(c *check.C) {
-	var stdout, stderr bytes.Buffer
-	result := `[{"name":"app1","platform":"python","pool":"pool2"},{"name":"app2","platform":"python","pool":"pool2"},{"name":"app3","platform":"go","pool":"pool1"}]`
-	expected := `app1
-app2
-app3
-`
-	context := cmd.Context{
-		Args:   []string{},
-		Stdout: &stdout,
-		Stderr: &stderr,
-	}
-	var request *http.Request
-	transport := cmdtest.ConditionalTransport{
-		CondFunc: func(r *http.Request) bool {
+func (s *S) TestAppListFormat(c *check.C) {
+	var stdout bytes.Buffer
+	result := `[{"name":"app2","pool":"pool1","units":[{"ID":"app2/0"},{"ID":"app2/1"}]},{"name":"app1","pool":"pool2","tags":["a","b"]}]`
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := AppList{}
+	err := command.Flags().Parse(true, []string{"--format", `{{.Name}} {{.Pool}} {{.UnitCount}} {{join .Tags ","}}`})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&cmd.Context{Stdout: &stdout})
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "app1 pool2 0 a,b\napp2 pool1 2 \n")
+	stdout.Reset()
+	command = AppList{}
+	err = command.Flags().Parse(true, []string{"--format", "{{.Name}}", "--sort", "units"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&cmd.Context{Stdout: &stdout})
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "app2\napp1\n")
+}
+
+func (s *S) TestAppListInvalidFormat(c *check.C) {
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: "[]", Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			c.Errorf("unexpected request to %s", req.URL)
+			return false
+		},
+	}
+	s.setupFakeTransport(trans)
+	for _, args := range [][]string{
+		{"--format", "{{.Name"},
+		{"--format", "{{.Name}}", "--output", "json"},
+		{"--format", "{{.Name}}", "-q"},
+	} {
+		command := AppList{}
+		err := command.Flags().Parse(true, args)
+		c.Assert(err, check.IsNil)
+		err = command.Run(&cmd.Context{Stdout: io.Discard})
+		c.Assert(err, check.NotNil)
+	}
+	command := AppList{}
+	command.Flags().Parse(true, []string{"--format", "{{.Name"})
+	err := command.Run(&cmd.Context{Stdout: io.Discard})
+	c.Assert(err, check.ErrorMatches, "invalid --format template: .*")
+}
+
+func (s *S) TestAppListInvalidSort(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	s.setupFakeTransport(&cmdtest.Transport{Message: `[]`, Status: http.StatusOK})
+	command := AppList{}
+	command.Flags().Parse(true, []string{"--sort", "memory"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `invalid sort option "memory".*`)
+}
+
+func (s *S) TestAppsByUnits(c *check.C) {
+	started := []unit{{ID: "u1", Status: "started"}, {ID: "u2", Status: "started"}}
+	apps := []app{
+		{Name: "b", Units: started[:1]},
+		{Name: "c", Units: started},
+		{Name: "a", Units: started[:1]},
+		{Name: "d", Units: []unit{{Status: "started"}}},
+	}
+	sort.Sort(appsByUnits(apps))
+	var names []string
+	for _, a := range apps {
+		names = append(names, a.Name)
+	}
+	c.Assert(names, check.DeepEquals, []string{"c", "a", "b", "d"})
+	sort.Sort(sort.Reverse(appsByUnits(apps)))
+	names = nil
+	for _, a := range apps {
+		names = append(names, a.Name)
+	}
+	c.Assert(names, check.DeepEquals, []string{"d", "b", "a", "c"})
+}
+
+func (s *S) TestAppListWithFlagQ(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	result := `[{"ip":"10.10.10.10","name":"app1","units":[{"ID":"app1/0","Status":"started"}]},{"ip":"10.10.10.11","name":"app2","units":[{"ID":"app2/0","Status":"started"}]},{"ip":"10.10.10.12","cname":["app3.tsuru.io"],"name":"app3","units":[{"ID":"app3/0","Status":"started"}]}]`
+	expected := `app1
+app2
+app3
+`
+	context := cmd.Context{
+		Args:   []string{},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	transport := cmdtest.ConditionalTransport{
+		CondFunc: func(r *http.Request) bool {
+			return true
+		},
+		Transport: cmdtest.Transport{Message: result, Status: http.StatusOK},
+	}
+	s.setupFakeTransport(&transport)
+	command := AppList{}
+	command.Flags().Parse(true, []string{"-q"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestAppListWithFlags(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	result := `[{"name":"app1","platform":"python","pool":"pool2"},{"name":"app2","platform":"python","pool":"pool2"},{"name":"app3","platform":"go","pool":"pool1"}]`
+	expected := `app1
+app2
+app3
+`
+	context := cmd.Context{
+		Args:   []string{},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	var request *http.Request
+	transport := cmdtest.ConditionalTransport{
+		CondFunc: func(r *http.Request) bool {
 			request = r
 			return true
 		},
@@ -2784,6 +3647,31 @@ app3
 	c.Assert(request.URL.Query(), check.DeepEquals, queryString)
 }
 
+func (s *S) TestColorUnitStatus(c *check.C) {
+	c.Assert(colorUnitStatus("started", false), check.Equals, "started")
+	c.Assert(colorUnitStatus("started", true), check.Equals, cmd.Colorfy("started", "green", "", ""))
+	c.Assert(colorUnitStatus("error (CrashLoopBackOff)", true), check.Equals, cmd.Colorfy("error (CrashLoopBackOff)", "red", "", ""))
+	c.Assert(colorUnitStatus("starting", true), check.Equals, cmd.Colorfy("starting", "yellow", "", ""))
+	c.Assert(colorUnitStatus("unknown", true), check.Equals, "unknown")
+}
+
+func (s *S) TestUseColors(c *check.C) {
+	var buf bytes.Buffer
+	c.Assert(useColors(&buf, false), check.Equals, false)
+	f, err := os.CreateTemp(c.MkDir(), "out")
+	c.Assert(err, check.IsNil)
+	defer f.Close()
+	c.Assert(useColors(f, false), check.Equals, false)
+	old := isTerminalWriter
+	defer func() { isTerminalWriter = old }()
+	isTerminalWriter = func(io.Writer) bool { return true }
+	c.Assert(useColors(&buf, false), check.Equals, true)
+	c.Assert(useColors(&buf, true), check.Equals, false)
+	os.Setenv("NO_COLOR", "1")
+	defer os.Unsetenv("NO_COLOR")
+	c.Assert(useColors(&buf, false), check.Equals, false)
+}
+
 func (s *S) TestAppListInfo(c *check.C) {
 	c.Assert((&AppList{}).Info(), check.NotNil)
 }
@@ -2805,12 +3693,20 @@ func (s *S) TestAppRestart(c *check.C) {
 	msg := tsuruIo.SimpleJsonMessage{Message: expectedOut}
 	result, err := json.Marshal(msg)
 	c.Assert(err, check.IsNil)
-	trans := &cmdtest.ConditionalTransport{
-		Transport: cmdtest.Transport{Message: string(result), Status: http.StatusOK},
-		CondFunc: func(req *http.Request) bool {
-			called = true
-			c.Assert(req.FormValue("process"), check.Equals, "web")
-			return strings.HasSuffix(req.URL.Path, "/apps/handful_of_nothing/restart") && req.Method == "POST"
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			processAppInfoTransport("handful_of_nothing"),
+			{
+				Transport: cmdtest.Transport{Message: string(result), Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					if !strings.HasSuffix(req.URL.Path, "/apps/handful_of_nothing/restart") || req.Method != "POST" {
+						return false
+					}
+					called = true
+					c.Assert(req.FormValue("process"), check.Equals, "web")
+					return true
+				},
+			},
 		},
 	}
 	s.setupFakeTransport(trans)
@@ -2819,7 +3715,185 @@ func (s *S) TestAppRestart(c *check.C) {
 	err = command.Run(&context)
 	c.Assert(err, check.IsNil)
 	c.Assert(called, check.Equals, true)
-	c.Assert(stdout.String(), check.Equals, expectedOut)
+	c.Assert(stdout.String(), check.Equals, "Restarting process web.\n"+expectedOut)
+}
+
+func (s *S) TestAppRestartCI(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	msg := tsuruIo.SimpleJsonMessage{Message: "-- restarted --"}
+	result, err := json.Marshal(msg)
+	c.Assert(err, check.IsNil)
+	s.setupFakeTransport(&cmdtest.Transport{Message: string(result), Status: http.StatusOK})
+	command := AppRestart{}
+	command.Flags().Parse(true, []string{"--app", "handful_of_nothing", "--ci"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "OK\n")
+}
+
+func (s *S) TestAppRestartCIWithError(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	msg := tsuruIo.SimpleJsonMessage{Message: "-- restarting --"}
+	okResult, err := json.Marshal(msg)
+	c.Assert(err, check.IsNil)
+	msg = tsuruIo.SimpleJsonMessage{Error: "unable to restart"}
+	errResult, err := json.Marshal(msg)
+	c.Assert(err, check.IsNil)
+	s.setupFakeTransport(&cmdtest.Transport{Message: string(okResult) + "\n" + string(errResult), Status: http.StatusOK})
+	command := AppRestart{}
+	command.Flags().Parse(true, []string{"--app", "handful_of_nothing", "--ci"})
+	err = command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "unable to restart")
+	c.Assert(stdout.String(), check.Equals, "")
+}
+
+func (s *S) TestAppRestartQuiet(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	msg := tsuruIo.SimpleJsonMessage{Message: "-- restarted --"}
+	result, err := json.Marshal(msg)
+	c.Assert(err, check.IsNil)
+	s.setupFakeTransport(&cmdtest.Transport{Message: string(result), Status: http.StatusOK})
+	command := AppRestart{}
+	command.Flags().Parse(true, []string{"--app", "handful_of_nothing", "--quiet"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "OK\n")
+}
+
+func (s *S) TestAppRestartScheduled(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	var waited time.Time
+	oldWait := waitRestart
+	defer func() { waitRestart = oldWait }()
+	waitRestart = func(w io.Writer, appName string, target time.Time) error {
+		c.Assert(appName, check.Equals, "handful_of_nothing")
+		waited = target
+		return nil
+	}
+	msg := tsuruIo.SimpleJsonMessage{Message: "-- restarted --"}
+	result, err := json.Marshal(msg)
+	c.Assert(err, check.IsNil)
+	s.setupFakeTransport(&cmdtest.Transport{Message: string(result), Status: http.StatusOK})
+	command := AppRestart{}
+	command.Flags().Parse(true, []string{"--app", "handful_of_nothing", "--at", "2099-01-01T02:00:00Z"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(waited.Equal(time.Date(2099, 1, 1, 2, 0, 0, 0, time.UTC)), check.Equals, true)
+	c.Assert(stdout.String(), check.Equals, "Restarting all processes.\n-- restarted --")
+}
+
+func (s *S) TestAppRestartScheduledCanceled(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	oldWait := waitRestart
+	defer func() { waitRestart = oldWait }()
+	waitRestart = func(w io.Writer, appName string, target time.Time) error {
+		return errors.New("scheduled restart canceled")
+	}
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			c.Errorf("unexpected request to %s", req.URL)
+			return false
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppRestart{}
+	command.Flags().Parse(true, []string{"--app", "handful_of_nothing", "--in", "2h"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "scheduled restart canceled")
+}
+
+func (s *S) TestAppRestartScheduledTime(c *check.C) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	command := AppRestart{in: 2 * time.Hour}
+	target, err := command.scheduledTime(now)
+	c.Assert(err, check.IsNil)
+	c.Assert(target, check.DeepEquals, now.Add(2*time.Hour))
+	command = AppRestart{}
+	target, err = command.scheduledTime(now)
+	c.Assert(err, check.IsNil)
+	c.Assert(target.IsZero(), check.Equals, true)
+	command = AppRestart{at: "2024-01-01T02:00:00Z", in: time.Hour}
+	_, err = command.scheduledTime(now)
+	c.Assert(err, check.ErrorMatches, "the --at and --in flags are mutually exclusive")
+	command = AppRestart{at: "2023-12-31T23:00:00Z"}
+	_, err = command.scheduledTime(now)
+	c.Assert(err, check.ErrorMatches, "the time 2023-12-31T23:00:00Z is in the past")
+	command = AppRestart{at: "tomorrow"}
+	_, err = command.scheduledTime(now)
+	c.Assert(err, check.ErrorMatches, `invalid value "tomorrow" for --at, .*`)
+}
+
+func processAppInfoTransport(appName string) cmdtest.ConditionalTransport {
+	return cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{
+			Message: `{"name": "` + appName + `", "processes": [{"name": "web"}, {"name": "worker"}]}`,
+			Status:  http.StatusOK,
+		},
+		CondFunc: func(req *http.Request) bool {
+			return strings.HasSuffix(req.URL.Path, "/apps/"+appName) && req.Method == "GET"
+		},
+	}
+}
+
+func (s *S) TestAppRestartUnknownProcess(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	oldWait := waitRestart
+	defer func() { waitRestart = oldWait }()
+	waitRestart = func(w io.Writer, appName string, target time.Time) error {
+		c.Error("the restart must not be scheduled for an unknown process")
+		return nil
+	}
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{processAppInfoTransport("handful_of_nothing")},
+	}
+	s.setupFakeTransport(trans)
+	command := AppRestart{}
+	command.Flags().Parse(true, []string{"--app", "handful_of_nothing", "--process", "api", "--in", "2h"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `process "api" not found in app "handful_of_nothing", the available processes are: web, worker`)
+	c.Assert(stdout.String(), check.Equals, "")
+}
+
+func (s *S) TestAppRestartAllProcesses(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	msg := tsuruIo.SimpleJsonMessage{Message: "-- restarted --"}
+	result, err := json.Marshal(msg)
+	c.Assert(err, check.IsNil)
+	s.setupFakeTransport(&cmdtest.Transport{Message: string(result), Status: http.StatusOK})
+	command := AppRestart{}
+	command.Flags().Parse(true, []string{"--app", "handful_of_nothing"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "Restarting all processes.\n-- restarted --")
 }
 
 func (s *S) TestAppRestartInfo(c *check.C) {
@@ -2879,6 +3953,126 @@ func (s *S) TestAddCNameFailure(c *check.C) {
 	c.Assert(err, check.ErrorMatches, ".* Invalid cname")
 }
 
+// cnameTransport serves app death, which has the cname
+// defined.evergrey.mycompany.com, rejecting the cname requests that the API
+// would reject and recording the cnames of the accepted ones.
+func cnameTransport(accepted *[]string) *cmdtest.AnyConditionalTransport {
+	const defined = "defined.evergrey.mycompany.com"
+	countDefined := func(req *http.Request) int {
+		req.ParseForm()
+		count := 0
+		for _, cname := range req.Form["cname"] {
+			if cname == defined {
+				count++
+			}
+		}
+		return count
+	}
+	return &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: `{"name": "death", "cname": ["` + defined + `"]}`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodGet && req.URL.Path == "/1.0/apps/death"
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: "cname " + defined + " already exists for this app", Status: http.StatusBadRequest},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodPost && countDefined(req) > 0
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: "cname missing.evergrey.mycompany.com not exists in app", Status: http.StatusBadRequest},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodDelete && countDefined(req) < len(req.Form["cname"])
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: "", Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					req.ParseForm()
+					*accepted = append(*accepted, req.Form["cname"]...)
+					return true
+				},
+			},
+		},
+	}
+}
+
+func (s *S) TestAddCNameIgnoreExisting(c *check.C) {
+	var stdout bytes.Buffer
+	var accepted []string
+	s.setupFakeTransport(cnameTransport(&accepted))
+	command := CnameAdd{}
+	err := command.Flags().Parse(true, []string{"-a", "death", "--ignore-existing", "defined.evergrey.mycompany.com", "new.evergrey.mycompany.com"})
+	c.Assert(err, check.IsNil)
+	context := cmd.Context{Args: command.Flags().Args(), Stdout: &stdout}
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(accepted, check.DeepEquals, []string{"new.evergrey.mycompany.com"})
+	c.Assert(stdout.String(), check.Equals, `cname defined.evergrey.mycompany.com is already defined in app "death", skipping.
+cname successfully defined.
+`)
+}
+
+func (s *S) TestAddCNameIgnoreExistingAllDefined(c *check.C) {
+	var stdout bytes.Buffer
+	var accepted []string
+	s.setupFakeTransport(cnameTransport(&accepted))
+	command := CnameAdd{}
+	err := command.Flags().Parse(true, []string{"-a", "death", "--ignore-existing", "defined.evergrey.mycompany.com"})
+	c.Assert(err, check.IsNil)
+	context := cmd.Context{Args: command.Flags().Args(), Stdout: &stdout}
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(accepted, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "cname defined.evergrey.mycompany.com is already defined in app \"death\", skipping.\n")
+}
+
+func (s *S) TestAddCNameExistingWithoutIgnore(c *check.C) {
+	var accepted []string
+	s.setupFakeTransport(cnameTransport(&accepted))
+	command := CnameAdd{}
+	err := command.Flags().Parse(true, []string{"-a", "death", "defined.evergrey.mycompany.com"})
+	c.Assert(err, check.IsNil)
+	context := cmd.Context{Args: command.Flags().Args(), Stdout: io.Discard}
+	err = command.Run(&context)
+	c.Assert(err, check.ErrorMatches, ".*cname defined.evergrey.mycompany.com already exists for this app")
+}
+
+func (s *S) TestAddCNameIgnoreExistingOtherApp(c *check.C) {
+	s.setupFakeTransport(&cmdtest.Transport{Message: "cname taken.evergrey.mycompany.com already exists for app other using same router", Status: http.StatusBadRequest})
+	command := CnameAdd{}
+	err := command.Flags().Parse(true, []string{"-a", "death", "--ignore-existing", "taken.evergrey.mycompany.com"})
+	c.Assert(err, check.IsNil)
+	context := cmd.Context{Args: command.Flags().Args(), Stdout: io.Discard}
+	err = command.Run(&context)
+	c.Assert(err, check.ErrorMatches, ".*already exists for app other using same router")
+}
+
+func (s *S) TestCNameErrorMatching(c *check.C) {
+	tests := []struct {
+		message string
+		re      *regexp.Regexp
+		matches bool
+	}{
+		{"cname a.example.com already exists for this app", cnameExistsRE, true},
+		{"CName already exists", cnameExistsRE, true},
+		{"CName already exists (a.example.com)", cnameExistsRE, true},
+		{"cname a.example.com already exists for app other using same router", cnameExistsRE, false},
+		{"cname a.example.com already exists for another app other and belongs to a different team owner", cnameExistsRE, false},
+		{"cname a.example.com not exists in app", cnameMissingRE, true},
+		{"cname does not exist in app", cnameMissingRE, true},
+		{"Invalid cname", cnameMissingRE, false},
+	}
+	for _, tt := range tests {
+		err := &tsuruErrors.HTTP{Code: http.StatusBadRequest, Message: tt.message}
+		c.Check(isCNameError(err, tt.re), check.Equals, tt.matches, check.Commentf("%q", tt.message))
+	}
+	c.Check(isCNameError(errors.New("cname does not exist in app"), cnameMissingRE), check.Equals, false)
+}
+
 func (s *S) TestAddCNameInfo(c *check.C) {
 	c.Assert((&CnameAdd{}).Info(), check.NotNil)
 }
@@ -2914,6 +4108,33 @@ func (s *S) TestRemoveCName(c *check.C) {
 	c.Assert(stdout.String(), check.Equals, "cname successfully undefined.\n")
 }
 
+func (s *S) TestRemoveCNameIgnoreMissing(c *check.C) {
+	var stdout bytes.Buffer
+	var accepted []string
+	s.setupFakeTransport(cnameTransport(&accepted))
+	command := CnameRemove{}
+	err := command.Flags().Parse(true, []string{"-a", "death", "--ignore-missing", "defined.evergrey.mycompany.com", "missing.evergrey.mycompany.com"})
+	c.Assert(err, check.IsNil)
+	context := cmd.Context{Args: command.Flags().Args(), Stdout: &stdout}
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(accepted, check.DeepEquals, []string{"defined.evergrey.mycompany.com"})
+	c.Assert(stdout.String(), check.Equals, `cname missing.evergrey.mycompany.com is not defined in app "death", skipping.
+cname successfully undefined.
+`)
+}
+
+func (s *S) TestRemoveCNameMissingWithoutIgnore(c *check.C) {
+	var accepted []string
+	s.setupFakeTransport(cnameTransport(&accepted))
+	command := CnameRemove{}
+	err := command.Flags().Parse(true, []string{"-a", "death", "missing.evergrey.mycompany.com"})
+	c.Assert(err, check.IsNil)
+	context := cmd.Context{Args: command.Flags().Args(), Stdout: io.Discard}
+	err = command.Run(&context)
+	c.Assert(err, check.ErrorMatches, ".*cname missing.evergrey.mycompany.com not exists in app")
+}
+
 func (s *S) TestRemoveCNameWithoutTheFlag(c *check.C) {
 	var (
 		called         bool
@@ -2941,6 +4162,117 @@ func (s *S) TestRemoveCNameWithoutTheFlag(c *check.C) {
 	c.Assert(stdout.String(), check.Equals, "cname successfully undefined.\n")
 }
 
+func (s *S) TestRemoveCNameCheckDNSLiveDomain(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Stdin:  strings.NewReader("death.example.com\n"),
+	}
+	oldLookupHost := lookupHost
+	defer func() { lookupHost = oldLookupHost }()
+	lookupHost = func(host string) ([]string, error) {
+		return []string{"10.10.10.10"}, nil
+	}
+	var removed bool
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: `{"name":"death","ip":"death.tsuru.io"}`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/apps/death")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					removed = req.Method == http.MethodDelete && strings.HasSuffix(req.URL.Path, "/apps/death/cname") &&
+						req.URL.Query().Get("cname") == "death.example.com"
+					return removed
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := CnameRemove{}
+	command.Flags().Parse(true, []string{"--app", "death", "--check-dns", "death.example.com"})
+	context.Args = command.Flags().Args()
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(removed, check.Equals, true)
+	expected := `WARNING: the following cnames currently resolve to app "death", removing them will affect live traffic: death.example.com
+Type the domain "death.example.com" to confirm its removal: cname successfully undefined.
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestRemoveCNameCheckDNSLiveDomainAbort(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Stdin:  strings.NewReader("other.example.com\n"),
+		Args:   []string{"death.example.com"},
+	}
+	oldLookupHost := lookupHost
+	defer func() { lookupHost = oldLookupHost }()
+	lookupHost = func(host string) ([]string, error) {
+		return []string{"10.10.10.10"}, nil
+	}
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: `{"name":"death","ip":"death.tsuru.io"}`, Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/apps/death")
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := CnameRemove{}
+	command.Flags().Parse(true, []string{"--app", "death", "--check-dns"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Matches, `(?s).*Abort\.\n$`)
+	c.Assert(strings.Contains(stdout.String(), "successfully"), check.Equals, false)
+}
+
+func (s *S) TestRemoveCNameCheckDNSNotLive(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Args:   []string{"death.example.com"},
+	}
+	oldLookupHost := lookupHost
+	defer func() { lookupHost = oldLookupHost }()
+	lookupHost = func(host string) ([]string, error) {
+		if host == "death.example.com" {
+			return []string{"10.20.30.40"}, nil
+		}
+		return []string{"10.10.10.10"}, nil
+	}
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: `{"name":"death","ip":"death.tsuru.io"}`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodGet
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodDelete
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := CnameRemove{}
+	command.Flags().Parse(true, []string{"--app", "death", "--check-dns"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "cname successfully undefined.\n")
+}
+
 func (s *S) TestRemoveCNameInfo(c *check.C) {
 	c.Assert((&CnameRemove{}).Info(), check.NotNil)
 }
@@ -2966,12 +4298,20 @@ func (s *S) TestAppStart(c *check.C) {
 	msg := tsuruIo.SimpleJsonMessage{Message: expectedOut}
 	result, err := json.Marshal(msg)
 	c.Assert(err, check.IsNil)
-	trans := &cmdtest.ConditionalTransport{
-		Transport: cmdtest.Transport{Message: string(result), Status: http.StatusOK},
-		CondFunc: func(req *http.Request) bool {
-			called = true
-			c.Assert(req.FormValue("process"), check.Equals, "worker")
-			return strings.HasSuffix(req.URL.Path, "/apps/handful_of_nothing/start") && req.Method == "POST"
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			processAppInfoTransport("handful_of_nothing"),
+			{
+				Transport: cmdtest.Transport{Message: string(result), Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					if !strings.HasSuffix(req.URL.Path, "/apps/handful_of_nothing/start") || req.Method != "POST" {
+						return false
+					}
+					called = true
+					c.Assert(req.FormValue("process"), check.Equals, "worker")
+					return true
+				},
+			},
 		},
 	}
 	s.setupFakeTransport(trans)
@@ -2980,7 +4320,27 @@ func (s *S) TestAppStart(c *check.C) {
 	err = command.Run(&context)
 	c.Assert(err, check.IsNil)
 	c.Assert(called, check.Equals, true)
-	c.Assert(stdout.String(), check.Equals, expectedOut)
+	c.Assert(stdout.String(), check.Equals, "Starting process worker.\n"+expectedOut)
+}
+
+func (s *S) TestAppStartAllInPool(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	var started []string
+	s.setupFakeTransport(poolActionTransport("start", "", &started))
+	command := AppStart{}
+	command.Flags().Parse(true, []string{"--all-in-pool", "pool1"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(started, check.DeepEquals, []string{"app1", "app2", "app3"})
+	c.Assert(stdout.String(), check.Equals, `[1/3] app1: started
+[2/3] app2: started
+[3/3] app3: started
+All 3 apps in pool "pool1" were started.
+`)
 }
 
 func (s *S) TestAppStartIsAFlaggedCommand(c *check.C) {
@@ -3031,12 +4391,20 @@ func (s *S) TestAppStop(c *check.C) {
 	msg := tsuruIo.SimpleJsonMessage{Message: expectedOut}
 	result, err := json.Marshal(msg)
 	c.Assert(err, check.IsNil)
-	trans := &cmdtest.ConditionalTransport{
-		Transport: cmdtest.Transport{Message: string(result), Status: http.StatusOK},
-		CondFunc: func(req *http.Request) bool {
-			called = true
-			c.Assert(req.FormValue("process"), check.Equals, "worker")
-			return strings.HasSuffix(req.URL.Path, "/apps/handful_of_nothing/stop") && req.Method == "POST"
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			processAppInfoTransport("handful_of_nothing"),
+			{
+				Transport: cmdtest.Transport{Message: string(result), Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					if !strings.HasSuffix(req.URL.Path, "/apps/handful_of_nothing/stop") || req.Method != "POST" {
+						return false
+					}
+					called = true
+					c.Assert(req.FormValue("process"), check.Equals, "worker")
+					return true
+				},
+			},
 		},
 	}
 	s.setupFakeTransport(trans)
@@ -3045,7 +4413,67 @@ func (s *S) TestAppStop(c *check.C) {
 	err = command.Run(&context)
 	c.Assert(err, check.IsNil)
 	c.Assert(called, check.Equals, true)
-	c.Assert(stdout.String(), check.Equals, expectedOut)
+	c.Assert(stdout.String(), check.Equals, "Stopping process worker.\n"+expectedOut)
+}
+
+func (s *S) TestAppStopUnknownProcess(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	s.setupFakeTransport(&cmdtest.Transport{Message: `{"name": "handful_of_nothing"}`, Status: http.StatusOK})
+	command := AppStop{}
+	command.Flags().Parse(true, []string{"--app", "handful_of_nothing", "--process", "worker"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `process "worker" not found, app "handful_of_nothing" has no processes`)
+}
+
+func (s *S) TestAppStopAllInPool(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	var stopped []string
+	s.setupFakeTransport(poolActionTransport("stop", "", &stopped))
+	command := AppStop{}
+	command.Flags().Parse(true, []string{"--all-in-pool", "pool1", "-y"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stopped, check.DeepEquals, []string{"app1", "app2", "app3"})
+	c.Assert(stdout.String(), check.Equals, `[1/3] app1: stopped
+[2/3] app2: stopped
+[3/3] app3: stopped
+All 3 apps in pool "pool1" were stopped.
+`)
+}
+
+func (s *S) TestAppStopAllInPoolContinueOnError(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	var stopped []string
+	s.setupFakeTransport(poolActionTransport("stop", "app2", &stopped))
+	command := AppStop{}
+	command.Flags().Parse(true, []string{"--all-in-pool", "pool1", "-y", "--continue-on-error"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "failed to stop 1 of 3 apps")
+	c.Assert(stopped, check.DeepEquals, []string{"app1", "app3"})
+	c.Assert(stdout.String(), check.Equals, `[1/3] app1: stopped
+[2/3] app2: failed: app is locked
+[3/3] app3: stopped
+`)
+}
+
+func (s *S) TestAppStopAllInPoolWithApp(c *check.C) {
+	context := cmd.Context{Args: []string{"app1"}}
+	command := AppStop{}
+	command.Flags().Parse(true, []string{"--all-in-pool", "pool1", "-y"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "the --all-in-pool flag can't be used with an app name")
 }
 
 func (s *S) TestAppStopIsAFlaggedCommand(c *check.C) {
@@ -3121,3 +4549,74 @@ func (s *S) TestAppProcessUpdateReset(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Assert(stdout.String(), check.Equals, "stream\n"+expectedOut)
 }
+
+// chdirTemp changes the working directory to a temporary one, returning a
+// function that restores it.
+func chdirTemp(c *check.C) func() {
+	wd, err := os.Getwd()
+	c.Assert(err, check.IsNil)
+	err = os.Chdir(c.MkDir())
+	c.Assert(err, check.IsNil)
+	return func() { os.Chdir(wd) }
+}
+
+func (s *S) TestAppUse(c *check.C) {
+	defer chdirTemp(c)()
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Args: []string{"myapp"}, Stdout: &stdout, Stderr: &stderr}
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: `{"name":"myapp"}`, Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/apps/myapp")
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppUse{}
+	command.Flags().Parse(true, []string{})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "Commands run in this directory will use app \"myapp\", set in .tsuru/app.\n")
+	data, err := os.ReadFile(filepath.Join(".tsuru", "app"))
+	c.Assert(err, check.IsNil)
+	c.Assert(string(data), check.Equals, "myapp\n")
+	name, err := (&tsuruClientApp.AppNameMixIn{}).AppNameByFlag()
+	c.Assert(err, check.IsNil)
+	c.Assert(name, check.Equals, "myapp")
+}
+
+func (s *S) TestAppUseAppNotFound(c *check.C) {
+	defer chdirTemp(c)()
+	context := cmd.Context{Args: []string{"myapp"}, Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	s.setupFakeTransport(&cmdtest.Transport{Message: "App myapp not found.", Status: http.StatusNotFound})
+	command := AppUse{}
+	command.Flags().Parse(true, []string{})
+	err := command.Run(&context)
+	c.Assert(err, check.NotNil)
+	_, err = os.Stat(filepath.Join(".tsuru", "app"))
+	c.Assert(os.IsNotExist(err), check.Equals, true)
+}
+
+func (s *S) TestAppUseUnset(c *check.C) {
+	defer chdirTemp(c)()
+	err := os.MkdirAll(".tsuru", 0755)
+	c.Assert(err, check.IsNil)
+	err = os.WriteFile(filepath.Join(".tsuru", "app"), []byte("myapp"), 0644)
+	c.Assert(err, check.IsNil)
+	var stdout bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &bytes.Buffer{}}
+	command := AppUse{}
+	command.Flags().Parse(true, []string{"--unset"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "Removed .tsuru/app.\n")
+	_, err = os.Stat(filepath.Join(".tsuru", "app"))
+	c.Assert(os.IsNotExist(err), check.Equals, true)
+}
+
+func (s *S) TestAppUseWithoutName(c *check.C) {
+	context := cmd.Context{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	command := AppUse{}
+	command.Flags().Parse(true, []string{})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "the name of the app is required, or --unset to remove the current one")
+}
@@ -0,0 +1,348 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/tsuru/gnuflag"
+	"github.com/tsuru/go-tsuruclient/pkg/config"
+	"github.com/tsuru/tsuru-client/tsuru/formatter"
+	tsuruHTTP "github.com/tsuru/tsuru-client/tsuru/http"
+	"github.com/tsuru/tsuru/cmd"
+	apiTypes "github.com/tsuru/tsuru/types/api"
+)
+
+const defaultBootstrapWaitTimeout = 5 * time.Minute
+
+type bootstrapService struct {
+	Service  string `json:"service"`
+	Instance string `json:"instance"`
+}
+
+// bootstrapManifest is the file used by app-bootstrap to describe an app.
+type bootstrapManifest struct {
+	Name        string             `json:"name"`
+	Platform    string             `json:"platform"`
+	Plan        string             `json:"plan"`
+	Pool        string             `json:"pool"`
+	Team        string             `json:"team"`
+	Router      string             `json:"router"`
+	Description string             `json:"description"`
+	Tags        []string           `json:"tags"`
+	Services    []bootstrapService `json:"services"`
+	Env         map[string]string  `json:"env"`
+	PrivateEnv  map[string]string  `json:"private-env"`
+	CNames      []string           `json:"cnames"`
+	Image       string             `json:"image"`
+	Units       map[string]int     `json:"units"`
+	WaitTimeout string             `json:"wait-timeout"`
+}
+
+type bootstrapStep struct {
+	desc string
+	run  func(w io.Writer) error
+}
+
+type AppBootstrap struct {
+	fs       *gnuflag.FlagSet
+	manifest string
+}
+
+func (c *AppBootstrap) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "app-bootstrap",
+		Usage: "app bootstrap --manifest <file>",
+		Desc: `Creates an application and sets it up from a manifest file in one command. The
+app is created, bound to the service instances and has its environment
+variables and CNAMEs set. When an image is given, it's deployed to the app, and
+the processes are scaled to the given number of units, waiting for all units
+to be started. Units can only be given with an image, as an app must be
+deployed before it has units.
+
+Example of manifest:
+
+    name: myapp
+    platform: python
+    plan: small
+    pool: prod
+    team: myteam
+    services:
+      - service: mysql
+        instance: myapp-db
+    env:
+      LOG_LEVEL: info
+    private-env:
+      API_TOKEN: secret
+    cnames:
+      - myapp.example.com
+    image: registry.example.com/myteam/myapp:v1
+    units:
+      web: 2
+    wait-timeout: 5m
+
+Each step is reported as it runs, and the command stops on the first failure.
+The app is kept in this case, so the remaining steps can be applied manually or
+the app can be removed with [[tsuru app-remove]]. Binding services and setting
+environment variables don't restart the app.`,
+		MinArgs: 0,
+		MaxArgs: 0,
+	}
+}
+
+func (c *AppBootstrap) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = gnuflag.NewFlagSet("app-bootstrap", gnuflag.ExitOnError)
+		c.fs.StringVar(&c.manifest, "manifest", "", "Path to the manifest file describing the app")
+		c.fs.StringVar(&c.manifest, "m", "", "Path to the manifest file describing the app")
+	}
+	return c.fs
+}
+
+func (c *AppBootstrap) Run(context *cmd.Context) error {
+	if c.manifest == "" {
+		return errors.New("please use the --manifest flag to specify the manifest file")
+	}
+	data, err := os.ReadFile(c.manifest)
+	if err != nil {
+		return err
+	}
+	m, err := parseBootstrapManifest(data)
+	if err != nil {
+		return err
+	}
+	steps, err := bootstrapSteps(m)
+	if err != nil {
+		return err
+	}
+	for i, step := range steps {
+		fmt.Fprintf(context.Stdout, "[%d/%d] %s\n", i+1, len(steps), step.desc)
+		err = step.run(context.Stdout)
+		if err == nil {
+			continue
+		}
+		if i > 0 {
+			fmt.Fprintf(context.Stderr, `
+The app %q was created, but the step %q failed. Fix the problem and apply the
+remaining steps manually, or remove the app and run app-bootstrap again:
+
+    tsuru app-remove -a %s
+
+`, m.Name, step.desc, m.Name)
+		}
+		return fmt.Errorf("failed to bootstrap app %q: %w", m.Name, err)
+	}
+	fmt.Fprintf(context.Stdout, "App %q successfully bootstrapped.\n", m.Name)
+	return nil
+}
+
+func parseBootstrapManifest(data []byte) (*bootstrapManifest, error) {
+	var m bootstrapManifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if m.Name == "" {
+		return nil, errors.New("invalid manifest: the name of the app is required")
+	}
+	for _, s := range m.Services {
+		if s.Service == "" || s.Instance == "" {
+			return nil, errors.New("invalid manifest: services must have both service and instance")
+		}
+	}
+	if err := validateCNames(m.CNames); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	for process, units := range m.Units {
+		if units <= 0 {
+			return nil, fmt.Errorf("invalid manifest: the number of units of process %q must be positive", process)
+		}
+	}
+	if len(m.Units) > 0 && m.Image == "" {
+		return nil, errors.New("invalid manifest: units require an image, the app must be deployed before it's scaled")
+	}
+	return &m, nil
+}
+
+// bootstrapSteps returns the steps needed to create and set up the app
+// described by the manifest, in the order they must run.
+func bootstrapSteps(m *bootstrapManifest) ([]bootstrapStep, error) {
+	waitTimeout := defaultBootstrapWaitTimeout
+	if m.WaitTimeout != "" {
+		var err error
+		waitTimeout, err = time.ParseDuration(m.WaitTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest: invalid wait-timeout: %w", err)
+		}
+	}
+	steps := []bootstrapStep{{
+		desc: fmt.Sprintf("Creating app %q", m.Name),
+		run: func(w io.Writer) error {
+			v := url.Values{}
+			v.Set("name", m.Name)
+			v.Set("platform", m.Platform)
+			v.Set("plan", m.Plan)
+			v.Set("teamOwner", m.Team)
+			v.Set("pool", m.Pool)
+			v.Set("description", m.Description)
+			v.Set("router", m.Router)
+			for _, tag := range m.Tags {
+				v.Add("tag", tag)
+			}
+			_, err := createApp(v)
+			return err
+		},
+	}}
+	for _, s := range m.Services {
+		s := s
+		steps = append(steps, bootstrapStep{
+			desc: fmt.Sprintf("Binding service instance %s/%s", s.Service, s.Instance),
+			run: func(w io.Writer) error {
+				path := "/services/" + s.Service + "/instances/" + s.Instance + "/apps/" + m.Name
+				resp, err := requestServiceInstanceBind("1.13", path, true)
+				if err != nil {
+					return err
+				}
+				return formatter.StreamJSONResponse(w, resp)
+			},
+		})
+	}
+	if len(m.Env)+len(m.PrivateEnv) > 0 {
+		steps = append(steps, bootstrapStep{
+			desc: fmt.Sprintf("Setting %d environment variables", len(m.Env)+len(m.PrivateEnv)),
+			run: func(w io.Writer) error {
+				e := apiTypes.Envs{NoRestart: true}
+				e.Envs = append(bootstrapEnvs(m.Env, false), bootstrapEnvs(m.PrivateEnv, true)...)
+				resp, err := requestEnvSet("1.0", fmt.Sprintf("/apps/%s/env", m.Name), &e)
+				if err != nil {
+					return err
+				}
+				return formatter.StreamJSONResponse(w, resp)
+			},
+		})
+	}
+	if len(m.CNames) > 0 {
+		steps = append(steps, bootstrapStep{
+			desc: fmt.Sprintf("Adding %d cnames", len(m.CNames)),
+			run: func(w io.Writer) error {
+				return addAppCNames(m.Name, m.CNames)
+			},
+		})
+	}
+	if m.Image != "" {
+		steps = append(steps, bootstrapStep{
+			desc: fmt.Sprintf("Deploying image %s", m.Image),
+			run: func(w io.Writer) error {
+				return deployAppImage(w, m.Name, m.Image)
+			},
+		})
+	}
+	processes := make([]string, 0, len(m.Units))
+	total := 0
+	for process, units := range m.Units {
+		processes = append(processes, process)
+		total += units
+	}
+	sort.Strings(processes)
+	for _, process := range processes {
+		process, units := process, m.Units[process]
+		steps = append(steps, bootstrapStep{
+			desc: fmt.Sprintf("Scaling process %q to %d units", process, units),
+			run: func(w io.Writer) error {
+				current, err := processUnitCount(m.Name, process)
+				if err != nil {
+					return err
+				}
+				if current >= units {
+					fmt.Fprintf(w, "Process %q already has %d units.\n", process, current)
+					return nil
+				}
+				add := UnitAdd{process: process}
+				return add.addUnits(w, m.Name, strconv.Itoa(units-current))
+			},
+		})
+	}
+	if total > 0 {
+		steps = append(steps, bootstrapStep{
+			desc: fmt.Sprintf("Waiting for %d units to be started", total),
+			run: func(w io.Writer) error {
+				return waitUnitsStarted(m.Name, total, waitTimeout)
+			},
+		})
+	}
+	return steps, nil
+}
+
+func bootstrapEnvs(envs map[string]string, private bool) []apiTypes.Env {
+	names := make([]string, 0, len(envs))
+	for name := range envs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := make([]apiTypes.Env, 0, len(names))
+	for _, name := range names {
+		result = append(result, apiTypes.Env{Name: name, Value: envs[name], Private: &private})
+	}
+	return result
+}
+
+// deployAppImage deploys the image to the app, writing the output of the
+// deploy to w.
+func deployAppImage(w io.Writer, appName, image string) error {
+	u, err := config.GetURL(fmt.Sprintf("/apps/%s/deploy", appName))
+	if err != nil {
+		return err
+	}
+	v := url.Values{}
+	v.Set("origin", "image")
+	v.Set("image", image)
+	request, err := http.NewRequest("POST", u, strings.NewReader(v.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response, err := tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	var out bytes.Buffer
+	if _, err = io.Copy(io.MultiWriter(w, &out), response.Body); err != nil {
+		return err
+	}
+	if !strings.HasSuffix(out.String(), "\nOK\n") {
+		return errors.New("the deploy failed")
+	}
+	return nil
+}
+
+// waitUnitsStarted polls the app until it has the expected number of started
+// units, or the timeout expires.
+func waitUnitsStarted(appName string, expected int, timeout time.Duration) error {
+	started := 0
+	err := pollApp(appName, unitWaitInterval, timeout, func(a *app) (bool, error) {
+		started = 0
+		for _, status := range unitStatuses(a) {
+			if unitStatusName(status) == "started" {
+				started++
+			}
+		}
+		return started >= expected, nil
+	})
+	if err == errPollTimeout {
+		return fmt.Errorf("only %d of %d units of app %q are started after %s", started, expected, appName, timeout)
+	}
+	return err
+}
@@ -0,0 +1,201 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tsuru/tsuru/cmd"
+	"github.com/tsuru/tsuru/cmd/cmdtest"
+	"gopkg.in/check.v1"
+)
+
+const bootstrapManifestData = `name: myapp
+platform: python
+pool: prod
+team: myteam
+services:
+  - service: mysql
+    instance: myapp-db
+env:
+  LOG_LEVEL: info
+private-env:
+  API_TOKEN: secret
+cnames:
+  - myapp.example.com
+image: registry.example.com/myapp:v1
+units:
+  web: 2
+`
+
+func (s *S) TestAppBootstrapInfo(c *check.C) {
+	c.Assert((&AppBootstrap{}).Info(), check.NotNil)
+}
+
+func writeBootstrapManifest(c *check.C, data string) string {
+	file := filepath.Join(c.MkDir(), "app.yaml")
+	err := os.WriteFile(file, []byte(data), 0600)
+	c.Assert(err, check.IsNil)
+	return file
+}
+
+func (s *S) TestAppBootstrap(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	oldSleep := pollSleep
+	defer func() { pollSleep = oldSleep }()
+	pollSleep = func(time.Duration) {}
+	var calls []string
+	getApps := 0
+	record := func(method, path string) func(*http.Request) bool {
+		return func(req *http.Request) bool {
+			if req.Method != method || !strings.HasSuffix(req.URL.Path, path) {
+				return false
+			}
+			req.ParseForm()
+			calls = append(calls, method+" "+path+" "+req.Form.Encode())
+			return true
+		}
+	}
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{Transport: cmdtest.Transport{Message: `{"status": "success"}`, Status: http.StatusOK}, CondFunc: record(http.MethodPost, "/apps")},
+			{Transport: cmdtest.Transport{Message: `{"Message": "bound\n"}`, Status: http.StatusOK}, CondFunc: record(http.MethodPut, "/services/mysql/instances/myapp-db/apps/myapp")},
+			{Transport: cmdtest.Transport{Message: `{"Message": "envs set\n"}`, Status: http.StatusOK}, CondFunc: record(http.MethodPost, "/apps/myapp/env")},
+			{Transport: cmdtest.Transport{Status: http.StatusOK}, CondFunc: record(http.MethodPost, "/apps/myapp/cname")},
+			{Transport: cmdtest.Transport{Message: "deploying\nOK\n", Status: http.StatusOK}, CondFunc: record(http.MethodPost, "/apps/myapp/deploy")},
+			{Transport: cmdtest.Transport{Message: `{"Message": "units added\n"}`, Status: http.StatusOK}, CondFunc: record(http.MethodPut, "/apps/myapp/units")},
+			{
+				Transport: cmdtest.Transport{Message: `{"name": "myapp", "units": [{"ID": "web-1", "ProcessName": "web", "Status": "started"}]}`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					if req.Method != http.MethodGet || !strings.HasSuffix(req.URL.Path, "/apps/myapp") {
+						return false
+					}
+					getApps++
+					return getApps == 1
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: `{"name": "myapp", "units": [{"ID": "web-1", "ProcessName": "web", "Status": "started"}, {"ID": "web-2", "ProcessName": "web", "Status": "started"}]}`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/apps/myapp")
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppBootstrap{}
+	command.Flags().Parse(true, []string{"--manifest", writeBootstrapManifest(c, bootstrapManifestData)})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(calls, check.DeepEquals, []string{
+		"POST /apps description=&name=myapp&plan=&platform=python&pool=prod&router=&teamOwner=myteam",
+		"PUT /services/mysql/instances/myapp-db/apps/myapp noRestart=true",
+		"POST /apps/myapp/env Envs.0.Alias=&Envs.0.ManagedBy=&Envs.0.Name=LOG_LEVEL&Envs.0.Private=&Envs.0.Value=info&Envs.1.Alias=&Envs.1.ManagedBy=&Envs.1.Name=API_TOKEN&Envs.1.Private=true&Envs.1.Value=secret&ManagedBy=&NoRestart=true&Private=&PruneUnused=",
+		"POST /apps/myapp/cname cname=myapp.example.com",
+		"POST /apps/myapp/deploy image=registry.example.com%2Fmyapp%3Av1&origin=image",
+		"PUT /apps/myapp/units process=web&units=1&version=",
+	})
+	expected := `[1/7] Creating app "myapp"
+[2/7] Binding service instance mysql/myapp-db
+bound
+[3/7] Setting 2 environment variables
+envs set
+[4/7] Adding 1 cnames
+[5/7] Deploying image registry.example.com/myapp:v1
+deploying
+OK
+[6/7] Scaling process "web" to 2 units
+units added
+[7/7] Waiting for 2 units to be started
+App "myapp" successfully bootstrapped.
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestAppBootstrapStopsOnFailure(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	var envSet bool
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: `{"status": "success"}`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/apps")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: "service instance not found", Status: http.StatusNotFound},
+				CondFunc: func(req *http.Request) bool {
+					return strings.Contains(req.URL.Path, "/services/")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					envSet = strings.HasSuffix(req.URL.Path, "/env")
+					return envSet
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppBootstrap{}
+	command.Flags().Parse(true, []string{"-m", writeBootstrapManifest(c, bootstrapManifestData)})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `failed to bootstrap app "myapp": .*service instance not found.*`)
+	c.Assert(envSet, check.Equals, false)
+	c.Assert(stderr.String(), check.Matches, `(?s).*the step "Binding service instance mysql/myapp-db" failed.*tsuru app-remove -a myapp.*`)
+}
+
+func (s *S) TestAppBootstrapInvalidManifest(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	command := AppBootstrap{}
+	command.Flags().Parse(true, []string{"-m", writeBootstrapManifest(c, "platform: python\n")})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "invalid manifest: the name of the app is required")
+	command = AppBootstrap{}
+	command.Flags().Parse(true, []string{"-m", writeBootstrapManifest(c, "name: myapp\nunits:\n  web: 0\n")})
+	err = command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `invalid manifest: the number of units of process "web" must be positive`)
+	command = AppBootstrap{}
+	command.Flags().Parse(true, []string{"-m", writeBootstrapManifest(c, "name: myapp\nunits:\n  web: 2\n")})
+	err = command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `invalid manifest: units require an image, the app must be deployed before it's scaled`)
+}
+
+func (s *S) TestAppBootstrapDeployFailure(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: `{"status": "success"}`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/apps")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: "deploying\nERROR: image not found\n", Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return strings.HasSuffix(req.URL.Path, "/apps/myapp/deploy")
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppBootstrap{}
+	command.Flags().Parse(true, []string{"-m", writeBootstrapManifest(c, "name: myapp\nimage: myapp:v1\nunits:\n  web: 2\n")})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `failed to bootstrap app "myapp": the deploy failed`)
+	c.Assert(stdout.String(), check.Equals, "[1/4] Creating app \"myapp\"\n[2/4] Deploying image myapp:v1\ndeploying\nERROR: image not found\n")
+}
@@ -117,7 +117,7 @@ func (c *AppBuild) Run(context *cmd.Context) error {
 	if err = uploadFiles(context, request, buf, body, values, &archive); err != nil {
 		return err
 	}
-	resp, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	resp, err := tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,50 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/tsuru/gnuflag"
+	"github.com/tsuru/tsuru-client/tsuru/formatter"
+)
+
+// ciOutput implements the --ci flag, and its alias --quiet, for commands that
+// stream their progress from the tsuru API. When enabled, the progress
+// messages, including the keep-alive ones, are discarded and a single OK line
+// is printed on success, giving pipelines a reliable signal. Errors are still
+// returned, so the exit code of the client reports the failure.
+type ciOutput struct {
+	ci bool
+}
+
+func (o *ciOutput) addFlags(fs *gnuflag.FlagSet) {
+	usage := "Print only OK on success or the error on failure, useful for CI pipelines"
+	fs.BoolVar(&o.ci, "ci", false, usage)
+	fs.BoolVar(&o.ci, "quiet", false, usage)
+}
+
+func (o *ciOutput) stream(w io.Writer, response *http.Response) error {
+	if !o.ci {
+		return formatter.StreamJSONResponse(w, response)
+	}
+	err := formatter.StreamJSONResponse(io.Discard, response)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "OK")
+	return nil
+}
+
+// progressWriter returns the writer for progress messages, which are hidden
+// in CI mode.
+func (o *ciOutput) progressWriter(w io.Writer) io.Writer {
+	if o.ci {
+		return io.Discard
+	}
+	return w
+}
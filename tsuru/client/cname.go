@@ -0,0 +1,289 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/tsuru/gnuflag"
+	"github.com/tsuru/tablecli"
+	tsuruClientApp "github.com/tsuru/tsuru-client/tsuru/app"
+	"github.com/tsuru/tsuru/cmd"
+)
+
+type CnameBackup struct {
+	tsuruClientApp.AppNameMixIn
+}
+
+func (c *CnameBackup) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "cname-backup",
+		Usage: "cname backup [-a/--app appname]",
+		Desc: `Prints the CNAMEs of the application, one per line. The output can be saved to
+a file and later used by [[tsuru cname restore]]:
+
+    tsuru cname backup -a myapp > cnames.txt`,
+		MinArgs: 0,
+		MaxArgs: 0,
+	}
+}
+
+func (c *CnameBackup) Run(context *cmd.Context) error {
+	appName, err := c.AppNameByFlag()
+	if err != nil {
+		return err
+	}
+	cnames, err := appCNames(appName)
+	if err != nil {
+		return err
+	}
+	for _, cname := range cnames {
+		fmt.Fprintln(context.Stdout, cname)
+	}
+	return nil
+}
+
+type CnameRestore struct {
+	tsuruClientApp.AppNameMixIn
+	cmd.ConfirmationCommand
+	fs *gnuflag.FlagSet
+}
+
+func (c *CnameRestore) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "cname-restore",
+		Usage: "cname restore <file> [-a/--app appname] [-y/--assume-yes]",
+		Desc: `Restores the CNAMEs of the application from a file generated by [[tsuru cname
+backup]]. CNAMEs present in the file and missing in the app are added, and
+CNAMEs of the app that are not in the file are removed.
+
+The changes are displayed and must be confirmed before being applied, unless
+[[--assume-yes]] is used. Empty lines and lines starting with # are ignored.`,
+		MinArgs: 1,
+		MaxArgs: 1,
+	}
+}
+
+func (c *CnameRestore) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = mergeFlagSet(
+			c.AppNameMixIn.Flags(),
+			c.ConfirmationCommand.Flags(),
+		)
+	}
+	return c.fs
+}
+
+func (c *CnameRestore) Run(context *cmd.Context) error {
+	appName, err := c.AppNameByFlag()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(context.Args[0])
+	if err != nil {
+		return err
+	}
+	wanted, err := parseCNames(data)
+	if err != nil {
+		return err
+	}
+	if err = validateCNames(wanted); err != nil {
+		return err
+	}
+	current, err := appCNames(appName)
+	if err != nil {
+		return err
+	}
+	missing, extra := diffCNames(current, wanted)
+	if len(missing) == 0 && len(extra) == 0 {
+		fmt.Fprintf(context.Stdout, "The cnames of app %q are already up to date.\n", appName)
+		return nil
+	}
+	fmt.Fprintf(context.Stdout, "The following changes will be applied to the cnames of app %q:\n", appName)
+	for _, cname := range missing {
+		fmt.Fprintf(context.Stdout, "+ %s\n", cname)
+	}
+	for _, cname := range extra {
+		fmt.Fprintf(context.Stdout, "- %s\n", cname)
+	}
+	if !c.Confirm(context, "Do you want to proceed?") {
+		return nil
+	}
+	if len(missing) > 0 {
+		err = addCName(missing, c.AppNameMixIn)
+		if err != nil {
+			return err
+		}
+	}
+	if len(extra) > 0 {
+		err = unsetCName(extra, c.AppNameMixIn)
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(context.Stdout, "cnames successfully restored.")
+	return nil
+}
+
+func appCNames(appName string) ([]string, error) {
+	a, err := getApp(appName)
+	if err != nil {
+		return nil, err
+	}
+	var cnames []string
+	for _, cname := range a.CName {
+		if cname != "" {
+			cnames = append(cnames, cname)
+		}
+	}
+	sort.Strings(cnames)
+	return cnames, nil
+}
+
+func parseCNames(data []byte) ([]string, error) {
+	var cnames []string
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || seen[line] {
+			continue
+		}
+		seen[line] = true
+		cnames = append(cnames, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return cnames, nil
+}
+
+// diffCNames returns the cnames in wanted that are missing in current and the
+// cnames in current that are not in wanted, both sorted.
+func diffCNames(current, wanted []string) (missing, extra []string) {
+	currentSet := map[string]bool{}
+	for _, cname := range current {
+		currentSet[cname] = true
+	}
+	wantedSet := map[string]bool{}
+	for _, cname := range wanted {
+		wantedSet[cname] = true
+		if !currentSet[cname] {
+			missing = append(missing, cname)
+		}
+	}
+	for _, cname := range current {
+		if !wantedSet[cname] {
+			extra = append(extra, cname)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(extra)
+	return missing, extra
+}
+
+type CnameConflicts struct{}
+
+func (c *CnameConflicts) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "cname-conflicts",
+		Usage: "cname conflicts",
+		Desc: `Lists CNAMEs claimed by more than one application, which may be left behind
+by an interrupted app swap and make the routing ambiguous. The command exits
+with an error if any conflict is found.`,
+		MinArgs: 0,
+		MaxArgs: 0,
+	}
+}
+
+func (c *CnameConflicts) Run(context *cmd.Context) error {
+	apps, err := listApps(nil)
+	if err != nil {
+		return err
+	}
+	conflicts := cnameConflicts(apps)
+	if len(conflicts) == 0 {
+		fmt.Fprintln(context.Stdout, "No cname conflicts found.")
+		return nil
+	}
+	cnames := make([]string, 0, len(conflicts))
+	for cname := range conflicts {
+		cnames = append(cnames, cname)
+	}
+	sort.Strings(cnames)
+	table := tablecli.NewTable()
+	table.Headers = tablecli.Row{"Cname", "Apps"}
+	for _, cname := range cnames {
+		table.AddRow(tablecli.Row{cname, strings.Join(conflicts[cname], ", ")})
+	}
+	fmt.Fprint(context.Stdout, table.String())
+	return fmt.Errorf("%d cnames are claimed by more than one app", len(conflicts))
+}
+
+// cnameConflicts returns the cnames claimed by more than one app, with the
+// sorted names of these apps.
+func cnameConflicts(apps []app) map[string][]string {
+	claims := map[string][]string{}
+	for _, a := range apps {
+		seen := map[string]bool{}
+		for _, cname := range a.CName {
+			if cname == "" || seen[cname] {
+				continue
+			}
+			seen[cname] = true
+			claims[cname] = append(claims[cname], a.Name)
+		}
+	}
+	conflicts := map[string][]string{}
+	for cname, names := range claims {
+		if len(names) > 1 {
+			sort.Strings(names)
+			conflicts[cname] = names
+		}
+	}
+	return conflicts
+}
+
+// cnameProblem returns why the cname is malformed, or an empty string if it
+// looks like a valid hostname. Fully qualified names, ending with a dot, are
+// accepted. Only obvious mistakes are detected, the tsuru API still validates
+// the cnames.
+func cnameProblem(cname string) string {
+	name := strings.TrimSuffix(cname, ".")
+	switch {
+	case cname == "":
+		return "it is empty"
+	case strings.IndexFunc(cname, unicode.IsSpace) >= 0:
+		return "it contains spaces"
+	case !strings.Contains(name, "."):
+		return "it has no dots"
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" {
+			return "it has an empty label"
+		}
+	}
+	return ""
+}
+
+// validateCNames returns an error listing all malformed cnames.
+func validateCNames(cnames []string) error {
+	var problems []string
+	for _, cname := range cnames {
+		if problem := cnameProblem(cname); problem != "" {
+			problems = append(problems, fmt.Sprintf("%q: %s", cname, problem))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid cnames, no changes were made:\n  %s", strings.Join(problems, "\n  "))
+}
@@ -0,0 +1,184 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tsuru/tsuru/cmd"
+	"github.com/tsuru/tsuru/cmd/cmdtest"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) TestCnameBackupInfo(c *check.C) {
+	c.Assert((&CnameBackup{}).Info(), check.NotNil)
+}
+
+func (s *S) TestCnameBackup(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	result := `{"name": "myapp", "cname": ["www.example.com", "api.example.com"]}`
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := CnameBackup{}
+	command.Flags().Parse(true, []string{"-a", "myapp"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "api.example.com\nwww.example.com\n")
+}
+
+func (s *S) TestCnameRestoreInfo(c *check.C) {
+	c.Assert((&CnameRestore{}).Info(), check.NotNil)
+}
+
+func (s *S) TestCnameRestore(c *check.C) {
+	file := filepath.Join(c.MkDir(), "cnames.txt")
+	err := os.WriteFile(file, []byte("# myapp cnames\nwww.example.com\n\nnew.example.com\n"), 0600)
+	c.Assert(err, check.IsNil)
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Stdin:  strings.NewReader("y\n"),
+		Args:   []string{file},
+	}
+	var added, removed string
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: `{"name": "myapp", "cname": ["www.example.com", "old.example.com"]}`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/apps/myapp")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					if req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/apps/myapp/cname") {
+						return false
+					}
+					req.ParseForm()
+					added = strings.Join(req.PostForm["cname"], ",")
+					return true
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					if req.Method != http.MethodDelete || !strings.HasSuffix(req.URL.Path, "/apps/myapp/cname") {
+						return false
+					}
+					removed = strings.Join(req.URL.Query()["cname"], ",")
+					return true
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := CnameRestore{}
+	command.Flags().Parse(true, []string{"-a", "myapp"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(added, check.Equals, "new.example.com")
+	c.Assert(removed, check.Equals, "old.example.com")
+	expected := `The following changes will be applied to the cnames of app "myapp":
++ new.example.com
+- old.example.com
+Do you want to proceed? (y/n) cnames successfully restored.
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestCnameRestoreUpToDate(c *check.C) {
+	file := filepath.Join(c.MkDir(), "cnames.txt")
+	err := os.WriteFile(file, []byte("www.example.com\n"), 0600)
+	c.Assert(err, check.IsNil)
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr, Args: []string{file}}
+	s.setupFakeTransport(&cmdtest.Transport{Message: `{"name": "myapp", "cname": ["www.example.com"]}`, Status: http.StatusOK})
+	command := CnameRestore{}
+	command.Flags().Parse(true, []string{"-a", "myapp"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "The cnames of app \"myapp\" are already up to date.\n")
+}
+
+func (s *S) TestDiffCNames(c *check.C) {
+	missing, extra := diffCNames([]string{"a.com", "b.com"}, []string{"c.com", "b.com"})
+	c.Assert(missing, check.DeepEquals, []string{"c.com"})
+	c.Assert(extra, check.DeepEquals, []string{"a.com"})
+}
+
+func (s *S) TestCnameConflictsInfo(c *check.C) {
+	c.Assert((&CnameConflicts{}).Info(), check.NotNil)
+}
+
+func (s *S) TestCnameConflicts(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	result := `[
+	{"name": "blue", "cname": ["www.example.com", "api.example.com"]},
+	{"name": "green", "cname": ["www.example.com"]},
+	{"name": "other", "cname": ["other.example.com", "api.example.com"]}
+]`
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := CnameConflicts{}
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "2 cnames are claimed by more than one app")
+	expected := `+-----------------+-------------+
+| Cname           | Apps        |
++-----------------+-------------+
+| api.example.com | blue, other |
+| www.example.com | blue, green |
++-----------------+-------------+
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestCnameConflictsNoConflicts(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	result := `[{"name": "blue", "cname": ["www.example.com"]}, {"name": "green", "cname": [""]}]`
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := CnameConflicts{}
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "No cname conflicts found.\n")
+}
+
+func (s *S) TestValidateCNames(c *check.C) {
+	c.Assert(validateCNames([]string{"www.example.com", "*.example.com", "www.example.com."}), check.IsNil)
+	err := validateCNames([]string{"www.example.com", "my app.example.com", "localhost", "www..example.com", "example.com..", "localhost.", ""})
+	c.Assert(err, check.NotNil)
+	c.Assert(err.Error(), check.Equals, `invalid cnames, no changes were made:
+  "my app.example.com": it contains spaces
+  "localhost": it has no dots
+  "www..example.com": it has an empty label
+  "example.com..": it has an empty label
+  "localhost.": it has no dots
+  "": it is empty`)
+}
+
+func (s *S) TestCnameAddInvalidCName(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr, Args: []string{"www.example.com", "example"}}
+	var called bool
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			called = true
+			return true
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := CnameAdd{}
+	command.Flags().Parse(true, []string{"-a", "myapp"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `(?s)invalid cnames, no changes were made:.*"example": it has no dots`)
+	c.Assert(called, check.Equals, false)
+}
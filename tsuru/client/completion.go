@@ -0,0 +1,53 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/tsuru/go-tsuruclient/pkg/config"
+	tsuruHTTP "github.com/tsuru/tsuru-client/tsuru/http"
+)
+
+// appNames returns the sorted names of the apps matching the filter. The
+// response is cached for a short time, so shell completion doesn't send a
+// request on every tab press.
+func appNames(filter url.Values) ([]string, error) {
+	qs := url.Values{}
+	for k, v := range filter {
+		qs[k] = v
+	}
+	qs.Set("simplified", "true")
+	u, err := config.GetURL("/apps?" + qs.Encode())
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := tsuruHTTP.DoCached(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNoContent {
+		return []string{}, nil
+	}
+	var apps []app
+	err = json.NewDecoder(response.Body).Decode(&apps)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(apps))
+	for i, a := range apps {
+		names[i] = a.Name
+	}
+	sort.Strings(names)
+	return names, nil
+}
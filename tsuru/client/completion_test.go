@@ -0,0 +1,95 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+
+	"github.com/tsuru/go-tsuruclient/pkg/config"
+	"github.com/tsuru/tsuru/cmd"
+	"github.com/tsuru/tsuru/cmd/cmdtest"
+	"github.com/tsuru/tsuru/fs/fstest"
+	"gopkg.in/check.v1"
+)
+
+func appNamesTransport(requests *int) http.RoundTripper {
+	return &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: `[{"name":"app2"},{"name":"app1"},{"name":"app3"}]`, Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			*requests++
+			return req.URL.Path == "/1.0/apps" && req.URL.Query().Get("simplified") == "true"
+		},
+	}
+}
+
+func setupAppNamesCache() func() {
+	config.SetFileSystem(&fstest.RecordingFs{})
+	os.Unsetenv("TSURU_NO_CACHE")
+	return config.ResetFileSystem
+}
+
+func (s *S) TestAppListNamesOnly(c *check.C) {
+	defer setupAppNamesCache()()
+	var requests int
+	s.setupFakeTransport(appNamesTransport(&requests))
+	for i := 0; i < 2; i++ {
+		var stdout bytes.Buffer
+		command := AppList{}
+		err := command.Flags().Parse(true, []string{"--names-only"})
+		c.Assert(err, check.IsNil)
+		err = command.Run(&cmd.Context{Stdout: &stdout})
+		c.Assert(err, check.IsNil)
+		c.Assert(stdout.String(), check.Equals, "app1\napp2\napp3\n")
+	}
+	c.Assert(requests, check.Equals, 1)
+}
+
+func (s *S) TestAppListNamesOnlyNoCache(c *check.C) {
+	var requests int
+	s.setupFakeTransport(appNamesTransport(&requests))
+	for i := 0; i < 2; i++ {
+		names, err := appNames(nil)
+		c.Assert(err, check.IsNil)
+		c.Assert(names, check.DeepEquals, []string{"app1", "app2", "app3"})
+	}
+	c.Assert(requests, check.Equals, 2)
+}
+
+func (s *S) TestAppListNamesOnlyCacheByFilter(c *check.C) {
+	defer setupAppNamesCache()()
+	trans := &cmdtest.MultiConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: `[{"name":"app1"},{"name":"app2"}]`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.URL.Query().Get("pool") == ""
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: `[{"name":"app2"}]`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.URL.Query().Get("pool") == "pool1"
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	var stdout bytes.Buffer
+	command := AppList{}
+	err := command.Flags().Parse(true, []string{"--names-only"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&cmd.Context{Stdout: &stdout})
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "app1\napp2\n")
+	stdout.Reset()
+	command = AppList{}
+	err = command.Flags().Parse(true, []string{"--names-only", "--pool", "pool1"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&cmd.Context{Stdout: &stdout})
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "app2\n")
+}
@@ -0,0 +1,37 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// forEachConcurrently calls fn with each index from 0 to n-1, with at most
+// concurrency calls running at the same time, and waits for them to finish.
+// Once a call returns false, no more calls are started, the ones already
+// running are left to finish.
+func forEachConcurrently(n, concurrency int, fn func(i int) bool) {
+	var (
+		wg      sync.WaitGroup
+		stopped atomic.Bool
+	)
+	sem := make(chan struct{}, concurrency)
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		if stopped.Load() {
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if !fn(i) {
+				stopped.Store(true)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
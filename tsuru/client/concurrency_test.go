@@ -0,0 +1,48 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"sync"
+	"time"
+
+	"gopkg.in/check.v1"
+)
+
+func (s *S) TestForEachConcurrently(c *check.C) {
+	var (
+		mu      sync.Mutex
+		running int
+		max     int
+	)
+	called := make([]bool, 10)
+	forEachConcurrently(10, 3, func(i int) bool {
+		mu.Lock()
+		running++
+		if running > max {
+			max = running
+		}
+		called[i] = true
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return true
+	})
+	c.Assert(max <= 3, check.Equals, true)
+	for i := range called {
+		c.Assert(called[i], check.Equals, true)
+	}
+}
+
+func (s *S) TestForEachConcurrentlyStops(c *check.C) {
+	var calls []int
+	forEachConcurrently(5, 1, func(i int) bool {
+		calls = append(calls, i)
+		return i < 1
+	})
+	c.Assert(calls, check.DeepEquals, []int{0, 1})
+}
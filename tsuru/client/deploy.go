@@ -17,6 +17,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/tsuru/gnuflag"
 	"github.com/tsuru/go-tsuruclient/pkg/config"
@@ -135,6 +136,174 @@ func (c *AppDeployList) Run(context *cmd.Context) error {
 	return nil
 }
 
+// deployInfo describes how a deploy was made.
+type deployInfo struct {
+	Method    string        `json:"method"`
+	Source    string        `json:"source,omitempty"`
+	Image     string        `json:"image,omitempty"`
+	User      string        `json:"user,omitempty"`
+	Message   string        `json:"message,omitempty"`
+	Timestamp time.Time     `json:"timestamp"`
+	Duration  time.Duration `json:"duration"`
+}
+
+func newDeployInfo(d tsuruapp.DeployData) *deployInfo {
+	info := &deployInfo{
+		Method:    d.Origin,
+		Image:     d.Image,
+		User:      d.User,
+		Message:   d.Message,
+		Timestamp: d.Timestamp,
+		Duration:  d.Duration,
+	}
+	switch d.Origin {
+	case "git":
+		info.Method = "git push"
+		info.Source = d.Commit
+	case "app-deploy":
+		info.Method = "archive upload"
+	case "rollback":
+		info.Source = d.Image
+	case "drag-and-drop":
+		info.Method = "drag and drop"
+	case "":
+		info.Method = "unknown"
+	}
+	return info
+}
+
+func (d *deployInfo) String() string {
+	source := d.Source
+	if d.Method == "git push" && len(source) > 7 {
+		source = source[:7]
+	}
+	if source == "" {
+		return d.Method
+	}
+	return fmt.Sprintf("%s (%s)", d.Method, source)
+}
+
+// lastDeployPageSize is the number of deploys requested at a time by
+// lastDeploy.
+var lastDeployPageSize = 10
+
+// lastDeploy returns the most recent successful deploy of the app, or nil if
+// the app was never successfully deployed. Failed deploys don't change the
+// code running in the app, so they're skipped.
+func lastDeploy(appName string) (*deployInfo, error) {
+	for skip := 0; ; skip += lastDeployPageSize {
+		deploys, err := listAppDeploys(appName, skip, lastDeployPageSize)
+		if err != nil {
+			return nil, err
+		}
+		sort.Sort(sort.Reverse(deployList(deploys)))
+		for _, d := range deploys {
+			if d.Error == "" {
+				return newDeployInfo(d), nil
+			}
+		}
+		if len(deploys) < lastDeployPageSize {
+			return nil, nil
+		}
+	}
+}
+
+// listAppDeploys returns a page of the deploys of the app, starting at the
+// given offset.
+func listAppDeploys(appName string, skip, limit int) ([]tsuruapp.DeployData, error) {
+	qs := url.Values{}
+	qs.Set("app", appName)
+	qs.Set("skip", strconv.Itoa(skip))
+	qs.Set("limit", strconv.Itoa(limit))
+	u, err := config.GetURL("/deploys?" + qs.Encode())
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+	result, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	var list []tsuruapp.DeployData
+	err = json.Unmarshal(result, &list)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+type AppDeployInfo struct {
+	tsuruClientApp.AppNameMixIn
+	fs   *gnuflag.FlagSet
+	json bool
+}
+
+func (c *AppDeployInfo) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "app-deploy-info",
+		Usage: "app deploy info [-a/--app appname] [--json]",
+		Desc: `Shows how the current code of an application was deployed: the method of the
+last successful deploy (git push, archive upload, image, rollback...), its
+source, the resulting image and the user who made it. Failed deploys don't
+change the code of the app, so they're skipped.`,
+		MinArgs: 0,
+		MaxArgs: 0,
+	}
+}
+
+func (c *AppDeployInfo) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = c.AppNameMixIn.Flags()
+		c.fs.BoolVar(&c.json, "json", false, "Show JSON")
+	}
+	return c.fs
+}
+
+func (c *AppDeployInfo) Run(context *cmd.Context) error {
+	appName, err := c.AppNameByFlag()
+	if err != nil {
+		return err
+	}
+	info, err := lastDeploy(appName)
+	if err != nil {
+		return err
+	}
+	if info == nil {
+		fmt.Fprintf(context.Stdout, "App %s has no successful deploy.\n", appName)
+		return nil
+	}
+	if c.json {
+		return formatter.JSON(context.Stdout, info)
+	}
+	source, user := info.Source, info.User
+	if source == "" {
+		source = "-"
+	}
+	if user == "" {
+		user = "-"
+	}
+	fmt.Fprintf(context.Stdout, "Method: %s\n", info.Method)
+	fmt.Fprintf(context.Stdout, "Source: %s\n", source)
+	fmt.Fprintf(context.Stdout, "Image: %s\n", info.Image)
+	fmt.Fprintf(context.Stdout, "Deployed by: %s\n", user)
+	fmt.Fprintf(context.Stdout, "Date: %s\n", formatter.FormatDateAndDuration(info.Timestamp, &info.Duration))
+	if info.Message != "" {
+		fmt.Fprintf(context.Stdout, "Message: %s\n", info.Message)
+	}
+	return nil
+}
+
 var _ cmd.Cancelable = &AppDeploy{}
 
 type AppDeploy struct {
@@ -309,7 +478,7 @@ func (c *AppDeploy) Run(context *cmd.Context) error {
 	}
 
 	c.m.Lock()
-	resp, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	resp, err := tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
 	if err != nil {
 		c.m.Unlock()
 		return err
@@ -430,7 +599,7 @@ func (c *AppDeployRollback) Run(context *cmd.Context) error {
 		return err
 	}
 	request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	response, err := tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
 	if err != nil {
 		return err
 	}
@@ -480,7 +649,7 @@ func (c *AppDeployRebuild) Run(context *cmd.Context) error {
 		return err
 	}
 	request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	response, err := tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
 	if err != nil {
 		return err
 	}
@@ -702,7 +871,7 @@ Use the --job/-j flag to specify it.
 	}
 
 	c.m.Lock()
-	resp, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	resp, err := tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
 	if err != nil {
 		c.m.Unlock()
 		return err
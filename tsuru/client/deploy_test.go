@@ -837,3 +837,103 @@ func (s *S) TestDeployRunDockerfileAndDockerImage(c *check.C) {
 	err = command.Run(ctx)
 	c.Assert(err, check.ErrorMatches, "You can't deploy container image and container file at same time.\n")
 }
+
+func (s *S) TestAppDeployInfoInfo(c *check.C) {
+	c.Assert((&AppDeployInfo{}).Info(), check.NotNil)
+}
+
+func (s *S) TestAppDeployInfo(c *check.C) {
+	old := formatter.LocalTZ
+	formatter.LocalTZ = time.UTC
+	defer func() { formatter.LocalTZ = old }()
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	result := `[{"App": "test", "Timestamp": "2026-10-16T10:00:00Z", "Duration": 65000000000, "Commit": "54c92d91a46ec0e78501d86b", "Image": "tsuru/app-test:v3", "User": "admin@example.com", "Origin": "git"}]`
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: result, Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return strings.HasSuffix(req.URL.Path, "/deploys") && req.URL.Query().Get("app") == "test" && req.URL.Query().Get("limit") == "10"
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppDeployInfo{}
+	command.Flags().Parse(true, []string{"-a", "test"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	expected := `Method: git push
+Source: 54c92d91a46ec0e78501d86b
+Image: tsuru/app-test:v3
+Deployed by: admin@example.com
+Date: 16 Oct 26 10:00 UTC (01:05)
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestAppDeployInfoJSON(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	result := `[{"App": "test", "Timestamp": "2026-10-16T10:00:00Z", "Image": "tsuru/app-test:v3", "User": "admin@example.com", "Origin": "app-deploy"}]`
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := AppDeployInfo{}
+	command.Flags().Parse(true, []string{"-a", "test", "--json"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	var info map[string]interface{}
+	err = json.Unmarshal(stdout.Bytes(), &info)
+	c.Assert(err, check.IsNil)
+	c.Assert(info["method"], check.Equals, "archive upload")
+	c.Assert(info["image"], check.Equals, "tsuru/app-test:v3")
+	c.Assert(info["user"], check.Equals, "admin@example.com")
+}
+
+func (s *S) TestAppDeployInfoNoDeploys(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	s.setupFakeTransport(&cmdtest.Transport{Status: http.StatusNoContent})
+	command := AppDeployInfo{}
+	command.Flags().Parse(true, []string{"-a", "test"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "App test has no successful deploy.\n")
+}
+
+func (s *S) TestAppDeployInfoSkipsFailedDeploys(c *check.C) {
+	oldPageSize := lastDeployPageSize
+	defer func() { lastDeployPageSize = oldPageSize }()
+	lastDeployPageSize = 2
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	pages := map[string]string{
+		"0": `[{"App": "test", "Timestamp": "2026-10-16T10:00:00Z", "Image": "tsuru/app-test:v5", "Origin": "git", "Error": "build failed"}, {"App": "test", "Timestamp": "2026-10-15T10:00:00Z", "Image": "tsuru/app-test:v4", "Origin": "git", "Error": "build failed"}]`,
+		"2": `[{"App": "test", "Timestamp": "2026-10-14T10:00:00Z", "Image": "tsuru/app-test:v3", "Origin": "app-deploy", "User": "admin@example.com"}, {"App": "test", "Timestamp": "2026-10-13T10:00:00Z", "Image": "tsuru/app-test:v2", "Origin": "git"}]`,
+	}
+	s.setupFakeTransport(transportFunc(func(req *http.Request) (*http.Response, error) {
+		q := req.URL.Query()
+		if !strings.HasSuffix(req.URL.Path, "/deploys") || q.Get("app") != "test" || q.Get("limit") != "2" {
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(pages[q.Get("skip")]))}, nil
+	}))
+	command := AppDeployInfo{}
+	command.Flags().Parse(true, []string{"-a", "test", "--json"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	var info map[string]interface{}
+	err = json.Unmarshal(stdout.Bytes(), &info)
+	c.Assert(err, check.IsNil)
+	c.Assert(info["method"], check.Equals, "archive upload")
+	c.Assert(info["image"], check.Equals, "tsuru/app-test:v3")
+	c.Assert(info["user"], check.Equals, "admin@example.com")
+}
+
+func (s *S) TestAppDeployInfoOnlyFailedDeploys(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	result := `[{"App": "test", "Timestamp": "2026-10-16T10:00:00Z", "Image": "tsuru/app-test:v1", "Origin": "git", "Error": "build failed"}]`
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := AppDeployInfo{}
+	command.Flags().Parse(true, []string{"-a", "test"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "App test has no successful deploy.\n")
+}
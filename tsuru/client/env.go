@@ -5,12 +5,14 @@
 package client
 
 import (
+	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
@@ -18,6 +20,8 @@ import (
 	"github.com/cezarsa/form"
 	"github.com/tsuru/gnuflag"
 	"github.com/tsuru/go-tsuruclient/pkg/config"
+	"github.com/tsuru/tablecli"
+	tsuruClientApp "github.com/tsuru/tsuru-client/tsuru/app"
 	"github.com/tsuru/tsuru-client/tsuru/formatter"
 	tsuruHTTP "github.com/tsuru/tsuru-client/tsuru/http"
 	"github.com/tsuru/tsuru/cmd"
@@ -40,11 +44,15 @@ const ErrMissingAppOrJob = "You must pass an application or job"
 const ErrAppAndJobNotAllowedTogether = "You must pass an application or job, not both"
 
 type EnvGet struct {
+	outputFlag
+	cmd.ConfirmationCommand
 	appName string
 	jobName string
 
-	fs   *gnuflag.FlagSet
-	json bool
+	fs          *gnuflag.FlagSet
+	json        bool
+	only        string
+	showPrivate bool
 }
 
 func (c *EnvGet) Flags() *gnuflag.FlagSet {
@@ -56,16 +64,37 @@ func (c *EnvGet) Flags() *gnuflag.FlagSet {
 		c.fs.StringVar(&c.jobName, "job", "", "The name of the job.")
 		c.fs.StringVar(&c.jobName, "j", "", "The name of the job.")
 		c.fs.BoolVar(&c.json, "json", false, "Display JSON format")
-
+		c.fs.StringVar(&c.only, "only", "", "Display only the variables set in the app or by service instances: app or services")
+		c.fs.BoolVar(&c.showPrivate, "show-private", false, "Display the values of private variables, after a confirmation")
+		c.addOutputFlag(c.fs)
+		c.fs = mergeFlagSet(c.fs, c.ConfirmationCommand.Flags())
 	}
 	return c.fs
 }
 
 func (c *EnvGet) Info() *cmd.Info {
 	return &cmd.Info{
-		Name:    "env-get",
-		Usage:   "env get [-a/--app appname] [-j/--job jobname] [ENVIRONMENT_VARIABLE1] [ENVIRONMENT_VARIABLE2] ...",
-		Desc:    `Retrieves environment variables for an application or job.`,
+		Name:  "env-get",
+		Usage: "env get [-a/--app appname] [-j/--job jobname] [--only app|services] [--show-private [-y/--assume-yes]] [--json] [--output table|json|yaml] [ENVIRONMENT_VARIABLE1] [ENVIRONMENT_VARIABLE2] ...",
+		Desc: `Retrieves environment variables for an application or job.
+
+The [[--output]] flag prints the variables as a JSON or YAML list, and
+[[--json]] is the same as [[--output json]]. The values of private variables
+are masked as "*****", and these variables have the "masked" field set to
+true, so they can be told apart from variables with an actual value.
+
+Use [[--show-private]] to display the values of private variables. As they
+usually hold secrets, a confirmation is asked first, which can be skipped
+with [[--assume-yes]] when the output is not shared. With the JSON and YAML
+outputs, the confirmation is asked on stderr.
+
+Variables exported by a bound service instance are annotated with the
+instance, like "(managed by mysql/db1)", and have the "serviceInstance" field
+in the JSON and YAML outputs. The [[--only]] flag
+displays only the variables set in the app, with [[--only app]], or only the
+variables exported by service instances, with [[--only services]]. The
+TSURU_SERVICES variable, which holds all the service variables, is listed with
+the service ones.`,
 		MinArgs: 0,
 	}
 }
@@ -73,11 +102,38 @@ func (c *EnvGet) Info() *cmd.Info {
 func (c *EnvGet) Run(context *cmd.Context) error {
 	context.RawOutput()
 
-	err := checkAppAndJobInputs(c.appName, c.jobName)
+	err := checkAppAndJobInputs(&c.appName, c.jobName)
 	if err != nil {
 		return err
 	}
 
+	output, err := c.format(c.json)
+	if err != nil {
+		return err
+	}
+
+	switch c.only {
+	case "", envSourceApp, envSourceServices:
+	default:
+		return fmt.Errorf("invalid --only value %q, use %q or %q", c.only, envSourceApp, envSourceServices)
+	}
+
+	if c.showPrivate {
+		target := fmt.Sprintf("app %q", c.appName)
+		if c.appName == "" {
+			target = fmt.Sprintf("job %q", c.jobName)
+		}
+		confirmContext := *context
+		if output != outputTable {
+			// The question is asked on stderr, so it doesn't end up in
+			// the JSON or YAML output.
+			confirmContext.Stdout = context.Stderr
+		}
+		if !c.Confirm(&confirmContext, fmt.Sprintf("The values of the private variables of %s will be displayed. Continue?", target)) {
+			return nil
+		}
+	}
+
 	b, err := requestEnvGetURL(c, context.Args)
 	if err != nil {
 		return err
@@ -89,8 +145,10 @@ func (c *EnvGet) Run(context *cmd.Context) error {
 		return err
 	}
 
-	if c.json {
-		return c.renderJSON(context, variables)
+	variables = c.filterSource(variables)
+
+	if output != outputTable {
+		return c.render(context, output, variables)
 	}
 
 	formatted := make([]string, 0, len(variables))
@@ -99,12 +157,16 @@ func (c *EnvGet) Run(context *cmd.Context) error {
 		public := v["public"].(bool)
 		managedBy, _ := v["managedBy"].(string)
 
+		if !public && !c.showPrivate {
+			value = tsuruHTTP.RedactedValue
+		}
+
 		if public && managedBy != "" {
 			value = fmt.Sprintf("%s (managed by %s)", value, managedBy)
 		} else if !public && managedBy != "" {
-			value = fmt.Sprintf("*** (private variable managed by %s)", managedBy)
+			value = fmt.Sprintf("%s (private variable managed by %s)", value, managedBy)
 		} else if !public {
-			value = "*** (private variable)"
+			value = value + " (private variable)"
 		}
 
 		formatted = append(formatted, fmt.Sprintf("%s=%s", v["name"], value))
@@ -114,72 +176,209 @@ func (c *EnvGet) Run(context *cmd.Context) error {
 	return nil
 }
 
-func (c *EnvGet) renderJSON(context *cmd.Context, variables []map[string]interface{}) error {
+func (c *EnvGet) render(context *cmd.Context, output string, variables []map[string]interface{}) error {
 	type envJSON struct {
-		Name      string `json:"name"`
-		Value     string `json:"value"`
-		Private   bool   `json:"private"`
-		ManagedBy string `json:"managedBy,omitempty"`
+		Name            string `json:"name"`
+		Value           string `json:"value"`
+		Public          bool   `json:"public"`
+		Private         bool   `json:"private"`
+		Masked          bool   `json:"masked"`
+		ManagedBy       string `json:"managedBy,omitempty"`
+		ServiceInstance string `json:"serviceInstance,omitempty"`
 	}
 
 	data := make([]envJSON, 0, len(variables))
 
 	for _, v := range variables {
-		private := true
-		value := "*** (private variable)"
-		if v["public"].(bool) {
-			value = v["value"].(string)
-			private = false
+		private := !v["public"].(bool)
+		masked := private && !c.showPrivate
+		value := v["value"].(string)
+		if masked {
+			value = tsuruHTTP.RedactedValue + " (private variable)"
 		}
 		managedBy, _ := v["managedBy"].(string)
+		name := v["name"].(string)
 		data = append(data, envJSON{
-			Name:      v["name"].(string),
-			Value:     value,
-			Private:   private,
-			ManagedBy: managedBy,
+			Name:            name,
+			Value:           value,
+			Public:          !private,
+			Private:         private,
+			Masked:          masked,
+			ManagedBy:       managedBy,
+			ServiceInstance: envServiceInstance(managedBy),
 		})
 	}
 
-	return formatter.JSON(context.Stdout, data)
+	return renderOutput(context.Stdout, output, data)
+}
+
+const (
+	envSourceApp      = "app"
+	envSourceServices = "services"
+)
+
+// filterSource returns the variables selected by the --only flag.
+func (c *EnvGet) filterSource(variables []map[string]interface{}) []map[string]interface{} {
+	if c.only == "" {
+		return variables
+	}
+	result := make([]map[string]interface{}, 0, len(variables))
+	for _, v := range variables {
+		name, _ := v["name"].(string)
+		managedBy, _ := v["managedBy"].(string)
+		fromServices := envServiceInstance(managedBy) != "" || name == tsuruServicesEnvVar
+		if fromServices == (c.only == envSourceServices) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+const tsuruServicesEnvVar = "TSURU_SERVICES"
+
+// envServiceInstance returns the service instance, like "mysql/db1", that
+// exported a variable, from its managedBy field. tsuru sets it to the
+// instance for service variables, and to "tsuru" for its own variables.
+func envServiceInstance(managedBy string) string {
+	if strings.Contains(managedBy, "/") {
+		return managedBy
+	}
+	return ""
 }
 
 type EnvSet struct {
-	appName   string
-	jobName   string
-	fs        *gnuflag.FlagSet
-	private   bool
-	noRestart bool
+	ciOutput
+	cmd.ConfirmationCommand
+	restartFlags
+	appName     string
+	apps        cmd.StringSliceFlag
+	jobName     string
+	fs          *gnuflag.FlagSet
+	private     bool
+	impact      bool
+	file        string
+	jsonFile    string
+	public      bool
+	concurrency int
+	noDiff      bool
 }
 
 func (c *EnvSet) Info() *cmd.Info {
 	return &cmd.Info{
-		Name:    "env-set",
-		Usage:   "env set <NAME=value> [NAME=value] ... [-a/--app appname] [-j/--job jobname] [-p/--private] [--no-restart]",
-		Desc:    `Sets environment variables for an application or job.`,
-		MinArgs: 1,
+		Name:  "env-set",
+		Usage: "env set <NAME=value> [NAME=value] ... [-a/--app appname]... [-j/--job jobname] [-f/--file envfile] [--json-file jsonfile] [-p/--private | --public] [--restart=false] [--impact [-y/--assume-yes]] [--no-diff] [--ci/--quiet]",
+		Desc: `Sets environment variables for an application or job.
+
+The [[--file]] flag reads the variables from a dotenv-style file, with one
+NAME=value per line. Empty lines and lines starting with # are ignored, and
+values may be enclosed in double or single quotes. Variables given in the
+command line override the ones in the file.
+
+The [[--json-file]] flag reads the variables from a file with a flat JSON
+object, like {"NAME": "value"}. Nested objects and arrays are not supported.
+
+A variable given as NAME=- has its value read from the standard input, which
+keeps it out of the shell history. When several variables are given this way,
+their values are read one per line, in order. These variables are private,
+unless [[--public]] is used.
+
+The [[--app]] flag can be used multiple times to set the same variables in
+several apps. The apps are updated concurrently, up to the number defined by
+the [[--concurrency]] flag, and the result for each app is displayed at the
+end.
+
+The [[--impact]] flag shows how many units of the app will be restarted by the
+change, and in which processes, asking for confirmation before proceeding.
+
+Before applying the change, the current variables are fetched and the
+variables that are new, changed or unchanged are displayed. Changed public
+variables show the old and the new value, while private ones are only shown
+as changed. Use [[--no-diff]] to skip this step, which is also skipped when
+setting variables in several apps.
+
+The app is restarted after the variables are set, unless [[--restart=false]]
+is used. The [[--no-restart]] flag is deprecated, and is the same as
+[[--restart=false]].
+
+The global [[--dry-run]] flag prints the variables that would be set, hiding
+the values of private variables, without changing anything.`,
+		MinArgs: 0,
 	}
 }
 
 func (c *EnvSet) Run(context *cmd.Context) error {
 	context.RawOutput()
 
-	err := checkAppAndJobInputs(c.appName, c.jobName)
+	if len(c.apps) > 0 {
+		c.appName = c.apps[0]
+	}
+	err := checkAppAndJobInputs(&c.appName, c.jobName)
 	if err != nil {
 		return err
 	}
+	if len(c.apps) > 1 && c.impact {
+		return errors.New("the --impact flag is not supported with multiple apps")
+	}
 
-	if len(context.Args) < 1 {
+	if len(context.Args) < 1 && c.file == "" && c.jsonFile == "" {
 		return errors.New(EnvSetValidationMessage)
 	}
 
-	envs := make([]apiTypes.Env, len(context.Args))
-	for i := range context.Args {
-		parts := strings.SplitN(context.Args[i], "=", 2)
+	if c.private && c.public {
+		return errors.New("the --private and --public flags are mutually exclusive")
+	}
+
+	err = c.resolveRestart(context, c.fs)
+	if err != nil {
+		return err
+	}
+
+	var envs []apiTypes.Env
+	if c.file != "" {
+		data, err := os.ReadFile(c.file)
+		if err != nil {
+			return err
+		}
+		envs, err = parseEnvFile(data)
+		if err != nil {
+			return err
+		}
+	}
+	if c.jsonFile != "" {
+		data, err := os.ReadFile(c.jsonFile)
+		if err != nil {
+			return err
+		}
+		jsonEnvs, err := parseEnvJSON(data)
+		if err != nil {
+			return fmt.Errorf("invalid JSON file %s: %w", c.jsonFile, err)
+		}
+		for _, env := range jsonEnvs {
+			envs = setEnv(envs, env)
+		}
+	}
+	var stdin *bufio.Reader
+	for _, arg := range context.Args {
+		parts := strings.SplitN(arg, "=", 2)
 		if len(parts) != 2 {
 			return errors.New(EnvSetValidationMessage)
 		}
-		envs[i] = apiTypes.Env{Name: parts[0], Value: parts[1]}
-
+		env := apiTypes.Env{Name: parts[0], Value: parts[1]}
+		if env.Value == "-" {
+			if stdin == nil {
+				stdin = bufio.NewReader(context.Stdin)
+			}
+			env.Value, err = readEnvValue(stdin)
+			if err != nil {
+				return fmt.Errorf("unable to read the value of %s from the standard input: %w", env.Name, err)
+			}
+			private := !c.public
+			env.Private = &private
+		}
+		envs = setEnv(envs, env)
+	}
+	if len(envs) == 0 {
+		return errors.New("no environment variables found in the given files")
 	}
 	e := apiTypes.Envs{
 		Envs:      envs,
@@ -187,6 +386,41 @@ func (c *EnvSet) Run(context *cmd.Context) error {
 		Private:   c.private,
 	}
 
+	if !c.noDiff && len(c.apps) <= 1 {
+		// The diff is only informative, so env-set goes on without it when
+		// the current variables can't be fetched.
+		current, err := getEnvs(&EnvGet{appName: c.appName, jobName: c.jobName})
+		if err != nil {
+			fmt.Fprintf(context.Stderr, "WARNING: unable to fetch the current environment variables, the changes won't be shown: %v\n", tsuruHTTP.UnwrapErr(err))
+		} else {
+			renderEnvSetDiff(c.progressWriter(context.Stdout), c.envTarget(), current, &e)
+		}
+	}
+
+	if tsuruHTTP.IsDryRun() {
+		printEnvSetDryRun(context.Stdout, c.envTarget(), &e)
+		return nil
+	}
+
+	if len(c.apps) > 1 {
+		return c.setAppsEnvs(context, &e)
+	}
+
+	if c.impact {
+		if c.appName == "" {
+			return errors.New("the --impact flag is only supported for apps")
+		}
+		var impact string
+		impact, err = envChangeImpact(c.appName, c.noRestart)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(context.Stdout, impact)
+		if !c.Confirm(context, "Do you want to proceed?") {
+			return nil
+		}
+	}
+
 	var path, apiVersion string
 	switch c.appName {
 	case "":
@@ -197,49 +431,350 @@ func (c *EnvSet) Run(context *cmd.Context) error {
 		apiVersion = "1.0"
 	}
 
-	url, err := config.GetURLVersion(apiVersion, path)
+	response, err := requestEnvSet(apiVersion, path, &e)
 	if err != nil {
 		return err
 	}
+	return c.stream(context.Stdout, response)
+}
+
+// envTarget describes the apps or job changed by env-set, like `app "myapp"`.
+func (c *EnvSet) envTarget() string {
+	if c.appName == "" {
+		return fmt.Sprintf("job %q", c.jobName)
+	}
+	if len(c.apps) <= 1 {
+		return fmt.Sprintf("app %q", c.appName)
+	}
+	names := make([]string, len(c.apps))
+	for i, name := range c.apps {
+		names[i] = strconv.Quote(name)
+	}
+	return "apps " + strings.Join(names, ", ")
+}
+
+// renderEnvSetDiff prints a table comparing the variables that will be set
+// with the current ones. Values of private variables are never shown.
+func renderEnvSetDiff(w io.Writer, target string, current map[string]appEnv, e *apiTypes.Envs) {
+	envs := make([]apiTypes.Env, len(e.Envs))
+	copy(envs, e.Envs)
+	sort.Slice(envs, func(i, j int) bool { return envs[i].Name < envs[j].Name })
+	table := tablecli.NewTable()
+	table.Headers = tablecli.Row{"Name", "Change", "Value"}
+	for _, env := range envs {
+		private := e.Private
+		if env.Private != nil {
+			private = *env.Private
+		}
+		value := env.Value
+		if private {
+			value = tsuruHTTP.RedactedValue
+		}
+		old, exists := current[env.Name]
+		switch {
+		case !exists:
+			table.AddRow(tablecli.Row{env.Name, "new", value})
+		case private || !old.Public:
+			table.AddRow(tablecli.Row{env.Name, "changed", tsuruHTTP.RedactedValue})
+		case old.Value != env.Value:
+			table.AddRow(tablecli.Row{env.Name, "changed", old.Value + " → " + env.Value})
+		default:
+			table.AddRow(tablecli.Row{env.Name, "unchanged", value})
+		}
+	}
+	fmt.Fprintf(w, "Changes to the environment variables of %s:\n", target)
+	fmt.Fprint(w, table.String())
+}
+
+// printEnvSetDryRun prints the variables that env-set would send in dry run
+// mode, hiding the values of private variables.
+func printEnvSetDryRun(w io.Writer, target string, e *apiTypes.Envs) {
+	fmt.Fprintf(w, "Dry run, the following environment variables would be set in %s:\n", target)
+	for _, env := range e.Envs {
+		private := e.Private
+		if env.Private != nil {
+			private = *env.Private
+		}
+		if private {
+			fmt.Fprintf(w, "  %s=%s (private variable)\n", env.Name, tsuruHTTP.RedactedValue)
+		} else {
+			fmt.Fprintf(w, "  %s=%s\n", env.Name, env.Value)
+		}
+	}
+	fmt.Fprintf(w, "No restart: %t\n", e.NoRestart)
+}
 
-	v, err := form.EncodeToValues(&e)
+func requestEnvSet(apiVersion, path string, e *apiTypes.Envs) (*http.Response, error) {
+	url, err := config.GetURLVersion(apiVersion, path)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	v, err := form.EncodeToValues(e)
+	if err != nil {
+		return nil, err
 	}
 	request, err := http.NewRequest("POST", url, strings.NewReader(v.Encode()))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
-	if err != nil {
-		return err
+	return tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
+}
+
+// setAppsEnvs sets the variables in each of the apps given by the --app flag,
+// concurrently, and displays the result for each app.
+func (c *EnvSet) setAppsEnvs(context *cmd.Context, e *apiTypes.Envs) error {
+	if c.concurrency <= 0 {
+		return errors.New("the concurrency must be greater than zero")
+	}
+	results := make([]error, len(c.apps))
+	forEachConcurrently(len(c.apps), c.concurrency, func(i int) bool {
+		response, err := requestEnvSet("1.0", fmt.Sprintf("/apps/%s/env", c.apps[i]), e)
+		if err == nil {
+			defer response.Body.Close()
+			err = formatter.StreamJSONResponse(io.Discard, response)
+		}
+		results[i] = err
+		return true
+	})
+	table := tablecli.NewTable()
+	table.Headers = tablecli.Row{"App", "Result"}
+	failures := 0
+	for i, appName := range c.apps {
+		result := "ok"
+		if results[i] != nil {
+			failures++
+			result = fmt.Sprintf("failed: %v", tsuruHTTP.UnwrapErr(results[i]))
+		}
+		table.AddRow(tablecli.Row{appName, result})
+	}
+	fmt.Fprint(context.Stdout, table.String())
+	if failures > 0 {
+		return fmt.Errorf("failed to set environment variables in %d of %d apps", failures, len(c.apps))
 	}
-	return formatter.StreamJSONResponse(context.Stdout, response)
+	return nil
 }
 
 func (c *EnvSet) Flags() *gnuflag.FlagSet {
 	if c.fs == nil {
 		c.fs = gnuflag.NewFlagSet("", gnuflag.ExitOnError)
 
-		c.fs.StringVar(&c.appName, "app", "", "The name of the app.")
-		c.fs.StringVar(&c.appName, "a", "", "The name of the app.")
+		c.fs.Var(&c.apps, "app", "The name of the app, can be used multiple times.")
+		c.fs.Var(&c.apps, "a", "The name of the app, can be used multiple times.")
+		c.fs.IntVar(&c.concurrency, "concurrency", 4, "How many apps are updated at the same time when using multiple apps")
 		c.fs.StringVar(&c.jobName, "job", "", "The name of the job.")
 		c.fs.StringVar(&c.jobName, "j", "", "The name of the job.")
 		c.fs.BoolVar(&c.private, "private", false, "Private environment variables")
 		c.fs.BoolVar(&c.private, "p", false, "Private environment variables")
-		c.fs.BoolVar(&c.noRestart, "no-restart", false, "Sets environment varibles without restart the application")
+		c.fs.BoolVar(&c.public, "public", false, "Don't make variables read from the standard input private")
+		c.addRestartFlags(c.fs, "Restart the application after setting the environment variables")
+		c.fs.BoolVar(&c.impact, "impact", false, "Shows how many units will be restarted before proceeding")
+		c.fs.StringVar(&c.file, "file", "", "Read environment variables from a dotenv file")
+		c.fs.StringVar(&c.file, "f", "", "Read environment variables from a dotenv file")
+		c.fs.StringVar(&c.jsonFile, "json-file", "", "Read environment variables from a file with a flat JSON object")
+		c.fs.BoolVar(&c.noDiff, "no-diff", false, "Don't display the changes to the current variables before applying them")
+		c.addFlags(c.fs)
+		c.fs = mergeFlagSet(c.fs, c.ConfirmationCommand.Flags())
 	}
 	return c.fs
 }
 
-type EnvUnset struct {
-	appName   string
-	jobName   string
-	fs        *gnuflag.FlagSet
+// parseEnvFile parses a dotenv-style file, with one NAME=value per line.
+func parseEnvFile(data []byte) ([]apiTypes.Env, error) {
+	var envs []apiTypes.Env
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		parts := strings.SplitN(line, "=", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || name == "" {
+			return nil, fmt.Errorf("invalid line %d in env file: %q", i+1, line)
+		}
+		value, err := parseEnvFileValue(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid line %d in env file: %w", i+1, err)
+		}
+		envs = setEnv(envs, apiTypes.Env{Name: name, Value: value})
+	}
+	return envs, nil
+}
+
+func parseEnvFileValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	switch value[0] {
+	case '"':
+		end := strings.LastIndex(value, `"`)
+		if end == 0 {
+			return "", fmt.Errorf("unterminated quoted value %s", value)
+		}
+		return strconv.Unquote(value[:end+1])
+	case '\'':
+		end := strings.LastIndex(value, "'")
+		if end == 0 {
+			return "", fmt.Errorf("unterminated quoted value %s", value)
+		}
+		return value[1:end], nil
+	}
+	if idx := strings.Index(value, " #"); idx >= 0 {
+		value = strings.TrimSpace(value[:idx])
+	}
+	return value, nil
+}
+
+// parseEnvJSON parses a flat JSON object of variables, sorted by name. String,
+// number and boolean values are accepted.
+func parseEnvJSON(data []byte) ([]apiTypes.Env, error) {
+	var values map[string]json.RawMessage
+	err := json.Unmarshal(data, &values)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	envs := make([]apiTypes.Env, 0, len(names))
+	for _, name := range names {
+		var value interface{}
+		err = json.Unmarshal(values[name], &value)
+		if err != nil {
+			return nil, err
+		}
+		var str string
+		switch v := value.(type) {
+		case string:
+			str = v
+		case float64, bool:
+			str = string(values[name])
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("the value of %q must be a string, nested objects and arrays are not supported", name)
+		default:
+			return nil, fmt.Errorf("the value of %q must be a string", name)
+		}
+		envs = append(envs, apiTypes.Env{Name: name, Value: str})
+	}
+	return envs, nil
+}
+
+// readEnvValue reads the next line of r as the value of a variable.
+func readEnvValue(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err == io.EOF {
+		if line == "" {
+			return "", errors.New("no more values to read")
+		}
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// setEnv sets env in envs, replacing any variable with the same name.
+func setEnv(envs []apiTypes.Env, env apiTypes.Env) []apiTypes.Env {
+	for i := range envs {
+		if envs[i].Name == env.Name {
+			envs[i] = env
+			return envs
+		}
+	}
+	return append(envs, env)
+}
+
+// envChangeImpact describes how many units of the app will be restarted by
+// an env change.
+func envChangeImpact(appName string, noRestart bool) (string, error) {
+	if noRestart {
+		return "This change will not restart any units (--restart=false).", nil
+	}
+	a, err := getApp(appName)
+	if err != nil {
+		return "", err
+	}
+	units := 0
+	processSet := map[string]struct{}{}
+	for _, u := range a.Units {
+		if u.ID == "" {
+			continue
+		}
+		units++
+		processSet[u.ProcessName] = struct{}{}
+	}
+	if units == 0 {
+		return "This change will not restart any units, the app has no units.", nil
+	}
+	processes := make([]string, 0, len(processSet))
+	for p := range processSet {
+		processes = append(processes, p)
+	}
+	sort.Strings(processes)
+	unitsLabel := "units"
+	if units == 1 {
+		unitsLabel = "unit"
+	}
+	processesLabel := "processes"
+	if len(processes) == 1 {
+		processesLabel = "process"
+	}
+	return fmt.Sprintf("This change will restart %d %s across %s %s.", units, unitsLabel, processesLabel, strings.Join(processes, ", ")), nil
+}
+
+// restartFlags adds the --restart flag, and its deprecated negative form
+// --no-restart, to commands that restart the app after changing it.
+type restartFlags struct {
+	restart   bool
 	noRestart bool
 }
 
+func (r *restartFlags) addRestartFlags(fs *gnuflag.FlagSet, usage string) {
+	fs.BoolVar(&r.restart, "restart", true, usage)
+	fs.BoolVar(&r.noRestart, "no-restart", false, "Deprecated, use --restart=false")
+}
+
+// resolveRestart sets noRestart from the flags given in the command line,
+// failing when both --restart and --no-restart are given.
+func (r *restartFlags) resolveRestart(context *cmd.Context, fs *gnuflag.FlagSet) error {
+	var restartSet, noRestartSet bool
+	if fs != nil {
+		fs.Visit(func(f *gnuflag.Flag) {
+			switch f.Name {
+			case "restart":
+				restartSet = true
+			case "no-restart":
+				noRestartSet = true
+			}
+		})
+	}
+	if restartSet && noRestartSet {
+		return errors.New("the --restart and --no-restart flags can't be used together, use only --restart")
+	}
+	if noRestartSet {
+		fmt.Fprintln(context.Stderr, "Warning: the --no-restart flag is deprecated, use --restart=false instead.")
+		return nil
+	}
+	if restartSet {
+		r.noRestart = !r.restart
+	}
+	return nil
+}
+
+type EnvUnset struct {
+	ciOutput
+	cmd.ConfirmationCommand
+	restartFlags
+	appName string
+	jobName string
+	all     bool
+	fs      *gnuflag.FlagSet
+}
+
 func (c *EnvUnset) Flags() *gnuflag.FlagSet {
 	if c.fs == nil {
 		c.fs = gnuflag.NewFlagSet("", gnuflag.ExitOnError)
@@ -248,30 +783,100 @@ func (c *EnvUnset) Flags() *gnuflag.FlagSet {
 		c.fs.StringVar(&c.appName, "a", "", "The name of the app.")
 		c.fs.StringVar(&c.jobName, "job", "", "The name of the job.")
 		c.fs.StringVar(&c.jobName, "j", "", "The name of the job.")
-		c.fs.BoolVar(&c.noRestart, "no-restart", false, "Unset environment variables without restart the application")
+		c.fs.BoolVar(&c.all, "all", false, "Unset all the variables set in the app or job, public and private, except the ones managed by tsuru or services")
+		c.addRestartFlags(c.fs, "Restart the application after unsetting the environment variables")
+		c.addFlags(c.fs)
+		c.fs = mergeFlagSet(c.fs, c.ConfirmationCommand.Flags())
 	}
 	return c.fs
 }
 
 func (c *EnvUnset) Info() *cmd.Info {
 	return &cmd.Info{
-		Name:    "env-unset",
-		Usage:   "env unset <ENVIRONMENT_VARIABLE1> [ENVIRONMENT_VARIABLE2] ... [ENVIRONMENT_VARIABLEN] [-a/--app appname] [-j/--job jobname] [--no-restart]",
-		Desc:    `Unset environment variables for an application or job.`,
-		MinArgs: 1,
+		Name:  "env-unset",
+		Usage: "env unset <ENVIRONMENT_VARIABLE1> [ENVIRONMENT_VARIABLE2] ... [ENVIRONMENT_VARIABLEN] [-a/--app appname] [-j/--job jobname] [--all [-y/--assume-yes]] [--restart=false] [--ci/--quiet]",
+		Desc: `Unset environment variables for an application or job.
+
+The app is restarted after the variables are removed, unless
+[[--restart=false]] is used. The [[--no-restart]] flag is deprecated, and is
+the same as [[--restart=false]].
+
+The [[--all]] flag removes all the variables set in the app or job in a single
+request, including the private ones, whose values can't be read back after
+they're removed. Variables managed by tsuru or exported by bound service
+instances are never removed, so the bindings keep working. The variables that
+will be removed, with the private ones marked, must be confirmed before
+proceeding, unless [[--assume-yes]] is used.
+
+The global [[--dry-run]] flag prints the variables that would be removed,
+without changing anything.`,
+		MinArgs: 0,
 	}
 }
 
 func (c *EnvUnset) Run(context *cmd.Context) error {
 	context.RawOutput()
 
-	err := checkAppAndJobInputs(c.appName, c.jobName)
+	err := checkAppAndJobInputs(&c.appName, c.jobName)
+	if err != nil {
+		return err
+	}
+
+	err = c.resolveRestart(context, c.fs)
 	if err != nil {
 		return err
 	}
 
+	names := context.Args
+	switch {
+	case c.all && len(names) > 0:
+		return errors.New("the --all flag can't be used with variable names")
+	case !c.all && len(names) == 0:
+		return errors.New("you must give the variables to unset, or use --all to unset all of them")
+	}
+
+	target := fmt.Sprintf("app %q", c.appName)
+	if c.appName == "" {
+		target = fmt.Sprintf("job %q", c.jobName)
+	}
+
+	labels := names
+	var private int
+	if c.all {
+		envs, err := getEnvs(&EnvGet{appName: c.appName, jobName: c.jobName})
+		if err != nil {
+			return err
+		}
+		names = userEnvNames(envs)
+		if len(names) == 0 {
+			fmt.Fprintf(context.Stdout, "There are no variables to unset in %s.\n", target)
+			return nil
+		}
+		labels = make([]string, len(names))
+		for i, name := range names {
+			labels[i] = name
+			if !envs[name].Public {
+				labels[i] += " (private)"
+				private++
+			}
+		}
+	}
+
+	if tsuruHTTP.IsDryRun() {
+		fmt.Fprintf(context.Stdout, "Dry run, the following environment variables would be removed from %s:\n", target)
+		for _, label := range labels {
+			fmt.Fprintf(context.Stdout, "  %s\n", label)
+		}
+		fmt.Fprintf(context.Stdout, "No restart: %t\n", c.noRestart)
+		return nil
+	}
+
+	if c.all && !c.Confirm(context, fmt.Sprintf("The following %d variables, %d of them private, will be removed from %s: %s. Continue?", len(names), private, target, strings.Join(labels, ", "))) {
+		return nil
+	}
+
 	v := url.Values{}
-	for _, e := range context.Args {
+	for _, e := range names {
 		v.Add("env", e)
 	}
 	v.Set("noRestart", strconv.FormatBool(c.noRestart))
@@ -295,11 +900,11 @@ func (c *EnvUnset) Run(context *cmd.Context) error {
 	if err != nil {
 		return err
 	}
-	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	response, err := tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
 	if err != nil {
 		return err
 	}
-	return formatter.StreamJSONResponse(context.Stdout, response)
+	return c.stream(context.Stdout, response)
 }
 
 func requestEnvGetURL(c *EnvGet, args []string) ([]byte, error) {
@@ -338,14 +943,432 @@ func requestEnvGetURL(c *EnvGet, args []string) ([]byte, error) {
 	return b, nil
 }
 
-func checkAppAndJobInputs(appName string, jobName string) error {
-	if appName == "" && jobName == "" {
+type appEnv struct {
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	Public    bool   `json:"public"`
+	ManagedBy string `json:"managedBy,omitempty"`
+}
+
+func getAppEnvs(appName string) (map[string]appEnv, error) {
+	return getEnvs(&EnvGet{appName: appName})
+}
+
+// getEnvs returns the environment variables of the app or job set in g, by
+// name.
+func getEnvs(g *EnvGet) (map[string]appEnv, error) {
+	b, err := requestEnvGetURL(g, nil)
+	if err != nil {
+		return nil, err
+	}
+	var envs []appEnv
+	err = json.Unmarshal(b, &envs)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]appEnv, len(envs))
+	for _, e := range envs {
+		result[e.Name] = e
+	}
+	return result, nil
+}
+
+// userEnvNames returns the sorted names of the variables set by users,
+// leaving out the ones managed by tsuru or by service instances.
+func userEnvNames(envs map[string]appEnv) []string {
+	var names []string
+	for name, e := range envs {
+		if e.ManagedBy != "" {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type EnvDiff struct {
+	fs    *gnuflag.FlagSet
+	patch bool
+}
+
+func (c *EnvDiff) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "env-diff",
+		Usage: "env diff <source-app> <target-app> [--patch]",
+		Desc: `Shows the differences between the environment variables of two apps.
+
+Variables only in the source app are prefixed by "+", variables only in the
+target app by "-" and variables with different values by "~". Variables
+managed by services are ignored, and values of private variables are never
+shown.
+
+The [[--patch]] flag prints the env-set and env-unset commands that would make
+the target app match the source app, so they can be reviewed and applied.
+Values of private variables must be filled in before applying the commands.`,
+		MinArgs: 2,
+		MaxArgs: 2,
+	}
+}
+
+func (c *EnvDiff) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = gnuflag.NewFlagSet("", gnuflag.ExitOnError)
+		c.fs.BoolVar(&c.patch, "patch", false, "Print the commands that make the target app match the source app")
+	}
+	return c.fs
+}
+
+type envChanges struct {
+	added     []appEnv
+	removed   []appEnv
+	changed   []appEnv
+	unchecked []string
+}
+
+func diffEnvs(source, target map[string]appEnv) envChanges {
+	var changes envChanges
+	for name, e := range source {
+		if e.ManagedBy != "" {
+			continue
+		}
+		other, ok := target[name]
+		switch {
+		case !ok || other.ManagedBy != "":
+			changes.added = append(changes.added, e)
+		case !e.Public && !other.Public:
+			changes.unchecked = append(changes.unchecked, name)
+		case e.Public != other.Public || e.Value != other.Value:
+			changes.changed = append(changes.changed, e)
+		}
+	}
+	for name, e := range target {
+		if e.ManagedBy != "" {
+			continue
+		}
+		if other, ok := source[name]; !ok || other.ManagedBy != "" {
+			changes.removed = append(changes.removed, e)
+		}
+	}
+	byName := func(envs []appEnv) {
+		sort.Slice(envs, func(i, j int) bool { return envs[i].Name < envs[j].Name })
+	}
+	byName(changes.added)
+	byName(changes.removed)
+	byName(changes.changed)
+	sort.Strings(changes.unchecked)
+	return changes
+}
+
+func maskedEnvValue(e appEnv) string {
+	if !e.Public {
+		return tsuruHTTP.RedactedValue
+	}
+	return e.Value
+}
+
+func (c *EnvDiff) Run(context *cmd.Context) error {
+	sourceApp, targetApp := context.Args[0], context.Args[1]
+	source, err := getAppEnvs(sourceApp)
+	if err != nil {
+		return err
+	}
+	target, err := getAppEnvs(targetApp)
+	if err != nil {
+		return err
+	}
+	changes := diffEnvs(source, target)
+	if c.patch {
+		renderEnvPatch(context.Stdout, targetApp, changes)
+		return nil
+	}
+	if len(changes.added)+len(changes.removed)+len(changes.changed)+len(changes.unchecked) == 0 {
+		fmt.Fprintf(context.Stdout, "No differences found between apps %q and %q.\n", sourceApp, targetApp)
+		return nil
+	}
+	for _, e := range changes.added {
+		fmt.Fprintf(context.Stdout, "+ %s=%s\n", e.Name, maskedEnvValue(e))
+	}
+	for _, e := range changes.removed {
+		fmt.Fprintf(context.Stdout, "- %s=%s\n", e.Name, maskedEnvValue(e))
+	}
+	for _, e := range changes.changed {
+		fmt.Fprintf(context.Stdout, "~ %s=%s -> %s\n", e.Name, maskedEnvValue(target[e.Name]), maskedEnvValue(e))
+	}
+	for _, name := range changes.unchecked {
+		fmt.Fprintf(context.Stdout, "? %s (private in both apps, values can not be compared)\n", name)
+	}
+	return nil
+}
+
+func renderEnvPatch(w io.Writer, targetApp string, changes envChanges) {
+	var public, private []string
+	for _, e := range append(changes.added, changes.changed...) {
+		if e.Public {
+			public = append(public, fmt.Sprintf("%s=%s", e.Name, shellQuote(e.Value)))
+		} else {
+			private = append(private, fmt.Sprintf("%s=%s", e.Name, shellQuote("<private value of "+e.Name+">")))
+		}
+	}
+	sort.Strings(public)
+	sort.Strings(private)
+	if len(changes.unchecked) > 0 {
+		fmt.Fprintf(w, "# private variables in both apps, values can not be compared: %s\n", strings.Join(changes.unchecked, ", "))
+	}
+	if len(public) > 0 {
+		fmt.Fprintf(w, "tsuru env-set -a %s %s\n", targetApp, strings.Join(public, " "))
+	}
+	if len(private) > 0 {
+		fmt.Fprintf(w, "tsuru env-set -a %s --private %s\n", targetApp, strings.Join(private, " "))
+	}
+	if len(changes.removed) > 0 {
+		names := make([]string, len(changes.removed))
+		for i, e := range changes.removed {
+			names[i] = e.Name
+		}
+		fmt.Fprintf(w, "tsuru env-unset -a %s %s\n", targetApp, strings.Join(names, " "))
+	}
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// checkAppAndJobInputs checks that either an app or a job is given. When
+// neither is, the app is guessed from the working directory.
+func checkAppAndJobInputs(appName *string, jobName string) error {
+	if *appName == "" && jobName == "" {
+		*appName = tsuruClientApp.GuessName()
+	}
+	if *appName == "" && jobName == "" {
 		return errors.New(ErrMissingAppOrJob)
 	}
 
-	if appName != "" && jobName != "" {
+	if *appName != "" && jobName != "" {
 		return errors.New(ErrAppAndJobNotAllowedTogether)
 	}
 
 	return nil
 }
+
+type EnvAudit struct {
+	fs      *gnuflag.FlagSet
+	require cmd.StringSliceFlag
+	pool    string
+	team    string
+}
+
+func (c *EnvAudit) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "env-audit",
+		Usage: "env audit --require NAME [--require NAME ...] [--pool pool] [--team team]",
+		Desc: `Lists the apps that are missing required environment variables.
+
+The [[--require]] flag can be used multiple times, one for each required
+variable. The apps can be limited to a pool with [[--pool]] and to a team owner
+with [[--team]]. The command exits with an error if any app is missing one of
+the required variables.`,
+		MinArgs: 0,
+		MaxArgs: 0,
+	}
+}
+
+func (c *EnvAudit) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = gnuflag.NewFlagSet("", gnuflag.ExitOnError)
+		c.fs.Var(&c.require, "require", "Name of a required environment variable. Can be used multiple times")
+		c.fs.StringVar(&c.pool, "pool", "", "Audit only the apps in the given pool")
+		c.fs.StringVar(&c.pool, "o", "", "Audit only the apps in the given pool")
+		c.fs.StringVar(&c.team, "team", "", "Audit only the apps owned by the given team")
+		c.fs.StringVar(&c.team, "t", "", "Audit only the apps owned by the given team")
+	}
+	return c.fs
+}
+
+func (c *EnvAudit) Run(context *cmd.Context) error {
+	if len(c.require) == 0 {
+		return errors.New("please use the --require flag to specify at least one environment variable")
+	}
+	filter := url.Values{}
+	if c.pool != "" {
+		filter.Set("pool", c.pool)
+	}
+	if c.team != "" {
+		filter.Set("teamOwner", c.team)
+	}
+	apps, err := listApps(filter)
+	if err != nil {
+		return err
+	}
+	if len(apps) == 0 {
+		fmt.Fprintln(context.Stdout, "No apps found.")
+		return nil
+	}
+	sort.Slice(apps, func(i, j int) bool { return apps[i].Name < apps[j].Name })
+	table := tablecli.NewTable()
+	table.Headers = tablecli.Row{"App", "Pool", "Missing"}
+	for _, a := range apps {
+		envs, err := getAppEnvs(a.Name)
+		if err != nil {
+			return fmt.Errorf("unable to get the environment variables of app %q: %w", a.Name, err)
+		}
+		var missing []string
+		for _, name := range c.require {
+			if _, ok := envs[name]; !ok {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			table.AddRow(tablecli.Row{a.Name, a.Pool, strings.Join(missing, ", ")})
+		}
+	}
+	if table.Rows() == 0 {
+		fmt.Fprintf(context.Stdout, "All %d apps define the required environment variables.\n", len(apps))
+		return nil
+	}
+	fmt.Fprint(context.Stdout, table.String())
+	return fmt.Errorf("%d of %d apps are missing required environment variables", table.Rows(), len(apps))
+}
+
+type EnvEffective struct {
+	tsuruClientApp.AppNameMixIn
+	fs      *gnuflag.FlagSet
+	process string
+	json    bool
+}
+
+func (c *EnvEffective) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "env-effective",
+		Usage: "env effective [-a/--app appname] --process process [--json]",
+		Desc: `Shows the environment variables received by the units of a process of the
+application, including the variables injected by services, which are listed
+with the service that manages them. Values of private variables are never
+shown.
+
+Besides the variables of the app, which all processes receive, tsuru sets
+TSURU_PROCESSNAME to the name of the process and TSURU_APPVERSION to the
+version run by its units. These are listed with the "tsuru" source. When the
+units run different versions, like during a rollout, each unit receives its
+own version and TSURU_APPVERSION isn't listed. The command fails if the app
+has no process with the given name.`,
+		MinArgs: 0,
+		MaxArgs: 0,
+	}
+}
+
+func (c *EnvEffective) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = c.AppNameMixIn.Flags()
+		c.fs.StringVar(&c.process, "process", "", "The name of the process")
+		c.fs.StringVar(&c.process, "p", "", "The name of the process")
+		c.fs.BoolVar(&c.json, "json", false, "Display JSON format")
+	}
+	return c.fs
+}
+
+func (c *EnvEffective) Run(context *cmd.Context) error {
+	appName, err := c.AppNameByFlag()
+	if err != nil {
+		return err
+	}
+	if c.process == "" {
+		return errors.New("please use the --process flag to specify a process")
+	}
+	a, err := getApp(appName)
+	if err != nil {
+		return err
+	}
+	if err = checkProcess(a, c.process); err != nil {
+		return err
+	}
+	envs, err := getAppEnvs(appName)
+	if err != nil {
+		return err
+	}
+	for _, e := range processEnvs(a, c.process) {
+		envs[e.Name] = e
+	}
+	names := make([]string, 0, len(envs))
+	for name := range envs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if c.json {
+		data := make([]appEnv, 0, len(names))
+		for _, name := range names {
+			e := envs[name]
+			e.Value = maskedEnvValue(e)
+			data = append(data, e)
+		}
+		return formatter.JSON(context.Stdout, data)
+	}
+	table := tablecli.NewTable()
+	table.Headers = tablecli.Row{"Name", "Value", "Source"}
+	for _, name := range names {
+		e := envs[name]
+		source := "app"
+		if e.ManagedBy != "" {
+			source = e.ManagedBy
+		}
+		table.AddRow(tablecli.Row{name, maskedEnvValue(e), source})
+	}
+	fmt.Fprintf(context.Stdout, "Environment variables of process %q of app %q:\n", c.process, appName)
+	fmt.Fprint(context.Stdout, table.String())
+	if versions := processVersions(a, c.process); len(versions) > 1 {
+		values := make([]string, len(versions))
+		for i, v := range versions {
+			values[i] = strconv.Itoa(v)
+		}
+		fmt.Fprintf(context.Stdout, "TSURU_APPVERSION isn't listed, as the units run versions %s.\n", strings.Join(values, ", "))
+	}
+	return nil
+}
+
+// processEnvs returns the variables set by tsuru for each process of the app,
+// besides the variables of the app. Each unit receives the version it runs in
+// TSURU_APPVERSION, so it's left out when the units of the process run more
+// than one version, like during a rollout.
+func processEnvs(a *app, process string) []appEnv {
+	envs := []appEnv{{Name: "TSURU_PROCESSNAME", Value: process, Public: true, ManagedBy: "tsuru"}}
+	if versions := processVersions(a, process); len(versions) == 1 {
+		envs = append(envs, appEnv{Name: "TSURU_APPVERSION", Value: strconv.Itoa(versions[0]), Public: true, ManagedBy: "tsuru"})
+	}
+	return envs
+}
+
+// processVersions returns the sorted versions run by the units of the
+// process.
+func processVersions(a *app, process string) []int {
+	seen := map[int]bool{}
+	var versions []int
+	for _, u := range a.Units {
+		if u.ProcessName == process && u.Version != 0 && !seen[u.Version] {
+			seen[u.Version] = true
+			versions = append(versions, u.Version)
+		}
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// appProcessNames returns the sorted names of the processes of the app, from
+// its processes and units.
+func appProcessNames(a *app) []string {
+	seen := map[string]bool{}
+	var names []string
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, p := range a.Processes {
+		add(p.Name)
+	}
+	for _, u := range a.Units {
+		add(u.ProcessName)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -8,7 +8,11 @@ import (
 	"bytes"
 	"encoding/json"
 	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/cezarsa/form"
 	"github.com/tsuru/tsuru/cmd"
@@ -88,7 +92,7 @@ func (s *S) TestEnvGetAlwaysPrintInAlphabeticalOrder(c *check.C) {
 func (s *S) TestEnvGetPrivateVariables(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	jsonResult := `[{"name": "DATABASE_USER", "value": "someuser", "public": true}, {"name": "DATABASE_HOST", "value": "somehost", "public": false}]`
-	result := "DATABASE_HOST=*** (private variable)\nDATABASE_USER=someuser\n"
+	result := "DATABASE_HOST=***** (private variable)\nDATABASE_USER=someuser\n"
 	params := []string{"DATABASE_HOST", "DATABASE_USER"}
 	context := cmd.Context{
 		Args:   params,
@@ -103,10 +107,132 @@ func (s *S) TestEnvGetPrivateVariables(c *check.C) {
 	c.Assert(stdout.String(), check.Equals, result)
 }
 
+func (s *S) TestEnvGetShowPrivate(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	jsonResult := `[{"name": "DATABASE_USER", "value": "someuser", "public": true}, {"name": "DATABASE_HOST", "value": "somehost", "public": false}]`
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Stdin:  strings.NewReader("y\n"),
+	}
+	s.setupFakeTransport(&cmdtest.Transport{Message: jsonResult, Status: http.StatusOK})
+	command := EnvGet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--show-private"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, `The values of the private variables of app "someapp" will be displayed. Continue? (y/n) DATABASE_HOST=somehost (private variable)
+DATABASE_USER=someuser
+`)
+}
+
+func (s *S) TestEnvGetShowPrivateAborted(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Stdin:  strings.NewReader("n\n"),
+	}
+	transport := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: "[]", Status: http.StatusOK},
+		CondFunc: func(*http.Request) bool {
+			c.Fatal("unexpected request")
+			return false
+		},
+	}
+	s.setupFakeTransport(transport)
+	command := EnvGet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--show-private"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, `The values of the private variables of app "someapp" will be displayed. Continue? (y/n) Abort.
+`)
+}
+
+func (s *S) TestEnvGetShowPrivateJSON(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	jsonResult := `[{"name": "DATABASE_PASSWORD", "value": "secret", "public": false}]`
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	s.setupFakeTransport(&cmdtest.Transport{Message: jsonResult, Status: http.StatusOK})
+	command := EnvGet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--show-private", "-y", "--json"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	var result []map[string]interface{}
+	err = json.Unmarshal(stdout.Bytes(), &result)
+	c.Assert(err, check.IsNil)
+	c.Assert(result, check.DeepEquals, []map[string]interface{}{
+		{"name": "DATABASE_PASSWORD", "value": "secret", "public": false, "private": true, "masked": false},
+	})
+}
+
+func (s *S) TestEnvGetShowPrivateJSONConfirmation(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	jsonResult := `[{"name": "DATABASE_PASSWORD", "value": "secret", "public": false}]`
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr, Stdin: strings.NewReader("y\n")}
+	s.setupFakeTransport(&cmdtest.Transport{Message: jsonResult, Status: http.StatusOK})
+	command := EnvGet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--show-private", "--json"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stderr.String(), check.Equals, `The values of the private variables of app "someapp" will be displayed. Continue? (y/n) `)
+	var result []map[string]interface{}
+	err = json.Unmarshal(stdout.Bytes(), &result)
+	c.Assert(err, check.IsNil)
+	c.Assert(result, check.HasLen, 1)
+	c.Assert(result[0]["value"], check.Equals, "secret")
+}
+
+func (s *S) TestEnvGetJSON(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	jsonResult := `[{"name": "DATABASE_USER", "value": "someuser", "public": true}, {"name": "DATABASE_PASSWORD", "value": "*** (private variable)", "public": false, "managedBy": "my-service/instance"}]`
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	s.setupFakeTransport(&cmdtest.Transport{Message: jsonResult, Status: http.StatusOK})
+	command := EnvGet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--json"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	var result []map[string]interface{}
+	err = json.Unmarshal(stdout.Bytes(), &result)
+	c.Assert(err, check.IsNil)
+	c.Assert(result, check.DeepEquals, []map[string]interface{}{
+		{"name": "DATABASE_USER", "value": "someuser", "public": true, "private": false, "masked": false},
+		{"name": "DATABASE_PASSWORD", "value": "***** (private variable)", "public": false, "private": true, "masked": true, "managedBy": "my-service/instance", "serviceInstance": "my-service/instance"},
+	})
+}
+
+func (s *S) TestEnvGetYAML(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	jsonResult := `[{"name": "DATABASE_USER", "value": "someuser", "public": true}]`
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	s.setupFakeTransport(&cmdtest.Transport{Message: jsonResult, Status: http.StatusOK})
+	command := EnvGet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--output", "yaml"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	expected := `- masked: false
+  name: DATABASE_USER
+  private: false
+  public: true
+  value: someuser
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestEnvGetJSONAndYAML(c *check.C) {
+	context := cmd.Context{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	command := EnvGet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--json", "--output", "yaml"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "the --json flag can't be used with --output yaml")
+}
+
 func (s *S) TestEnvGetManagedByVariables(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	jsonResult := `[{"name": "DATABASE_USER", "value": "someuser", "public": false, "managedBy": "my-service/instance"}, {"name": "DATABASE_HOST", "value": "somehost", "public": true, "managedBy": "my-service/instance"}]`
-	result := "DATABASE_HOST=somehost (managed by my-service/instance)\nDATABASE_USER=*** (private variable managed by my-service/instance)\n"
+	result := "DATABASE_HOST=somehost (managed by my-service/instance)\nDATABASE_USER=***** (private variable managed by my-service/instance)\n"
 	params := []string{"DATABASE_HOST", "DATABASE_USER"}
 	context := cmd.Context{
 		Args:   params,
@@ -121,6 +247,91 @@ func (s *S) TestEnvGetManagedByVariables(c *check.C) {
 	c.Assert(stdout.String(), check.Equals, result)
 }
 
+func serviceEnvsResult() string {
+	services := `{"mysql":[{"instance_name":"db1","envs":{"DATABASE_HOST":"somehost","DATABASE_PASSWORD":"secret"}}]}`
+	vars := []map[string]interface{}{
+		{"name": "APP_MODE", "value": "production", "public": true},
+		{"name": "DATABASE_HOST", "value": "somehost", "public": true, "managedBy": "mysql/db1"},
+		{"name": "DATABASE_PASSWORD", "value": "secret", "public": false, "managedBy": "mysql/db1"},
+		{"name": "TSURU_SERVICES", "value": services, "public": false, "managedBy": "tsuru"},
+	}
+	b, _ := json.Marshal(vars)
+	return string(b)
+}
+
+func (s *S) TestEnvGetServiceVariables(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	s.setupFakeTransport(&cmdtest.Transport{Message: serviceEnvsResult(), Status: http.StatusOK})
+	command := EnvGet{}
+	command.Flags().Parse(true, []string{"-a", "someapp"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	expected := `APP_MODE=production
+DATABASE_HOST=somehost (managed by mysql/db1)
+DATABASE_PASSWORD=***** (private variable managed by mysql/db1)
+TSURU_SERVICES=***** (private variable managed by tsuru)
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestEnvGetOnlyApp(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	s.setupFakeTransport(&cmdtest.Transport{Message: serviceEnvsResult(), Status: http.StatusOK})
+	command := EnvGet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--only", "app"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "APP_MODE=production\n")
+}
+
+func (s *S) TestEnvGetOnlyServices(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	s.setupFakeTransport(&cmdtest.Transport{Message: serviceEnvsResult(), Status: http.StatusOK})
+	command := EnvGet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--only", "services", "--json"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	var result []map[string]interface{}
+	err = json.Unmarshal(stdout.Bytes(), &result)
+	c.Assert(err, check.IsNil)
+	c.Assert(result, check.HasLen, 3)
+	c.Assert(result[0]["name"], check.Equals, "DATABASE_HOST")
+	c.Assert(result[0]["serviceInstance"], check.Equals, "mysql/db1")
+	c.Assert(result[1]["name"], check.Equals, "DATABASE_PASSWORD")
+	c.Assert(result[1]["serviceInstance"], check.Equals, "mysql/db1")
+	c.Assert(result[2]["name"], check.Equals, "TSURU_SERVICES")
+	c.Assert(result[2]["serviceInstance"], check.IsNil)
+}
+
+func (s *S) TestEnvGetOnlyServicesByName(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr, Args: []string{"DATABASE_HOST", "APP_MODE"}}
+	jsonResult := `[{"name": "APP_MODE", "value": "production", "public": true}, {"name": "DATABASE_HOST", "value": "somehost", "public": true, "managedBy": "mysql/db1"}]`
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: jsonResult, Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return req.URL.Path == "/1.0/apps/someapp/env" && len(req.URL.Query()["env"]) == 2
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := EnvGet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--only", "services"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "DATABASE_HOST=somehost (managed by mysql/db1)\n")
+}
+
+func (s *S) TestEnvGetInvalidOnly(c *check.C) {
+	context := cmd.Context{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	command := EnvGet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--only", "units"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `invalid --only value "units", use "app" or "services"`)
+}
+
 func (s *S) TestEnvGetWithoutTheFlag(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	jsonResult := `[{"name": "DATABASE_HOST", "value": "somehost", "public": true}, {"name": "DATABASE_USER", "value": "someuser", "public": true}]`
@@ -183,12 +394,100 @@ func (s *S) TestEnvSetRun(c *check.C) {
 	}
 	s.setupFakeTransport(trans)
 	command := EnvSet{}
-	command.Flags().Parse(true, []string{"-a", "someapp"})
+	command.Flags().Parse(true, []string{"-a", "someapp", "--no-diff"})
 	err = command.Run(&context)
 	c.Assert(err, check.IsNil)
 	c.Assert(stdout.String(), check.Equals, expectedOut)
 }
 
+func (s *S) TestEnvSetRunWithImpact(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"DATABASE_HOST=somehost"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Stdin:  strings.NewReader("y\n"),
+	}
+	msg := io.SimpleJsonMessage{Message: "variable(s) successfully exported\n"}
+	result, err := json.Marshal(msg)
+	c.Assert(err, check.IsNil)
+	appInfo := `{"name": "someapp", "units": [
+	{"ID": "someapp-web-1", "ProcessName": "web"},
+	{"ID": "someapp-web-2", "ProcessName": "web"},
+	{"ID": "someapp-worker-1", "ProcessName": "worker"}
+]}`
+	trans := &cmdtest.MultiConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: appInfo, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == "GET" && strings.HasSuffix(req.URL.Path, "/apps/someapp")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: string(result), Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == "POST" && strings.HasSuffix(req.URL.Path, "/apps/someapp/env")
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := EnvSet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--impact", "--no-diff"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	expected := "This change will restart 3 units across processes web, worker.\n" +
+		"Do you want to proceed? (y/n) variable(s) successfully exported\n"
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestEnvSetRunWithImpactAbort(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"DATABASE_HOST=somehost"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Stdin:  strings.NewReader("n\n"),
+	}
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: `{"name": "someapp", "units": [{"ID": "someapp-web-1", "ProcessName": "web"}]}`, Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return req.Method == "GET" && strings.HasSuffix(req.URL.Path, "/apps/someapp")
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := EnvSet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--impact", "--no-diff"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "This change will restart 1 unit across process web.\nDo you want to proceed? (y/n) Abort.\n")
+}
+
+func (s *S) TestEnvSetRunWithImpactAndNoRestart(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"DATABASE_HOST=somehost"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	msg := io.SimpleJsonMessage{Message: "variable(s) successfully exported\n"}
+	result, err := json.Marshal(msg)
+	c.Assert(err, check.IsNil)
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: string(result), Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return req.Method == "POST" && strings.HasSuffix(req.URL.Path, "/apps/someapp/env")
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := EnvSet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--impact", "--no-restart", "-y", "--no-diff"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "This change will not restart any units (--restart=false).\nvariable(s) successfully exported\n")
+}
+
 func (s *S) TestEnvSetRunWithMultipleParams(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	context := cmd.Context{
@@ -202,7 +501,7 @@ func (s *S) TestEnvSetRunWithMultipleParams(c *check.C) {
 	c.Assert(err, check.IsNil)
 	s.setupFakeTransport(&cmdtest.Transport{Message: string(result), Status: http.StatusOK})
 	command := EnvSet{}
-	command.Flags().Parse(true, []string{"-a", "someapp"})
+	command.Flags().Parse(true, []string{"-a", "someapp", "--no-diff"})
 	err = command.Run(&context)
 	c.Assert(err, check.IsNil)
 	c.Assert(stdout.String(), check.Equals, expectedOut)
@@ -252,7 +551,7 @@ variable 2`, Alias: "", Private: &private},
 	}
 	s.setupFakeTransport(trans)
 	command := EnvSet{}
-	command.Flags().Parse(true, []string{"-a", "someapp"})
+	command.Flags().Parse(true, []string{"-a", "someapp", "--no-diff"})
 	err = command.Run(&context)
 	c.Assert(err, check.IsNil)
 	c.Assert(stdout.String(), check.Equals, expectedOut)
@@ -309,7 +608,7 @@ func (s *S) TestEnvSetValues(c *check.C) {
 	}
 	s.setupFakeTransport(trans)
 	command := EnvSet{}
-	command.Flags().Parse(true, []string{"-a", "someapp"})
+	command.Flags().Parse(true, []string{"-a", "someapp", "--no-diff"})
 	err = command.Run(&context)
 	c.Assert(err, check.IsNil)
 	c.Assert(stdout.String(), check.Equals, expectedOut)
@@ -365,7 +664,7 @@ func (s *S) TestEnvSetValuesAndPrivateAndNoRestart(c *check.C) {
 	}
 	s.setupFakeTransport(trans)
 	command := EnvSet{}
-	command.Flags().Parse(true, []string{"-a", "someapp", "-p", "1", "--no-restart"})
+	command.Flags().Parse(true, []string{"-a", "someapp", "-p", "1", "--no-restart", "--no-diff"})
 	err = command.Run(&context)
 	c.Assert(err, check.IsNil)
 	c.Assert(stdout.String(), check.Equals, expectedOut)
@@ -385,6 +684,250 @@ func (s *S) TestEnvSetInvalidParameters(c *check.C) {
 	c.Assert(err.Error(), check.Equals, EnvSetValidationMessage)
 }
 
+func (s *S) TestEnvSetFromFile(c *check.C) {
+	file := filepath.Join(c.MkDir(), ".env")
+	data := `# database settings
+DATABASE_HOST=somehost
+export DATABASE_USER="root"
+DATABASE_PASSWORD='.1234 #abc'
+GREETING="hello\nworld"
+LOG_LEVEL=info # overridden below
+`
+	err := os.WriteFile(file, []byte(data), 0600)
+	c.Assert(err, check.IsNil)
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"LOG_LEVEL=debug", "WORKERS=4"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	expectedOut := "variable(s) successfully exported\n"
+	msg := io.SimpleJsonMessage{Message: expectedOut}
+	result, err := json.Marshal(msg)
+	c.Assert(err, check.IsNil)
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: string(result), Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			err = req.ParseForm()
+			c.Assert(err, check.IsNil)
+			var e apiTypes.Envs
+			dec := form.NewDecoder(nil)
+			dec.IgnoreUnknownKeys(true)
+			dec.UseJSONTags(false)
+			err = dec.DecodeValues(&e, req.Form)
+			c.Assert(err, check.IsNil)
+			envs := map[string]string{}
+			for _, env := range e.Envs {
+				envs[env.Name] = env.Value
+			}
+			c.Assert(envs, check.DeepEquals, map[string]string{
+				"DATABASE_HOST":     "somehost",
+				"DATABASE_USER":     "root",
+				"DATABASE_PASSWORD": ".1234 #abc",
+				"GREETING":          "hello\nworld",
+				"LOG_LEVEL":         "debug",
+				"WORKERS":           "4",
+			})
+			return strings.HasSuffix(req.URL.Path, "/apps/someapp/env") && e.Private && e.NoRestart
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := EnvSet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--file", file, "--private", "--no-restart", "--no-diff"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, expectedOut)
+}
+
+func (s *S) TestEnvSetFromStdin(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"DATABASE_HOST=somehost", "DATABASE_PASSWORD=-", "API_KEY=-"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Stdin:  strings.NewReader("s3cr3t\nabc=123\n"),
+	}
+	expectedOut := "variable(s) successfully exported\n"
+	msg := io.SimpleJsonMessage{Message: expectedOut}
+	result, err := json.Marshal(msg)
+	c.Assert(err, check.IsNil)
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: string(result), Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			err = req.ParseForm()
+			c.Assert(err, check.IsNil)
+			var e apiTypes.Envs
+			dec := form.NewDecoder(nil)
+			dec.IgnoreUnknownKeys(true)
+			dec.UseJSONTags(false)
+			err = dec.DecodeValues(&e, req.Form)
+			c.Assert(err, check.IsNil)
+			c.Assert(e.Envs, check.HasLen, 3)
+			c.Assert(e.Envs[0].Value, check.Equals, "somehost")
+			c.Assert(e.Envs[0].Private == nil || !*e.Envs[0].Private, check.Equals, true)
+			c.Assert(e.Envs[1].Value, check.Equals, "s3cr3t")
+			c.Assert(*e.Envs[1].Private, check.Equals, true)
+			c.Assert(e.Envs[2].Value, check.Equals, "abc=123")
+			c.Assert(*e.Envs[2].Private, check.Equals, true)
+			return strings.HasSuffix(req.URL.Path, "/apps/someapp/env") && !e.Private
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := EnvSet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--no-diff"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, expectedOut)
+}
+
+func (s *S) TestEnvSetFromStdinPublic(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"GREETING=-"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Stdin:  strings.NewReader("hello"),
+	}
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			req.ParseForm()
+			var e apiTypes.Envs
+			dec := form.NewDecoder(nil)
+			dec.IgnoreUnknownKeys(true)
+			dec.UseJSONTags(false)
+			err := dec.DecodeValues(&e, req.Form)
+			c.Assert(err, check.IsNil)
+			c.Assert(e.Envs, check.HasLen, 1)
+			c.Assert(e.Envs[0].Value, check.Equals, "hello")
+			c.Assert(*e.Envs[0].Private, check.Equals, false)
+			return true
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := EnvSet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--public", "--no-diff"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+}
+
+func (s *S) TestEnvSetFromStdinMissingValue(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"A=-", "B=-"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Stdin:  strings.NewReader("only one\n"),
+	}
+	command := EnvSet{}
+	command.Flags().Parse(true, []string{"-a", "someapp"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "unable to read the value of B from the standard input: no more values to read")
+}
+
+func (s *S) TestEnvSetFromJSONFile(c *check.C) {
+	file := filepath.Join(c.MkDir(), "config.json")
+	err := os.WriteFile(file, []byte(`{"DATABASE_HOST": "somehost", "WORKERS": 4, "DEBUG": false}`), 0600)
+	c.Assert(err, check.IsNil)
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"DEBUG=true"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			req.ParseForm()
+			var e apiTypes.Envs
+			dec := form.NewDecoder(nil)
+			dec.IgnoreUnknownKeys(true)
+			dec.UseJSONTags(false)
+			err := dec.DecodeValues(&e, req.Form)
+			c.Assert(err, check.IsNil)
+			var envs []string
+			for _, env := range e.Envs {
+				envs = append(envs, env.Name+"="+env.Value)
+			}
+			c.Assert(envs, check.DeepEquals, []string{"DATABASE_HOST=somehost", "DEBUG=true", "WORKERS=4"})
+			return e.Private
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := EnvSet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--json-file", file, "--private", "--no-diff"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+}
+
+func (s *S) TestParseEnvJSONNested(c *check.C) {
+	_, err := parseEnvJSON([]byte(`{"A": "1", "DB": {"HOST": "somehost"}}`))
+	c.Assert(err, check.ErrorMatches, `the value of "DB" must be a string, nested objects and arrays are not supported`)
+	_, err = parseEnvJSON([]byte(`{"A": null}`))
+	c.Assert(err, check.ErrorMatches, `the value of "A" must be a string`)
+	_, err = parseEnvJSON([]byte(`["A"]`))
+	c.Assert(err, check.NotNil)
+}
+
+func (s *S) TestEnvSetMultipleApps(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"LOG_LEVEL=debug"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	msg, err := json.Marshal(io.SimpleJsonMessage{Message: "variable(s) successfully exported\n"})
+	c.Assert(err, check.IsNil)
+	errMsg, err := json.Marshal(io.SimpleJsonMessage{Error: "app is locked"})
+	c.Assert(err, check.IsNil)
+	var mu sync.Mutex
+	var apps []string
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: string(errMsg), Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.URL.Path == "/1.0/apps/app2/env"
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: string(msg), Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					req.ParseForm()
+					c.Check(req.Form.Get("Envs.0.Name"), check.Equals, "LOG_LEVEL")
+					mu.Lock()
+					defer mu.Unlock()
+					apps = append(apps, req.URL.Path)
+					return req.Method == http.MethodPost
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := EnvSet{}
+	command.Flags().Parse(true, []string{"-a", "app1", "-a", "app2", "--app", "app3", "--concurrency", "2"})
+	err = command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "failed to set environment variables in 1 of 3 apps")
+	sort.Strings(apps)
+	c.Assert(apps, check.DeepEquals, []string{"/1.0/apps/app1/env", "/1.0/apps/app3/env"})
+	expected := `+------+-----------------------+
+| App  | Result                |
++------+-----------------------+
+| app1 | ok                    |
+| app2 | failed: app is locked |
+| app3 | ok                    |
++------+-----------------------+
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestParseEnvFileInvalidLine(c *check.C) {
+	_, err := parseEnvFile([]byte("A=1\nINVALID\n"))
+	c.Assert(err, check.ErrorMatches, `invalid line 2 in env file: "INVALID"`)
+	_, err = parseEnvFile([]byte(`A="unterminated`))
+	c.Assert(err, check.ErrorMatches, `invalid line 1 in env file: unterminated quoted value "unterminated`)
+}
+
 func (s *S) TestEnvUnsetInfo(c *check.C) {
 	c.Assert((&EnvUnset{}).Info(), check.NotNil)
 }
@@ -447,6 +990,81 @@ func (s *S) TestEnvUnsetWithNoRestartFlag(c *check.C) {
 	c.Assert(stdout.String(), check.Equals, expectedOut)
 }
 
+func (s *S) TestEnvUnsetWithRestartFalse(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"DATABASE_HOST"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	result, err := json.Marshal(io.SimpleJsonMessage{Message: "variable(s) successfully unset\n"})
+	c.Assert(err, check.IsNil)
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: string(result), Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return req.URL.Query().Get("noRestart") == "true"
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := EnvUnset{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--restart=false"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stderr.String(), check.Equals, "")
+}
+
+func (s *S) TestEnvUnsetNoRestartIsDeprecated(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"DATABASE_HOST"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	result, err := json.Marshal(io.SimpleJsonMessage{Message: "variable(s) successfully unset\n"})
+	c.Assert(err, check.IsNil)
+	s.setupFakeTransport(&cmdtest.Transport{Message: string(result), Status: http.StatusOK})
+	command := EnvUnset{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--no-restart"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stderr.String(), check.Equals, "Warning: the --no-restart flag is deprecated, use --restart=false instead.\n")
+}
+
+func (s *S) TestEnvUnsetRestartAndNoRestart(c *check.C) {
+	context := cmd.Context{Args: []string{"DATABASE_HOST"}, Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	command := EnvUnset{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--restart", "--no-restart"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "the --restart and --no-restart flags can't be used together, use only --restart")
+}
+
+func (s *S) TestEnvSetRestartAndNoRestart(c *check.C) {
+	context := cmd.Context{Args: []string{"DATABASE_HOST=somehost"}, Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	command := EnvSet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--restart=false", "--no-restart"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "the --restart and --no-restart flags can't be used together, use only --restart")
+}
+
+func (s *S) TestEnvSetRestartFalse(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Args: []string{"DATABASE_HOST=somehost"}, Stdout: &stdout, Stderr: &stderr}
+	result, err := json.Marshal(io.SimpleJsonMessage{Message: "variable(s) successfully exported\n"})
+	c.Assert(err, check.IsNil)
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: string(result), Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return req.FormValue("NoRestart") == "true"
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := EnvSet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--restart=false", "--no-diff"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "variable(s) successfully exported\n")
+}
+
 func (s *S) TestRequestEnvURL(c *check.C) {
 	result := "DATABASE_HOST=somehost"
 	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
@@ -524,7 +1142,7 @@ func (s *S) TestJobEnvGetAlwaysPrintInAlphabeticalOrder(c *check.C) {
 func (s *S) TestJobEnvGetPrivateVariables(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	jsonResult := `[{"name": "DATABASE_USER", "value": "someuser", "public": true}, {"name": "DATABASE_HOST", "value": "somehost", "public": false}]`
-	result := "DATABASE_HOST=*** (private variable)\nDATABASE_USER=someuser\n"
+	result := "DATABASE_HOST=***** (private variable)\nDATABASE_USER=someuser\n"
 	params := []string{"DATABASE_HOST", "DATABASE_USER"}
 	context := cmd.Context{
 		Args:   params,
@@ -597,7 +1215,7 @@ func (s *S) TestJobEnvSetRun(c *check.C) {
 	}
 	s.setupFakeTransport(trans)
 	command := EnvSet{}
-	command.Flags().Parse(true, []string{"-j", "sample-job"})
+	command.Flags().Parse(true, []string{"-j", "sample-job", "--no-diff"})
 	err = command.Run(&context)
 	c.Assert(err, check.IsNil)
 	c.Assert(stdout.String(), check.Equals, expectedOut)
@@ -616,7 +1234,7 @@ func (s *S) TestJobEnvSetRunWithMultipleParams(c *check.C) {
 	c.Assert(err, check.IsNil)
 	s.setupFakeTransport(&cmdtest.Transport{Message: string(result), Status: http.StatusOK})
 	command := EnvSet{}
-	command.Flags().Parse(true, []string{"-j", "sample-job"})
+	command.Flags().Parse(true, []string{"-j", "sample-job", "--no-diff"})
 	err = command.Run(&context)
 	c.Assert(err, check.IsNil)
 	c.Assert(stdout.String(), check.Equals, expectedOut)
@@ -665,7 +1283,7 @@ variable 2`, Alias: "", Private: &private},
 	}
 	s.setupFakeTransport(trans)
 	command := EnvSet{}
-	command.Flags().Parse(true, []string{"-j", "sample-job"})
+	command.Flags().Parse(true, []string{"-j", "sample-job", "--no-diff"})
 	err = command.Run(&context)
 	c.Assert(err, check.IsNil)
 	c.Assert(stdout.String(), check.Equals, expectedOut)
@@ -721,7 +1339,7 @@ func (s *S) TestJobEnvSetValues(c *check.C) {
 	}
 	s.setupFakeTransport(trans)
 	command := EnvSet{}
-	command.Flags().Parse(true, []string{"-j", "sample-job"})
+	command.Flags().Parse(true, []string{"-j", "sample-job", "--no-diff"})
 	err = command.Run(&context)
 	c.Assert(err, check.IsNil)
 	c.Assert(stdout.String(), check.Equals, expectedOut)
@@ -776,7 +1394,7 @@ func (s *S) TestJobEnvSetValuesAndPrivate(c *check.C) {
 	}
 	s.setupFakeTransport(trans)
 	command := EnvSet{}
-	command.Flags().Parse(true, []string{"-j", "sample-job", "-p"})
+	command.Flags().Parse(true, []string{"-j", "sample-job", "-p", "--no-diff"})
 	err = command.Run(&context)
 	c.Assert(err, check.IsNil)
 	c.Assert(stdout.String(), check.Equals, expectedOut)
@@ -862,3 +1480,517 @@ func (s *S) TestCheckAppAndJobInputsPassingBothAppAndJob(c *check.C) {
 	c.Assert(err, check.NotNil)
 	c.Assert(err.Error(), check.Equals, "You must pass an application or job, not both")
 }
+
+func (s *S) TestEnvDiffInfo(c *check.C) {
+	c.Assert((&EnvDiff{}).Info(), check.NotNil)
+}
+
+func envDiffTransport() http.RoundTripper {
+	staging := `[
+	{"name": "DEBUG", "value": "true", "public": true},
+	{"name": "LOG_LEVEL", "value": "debug", "public": true},
+	{"name": "API_KEY", "value": "", "public": false},
+	{"name": "NEW_SECRET", "value": "", "public": false},
+	{"name": "DATABASE_URL", "value": "", "public": false, "managedBy": "mysql/staging-db"}
+]`
+	prod := `[
+	{"name": "LOG_LEVEL", "value": "info", "public": true},
+	{"name": "API_KEY", "value": "", "public": false},
+	{"name": "LEGACY_FLAG", "value": "it's on", "public": true},
+	{"name": "DATABASE_URL", "value": "", "public": false, "managedBy": "mysql/prod-db"}
+]`
+	return &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: staging, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return strings.HasSuffix(req.URL.Path, "/apps/staging/env")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: prod, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return strings.HasSuffix(req.URL.Path, "/apps/prod/env")
+				},
+			},
+		},
+	}
+}
+
+func (s *S) TestEnvDiff(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"staging", "prod"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	s.setupFakeTransport(envDiffTransport())
+	command := EnvDiff{}
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	expected := `+ DEBUG=true
++ NEW_SECRET=*****
+- LEGACY_FLAG=it's on
+~ LOG_LEVEL=info -> debug
+? API_KEY (private in both apps, values can not be compared)
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestEnvDiffPatch(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"staging", "prod"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	s.setupFakeTransport(envDiffTransport())
+	command := EnvDiff{}
+	command.Flags().Parse(true, []string{"--patch"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	expected := `# private variables in both apps, values can not be compared: API_KEY
+tsuru env-set -a prod DEBUG='true' LOG_LEVEL='debug'
+tsuru env-set -a prod --private NEW_SECRET='<private value of NEW_SECRET>'
+tsuru env-unset -a prod LEGACY_FLAG
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestEnvAuditInfo(c *check.C) {
+	c.Assert((&EnvAudit{}).Info(), check.NotNil)
+}
+
+func envAuditTransport(pool *string) *cmdtest.AnyConditionalTransport {
+	return &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: `[{"name": "app2", "pool": "prod"}, {"name": "app1", "pool": "prod"}]`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					if !strings.HasSuffix(req.URL.Path, "/apps") {
+						return false
+					}
+					*pool = req.URL.Query().Get("pool")
+					return true
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: `[{"name": "VAULT_TOKEN", "value": "", "public": false}, {"name": "LOG_LEVEL", "value": "info", "public": true}]`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return strings.HasSuffix(req.URL.Path, "/apps/app1/env")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: `[{"name": "LOG_LEVEL", "value": "info", "public": true}]`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return strings.HasSuffix(req.URL.Path, "/apps/app2/env")
+				},
+			},
+		},
+	}
+}
+
+func (s *S) TestEnvAudit(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	var pool string
+	s.setupFakeTransport(envAuditTransport(&pool))
+	command := EnvAudit{}
+	command.Flags().Parse(true, []string{"--require", "VAULT_TOKEN", "--require", "LOG_LEVEL", "--pool", "prod"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "1 of 2 apps are missing required environment variables")
+	c.Assert(pool, check.Equals, "prod")
+	expected := `+------+------+-------------+
+| App  | Pool | Missing     |
++------+------+-------------+
+| app2 | prod | VAULT_TOKEN |
++------+------+-------------+
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestEnvAuditCompliant(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	var pool string
+	s.setupFakeTransport(envAuditTransport(&pool))
+	command := EnvAudit{}
+	command.Flags().Parse(true, []string{"--require", "LOG_LEVEL"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "All 2 apps define the required environment variables.\n")
+}
+
+func (s *S) TestEnvAuditWithoutRequire(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	command := EnvAudit{}
+	command.Flags().Parse(true, []string{})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "please use the --require flag to specify at least one environment variable")
+}
+
+func (s *S) TestEnvEffectiveInfo(c *check.C) {
+	c.Assert((&EnvEffective{}).Info(), check.NotNil)
+}
+
+func envEffectiveTransport() *cmdtest.AnyConditionalTransport {
+	return &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: `[
+	{"name": "DATABASE_HOST", "value": "db.example.com", "public": true, "managedBy": "mysql/db"},
+	{"name": "DATABASE_PASSWORD", "value": "secret", "public": false, "managedBy": "mysql/db"},
+	{"name": "QUEUE", "value": "jobs", "public": true}
+]`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return strings.HasSuffix(req.URL.Path, "/apps/myapp/env")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: `{"name": "myapp", "processes": [{"name": "web"}], "units": [{"ID": "myapp-worker-1", "ProcessName": "worker", "Version": 2}, {"ID": "myapp-worker-2", "ProcessName": "worker", "Version": 3}]}`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return strings.HasSuffix(req.URL.Path, "/apps/myapp")
+				},
+			},
+		},
+	}
+}
+
+func (s *S) TestEnvEffective(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	s.setupFakeTransport(envEffectiveTransport())
+	command := EnvEffective{}
+	command.Flags().Parse(true, []string{"-a", "myapp", "--process", "worker"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	expected := `Environment variables of process "worker" of app "myapp":
++-------------------+----------------+----------+
+| Name              | Value          | Source   |
++-------------------+----------------+----------+
+| DATABASE_HOST     | db.example.com | mysql/db |
+| DATABASE_PASSWORD | *****          | mysql/db |
+| QUEUE             | jobs           | app      |
+| TSURU_PROCESSNAME | worker         | tsuru    |
++-------------------+----------------+----------+
+TSURU_APPVERSION isn't listed, as the units run versions 2, 3.
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestEnvEffectiveJSON(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	s.setupFakeTransport(envEffectiveTransport())
+	command := EnvEffective{}
+	command.Flags().Parse(true, []string{"-a", "myapp", "-p", "web", "--json"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	var envs []appEnv
+	err = json.Unmarshal(stdout.Bytes(), &envs)
+	c.Assert(err, check.IsNil)
+	c.Assert(envs, check.DeepEquals, []appEnv{
+		{Name: "DATABASE_HOST", Value: "db.example.com", Public: true, ManagedBy: "mysql/db"},
+		{Name: "DATABASE_PASSWORD", Value: "*****", ManagedBy: "mysql/db"},
+		{Name: "QUEUE", Value: "jobs", Public: true},
+		{Name: "TSURU_PROCESSNAME", Value: "web", Public: true, ManagedBy: "tsuru"},
+	})
+}
+
+func (s *S) TestProcessEnvs(c *check.C) {
+	a := &app{Units: []unit{
+		{ID: "myapp-web-1", ProcessName: "web", Version: 3},
+		{ID: "myapp-web-2", ProcessName: "web", Version: 3},
+		{ID: "myapp-worker-1", ProcessName: "worker", Version: 2},
+		{ID: "myapp-worker-2", ProcessName: "worker", Version: 3},
+	}}
+	c.Assert(processEnvs(a, "web"), check.DeepEquals, []appEnv{
+		{Name: "TSURU_PROCESSNAME", Value: "web", Public: true, ManagedBy: "tsuru"},
+		{Name: "TSURU_APPVERSION", Value: "3", Public: true, ManagedBy: "tsuru"},
+	})
+	c.Assert(processEnvs(a, "worker"), check.DeepEquals, []appEnv{
+		{Name: "TSURU_PROCESSNAME", Value: "worker", Public: true, ManagedBy: "tsuru"},
+	})
+}
+
+func (s *S) TestEnvEffectiveUnknownProcess(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	s.setupFakeTransport(envEffectiveTransport())
+	command := EnvEffective{}
+	command.Flags().Parse(true, []string{"-a", "myapp", "--process", "cron"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `process "cron" not found in app "myapp", the available processes are: web, worker`)
+}
+
+func (s *S) TestEnvSetDryRun(c *check.C) {
+	os.Setenv("TSURU_DRY_RUN", "true")
+	defer os.Unsetenv("TSURU_DRY_RUN")
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Args:   []string{"DATABASE_HOST=somehost", "DATABASE_PASSWORD=-"},
+		Stdin:  strings.NewReader("secret\n"),
+	}
+	var called bool
+	s.setupFakeTransport(&cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Status: http.StatusOK},
+		CondFunc: func(*http.Request) bool {
+			called = true
+			return true
+		},
+	})
+	command := EnvSet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--no-restart", "--no-diff"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(called, check.Equals, false)
+	expected := `Dry run, the following environment variables would be set in app "someapp":
+  DATABASE_HOST=somehost
+  DATABASE_PASSWORD=***** (private variable)
+No restart: true
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestEnvUnsetDryRun(c *check.C) {
+	os.Setenv("TSURU_DRY_RUN", "true")
+	defer os.Unsetenv("TSURU_DRY_RUN")
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr, Args: []string{"DATABASE_HOST", "DATABASE_USER"}}
+	var called bool
+	s.setupFakeTransport(&cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Status: http.StatusOK},
+		CondFunc: func(*http.Request) bool {
+			called = true
+			return true
+		},
+	})
+	command := EnvUnset{}
+	command.Flags().Parse(true, []string{"-j", "somejob"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(called, check.Equals, false)
+	expected := `Dry run, the following environment variables would be removed from job "somejob":
+  DATABASE_HOST
+  DATABASE_USER
+No restart: false
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+// envUnsetAllTransport serves the variables of someapp, some of them
+// managed by tsuru or by a service instance, and records the variables
+// unset.
+func envUnsetAllTransport(unset *[]string) *cmdtest.AnyConditionalTransport {
+	services := `{"mysql": [{"instance_name": "db1", "envs": {"DATABASE_HOST": "10.0.0.1"}}]}`
+	envs, _ := json.Marshal([]map[string]interface{}{
+		{"name": "DATABASE_HOST", "value": "10.0.0.1", "public": true, "managedBy": "mysql/db1"},
+		{"name": "TSURU_SERVICES", "value": services, "public": true, "managedBy": "tsuru"},
+		{"name": "TSURU_APPNAME", "value": "someapp", "public": true, "managedBy": "tsuru"},
+		{"name": "SECRET_KEY", "value": "*** (private variable)", "public": false},
+		{"name": "LOG_LEVEL", "value": "debug", "public": true},
+	})
+	return &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: string(envs), Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodGet && req.URL.Path == "/1.0/apps/someapp/env"
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: `{"Message": "variable(s) successfully unset\n"}`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					if req.Method != http.MethodDelete || req.URL.Path != "/1.0/apps/someapp/env" {
+						return false
+					}
+					*unset = req.URL.Query()["env"]
+					return true
+				},
+			},
+		},
+	}
+}
+
+func (s *S) TestEnvUnsetAll(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	var unset []string
+	s.setupFakeTransport(envUnsetAllTransport(&unset))
+	command := EnvUnset{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--all", "-y"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(unset, check.DeepEquals, []string{"LOG_LEVEL", "SECRET_KEY"})
+	c.Assert(stdout.String(), check.Equals, "variable(s) successfully unset\n")
+}
+
+func (s *S) TestEnvUnsetAllAborted(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr, Stdin: strings.NewReader("n\n")}
+	var unset []string
+	s.setupFakeTransport(envUnsetAllTransport(&unset))
+	command := EnvUnset{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--all"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(unset, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, `The following 2 variables, 1 of them private, will be removed from app "someapp": LOG_LEVEL, SECRET_KEY (private). Continue? (y/n) Abort.`+"\n")
+}
+
+func (s *S) TestEnvUnsetAllDryRun(c *check.C) {
+	os.Setenv("TSURU_DRY_RUN", "true")
+	defer os.Unsetenv("TSURU_DRY_RUN")
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	var unset []string
+	s.setupFakeTransport(envUnsetAllTransport(&unset))
+	command := EnvUnset{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--all"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(unset, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, `Dry run, the following environment variables would be removed from app "someapp":
+  LOG_LEVEL
+  SECRET_KEY (private)
+No restart: false
+`)
+}
+
+func (s *S) TestEnvUnsetAllWithNames(c *check.C) {
+	context := cmd.Context{Args: []string{"LOG_LEVEL"}}
+	command := EnvUnset{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--all", "-y"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "the --all flag can't be used with variable names")
+}
+
+func (s *S) TestEnvUnsetWithoutNames(c *check.C) {
+	command := EnvUnset{}
+	command.Flags().Parse(true, []string{"-a", "someapp"})
+	err := command.Run(&cmd.Context{})
+	c.Assert(err, check.ErrorMatches, "you must give the variables to unset, or use --all to unset all of them")
+}
+
+func (s *S) TestEnvSetDiff(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"LOG_LEVEL=debug", "WORKERS=4", "NEW_VAR=value", "TOKEN=-"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Stdin:  strings.NewReader("new-token\n"),
+	}
+	msg := io.SimpleJsonMessage{Message: "variable(s) successfully exported\n"}
+	result, err := json.Marshal(msg)
+	c.Assert(err, check.IsNil)
+	current := `[
+	{"name": "LOG_LEVEL", "value": "info", "public": true},
+	{"name": "WORKERS", "value": "4", "public": true},
+	{"name": "TOKEN", "value": "***** (private variable)", "public": false}
+]`
+	trans := &cmdtest.MultiConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: current, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/apps/someapp/env")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: string(result), Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/apps/someapp/env")
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := EnvSet{}
+	command.Flags().Parse(true, []string{"-a", "someapp"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	expected := `Changes to the environment variables of app "someapp":
++-----------+-----------+--------------+
+| Name      | Change    | Value        |
++-----------+-----------+--------------+
+| LOG_LEVEL | changed   | info → debug |
+| NEW_VAR   | new       | value        |
+| TOKEN     | changed   | *****        |
+| WORKERS   | unchanged | 4            |
++-----------+-----------+--------------+
+variable(s) successfully exported
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestEnvSetDiffFetchFailure(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"LOG_LEVEL=debug"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	result, err := json.Marshal(io.SimpleJsonMessage{Message: "variable(s) successfully exported\n"})
+	c.Assert(err, check.IsNil)
+	trans := &cmdtest.MultiConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: "permission denied", Status: http.StatusForbidden},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/apps/someapp/env")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: string(result), Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/apps/someapp/env")
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := EnvSet{}
+	command.Flags().Parse(true, []string{"-a", "someapp"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "variable(s) successfully exported\n")
+	c.Assert(stderr.String(), check.Equals, "WARNING: unable to fetch the current environment variables, the changes won't be shown: permission denied\n")
+}
+
+func (s *S) TestEnvGetAppFromFile(c *check.C) {
+	defer chdirTemp(c)()
+	err := os.MkdirAll(".tsuru", 0755)
+	c.Assert(err, check.IsNil)
+	err = os.WriteFile(filepath.Join(".tsuru", "app"), []byte("myapp"), 0644)
+	c.Assert(err, check.IsNil)
+	var stdout bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &bytes.Buffer{}}
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: `[{"name": "DATABASE_HOST", "value": "somehost", "public": true}]`, Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return strings.HasSuffix(req.URL.Path, "/apps/myapp/env")
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := EnvGet{}
+	command.Flags().Parse(true, []string{})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "DATABASE_HOST=somehost\n")
+}
+
+func (s *S) TestEnvSetQuiet(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Args: []string{"DATABASE_HOST=somehost"}, Stdout: &stdout, Stderr: &stderr}
+	result, err := json.Marshal(io.SimpleJsonMessage{Message: "variable(s) successfully exported\n"})
+	c.Assert(err, check.IsNil)
+	s.setupFakeTransport(&cmdtest.Transport{Message: string(result) + "\n" + `{"message":""}`, Status: http.StatusOK})
+	command := EnvSet{}
+	command.Flags().Parse(true, []string{"-a", "someapp", "--no-diff", "--quiet"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "OK\n")
+}
@@ -6,10 +6,12 @@ package client
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
@@ -20,6 +22,7 @@ import (
 	"github.com/tsuru/gnuflag"
 	"github.com/tsuru/go-tsuruclient/pkg/config"
 	"github.com/tsuru/tablecli"
+	tsuruClientApp "github.com/tsuru/tsuru-client/tsuru/app"
 	"github.com/tsuru/tsuru-client/tsuru/formatter"
 	tsuruHTTP "github.com/tsuru/tsuru-client/tsuru/http"
 	"github.com/tsuru/tsuru/cmd"
@@ -37,12 +40,20 @@ type eventFilter struct {
 	filter    event.Filter
 	kindNames cmd.StringSliceFlag
 	running   bool
+	since     string
 }
 
 func (f *eventFilter) queryString() (url.Values, error) {
 	if f.running {
 		f.filter.Running = &f.running
 	}
+	if f.since != "" {
+		since, err := parseSince(f.since, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		f.filter.Since = since
+	}
 	values, err := form.EncodeToValues(f.filter)
 	if err != nil {
 		return nil, err
@@ -77,6 +88,22 @@ func (f *eventFilter) flags(fs *gnuflag.FlagSet) {
 	name = "Shows only currently running events"
 	fs.BoolVar(&f.running, "running", false, name)
 	fs.BoolVar(&f.running, "r", false, name)
+	name = "Shows only events started after the given time, either a duration like 24h or a date like 2006-01-02"
+	fs.StringVar(&f.since, "since", "", name)
+}
+
+// parseSince parses value either as a duration before now or as a date, in
+// the 2006-01-02 or RFC 3339 formats.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid value %q for since, use a duration like 24h or a date like 2006-01-02", value)
 }
 
 func (c *EventList) Info() *cmd.Info {
@@ -99,42 +126,294 @@ func (c *EventList) Flags() *gnuflag.FlagSet {
 }
 
 func (c *EventList) Run(context *cmd.Context) error {
-	qs, err := c.filter.queryString()
+	evts, err := listEvents(&c.filter)
 	if err != nil {
 		return err
 	}
+	if evts == nil {
+		return nil
+	}
+
+	if c.json {
+
+		return formatter.JSON(context.Stdout, evts)
+	}
+
+	return c.Show(evts, context)
+}
+
+func listEvents(filter *eventFilter) ([]eventTypes.EventData, error) {
+	qs, err := filter.queryString()
+	if err != nil {
+		return nil, err
+	}
 	u, err := config.GetURLVersion("1.1", fmt.Sprintf("/events?%s", qs.Encode()))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	request, err := http.NewRequest("GET", u, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer response.Body.Close()
 	if response.StatusCode == http.StatusNoContent {
-		return nil
+		return nil, nil
 	}
 	result, err := io.ReadAll(response.Body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	var evts []eventTypes.EventData
 	err = json.Unmarshal(result, &evts)
 	if err != nil {
-		return fmt.Errorf("unable to unmarshal %q: %s", string(result), err)
+		return nil, fmt.Errorf("unable to unmarshal %q: %s", string(result), err)
 	}
+	return evts, nil
+}
 
-	if c.json {
+// eventsMaxLimit is the maximum number of events the API returns in a single
+// request.
+const eventsMaxLimit = 100
+
+type EventsList struct {
+	EventList
+	fs    *gnuflag.FlagSet
+	pool  string
+	limit int
+	page  int
+}
 
+func (c *EventsList) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "events-list",
+		Usage: "events list [--kind/-k kind name]... [--since 24h] [--pool pool] [--owner/-o owner] [--running/-r] [--target/-t target type] [--target-value/-v target value] [--limit 100] [--page 1] [--json]",
+		Desc: `Lists events across the whole platform, meant for admins auditing and
+troubleshooting it. It accepts the same filters as [[event list]], and shows
+the events in pages of up to 100 events, use [[--page]] to navigate through
+them.
+
+The [[--since]] flag accepts a duration, like 24h, or a date, like 2006-01-02.
+The [[--pool]] flag shows only events targeting the pool itself or apps
+running in it. As the API can't filter events by pool, the client goes through
+the events until the page is filled, use [[--since]] to limit how far back it
+goes.`,
+	}
+}
+
+func (c *EventsList) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = c.EventList.Flags()
+		c.fs.StringVar(&c.pool, "pool", "", "Filter events by pool")
+		c.fs.IntVar(&c.limit, "limit", eventsMaxLimit, "Number of events per page")
+		c.fs.IntVar(&c.page, "page", 1, "Page of events to show")
+	}
+	return c.fs
+}
+
+func (c *EventsList) Run(context *cmd.Context) error {
+	if c.limit <= 0 || c.limit > eventsMaxLimit {
+		return fmt.Errorf("the limit must be between 1 and %d", eventsMaxLimit)
+	}
+	if c.page <= 0 {
+		return errors.New("the page must be greater than zero")
+	}
+	listPage := c.listPage
+	if c.pool != "" {
+		listPage = c.listPoolPage
+	}
+	evts, more, err := listPage()
+	if err != nil {
+		return err
+	}
+	if evts == nil {
+		evts = []eventTypes.EventData{}
+	}
+	if c.json {
 		return formatter.JSON(context.Stdout, evts)
 	}
+	if len(evts) > 0 {
+		err = c.Show(evts, context)
+		if err != nil {
+			return err
+		}
+	}
+	if more {
+		fmt.Fprintf(context.Stdout, "Page %d, use --page %d to see more events.\n", c.page, c.page+1)
+	} else if len(evts) == 0 {
+		fmt.Fprintln(context.Stdout, "No events found.")
+	}
+	return nil
+}
 
-	return c.Show(evts, context)
+// listPage returns the events of the page and whether there may be more
+// pages, which is the case when the page is full.
+func (c *EventsList) listPage() ([]eventTypes.EventData, bool, error) {
+	c.filter.filter.Limit = c.limit
+	c.filter.filter.Skip = (c.page - 1) * c.limit
+	evts, err := listEvents(&c.filter)
+	if err != nil {
+		return nil, false, err
+	}
+	return evts, len(evts) == c.limit, nil
+}
+
+// listPoolPage returns the events of the pool in the page and whether there
+// are more pages. The events are fetched in batches and filtered until there
+// are enough events of the pool to fill the page, or no events are left.
+func (c *EventsList) listPoolPage() ([]eventTypes.EventData, bool, error) {
+	apps, err := listApps(url.Values{"pool": []string{c.pool}})
+	if err != nil {
+		return nil, false, err
+	}
+	poolApps := make(map[string]bool, len(apps))
+	for _, a := range apps {
+		poolApps[a.Name] = true
+	}
+	start := (c.page - 1) * c.limit
+	end := start + c.limit
+	var matched []eventTypes.EventData
+	c.filter.filter.Limit = eventsMaxLimit
+	for skip := 0; len(matched) <= end; skip += eventsMaxLimit {
+		c.filter.filter.Skip = skip
+		evts, err := listEvents(&c.filter)
+		if err != nil {
+			return nil, false, err
+		}
+		matched = append(matched, filterEventsByPool(evts, c.pool, poolApps)...)
+		if len(evts) < eventsMaxLimit {
+			break
+		}
+	}
+	if start >= len(matched) {
+		return nil, false, nil
+	}
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[start:end], len(matched) > end, nil
+}
+
+// filterEventsByPool returns the events targeting the pool or one of its
+// apps.
+func filterEventsByPool(evts []eventTypes.EventData, pool string, poolApps map[string]bool) []eventTypes.EventData {
+	inPool := func(t eventTypes.Target) bool {
+		switch t.Type {
+		case eventTypes.TargetTypePool:
+			return t.Value == pool
+		case eventTypes.TargetTypeApp:
+			return poolApps[t.Value]
+		}
+		return false
+	}
+	var result []eventTypes.EventData
+	for _, evt := range evts {
+		matches := inPool(evt.Target)
+		for _, et := range evt.ExtraTargets {
+			matches = matches || inPool(et.Target)
+		}
+		if matches {
+			result = append(result, evt)
+		}
+	}
+	return result
+}
+
+type AppEvents struct {
+	tsuruClientApp.AppNameMixIn
+	fs          *gnuflag.FlagSet
+	export      string
+	includeData bool
+}
+
+func (c *AppEvents) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "app-events",
+		Usage: "app events [-a/--app appname] [--export file [--include-data]]",
+		Desc: `Lists the events of an application.
+
+The [[--export]] flag writes the events to the given file instead, as JSON
+lines, one event per line. With [[--include-data]], each event also includes
+its custom data, like the parameters of the operation, which is useful for
+audits. Values that look like secrets, like passwords and tokens, are
+redacted from the custom data.`,
+		MinArgs: 0,
+		MaxArgs: 0,
+	}
+}
+
+func (c *AppEvents) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = c.AppNameMixIn.Flags()
+		c.fs.StringVar(&c.export, "export", "", "Write the events to the given file, as JSON lines")
+		c.fs.BoolVar(&c.includeData, "include-data", false, "Include the custom data of the events in the export")
+	}
+	return c.fs
+}
+
+func (c *AppEvents) Run(context *cmd.Context) error {
+	appName, err := c.AppNameByFlag()
+	if err != nil {
+		return err
+	}
+	if c.includeData && c.export == "" {
+		return errors.New("the --include-data flag requires --export")
+	}
+	filter := eventFilter{}
+	filter.filter.Target = eventTypes.Target{Type: eventTypes.TargetTypeApp, Value: appName}
+	evts, err := listEvents(&filter)
+	if err != nil {
+		return err
+	}
+	if c.export == "" {
+		if len(evts) == 0 {
+			fmt.Fprintln(context.Stdout, "No events found.")
+			return nil
+		}
+		return (&EventList{}).Show(evts, context)
+	}
+	file, err := os.Create(c.export)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	err = c.writeEvents(file, evts)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(context.Stdout, "%d events exported to %s.\n", len(evts), c.export)
+	return nil
+}
+
+type exportedEvent struct {
+	eventTypes.EventData
+	CustomData *eventTypes.EventInfoCustomData `json:",omitempty"`
+}
+
+func (c *AppEvents) writeEvents(w io.Writer, evts []eventTypes.EventData) error {
+	enc := json.NewEncoder(w)
+	for _, evt := range evts {
+		exported := exportedEvent{EventData: evt}
+		if c.includeData {
+			info, err := getEventInfo(evt.UniqueID.Hex())
+			if err != nil {
+				return err
+			}
+			exported.CustomData = &eventTypes.EventInfoCustomData{
+				Start: tsuruHTTP.Redact(info.CustomData.Start),
+				End:   tsuruHTTP.Redact(info.CustomData.End),
+				Other: tsuruHTTP.Redact(info.CustomData.Other),
+			}
+		}
+		err := enc.Encode(exported)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 var reEmailShort = regexp.MustCompile(`@.*$`)
@@ -216,33 +495,57 @@ func (c *EventInfo) Info() *cmd.Info {
 }
 
 func (c *EventInfo) Run(context *cmd.Context) error {
-	u, err := config.GetURLVersion("1.1", fmt.Sprintf("/events/%s", context.Args[0]))
+	evt, err := getEventInfo(context.Args[0])
 	if err != nil {
 		return err
 	}
+
+	if c.json {
+		return formatter.JSON(context.Stdout, redactEventInfo(evt))
+	}
+	return c.Show(evt, context)
+}
+
+// redactEventInfo returns a copy of evt with the secrets in its custom data
+// redacted. The legacy custom data fields hold the same data as raw BSON,
+// which can't be redacted, so they're left out.
+func redactEventInfo(evt *eventTypes.EventInfo) *eventTypes.EventInfo {
+	redacted := *evt
+	redacted.StartCustomData = eventTypes.LegacyBSONRaw{}
+	redacted.EndCustomData = eventTypes.LegacyBSONRaw{}
+	redacted.OtherCustomData = eventTypes.LegacyBSONRaw{}
+	redacted.CustomData = eventTypes.EventInfoCustomData{
+		Start: tsuruHTTP.Redact(evt.CustomData.Start),
+		End:   tsuruHTTP.Redact(evt.CustomData.End),
+		Other: tsuruHTTP.Redact(evt.CustomData.Other),
+	}
+	return &redacted
+}
+
+func getEventInfo(id string) (*eventTypes.EventInfo, error) {
+	u, err := config.GetURLVersion("1.1", fmt.Sprintf("/events/%s", id))
+	if err != nil {
+		return nil, err
+	}
 	request, err := http.NewRequest("GET", u, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer response.Body.Close()
 	result, err := io.ReadAll(response.Body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	var evt eventTypes.EventInfo
 	err = json.Unmarshal(result, &evt)
 	if err != nil {
-		return fmt.Errorf("unable to unmarshal %q: %s", string(result), err)
-	}
-
-	if c.json {
-		return formatter.JSON(context.Stdout, evt)
+		return nil, fmt.Errorf("unable to unmarshal %q: %s", string(result), err)
 	}
-	return c.Show(&evt, context)
+	return &evt, nil
 }
 
 func (c *EventInfo) Show(evt *eventTypes.EventInfo, context *cmd.Context) error {
@@ -330,7 +633,7 @@ func (c *EventInfo) Show(evt *eventTypes.EventInfo, context *cmd.Context) error
 	labels := []string{"Start", "End", "Other"}
 	for i, data := range []any{evt.CustomData.Start, evt.CustomData.End, evt.CustomData.Other} {
 		if data != nil {
-			str, err := yaml.Marshal(data)
+			str, err := yaml.Marshal(tsuruHTTP.Redact(data))
 			if err == nil {
 				padded := padLines(string(str), "    ")
 				items = append(items, item{fmt.Sprintf("%s Custom Data", labels[i]), "\n" + padded})
@@ -6,9 +6,13 @@ package client
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/tsuru/tsuru/cmd"
 	"github.com/tsuru/tsuru/cmd/cmdtest"
@@ -246,6 +250,228 @@ func (s *S) TestEventListWithFilters(c *check.C) {
 	c.Assert(err, check.IsNil)
 }
 
+func (s *S) TestEventsListInfo(c *check.C) {
+	c.Assert((&EventsList{}).Info(), check.NotNil)
+}
+
+func (s *S) TestEventsListPagination(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: fmt.Sprintf("[%s, %s]", okEvt, errEvt), Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			c.Assert(req.URL.Path, check.Equals, "/1.1/events")
+			c.Assert(req.URL.Query()["kindname"], check.DeepEquals, []string{"app.update.env.set"})
+			c.Assert(req.URL.Query().Get("limit"), check.Equals, "2")
+			c.Assert(req.URL.Query().Get("skip"), check.Equals, "2")
+			c.Assert(req.URL.Query().Get("since"), check.Not(check.Equals), "")
+			return true
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := EventsList{}
+	err := command.Flags().Parse(true, []string{"--kind", "app.update.env.set", "--since", "24h", "--limit", "2", "--page", "2"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Matches, `(?s).*578e3908413daf5fd9891aac.*Page 2, use --page 3 to see more events.\n$`)
+}
+
+func (s *S) TestEventsListByPool(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: evtsData, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.URL.Path == "/1.1/events"
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: `[{"name": "myapp2", "pool": "pool1"}]`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.URL.Path == "/1.0/apps" && req.URL.Query().Get("pool") == "pool1"
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := EventsList{}
+	err := command.Flags().Parse(true, []string{"--pool", "pool1", "--json"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Matches, `(?s).*"578e3908413daf5fd9891aac".*`)
+	c.Assert(stdout.String(), check.Not(check.Matches), `(?s).*"888e3908413daf5fd9891aac".*`)
+}
+
+func (s *S) TestEventsListByPoolPagination(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: evtsData, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.URL.Path == "/1.1/events" && req.URL.Query().Get("limit") == "100"
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: `[{"name": "myapp", "pool": "pool1"}]`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.URL.Path == "/1.0/apps" && req.URL.Query().Get("pool") == "pool1"
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := EventsList{}
+	err := command.Flags().Parse(true, []string{"--pool", "pool1", "--limit", "1", "--page", "2", "--json"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	var evts []map[string]interface{}
+	err = json.Unmarshal(stdout.Bytes(), &evts)
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 1)
+	c.Assert(evts[0]["UniqueID"], check.Equals, "888e3908413daf5fd9891aac")
+	stdout.Reset()
+	command = EventsList{}
+	err = command.Flags().Parse(true, []string{"--pool", "pool1", "--limit", "2", "--page", "2"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Matches, `(?s).*998e3908413daf5fd9891aac.*`)
+	c.Assert(stdout.String(), check.Not(check.Matches), `(?s).*Page 2.*`)
+}
+
+func (s *S) TestEventsListInvalidLimit(c *check.C) {
+	command := EventsList{}
+	err := command.Flags().Parse(true, []string{"--limit", "500"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&cmd.Context{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}})
+	c.Assert(err, check.ErrorMatches, "the limit must be between 1 and 100")
+}
+
+func (s *S) TestEventsListNoEvents(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	s.setupFakeTransport(&cmdtest.Transport{Status: http.StatusNoContent})
+	command := EventsList{}
+	err := command.Flags().Parse(true, []string{})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "No events found.\n")
+}
+
+func (s *S) TestAppEventsInfo(c *check.C) {
+	c.Assert((&AppEvents{}).Info(), check.NotNil)
+}
+
+func (s *S) TestAppEventsExport(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	evt := `{
+  "UniqueID": "578e3908413daf5fd9891aac",
+  "Target": {"Type": "app", "Value": "myapp"},
+  "Kind": {"Type": "permission", "Name": "app.update.env.set"},
+  "CustomData": {
+    "Start": [{"name": ":app", "value": "myapp"}, {"name": "Envs.0.Name", "value": "DATABASE_PASSWORD"}, {"name": "Envs.0.Value", "value": "123"}, {"name": "api_token", "value": "abc123"}],
+    "End": null,
+    "Other": {"secret": "s3cr3t", "nested": {"password": "123"}}
+  }
+}`
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: "[" + evt + "]", Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.URL.Path == "/1.1/events" && req.URL.Query().Get("target.type") == "app" &&
+						req.URL.Query().Get("target.value") == "myapp"
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: evt, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.URL.Path == "/1.1/events/578e3908413daf5fd9891aac"
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	file := filepath.Join(c.MkDir(), "events.jsonl")
+	command := AppEvents{}
+	err := command.Flags().Parse(true, []string{"-a", "myapp", "--export", file, "--include-data"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, fmt.Sprintf("1 events exported to %s.\n", file))
+	data, err := os.ReadFile(file)
+	c.Assert(err, check.IsNil)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	c.Assert(lines, check.HasLen, 1)
+	var exported map[string]any
+	err = json.Unmarshal([]byte(lines[0]), &exported)
+	c.Assert(err, check.IsNil)
+	c.Assert(exported["UniqueID"], check.Equals, "578e3908413daf5fd9891aac")
+	c.Assert(exported["CustomData"], check.DeepEquals, map[string]any{
+		"Start": []any{
+			map[string]any{"name": ":app", "value": "myapp"},
+			map[string]any{"name": "Envs.0.Name", "value": "DATABASE_PASSWORD"},
+			map[string]any{"name": "Envs.0.Value", "value": "*****"},
+			map[string]any{"name": "api_token", "value": "*****"},
+		},
+		"End":   nil,
+		"Other": map[string]any{"secret": "*****", "nested": map[string]any{"password": "*****"}},
+	})
+}
+
+func (s *S) TestAppEventsIncludeDataWithoutExport(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	command := AppEvents{}
+	err := command.Flags().Parse(true, []string{"-a", "myapp", "--include-data"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "the --include-data flag requires --export")
+}
+
+func (s *S) TestParseSince(c *check.C) {
+	now := time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+	since, err := parseSince("24h", now)
+	c.Assert(err, check.IsNil)
+	c.Assert(since, check.DeepEquals, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC))
+	since, err = parseSince("2024-02-01", now)
+	c.Assert(err, check.IsNil)
+	c.Assert(since, check.DeepEquals, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	_, err = parseSince("yesterday", now)
+	c.Assert(err, check.ErrorMatches, `invalid value "yesterday" for since.*`)
+}
+
 func (s *S) TestEventInfoInfo(c *check.C) {
 	c.Assert((&EventInfo{}).Info(), check.NotNil)
 }
@@ -285,21 +511,17 @@ Start Custom Data:
       deploys: 0
       description: ""
       env:
-        TSURU_APP_TOKEN:
-          instancename: ""
-          name: TSURU_APP_TOKEN
-          public: false
-          value: 900d072c007a7f8029ff7b2ce79351b24ea64b3f
+        TSURU_APP_TOKEN: '\*\*\*\*\*'
         TSURU_APPDIR:
           instancename: ""
           name: TSURU_APPDIR
           public: false
-          value: /home/application/current
+          value: '\*\*\*\*\*'
         TSURU_APPNAME:
           instancename: ""
           name: TSURU_APPNAME
           public: false
-          value: otherapp
+          value: '\*\*\*\*\*'
       framework: python
       ip: otherapp\.fakerouter\.com
       lock:
@@ -381,6 +603,50 @@ Log:
 	c.Assert(stdout.String(), check.Matches, expected)
 }
 
+func (s *S) TestEventInfoJSONRedactsCustomData(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"578e3908413daf5fd9891aac"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	evt := `{
+  "UniqueID": "578e3908413daf5fd9891aac",
+  "Kind": {"Type": "permission", "Name": "app.update.env.set"},
+  "StartCustomData": {"Kind": 3, "Data": "c2VjcmV0"},
+  "CustomData": {
+    "Start": [{"name": ":app", "value": "myapp"}, {"name": "Envs.0.Value", "value": "123"}, {"name": "api_token", "value": "abc123"}],
+    "End": null,
+    "Other": {"secret": "s3cr3t", "nested": {"password": "123"}}
+  }
+}`
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: evt, Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return req.URL.Path == "/1.1/events/578e3908413daf5fd9891aac"
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := EventInfo{}
+	err := command.Flags().Parse(true, []string{"--json"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Not(check.Matches), `(?s).*(abc123|s3cr3t|c2VjcmV0).*`)
+	var result map[string]any
+	err = json.Unmarshal(stdout.Bytes(), &result)
+	c.Assert(err, check.IsNil)
+	c.Assert(result["CustomData"], check.DeepEquals, map[string]any{
+		"Start": []any{
+			map[string]any{"name": ":app", "value": "myapp"},
+			map[string]any{"name": "Envs.0.Value", "value": "*****"},
+			map[string]any{"name": "api_token", "value": "*****"},
+		},
+		"End":   nil,
+		"Other": map[string]any{"secret": "*****", "nested": map[string]any{"password": "*****"}},
+	})
+}
+
 func (s *S) TestEventInfoWithError(c *check.C) {
 	os.Setenv("TSURU_DISABLE_COLORS", "1")
 	defer os.Unsetenv("TSURU_DISABLE_COLORS")
@@ -8,6 +8,7 @@ import (
 	"errors"
 
 	"github.com/tsuru/gnuflag"
+	tsuruClientApp "github.com/tsuru/tsuru-client/tsuru/app"
 )
 
 type JobOrApp struct {
@@ -26,6 +27,9 @@ func (c *JobOrApp) validate() error {
 		processName = flag.Value.String()
 	}
 
+	if appName == "" && jobName == "" {
+		appName = tsuruClientApp.GuessName()
+	}
 	if appName == "" && jobName == "" {
 		return errors.New("job name or app name is required")
 	}
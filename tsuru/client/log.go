@@ -6,10 +6,15 @@ package client
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"net/http"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/tsuru/gnuflag"
@@ -22,75 +27,282 @@ import (
 
 type AppLog struct {
 	tsuruClientApp.AppNameMixIn
-	fs       *gnuflag.FlagSet
-	source   string
-	unit     string
-	lines    int
-	follow   bool
-	noDate   bool
-	noSource bool
+	fs         *gnuflag.FlagSet
+	sources    logSources
+	unit       string
+	lines      int
+	follow     bool
+	noDate     bool
+	noSource   bool
+	noColor    bool
+	grep       string
+	invert     bool
+	since      time.Duration
+	before     string
+	json       bool
+	timeFormat string
 }
 
+// sinceLines is the number of past log lines requested when --since is used
+// without --lines.
+const sinceLines = 1000
+
+// beforeLines is the number of past log lines requested when --before is
+// used. The server only returns the last lines of the log, so the entries
+// older than the timestamp are found among them by the client.
+const beforeLines = 10000
+
 func (c *AppLog) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:  "app-log",
-		Usage: "app log [appname] [-l/--lines numberOfLines] [-s/--source source] [-u/--unit unit] [-f/--follow]",
+		Usage: "app log [appname] [-l/-n/--lines numberOfLines] [-s/--source source]... [-u/--unit unit] [-f/--follow] [--since duration] [--before timestamp] [--time-format relative|short|full] [--no-color] [--grep regexp [--invert]] [--json]",
 		Desc: `Shows log entries for an application. These logs include everything the
 application send to stdout and stderr, alongside with logs from tsuru server
 (deployments, restarts, etc.)
 
-The [[--lines]] flag is optional and by default its value is 10.
+The [[--lines]] flag is optional and by default its value is 10. [[-n]] can be
+used as a shorthand, like in tail. When following, [[--lines]] is the number of
+past lines displayed before the new ones, so [[--lines 0]] displays no past
+lines and only follows the new log entries.
 
 The [[--source]] flag is optional and allows filtering logs by log source
-(e.g. application, tsuru api).
+(e.g. application, tsuru api). It can be used multiple times, in which case the
+logs of all the given sources are merged in timestamp order.
 
 The [[--unit]] flag is optional and allows filtering by unit. It's useful if
-your application has multiple units and you want logs from a single one.
+your application has multiple units and you want logs from a single one. It
+accepts a prefix of the unit id, with or without the name of the app, like
+"web-5d8f", which is resolved among the units of the app, failing if more than
+one unit matches it. When no unit of the app matches it, like for units that
+are no longer running, the given id is used as is. The resolved unit is kept
+while following. Use
+[[--unit all]] to explicitly display the logs of all units.
 
 The [[--follow]] flag is optional and makes the command wait for additional
-log output
+log output. While following, each unit name is shortened and shown with its
+own color, which makes it easier to tell units apart.
 
 The [[--no-date]] flag is optional and makes the log output without date.
 
+The [[--time-format]] flag is optional and sets how the date of each entry is
+displayed, in the local time zone: "relative" shows how long ago the entry was
+written, like "2s ago", "short" shows only the time, like "15:04:05", and
+"full" shows the date, the time and the time zone offset. The default is
+"short" when the output is a terminal and "full" otherwise, or when
+[[--before]] is given, so the dates displayed can be used in the next
+[[--before]].
+
 The [[--no-source]] flag is optional and makes the log output without source
 information, useful to very dense logs.
+
+The log output is only colored on terminals. The [[--no-color]] flag, or the
+NO_COLOR environment variable, makes it without colors.
+
+The [[--grep]] flag is optional and displays only the log entries whose
+message matches the given regular expression. The filter is applied by the
+client, to both past and followed entries. Use [[--invert]] to display only
+the entries that don't match it.
+
+The [[--since]] flag is optional and displays only the log entries newer than
+the given duration, like "10m" or "2h". The filter is applied by the client to
+the past lines returned by the server, so it's combined with [[--lines]]: only
+the entries that are both among the last lines and newer than the duration are
+displayed. When [[--lines]] isn't given, up to 1000 past lines are requested.
+
+The [[--before]] flag is optional and displays the last [[--lines]] entries
+older than the given timestamp, which can be in RFC 3339 format, like
+"2026-01-02T15:04:05Z", or in the format of the dates displayed in the log,
+like "2026-01-02 15:04:05 -0300". A time without a date, like "15:04:05", is
+a time of the current day, in the local time zone. Using the date of the first
+entry displayed as the next [[--before]] pages backwards through the log. The server only
+returns the last lines of the log, so the client requests the last 10000 lines
+and looks for the older entries among them: entries older than these can't be
+reached. It can't be used with [[--follow]].
+
+The [[--json]] flag is optional and displays each log entry as a JSON object
+in its own line (JSON lines), both past and followed entries, which is useful
+to send the logs to other tools. Each line is written as soon as the entry
+arrives. The [[--no-date]], [[--no-source]] and [[--no-color]] flags don't
+apply to this format and errors are written to stderr.
 `,
 		MinArgs: 0,
 	}
 }
 
 type logFormatter struct {
-	noDate   bool
-	noSource bool
+	noDate     bool
+	noSource   bool
+	noColor    bool
+	colorUnits bool
+	appName    string
+	grep       *regexp.Regexp
+	invert     bool
+	since      time.Time
+	before     time.Time
+	timeFormat string
+	// limit is the number of matching entries displayed from each write,
+	// the last ones. Zero means no limit.
+	limit int
+	json  bool
 }
 
+const logDateFormat = "2006-01-02 15:04:05 -0700"
+
+const (
+	logTimeRelative = "relative"
+	logTimeShort    = "short"
+	logTimeFull     = "full"
+)
+
+// logNow returns the current time, used by the relative time format.
+var logNow = time.Now
+
+// date formats the date of a log entry in the local time zone, as set by
+// --time-format.
+func (f logFormatter) date(date time.Time) string {
+	switch f.timeFormat {
+	case logTimeRelative:
+		return formatter.FormatRelative(date, logNow())
+	case logTimeShort:
+		return formatter.Local(date).Format("15:04:05")
+	}
+	return formatter.Local(date).Format(logDateFormat)
+}
+
+var unitColors = []string{"green", "yellow", "magenta", "cyan"}
+
 func (f logFormatter) Format(out io.Writer, dec *json.Decoder) error {
+	logs, err := decodeLogs(dec)
+	if err != nil {
+		return err
+	}
+	f.write(out, logs)
+	return nil
+}
+
+func decodeLogs(dec *json.Decoder) ([]log, error) {
 	var logs []log
 	err := dec.Decode(&logs)
 	if err != nil {
 		if err == io.EOF {
-			return err
+			return nil, err
 		}
 		buffered := dec.Buffered()
 		bufferedData, _ := io.ReadAll(buffered)
-		return fmt.Errorf("unable to parse json: %v: %q", err, string(bufferedData))
+		return nil, fmt.Errorf("unable to parse json: %v: %q", err, string(bufferedData))
+	}
+	return logs, nil
+}
+
+func (f logFormatter) write(out io.Writer, logs []log) {
+	if f.limit > 0 {
+		logs = f.last(logs)
 	}
 	for _, l := range logs {
+		if !f.matches(l) {
+			continue
+		}
+		if f.json {
+			writeJSONLine(out, l)
+			continue
+		}
+		if f.colorUnits && !f.noSource && l.Unit != "" {
+			fmt.Fprintf(out, "%s %s\n", f.unitPrefix(l), l.Message)
+			continue
+		}
+
 		prefix := f.prefix(l)
 
 		if prefix == "" {
 			fmt.Fprintf(out, "%s\n", l.Message)
 		} else {
-			fmt.Fprintf(out, "%s %s\n", cmd.Colorfy(prefix, "blue", "", ""), l.Message)
+			fmt.Fprintf(out, "%s %s\n", f.colorfy(prefix, "blue"), l.Message)
 		}
 	}
-	return nil
+}
+
+// writeJSONLine writes the log entry as a JSON object followed by a newline,
+// in a single write.
+func writeJSONLine(out io.Writer, l log) {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return
+	}
+	out.Write(append(data, '\n'))
+}
+
+// last returns the last limit entries of logs that match the filters.
+func (f logFormatter) last(logs []log) []log {
+	matching := make([]log, 0, len(logs))
+	for _, l := range logs {
+		if f.matches(l) {
+			matching = append(matching, l)
+		}
+	}
+	if len(matching) > f.limit {
+		matching = matching[len(matching)-f.limit:]
+	}
+	return matching
+}
+
+func (f logFormatter) matches(l log) bool {
+	if !f.since.IsZero() && l.Date.Before(f.since) {
+		return false
+	}
+	if !f.before.IsZero() && !l.Date.Before(f.before) {
+		return false
+	}
+	if f.grep == nil {
+		return true
+	}
+	return f.grep.MatchString(l.Message) != f.invert
+}
+
+func (f logFormatter) colorfy(msg, color string) string {
+	if f.noColor {
+		return msg
+	}
+	return cmd.Colorfy(msg, color, "", "")
+}
+
+// unitPrefix returns the prefix of a log line with the unit name shortened
+// and painted with a color that is stable for the unit.
+func (f logFormatter) unitPrefix(l log) string {
+	var head []string
+	if !f.noDate {
+		head = append(head, f.date(l.Date))
+	}
+	if l.Source != "" {
+		head = append(head, fmt.Sprintf("[%s]", l.Source))
+	}
+	unit := f.colorfy(fmt.Sprintf("[%s]", f.shortUnitName(l.Unit)), unitColor(l.Unit))
+	if len(head) == 0 {
+		return unit + f.colorfy(":", "blue")
+	}
+	separator := ""
+	if l.Source == "" {
+		separator = " "
+	}
+	return f.colorfy(strings.Join(head, " ")+separator, "blue") + unit + f.colorfy(":", "blue")
+}
+
+func (f logFormatter) shortUnitName(unit string) string {
+	if f.appName != "" && strings.HasPrefix(unit, f.appName+"-") {
+		return strings.TrimPrefix(unit, f.appName+"-")
+	}
+	return ShortID(unit)
+}
+
+func unitColor(unit string) string {
+	h := fnv.New32a()
+	h.Write([]byte(unit))
+	return unitColors[h.Sum32()%uint32(len(unitColors))]
 }
 
 func (f logFormatter) prefix(l log) string {
 	parts := make([]string, 0, 2)
 	if !f.noDate {
-		parts = append(parts, formatter.Local(l.Date).Format("2006-01-02 15:04:05 -0700"))
+		parts = append(parts, f.date(l.Date))
 	}
 	if !f.noSource {
 		if l.Unit != "" && l.Source != "" {
@@ -109,10 +321,10 @@ func (f logFormatter) prefix(l log) string {
 }
 
 type log struct {
-	Date    time.Time
-	Message string
-	Source  string
-	Unit    string
+	Date    time.Time `json:"date"`
+	Message string    `json:"message"`
+	Source  string    `json:"source"`
+	Unit    string    `json:"unit"`
 }
 
 func (c *AppLog) Run(context *cmd.Context) error {
@@ -121,24 +333,73 @@ func (c *AppLog) Run(context *cmd.Context) error {
 	if err != nil {
 		return err
 	}
-	url, err := config.GetURL(fmt.Sprintf("/apps/%s/log?lines=%d", appName, c.lines))
-	if err != nil {
-		return err
+	if c.lines < 0 {
+		return errors.New("the number of lines must not be negative")
 	}
-	if c.source != "" {
-		url = fmt.Sprintf("%s&source=%s", url, c.source)
+	if c.invert && c.grep == "" {
+		return errors.New("the --invert flag requires --grep")
 	}
-	if c.unit != "" {
-		url = fmt.Sprintf("%s&unit=%s", url, c.unit)
+	if c.since < 0 {
+		return errors.New("the --since duration must not be negative")
 	}
-	if c.follow {
-		url += "&follow=1"
+	if c.before != "" && c.follow {
+		return errors.New("the --before flag can't be used with --follow")
 	}
-	request, err := http.NewRequest("GET", url, nil)
+	timeFormat := c.timeFormat
+	switch timeFormat {
+	case "":
+		timeFormat = logTimeFull
+		if c.before == "" && isTerminalWriter(context.Stdout) {
+			timeFormat = logTimeShort
+		}
+	case logTimeRelative, logTimeShort, logTimeFull:
+	default:
+		return fmt.Errorf("invalid --time-format %q, use %q, %q or %q", c.timeFormat, logTimeRelative, logTimeShort, logTimeFull)
+	}
+	formatter := logFormatter{
+		noDate:     c.noDate,
+		noSource:   c.noSource,
+		noColor:    !useColors(context.Stdout, c.noColor),
+		colorUnits: c.follow,
+		appName:    appName,
+		invert:     c.invert,
+		json:       c.json,
+		timeFormat: timeFormat,
+	}
+	if c.json {
+		context.Stdout = &flushWriter{w: context.Stdout}
+	}
+	if c.since > 0 {
+		formatter.since = time.Now().Add(-c.since)
+		if !c.linesSet() {
+			c.lines = sinceLines
+		}
+	}
+	if c.before != "" {
+		formatter.before, err = parseLogTime(c.before)
+		if err != nil {
+			return fmt.Errorf("invalid --before timestamp %q, use RFC 3339, like 2026-01-02T15:04:05Z, or a time of today, like 15:04:05", c.before)
+		}
+		formatter.limit = c.lines
+	}
+	if c.grep != "" {
+		formatter.grep, err = regexp.Compile(c.grep)
+		if err != nil {
+			return fmt.Errorf("invalid --grep expression: %w", err)
+		}
+	}
+	c.unit, err = resolveLogUnit(appName, c.unit)
 	if err != nil {
 		return err
 	}
-	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	if len(c.sources) > 1 {
+		return c.mergeSources(context, appName, formatter)
+	}
+	var source string
+	if len(c.sources) == 1 {
+		source = c.sources[0]
+	}
+	response, err := c.requestLogs(appName, source)
 	if err != nil {
 		return err
 	}
@@ -146,16 +407,15 @@ func (c *AppLog) Run(context *cmd.Context) error {
 		return nil
 	}
 	defer response.Body.Close()
-	formatter := logFormatter{
-		noDate:   c.noDate,
-		noSource: c.noSource,
-	}
 	dec := json.NewDecoder(response.Body)
+	if formatter.limit > 0 {
+		return c.writeLast(context, dec, formatter)
+	}
 	for {
 		err = formatter.Format(context.Stdout, dec)
 		if err != nil {
 			if err != io.EOF {
-				fmt.Fprintf(context.Stdout, "Error: %v", err)
+				c.printError(context, err)
 			}
 			break
 		}
@@ -168,14 +428,247 @@ func (c *AppLog) Flags() *gnuflag.FlagSet {
 		c.fs = c.AppNameMixIn.Flags()
 		c.fs.IntVar(&c.lines, "lines", 10, "The number of log lines to display")
 		c.fs.IntVar(&c.lines, "l", 10, "The number of log lines to display")
-		c.fs.StringVar(&c.source, "source", "", "The log from the given source")
-		c.fs.StringVar(&c.source, "s", "", "The log from the given source")
-		c.fs.StringVar(&c.unit, "unit", "", "The log from the given unit")
-		c.fs.StringVar(&c.unit, "u", "", "The log from the given unit")
+		c.fs.IntVar(&c.lines, "n", 10, "The number of log lines to display")
+		c.fs.Var(&c.sources, "source", "The log from the given source")
+		c.fs.Var(&c.sources, "s", "The log from the given source")
+		c.fs.StringVar(&c.unit, "unit", "", "The log from the unit with the given id or id prefix, or all")
+		c.fs.StringVar(&c.unit, "u", "", "The log from the unit with the given id or id prefix, or all")
 		c.fs.BoolVar(&c.follow, "follow", false, "Follow logs")
 		c.fs.BoolVar(&c.follow, "f", false, "Follow logs")
 		c.fs.BoolVar(&c.noDate, "no-date", false, "No date information")
+		c.fs.StringVar(&c.timeFormat, "time-format", "", "How dates are displayed: relative, short or full")
 		c.fs.BoolVar(&c.noSource, "no-source", false, "No source information")
+		c.fs.BoolVar(&c.noColor, "no-color", false, "No colors in the output")
+		c.fs.StringVar(&c.grep, "grep", "", "Display only the log entries whose message matches the regular expression")
+		c.fs.BoolVar(&c.invert, "invert", false, "Display only the log entries that don't match --grep")
+		c.fs.DurationVar(&c.since, "since", 0, "Display only the log entries newer than the given duration, like 10m")
+		c.fs.StringVar(&c.before, "before", "", "Display the last log entries older than the given timestamp, like 2026-01-02T15:04:05Z")
+		c.fs.BoolVar(&c.json, "json", false, "Display each log entry as a JSON object in its own line")
 	}
 	return c.fs
 }
+
+// printError displays an error found while reading the logs. In the JSON
+// format, it goes to stderr to keep the output parseable.
+func (c *AppLog) printError(context *cmd.Context, err error) {
+	if c.json {
+		fmt.Fprintf(context.Stderr, "Error: %v\n", err)
+		return
+	}
+	fmt.Fprintf(context.Stdout, "Error: %v", err)
+}
+
+// writeLast reads all the past entries before displaying the last ones that
+// match the filters, as set by the limit of the formatter.
+func (c *AppLog) writeLast(context *cmd.Context, dec *json.Decoder, formatter logFormatter) error {
+	var logs []log
+	for {
+		batch, err := decodeLogs(dec)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.printError(context, err)
+			break
+		}
+		logs = append(logs, batch...)
+	}
+	formatter.write(context.Stdout, logs)
+	return nil
+}
+
+// parseLogTime parses a timestamp given in RFC 3339 format or in the formats
+// of the dates displayed in the log. A time without a date, as displayed by
+// the short time format, is a time of the current day in the local time zone.
+func parseLogTime(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t, nil
+	}
+	if t, err = time.Parse("15:04:05", value); err == nil {
+		now := formatter.Local(logNow())
+		return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, formatter.LocalTZ), nil
+	}
+	return time.Parse(logDateFormat, value)
+}
+
+// resolveLogUnit returns the id of the unit of the app that starts with the
+// given prefix, which may leave out the name of the app, like "web-5d8f". An
+// empty unit or "all" request the logs of all units. The prefix is returned
+// unchanged when no unit matches it, as the logs of units that are no longer
+// running are still kept.
+func resolveLogUnit(appName, prefix string) (string, error) {
+	if prefix == "" || prefix == "all" {
+		return "", nil
+	}
+	a, err := getApp(appName)
+	if err != nil {
+		return "", err
+	}
+	var matches []string
+	for _, u := range a.Units {
+		if u.ID == prefix {
+			return u.ID, nil
+		}
+		if strings.HasPrefix(u.ID, prefix) || strings.HasPrefix(u.ID, appName+"-"+prefix) {
+			matches = append(matches, u.ID)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return prefix, nil
+	case 1:
+		return matches[0], nil
+	}
+	sort.Strings(matches)
+	return "", fmt.Errorf("unit %q is ambiguous, it matches %s", prefix, strings.Join(matches, ", "))
+}
+
+// linesSet reports whether the number of lines was given in the command line.
+func (c *AppLog) linesSet() bool {
+	set := false
+	c.Flags().Visit(func(f *gnuflag.Flag) {
+		switch f.Name {
+		case "lines", "l", "n":
+			set = true
+		}
+	})
+	return set
+}
+
+// logSources is a flag that can be repeated to get the logs of multiple
+// sources.
+type logSources []string
+
+func (s *logSources) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *logSources) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// logMergeInterval is how long logs of multiple sources are buffered while
+// following, so they can be displayed in timestamp order.
+var logMergeInterval = time.Second
+
+func (c *AppLog) requestLogs(appName, source string) (*http.Response, error) {
+	lines := c.lines
+	if c.before != "" && lines < beforeLines {
+		lines = beforeLines
+	}
+	url, err := config.GetURL(fmt.Sprintf("/apps/%s/log?lines=%d", appName, lines))
+	if err != nil {
+		return nil, err
+	}
+	if source != "" {
+		url = fmt.Sprintf("%s&source=%s", url, source)
+	}
+	if c.unit != "" {
+		url = fmt.Sprintf("%s&unit=%s", url, c.unit)
+	}
+	if c.follow {
+		url += "&follow=1"
+	}
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.follow {
+		request = tsuruHTTP.Streaming(request)
+	}
+	return tsuruHTTP.AuthenticatedClient.Do(request)
+}
+
+// mergeSources requests the logs of each source separately and displays them
+// in timestamp order.
+func (c *AppLog) mergeSources(context *cmd.Context, appName string, formatter logFormatter) error {
+	type logBatch struct {
+		logs []log
+		err  error
+	}
+	batches := make(chan logBatch)
+	var wg sync.WaitGroup
+	var bodies []io.ReadCloser
+	defer func() {
+		for _, body := range bodies {
+			body.Close()
+		}
+	}()
+	for _, source := range c.sources {
+		response, err := c.requestLogs(appName, source)
+		if err != nil {
+			return err
+		}
+		if response.StatusCode == http.StatusNoContent {
+			response.Body.Close()
+			continue
+		}
+		bodies = append(bodies, response.Body)
+	}
+	for _, body := range bodies {
+		wg.Add(1)
+		go func(body io.Reader) {
+			defer wg.Done()
+			dec := json.NewDecoder(body)
+			for {
+				logs, err := decodeLogs(dec)
+				if err == io.EOF {
+					return
+				}
+				batches <- logBatch{logs: logs, err: err}
+				if err != nil {
+					return
+				}
+			}
+		}(body)
+	}
+	go func() {
+		wg.Wait()
+		close(batches)
+	}()
+	var pending []log
+	flush := func() {
+		sort.SliceStable(pending, func(i, j int) bool {
+			return pending[i].Date.Before(pending[j].Date)
+		})
+		formatter.write(context.Stdout, pending)
+		pending = nil
+	}
+	if !c.follow {
+		for batch := range batches {
+			if batch.err != nil {
+				c.printError(context, batch.err)
+				continue
+			}
+			pending = append(pending, batch.logs...)
+		}
+		sort.SliceStable(pending, func(i, j int) bool {
+			return pending[i].Date.Before(pending[j].Date)
+		})
+		if formatter.limit == 0 && len(pending) > c.lines {
+			pending = pending[len(pending)-c.lines:]
+		}
+		flush()
+		return nil
+	}
+	ticker := time.NewTicker(logMergeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case batch, ok := <-batches:
+			if !ok {
+				flush()
+				return nil
+			}
+			if batch.err != nil {
+				c.printError(context, batch.err)
+				continue
+			}
+			pending = append(pending, batch.logs...)
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
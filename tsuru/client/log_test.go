@@ -7,10 +7,13 @@ package client
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/tsuru/gnuflag"
 	"github.com/tsuru/tsuru-client/tsuru/formatter"
 	"github.com/tsuru/tsuru/cmd"
 	"github.com/tsuru/tsuru/cmd/cmdtest"
@@ -52,8 +55,8 @@ func (s *S) TestAppLog(c *check.C) {
 	c.Assert(err, check.IsNil)
 	t = formatter.Local(t)
 	tfmt := "2006-01-02 15:04:05 -0700"
-	expected := cmd.Colorfy(t.Format(tfmt)+" [tsuru]:", "blue", "", "") + " creating app lost\n"
-	expected = expected + cmd.Colorfy(t.Add(2*time.Hour).Format(tfmt)+" [app][abcdef]:", "blue", "", "") + " app lost successfully created\n"
+	expected := t.Format(tfmt) + " [tsuru]: creating app lost\n"
+	expected = expected + t.Add(2*time.Hour).Format(tfmt) + " [app][abcdef]: app lost successfully created\n"
 	context := cmd.Context{
 		Stdout: &stdout,
 		Stderr: &stderr,
@@ -94,7 +97,7 @@ func (s *S) TestAppLogWithUnparsableData(c *check.C) {
 	command.Flags().Parse(true, []string{"--app", "appName"})
 	err = command.Run(&context)
 	c.Assert(err, check.IsNil)
-	expected := cmd.Colorfy(t.Format(tfmt)+" [tsuru]:", "blue", "", "") + " creating app lost\n"
+	expected := t.Format(tfmt) + " [tsuru]: creating app lost\n"
 	expected += "Error: unable to parse json: invalid character 'u' looking for beginning of value: \"\\nunparseable data\""
 	c.Assert(stdout.String(), check.Equals, expected)
 }
@@ -110,8 +113,8 @@ func (s *S) TestAppLogWithoutTheFlag(c *check.C) {
 	c.Assert(err, check.IsNil)
 	t = formatter.Local(t)
 	tfmt := "2006-01-02 15:04:05 -0700"
-	expected := cmd.Colorfy(t.Format(tfmt)+" [tsuru]:", "blue", "", "") + " creating app lost\n"
-	expected = expected + cmd.Colorfy(t.Add(2*time.Hour).Format(tfmt)+" [app]:", "blue", "", "") + " app lost successfully created\n"
+	expected := t.Format(tfmt) + " [tsuru]: creating app lost\n"
+	expected = expected + t.Add(2*time.Hour).Format(tfmt) + " [app]: app lost successfully created\n"
 	context := cmd.Context{
 		Stdout: &stdout,
 		Stderr: &stderr,
@@ -160,8 +163,8 @@ func (s *S) TestAppLogBySource(c *check.C) {
 	c.Assert(err, check.IsNil)
 	t = formatter.Local(t)
 	tfmt := "2006-01-02 15:04:05 -0700"
-	expected := cmd.Colorfy(t.Format(tfmt)+" [tsuru]:", "blue", "", "") + " creating app lost\n"
-	expected = expected + cmd.Colorfy(t.Add(2*time.Hour).Format(tfmt)+" [tsuru]:", "blue", "", "") + " app lost successfully created\n"
+	expected := t.Format(tfmt) + " [tsuru]: creating app lost\n"
+	expected = expected + t.Add(2*time.Hour).Format(tfmt) + " [tsuru]: app lost successfully created\n"
 	context := cmd.Context{
 		Stdout: &stdout,
 		Stderr: &stderr,
@@ -180,6 +183,87 @@ func (s *S) TestAppLogBySource(c *check.C) {
 	c.Assert(stdout.String(), check.Equals, expected)
 }
 
+func (s *S) TestAppLogByMultipleSources(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	t := time.Now()
+	appLogs, err := json.Marshal([]log{
+		{Date: t, Message: "starting worker", Source: "app"},
+		{Date: t.Add(2 * time.Minute), Message: "job done", Source: "app"},
+	})
+	c.Assert(err, check.IsNil)
+	cronLogs, err := json.Marshal([]log{
+		{Date: t.Add(time.Minute), Message: "running job", Source: "cron"},
+	})
+	c.Assert(err, check.IsNil)
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"-a", "hitthelights", "--source", "app", "-s", "cron", "--no-date", "--no-color"})
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: string(appLogs), Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.URL.Query().Get("source") == "app"
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: string(cronLogs), Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.URL.Query().Get("source") == "cron"
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	expected := "[app]: starting worker\n[cron]: running job\n[app]: job done\n"
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestAppLogByMultipleSourcesLimitsLines(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	t := time.Now()
+	appLogs, err := json.Marshal([]log{
+		{Date: t, Message: "starting worker", Source: "app"},
+		{Date: t.Add(2 * time.Minute), Message: "job done", Source: "app"},
+	})
+	c.Assert(err, check.IsNil)
+	cronLogs, err := json.Marshal([]log{
+		{Date: t.Add(time.Minute), Message: "running job", Source: "cron"},
+	})
+	c.Assert(err, check.IsNil)
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"-a", "hitthelights", "-s", "app", "-s", "cron", "-n", "2", "--no-date", "--no-color"})
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: string(appLogs), Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.URL.Query().Get("source") == "app"
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: string(cronLogs), Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.URL.Query().Get("source") == "cron"
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "[cron]: running job\n[app]: job done\n")
+}
+
 func (s *S) TestAppLogByUnit(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	t := time.Now()
@@ -191,24 +275,114 @@ func (s *S) TestAppLogByUnit(c *check.C) {
 	c.Assert(err, check.IsNil)
 	t = formatter.Local(t)
 	tfmt := "2006-01-02 15:04:05 -0700"
-	expected := cmd.Colorfy(t.Format(tfmt)+" [tsuru][api]:", "blue", "", "") + " creating app lost\n"
-	expected = expected + cmd.Colorfy(t.Add(2*time.Hour).Format(tfmt)+" [tsuru][api]:", "blue", "", "") + " app lost successfully created\n"
+	expected := t.Format(tfmt) + " [tsuru][api]: creating app lost\n"
+	expected = expected + t.Add(2*time.Hour).Format(tfmt) + " [tsuru][api]: app lost successfully created\n"
 	context := cmd.Context{
 		Stdout: &stdout,
 		Stderr: &stderr,
 	}
 	command := AppLog{}
 	command.Flags().Parse(true, []string{"-a", "hitthelights", "--unit", "api"})
+	s.setupFakeTransport(logUnitsTransport("hitthelights", string(result), "api"))
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+// logUnitsTransport serves the units of the app and the logs of the given
+// unit.
+func logUnitsTransport(appName, logs, unit string) *cmdtest.AnyConditionalTransport {
+	return &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{
+					Message: fmt.Sprintf(`{"name": %q, "units": [{"ID": "api"}, {"ID": "%[1]s-web-5d8f7-abcde"}, {"ID": "%[1]s-web-5d8f7-fghij"}, {"ID": "%[1]s-worker-9c2a1-klmno"}]}`, appName),
+					Status:  http.StatusOK,
+				},
+				CondFunc: func(req *http.Request) bool {
+					return req.URL.Path == "/1.0/apps/"+appName
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: logs, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					_, ok := req.URL.Query()["unit"]
+					return strings.HasSuffix(req.URL.Path, "/log") && ok == (unit != "") && req.URL.Query().Get("unit") == unit
+				},
+			},
+		},
+	}
+}
+
+func (s *S) TestAppLogByUnitPrefix(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	logs := `[{"Message": "listening", "Source": "web", "Unit": "myapp-worker-9c2a1-klmno"}]`
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	for _, prefix := range []string{"worker", "myapp-worker-9c", "myapp-worker-9c2a1-klmno"} {
+		stdout.Reset()
+		command := AppLog{}
+		command.Flags().Parse(true, []string{"-a", "myapp", "--unit", prefix, "--no-date", "--no-color"})
+		s.setupFakeTransport(logUnitsTransport("myapp", logs, "myapp-worker-9c2a1-klmno"))
+		err := command.Run(&context)
+		c.Assert(err, check.IsNil)
+		c.Assert(stdout.String(), check.Equals, "[web][myapp-worker-9c2a1-klmno]: listening\n")
+	}
+}
+
+func (s *S) TestAppLogByUnitFollowKeepsResolvedUnit(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	logs := `[{"Message": "listening", "Source": "web", "Unit": "myapp-worker-9c2a1-klmno"}]`
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"-a", "myapp", "--unit", "worker", "-f", "--no-date", "--no-color"})
+	trans := logUnitsTransport("myapp", logs, "myapp-worker-9c2a1-klmno")
+	trans.ConditionalTransports[1].CondFunc = func(req *http.Request) bool {
+		return req.URL.Query().Get("unit") == "myapp-worker-9c2a1-klmno" && req.URL.Query().Get("follow") == "1"
+	}
+	s.setupFakeTransport(trans)
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "[web][worker-9c2a1-klmno]: listening\n")
+}
+
+func (s *S) TestAppLogByUnitAll(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	logs := `[{"Message": "listening", "Source": "web", "Unit": "api"}]`
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"-a", "myapp", "--unit", "all", "--no-date", "--no-color"})
 	trans := &cmdtest.ConditionalTransport{
-		Transport: cmdtest.Transport{Message: string(result), Status: http.StatusOK},
+		Transport: cmdtest.Transport{Message: logs, Status: http.StatusOK},
 		CondFunc: func(req *http.Request) bool {
-			return req.URL.Query().Get("unit") == "api"
+			_, ok := req.URL.Query()["unit"]
+			return strings.HasSuffix(req.URL.Path, "/log") && !ok
 		},
 	}
 	s.setupFakeTransport(trans)
-	err = command.Run(&context)
+	err := command.Run(&context)
 	c.Assert(err, check.IsNil)
-	c.Assert(stdout.String(), check.Equals, expected)
+	c.Assert(stdout.String(), check.Equals, "[web][api]: listening\n")
+}
+
+func (s *S) TestAppLogByUnitAmbiguous(c *check.C) {
+	context := cmd.Context{Stdout: io.Discard, Stderr: io.Discard}
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"-a", "myapp", "--unit", "web"})
+	s.setupFakeTransport(logUnitsTransport("myapp", "[]", ""))
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `unit "web" is ambiguous, it matches myapp-web-5d8f7-abcde, myapp-web-5d8f7-fghij`)
+}
+
+func (s *S) TestAppLogByUnitNotRunning(c *check.C) {
+	var stdout bytes.Buffer
+	logs := `[{"Message": "crashed", "Source": "web", "Unit": "myapp-web-1a2b3-zzzzz"}]`
+	context := cmd.Context{Stdout: &stdout, Stderr: io.Discard}
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"-a", "myapp", "--unit", "myapp-web-1a2b3-zzzzz", "--no-date", "--no-color"})
+	s.setupFakeTransport(logUnitsTransport("myapp", logs, "myapp-web-1a2b3-zzzzz"))
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "[web][myapp-web-1a2b3-zzzzz]: crashed\n")
 }
 
 func (s *S) TestAppLogWithLines(c *check.C) {
@@ -222,8 +396,8 @@ func (s *S) TestAppLogWithLines(c *check.C) {
 	c.Assert(err, check.IsNil)
 	t = formatter.Local(t)
 	tfmt := "2006-01-02 15:04:05 -0700"
-	expected := cmd.Colorfy(t.Format(tfmt)+" [tsuru]:", "blue", "", "") + " creating app lost\n"
-	expected = expected + cmd.Colorfy(t.Add(2*time.Hour).Format(tfmt)+" [tsuru]:", "blue", "", "") + " app lost successfully created\n"
+	expected := t.Format(tfmt) + " [tsuru]: creating app lost\n"
+	expected = expected + t.Add(2*time.Hour).Format(tfmt) + " [tsuru]: app lost successfully created\n"
 	context := cmd.Context{
 		Stdout: &stdout,
 		Stderr: &stderr,
@@ -253,8 +427,8 @@ func (s *S) TestAppLogWithFollow(c *check.C) {
 	c.Assert(err, check.IsNil)
 	t = formatter.Local(t)
 	tfmt := "2006-01-02 15:04:05 -0700"
-	expected := cmd.Colorfy(t.Format(tfmt)+" [tsuru]:", "blue", "", "") + " creating app lost\n"
-	expected = expected + cmd.Colorfy(t.Add(2*time.Hour).Format(tfmt)+" [tsuru]:", "blue", "", "") + " app lost successfully created\n"
+	expected := t.Format(tfmt) + " [tsuru]: creating app lost\n"
+	expected = expected + t.Add(2*time.Hour).Format(tfmt) + " [tsuru]: app lost successfully created\n"
 	context := cmd.Context{
 		Stdout: &stdout,
 		Stderr: &stderr,
@@ -273,6 +447,432 @@ func (s *S) TestAppLogWithFollow(c *check.C) {
 	c.Assert(stdout.String(), check.Equals, expected)
 }
 
+func (s *S) TestAppLogJSONFollow(c *check.C) {
+	var stderr bytes.Buffer
+	var stdout flushRecorder
+	t := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	past, err := json.Marshal([]log{
+		{Date: t, Message: "creating app lost", Source: "tsuru"},
+		{Date: t.Add(time.Second), Message: "GET /healthcheck 200", Source: "web", Unit: "lost-web-1"},
+	})
+	c.Assert(err, check.IsNil)
+	followed, err := json.Marshal([]log{
+		{Date: t.Add(time.Minute), Message: "GET /users 500", Source: "web", Unit: "lost-web-2"},
+	})
+	c.Assert(err, check.IsNil)
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"-a", "lost", "-f", "--json"})
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: string(past) + string(followed), Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return req.URL.Query().Get("follow") == "1"
+		},
+	}
+	s.setupFakeTransport(trans)
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	expected := `{"date":"2026-10-16T12:00:00Z","message":"creating app lost","source":"tsuru","unit":""}
+{"date":"2026-10-16T12:00:01Z","message":"GET /healthcheck 200","source":"web","unit":"lost-web-1"}
+{"date":"2026-10-16T12:01:00Z","message":"GET /users 500","source":"web","unit":"lost-web-2"}
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+	c.Assert(stdout.flushes, check.Equals, 3)
+	c.Assert(stderr.String(), check.Equals, "")
+}
+
+func (s *S) TestAppLogJSONError(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	s.setupFakeTransport(&cmdtest.Transport{Message: `[{"Message":"ok"}]{invalid`, Status: http.StatusOK})
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"-a", "lost", "--json"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, `{"date":"0001-01-01T00:00:00Z","message":"ok","source":"","unit":""}`+"\n")
+	c.Assert(stderr.String(), check.Matches, "Error: unable to parse json: .*\n")
+}
+
+func (s *S) TestAppLogFollowOnly(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"-a", "hitthelights", "-n", "0", "-f"})
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: "[]", Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return req.URL.Query().Get("lines") == "0" && req.URL.Query().Get("follow") == "1"
+		},
+	}
+	s.setupFakeTransport(trans)
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "")
+}
+
+func (s *S) TestAppLogNegativeLines(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"-a", "hitthelights", "-n", "-1"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "the number of lines must not be negative")
+}
+
+func (s *S) TestAppLogGrep(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	logs := []log{
+		{Message: "GET /healthcheck 200"},
+		{Message: "panic: something went wrong"},
+		{Message: "GET /users 500"},
+	}
+	result, err := json.Marshal(logs)
+	c.Assert(err, check.IsNil)
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	s.setupFakeTransport(&cmdtest.Transport{Message: string(result), Status: http.StatusOK})
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"--app", "appName", "--no-date", "--no-source", "--grep", "^GET .* 5[0-9]{2}$"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "GET /users 500\n")
+}
+
+func (s *S) TestAppLogGrepInvert(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	logs := []log{
+		{Message: "GET /healthcheck 200"},
+		{Message: "panic: something went wrong"},
+		{Message: "GET /healthcheck 200"},
+	}
+	result, err := json.Marshal(logs)
+	c.Assert(err, check.IsNil)
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	s.setupFakeTransport(&cmdtest.Transport{Message: string(result), Status: http.StatusOK})
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"--app", "appName", "--no-date", "--no-source", "--grep", "healthcheck", "--invert"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "panic: something went wrong\n")
+}
+
+func (s *S) TestAppLogInvalidGrep(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: "[]", Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			c.Errorf("unexpected request to %s", req.URL)
+			return false
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"--app", "appName", "--grep", "error("})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "invalid --grep expression: .*")
+}
+
+func (s *S) TestAppLogInvertWithoutGrep(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"--app", "appName", "--invert"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "the --invert flag requires --grep")
+}
+
+func (s *S) TestAppLogSince(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	now := time.Now()
+	logs := []log{
+		{Date: now.Add(-time.Hour), Message: "old entry"},
+		{Date: now.Add(-5 * time.Minute), Message: "recent entry"},
+		{Date: now.Add(-time.Minute), Message: "newest entry"},
+	}
+	result, err := json.Marshal(logs)
+	c.Assert(err, check.IsNil)
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: string(result), Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return req.URL.Query().Get("lines") == "1000"
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"--app", "appName", "--no-date", "--no-source", "--since", "10m"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "recent entry\nnewest entry\n")
+}
+
+func (s *S) TestAppLogSinceWithLines(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	now := time.Now()
+	logs := []log{
+		{Date: now.Add(-time.Hour), Message: "old entry"},
+		{Date: now.Add(-time.Minute), Message: "recent entry"},
+	}
+	result, err := json.Marshal(logs)
+	c.Assert(err, check.IsNil)
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: string(result), Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return req.URL.Query().Get("lines") == "2"
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"--app", "appName", "--no-date", "--no-source", "-n", "2", "--since", "30m"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "recent entry\n")
+}
+
+func (s *S) TestAppLogNegativeSince(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"--app", "appName", "--since", "-10m"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "the --since duration must not be negative")
+}
+
+func (s *S) TestAppLogInvalidSince(c *check.C) {
+	command := AppLog{}
+	command.Flags().Init("app-log", gnuflag.ContinueOnError)
+	command.Flags().SetOutput(io.Discard)
+	err := command.Flags().Parse(true, []string{"--app", "appName", "--since", "yesterday"})
+	c.Assert(err, check.ErrorMatches, `invalid value "yesterday" for flag --since: .*`)
+}
+
+func (s *S) TestAppLogBefore(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	start := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	logs := []log{
+		{Date: start, Message: "first entry"},
+		{Date: start.Add(time.Minute), Message: "second entry"},
+		{Date: start.Add(2 * time.Minute), Message: "third entry"},
+		{Date: start.Add(3 * time.Minute), Message: "fourth entry"},
+	}
+	result, err := json.Marshal(logs)
+	c.Assert(err, check.IsNil)
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: string(result), Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return req.URL.Query().Get("lines") == "10000"
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"--app", "appName", "--no-date", "--no-source", "-n", "2", "--before", "2026-01-02T15:03:00Z"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "second entry\nthird entry\n")
+}
+
+func (s *S) TestAppLogBeforeLogDateFormat(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	start := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	logs := []log{
+		{Date: start, Message: "first entry", Source: "web"},
+		{Date: start.Add(time.Minute), Message: "second entry", Source: "web"},
+	}
+	result, err := json.Marshal(logs)
+	c.Assert(err, check.IsNil)
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	s.setupFakeTransport(&cmdtest.Transport{Message: string(result), Status: http.StatusOK})
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"--app", "appName", "--no-date", "--no-source", "-s", "web", "-s", "worker", "--before", "2026-01-02 12:01:00 -0300"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "first entry\nfirst entry\n")
+}
+
+func (s *S) TestAppLogBeforeShortTime(c *check.C) {
+	old, oldTerminal, oldNow := formatter.LocalTZ, isTerminalWriter, logNow
+	defer func() { formatter.LocalTZ, isTerminalWriter, logNow = old, oldTerminal, oldNow }()
+	start := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	formatter.LocalTZ = time.UTC
+	isTerminalWriter = func(io.Writer) bool { return true }
+	logNow = func() time.Time { return start.Add(time.Hour) }
+	var stdout, stderr bytes.Buffer
+	logs := []log{
+		{Date: start.Add(-24 * time.Hour), Message: "yesterday entry", Source: "web"},
+		{Date: start, Message: "first entry", Source: "web"},
+		{Date: start.Add(time.Minute), Message: "second entry", Source: "web"},
+	}
+	result, err := json.Marshal(logs)
+	c.Assert(err, check.IsNil)
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	s.setupFakeTransport(&cmdtest.Transport{Message: string(result), Status: http.StatusOK})
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"--app", "appName", "--no-source", "--no-color", "-n", "1", "--before", "15:01:00"})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "2026-01-02 15:00:00 +0000: first entry\n")
+}
+
+func (s *S) TestAppLogBeforeInvalidTimestamp(c *check.C) {
+	context := cmd.Context{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"--app", "appName", "--before", "yesterday"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `invalid --before timestamp "yesterday", use RFC 3339, like 2026-01-02T15:04:05Z, or a time of today, like 15:04:05`)
+}
+
+func (s *S) TestAppLogBeforeWithFollow(c *check.C) {
+	context := cmd.Context{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"--app", "appName", "--before", "2026-01-02T15:03:00Z", "-f"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "the --before flag can't be used with --follow")
+}
+
+func (s *S) runAppLogTimeFormat(c *check.C, args ...string) string {
+	var stdout, stderr bytes.Buffer
+	date := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	result, err := json.Marshal([]log{{Date: date, Message: "app started", Source: "tsuru"}})
+	c.Assert(err, check.IsNil)
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	s.setupFakeTransport(&cmdtest.Transport{Message: string(result), Status: http.StatusOK})
+	command := AppLog{}
+	command.Flags().Parse(true, append([]string{"--app", "appName", "--no-color"}, args...))
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	return stdout.String()
+}
+
+func (s *S) TestAppLogTimeFormat(c *check.C) {
+	old := formatter.LocalTZ
+	formatter.LocalTZ = time.FixedZone("BRT", -3*60*60)
+	defer func() { formatter.LocalTZ = old }()
+	c.Assert(s.runAppLogTimeFormat(c), check.Equals, "2026-01-02 12:04:05 -0300 [tsuru]: app started\n")
+	c.Assert(s.runAppLogTimeFormat(c, "--time-format", "full"), check.Equals, "2026-01-02 12:04:05 -0300 [tsuru]: app started\n")
+	c.Assert(s.runAppLogTimeFormat(c, "--time-format", "short"), check.Equals, "12:04:05 [tsuru]: app started\n")
+	oldNow := logNow
+	logNow = func() time.Time { return time.Date(2026, 1, 2, 15, 4, 7, 0, time.UTC) }
+	defer func() { logNow = oldNow }()
+	c.Assert(s.runAppLogTimeFormat(c, "--time-format", "relative"), check.Equals, "2s ago [tsuru]: app started\n")
+}
+
+func (s *S) TestAppLogTimeFormatShortOnTerminal(c *check.C) {
+	old, oldTerminal := formatter.LocalTZ, isTerminalWriter
+	formatter.LocalTZ = time.UTC
+	isTerminalWriter = func(io.Writer) bool { return true }
+	defer func() { formatter.LocalTZ, isTerminalWriter = old, oldTerminal }()
+	c.Assert(s.runAppLogTimeFormat(c), check.Equals, "15:04:05 [tsuru]: app started\n")
+}
+
+func (s *S) TestAppLogInvalidTimeFormat(c *check.C) {
+	context := cmd.Context{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"--app", "appName", "--time-format", "iso"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `invalid --time-format "iso", use "relative", "short" or "full"`)
+}
+
+func (s *S) TestAppLogFollowColorsUnits(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	old := isTerminalWriter
+	defer func() { isTerminalWriter = old }()
+	isTerminalWriter = func(io.Writer) bool { return true }
+	t := time.Now()
+	logs := []log{
+		{Date: t, Message: "GET /", Source: "web", Unit: "myapp-web-6d9f7c8b5-x2x7z"},
+		{Date: t, Message: "POST /", Source: "web", Unit: "myapp-web-6d9f7c8b5-k8w2p"},
+		{Date: t, Message: "app restarted", Source: "tsuru"},
+	}
+	result, err := json.Marshal(logs)
+	c.Assert(err, check.IsNil)
+	t = formatter.Local(t)
+	tfmt := "2006-01-02 15:04:05 -0700"
+	blue := func(msg string) string { return cmd.Colorfy(msg, "blue", "", "") }
+	expected := blue(t.Format(tfmt)+" [web]") + cmd.Colorfy("[web-6d9f7c8b5-x2x7z]", unitColor("myapp-web-6d9f7c8b5-x2x7z"), "", "") + blue(":") + " GET /\n"
+	expected += blue(t.Format(tfmt)+" [web]") + cmd.Colorfy("[web-6d9f7c8b5-k8w2p]", unitColor("myapp-web-6d9f7c8b5-k8w2p"), "", "") + blue(":") + " POST /\n"
+	expected += blue(t.Format(tfmt)+" [tsuru]:") + " app restarted\n"
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"-a", "myapp", "-f", "--time-format", "full"})
+	s.setupFakeTransport(&cmdtest.Transport{Message: string(result), Status: http.StatusOK})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestAppLogFollowNoColor(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	t := time.Now()
+	logs := []log{
+		{Date: t, Message: "GET /", Unit: "myapp-web-6d9f7c8b5-x2x7z"},
+		{Date: t, Message: "app restarted", Source: "tsuru"},
+	}
+	result, err := json.Marshal(logs)
+	c.Assert(err, check.IsNil)
+	t = formatter.Local(t)
+	tfmt := "2006-01-02 15:04:05 -0700"
+	expected := t.Format(tfmt) + " [web-6d9f7c8b5-x2x7z]: GET /\n"
+	expected += t.Format(tfmt) + " [tsuru]: app restarted\n"
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	command := AppLog{}
+	command.Flags().Parse(true, []string{"-a", "myapp", "-f", "--no-color"})
+	s.setupFakeTransport(&cmdtest.Transport{Message: string(result), Status: http.StatusOK})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestUnitColorIsStable(c *check.C) {
+	c.Assert(unitColor("myapp-web-6d9f7c8b5-x2x7z"), check.Equals, unitColor("myapp-web-6d9f7c8b5-x2x7z"))
+}
+
 func (s *S) TestAppLogWithNoDateAndNoSource(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	t := time.Now()
@@ -313,8 +913,8 @@ func (s *S) TestAppLogWithNoSource(c *check.C) {
 	c.Assert(err, check.IsNil)
 	t = formatter.Local(t)
 	tfmt := "2006-01-02 15:04:05 -0700"
-	expected := cmd.Colorfy(t.Format(tfmt)+":", "blue", "", "") + " GET /\n"
-	expected = expected + cmd.Colorfy(t.Add(2*time.Hour).Format(tfmt)+":", "blue", "", "") + " POST /\n"
+	expected := t.Format(tfmt) + ": GET /\n"
+	expected = expected + t.Add(2*time.Hour).Format(tfmt) + ": POST /\n"
 	context := cmd.Context{
 		Stdout: &stdout,
 		Stderr: &stderr,
@@ -352,13 +952,13 @@ func (s *S) TestAppLogFlagSet(c *check.C) {
 	unit := flagset.Lookup("unit")
 	c.Check(unit, check.NotNil)
 	c.Check(unit.Name, check.Equals, "unit")
-	c.Check(unit.Usage, check.Equals, "The log from the given unit")
+	c.Check(unit.Usage, check.Equals, "The log from the unit with the given id or id prefix, or all")
 	c.Check(unit.Value.String(), check.Equals, "abcdef")
 	c.Check(unit.DefValue, check.Equals, "")
 	sunit := flagset.Lookup("u")
 	c.Check(sunit, check.NotNil)
 	c.Check(sunit.Name, check.Equals, "u")
-	c.Check(sunit.Usage, check.Equals, "The log from the given unit")
+	c.Check(sunit.Usage, check.Equals, "The log from the unit with the given id or id prefix, or all")
 	c.Check(sunit.Value.String(), check.Equals, "abcdef")
 	c.Check(sunit.DefValue, check.Equals, "")
 	lines := flagset.Lookup("lines")
@@ -373,6 +973,12 @@ func (s *S) TestAppLogFlagSet(c *check.C) {
 	c.Check(slines.Usage, check.Equals, "The number of log lines to display")
 	c.Check(slines.Value.String(), check.Equals, "12")
 	c.Check(slines.DefValue, check.Equals, "10")
+	nlines := flagset.Lookup("n")
+	c.Check(nlines, check.NotNil)
+	c.Check(nlines.Name, check.Equals, "n")
+	c.Check(nlines.Usage, check.Equals, "The number of log lines to display")
+	c.Check(nlines.Value.String(), check.Equals, "12")
+	c.Check(nlines.DefValue, check.Equals, "10")
 	app := flagset.Lookup("app")
 	c.Check(app, check.NotNil)
 	c.Check(app.Name, check.Equals, "app")
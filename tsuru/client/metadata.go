@@ -71,7 +71,7 @@ func (c *JobOrApp) setMetadata(apiClient *tsuru.APIClient, metadata tsuru.Metada
 			Metadata: metadata,
 		})
 	}
-	return apiClient.AppApi.AppUpdate(context.Background(), c.val, a)
+	return apiClient.AppApi.AppUpdate(tsuruHTTP.StreamingContext(context.Background()), c.val, a)
 }
 
 type MetadataGet struct {
@@ -0,0 +1,169 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tsuru/gnuflag"
+	"github.com/tsuru/go-tsuruclient/pkg/config"
+	"github.com/tsuru/tablecli"
+	tsuruClientApp "github.com/tsuru/tsuru-client/tsuru/app"
+	tsuruHTTP "github.com/tsuru/tsuru-client/tsuru/http"
+	"github.com/tsuru/tsuru/cmd"
+)
+
+type AppMetrics struct {
+	tsuruClientApp.AppNameMixIn
+	fs         *gnuflag.FlagSet
+	all        bool
+	prometheus bool
+}
+
+func (c *AppMetrics) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "app-metrics",
+		Usage: "app metrics [-a/--app appname] [--all] [--prometheus]",
+		Desc: `Shows the number of units of an application grouped by process and status.
+
+The [[--prometheus]] flag emits the unit counts as Prometheus gauges named
+[[tsuru_app_units]], labeled by app, process and status, so they can be
+scraped without a custom exporter.
+
+The [[--all]] flag emits the unit counts for every app you have access to.`,
+		MinArgs: 0,
+		MaxArgs: 0,
+	}
+}
+
+func (c *AppMetrics) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = c.AppNameMixIn.Flags()
+		c.fs.BoolVar(&c.all, "all", false, "Show unit counts for all apps")
+		c.fs.BoolVar(&c.prometheus, "prometheus", false, "Show unit counts in the Prometheus text format")
+	}
+	return c.fs
+}
+
+func (c *AppMetrics) Run(context *cmd.Context) error {
+	var apps []app
+	if c.all {
+		if c.Flags().Lookup("app").Value.String() != "" {
+			return errors.New("please use only one of the -a/--app and --all flags")
+		}
+		var err error
+		apps, err = listApps(nil)
+		if err != nil {
+			return err
+		}
+	} else {
+		appName, err := c.AppNameByFlag()
+		if err != nil {
+			return err
+		}
+		a, err := getApp(appName)
+		if err != nil {
+			return err
+		}
+		apps = []app{*a}
+	}
+	samples := unitCountSamples(apps)
+	if c.prometheus {
+		renderUnitsPrometheus(context.Stdout, samples)
+		return nil
+	}
+	table := tablecli.NewTable()
+	table.Headers = tablecli.Row{"App", "Process", "Status", "Units"}
+	for _, sample := range samples {
+		table.AddRow(tablecli.Row{sample.app, sample.process, sample.status, strconv.Itoa(sample.count)})
+	}
+	context.Stdout.Write(table.Bytes())
+	return nil
+}
+
+type unitCountSample struct {
+	app     string
+	process string
+	status  string
+	count   int
+}
+
+func unitCountSamples(apps []app) []unitCountSample {
+	var samples []unitCountSample
+	for _, a := range apps {
+		counts := map[[2]string]int{}
+		for _, u := range a.Units {
+			if u.ID == "" {
+				continue
+			}
+			counts[[2]string{u.ProcessName, u.Status}]++
+		}
+		for key, count := range counts {
+			samples = append(samples, unitCountSample{app: a.Name, process: key[0], status: key[1], count: count})
+		}
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].app != samples[j].app {
+			return samples[i].app < samples[j].app
+		}
+		if samples[i].process != samples[j].process {
+			return samples[i].process < samples[j].process
+		}
+		return samples[i].status < samples[j].status
+	})
+	return samples
+}
+
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func renderUnitsPrometheus(w io.Writer, samples []unitCountSample) {
+	fmt.Fprintln(w, "# HELP tsuru_app_units Number of units of the app by process and status.")
+	fmt.Fprintln(w, "# TYPE tsuru_app_units gauge")
+	for _, sample := range samples {
+		fmt.Fprintf(w, "tsuru_app_units{app=\"%s\",process=\"%s\",status=\"%s\"} %d\n",
+			prometheusLabelEscaper.Replace(sample.app),
+			prometheusLabelEscaper.Replace(sample.process),
+			prometheusLabelEscaper.Replace(sample.status),
+			sample.count,
+		)
+	}
+}
+
+func listApps(filter url.Values) ([]app, error) {
+	path := "/apps"
+	if len(filter) > 0 {
+		path += "?" + filter.Encode()
+	}
+	u, err := config.GetURL(path)
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := tsuruHTTP.DoWithRetries(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+	var apps []app
+	err = json.NewDecoder(response.Body).Decode(&apps)
+	if err != nil {
+		return nil, err
+	}
+	return apps, nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/tsuru/tsuru/cmd"
+	"github.com/tsuru/tsuru/cmd/cmdtest"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) TestAppMetricsPrometheus(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	result := `{"name":"app1","units":[{"ID":"u1","Status":"started","ProcessName":"web"},{"ID":"u2","Status":"started","ProcessName":"web"},{"ID":"u3","Status":"error","ProcessName":"worker"}]}`
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: result, Status: http.StatusOK},
+		CondFunc: func(r *http.Request) bool {
+			return r.URL.Path == "/1.0/apps/app1" && r.Method == "GET"
+		},
+	}
+	s.setupFakeTransport(trans)
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	command := AppMetrics{}
+	command.Flags().Parse(true, []string{"-a", "app1", "--prometheus"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, `# HELP tsuru_app_units Number of units of the app by process and status.
+# TYPE tsuru_app_units gauge
+tsuru_app_units{app="app1",process="web",status="started"} 2
+tsuru_app_units{app="app1",process="worker",status="error"} 1
+`)
+}
+
+func (s *S) TestAppMetricsAll(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	result := `[{"name":"app2","units":[{"ID":"u1","Status":"started","ProcessName":"web"}]},{"name":"app1","units":[{"ID":"u2","Status":"stopped","ProcessName":"web"}]}]`
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: result, Status: http.StatusOK},
+		CondFunc: func(r *http.Request) bool {
+			return r.URL.Path == "/1.0/apps" && r.Method == "GET"
+		},
+	}
+	s.setupFakeTransport(trans)
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	command := AppMetrics{}
+	command.Flags().Parse(true, []string{"--all"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, `+------+---------+---------+-------+
+| App  | Process | Status  | Units |
++------+---------+---------+-------+
+| app1 | web     | stopped | 1     |
+| app2 | web     | started | 1     |
++------+---------+---------+-------+
+`)
+}
@@ -0,0 +1,171 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tsuru/gnuflag"
+	"github.com/tsuru/go-tsuruclient/pkg/tsuru"
+	tsuruClientApp "github.com/tsuru/tsuru-client/tsuru/app"
+	"github.com/tsuru/tsuru-client/tsuru/formatter"
+	tsuruHTTP "github.com/tsuru/tsuru-client/tsuru/http"
+	"github.com/tsuru/tsuru/cmd"
+)
+
+var appMoveWaitInterval = 5 * time.Second
+
+type AppMove struct {
+	tsuruClientApp.AppNameMixIn
+	fs      *gnuflag.FlagSet
+	to      string
+	wait    bool
+	timeout time.Duration
+}
+
+func (c *AppMove) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "app-move",
+		Usage: "app move [-a/--app appname] --to pool [--wait] [--timeout duration]",
+		Desc: `Moves an application to another pool. Before changing the pool, the command
+checks that the target pool exists, that the team owner of the app is allowed
+to use it and that it uses the same provisioner as the current pool.
+
+The units of the app are rescheduled in the new pool by the tsuru server, and
+the progress is streamed. With the [[--wait]] flag, the command waits until all
+units are started and ready in the new pool, up to the time defined by the
+[[--timeout]] flag.
+
+If the pool change fails after the pool of the app was changed, the app is
+moved back to its original pool.`,
+		MinArgs: 0,
+		MaxArgs: 0,
+	}
+}
+
+func (c *AppMove) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = c.AppNameMixIn.Flags()
+		c.fs.StringVar(&c.to, "to", "", "The pool the app will be moved to")
+		c.fs.BoolVar(&c.wait, "wait", false, "Wait until the units are healthy in the new pool")
+		c.fs.DurationVar(&c.timeout, "timeout", 10*time.Minute, "How long to wait for the units when using --wait")
+	}
+	return c.fs
+}
+
+func (c *AppMove) Run(ctx *cmd.Context) error {
+	ctx.RawOutput()
+	appName, err := c.AppNameByFlag()
+	if err != nil {
+		return err
+	}
+	if c.to == "" {
+		return errors.New("please use the --to flag to specify the target pool")
+	}
+	a, err := getApp(appName)
+	if err != nil {
+		return err
+	}
+	err = validateAppMove(a, c.to)
+	if err != nil {
+		return err
+	}
+	apiClient, err := tsuruHTTP.TsuruClientFromEnvironment()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(ctx.Stdout, "Moving app %q from pool %q to pool %q...\n", appName, a.Pool, c.to)
+	err = updateAppPool(ctx, apiClient, appName, c.to)
+	if err != nil {
+		if tsuruHTTP.UnwrapErr(err) == tsuruHTTP.ErrDryRun {
+			return err
+		}
+		fmt.Fprintf(ctx.Stderr, "Failed to move app %q to pool %q: %v\n", appName, c.to, err)
+		current, getErr := getApp(appName)
+		if getErr != nil {
+			return fmt.Errorf("unable to check the pool of app %q after the failure, it must be checked manually: %v: %w", appName, getErr, err)
+		}
+		if current.Pool == a.Pool {
+			return fmt.Errorf("app %q was kept in pool %q: %w", appName, a.Pool, err)
+		}
+		fmt.Fprintf(ctx.Stderr, "Moving app %q back to pool %q...\n", appName, a.Pool)
+		if rollbackErr := updateAppPool(ctx, apiClient, appName, a.Pool); rollbackErr != nil {
+			return fmt.Errorf("unable to move app %q back to pool %q, it must be fixed manually: %w", appName, a.Pool, rollbackErr)
+		}
+		return fmt.Errorf("app %q was kept in pool %q: %w", appName, a.Pool, err)
+	}
+	if c.wait {
+		err = waitAppUnitsHealthy(ctx, appName, c.timeout)
+		if err != nil {
+			return fmt.Errorf("app %q was moved to pool %q, but %w", appName, c.to, err)
+		}
+	}
+	fmt.Fprintf(ctx.Stdout, "App %q successfully moved to pool %q.\n", appName, c.to)
+	return nil
+}
+
+func validateAppMove(a *app, to string) error {
+	if a.Pool == to {
+		return fmt.Errorf("app %q is already in pool %q", a.Name, to)
+	}
+	pools, err := listPools()
+	if err != nil {
+		return err
+	}
+	var target, current *Pool
+	for i := range pools {
+		switch pools[i].Name {
+		case to:
+			target = &pools[i]
+		case a.Pool:
+			current = &pools[i]
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("pool %q not found", to)
+	}
+	if !target.Public && !target.Default && !sliceContains(target.Allowed["team"], a.TeamOwner) {
+		return fmt.Errorf("team %q is not allowed to use pool %q", a.TeamOwner, to)
+	}
+	if current != nil && current.GetProvisioner() != target.GetProvisioner() {
+		return fmt.Errorf("pool %q uses the %q provisioner, but app %q is running on the %q provisioner", to, target.GetProvisioner(), a.Name, current.GetProvisioner())
+	}
+	return nil
+}
+
+func updateAppPool(ctx *cmd.Context, apiClient *tsuru.APIClient, appName, pool string) error {
+	response, err := apiClient.AppApi.AppUpdate(tsuruHTTP.StreamingContext(context.TODO()), appName, tsuru.UpdateApp{Pool: pool})
+	if err != nil {
+		return err
+	}
+	return formatter.StreamJSONResponse(ctx.Stdout, response)
+}
+
+// waitAppUnitsHealthy polls the app until all of its units are started and
+// ready, or the timeout expires.
+func waitAppUnitsHealthy(ctx *cmd.Context, appName string, timeout time.Duration) error {
+	total, healthy := 0, 0
+	err := pollApp(appName, appMoveWaitInterval, timeout, func(a *app) (bool, error) {
+		total, healthy = 0, 0
+		for _, u := range a.Units {
+			if u.ID == "" {
+				continue
+			}
+			total++
+			if u.Status == "started" && (u.Ready == nil || *u.Ready) {
+				healthy++
+			}
+		}
+		fmt.Fprintf(ctx.Stdout, "Waiting for units of app %q: %d/%d healthy.\n", appName, healthy, total)
+		return total > 0 && healthy == total, nil
+	})
+	if err == errPollTimeout {
+		return fmt.Errorf("only %d of %d units are healthy after %s", healthy, total, timeout)
+	}
+	return err
+}
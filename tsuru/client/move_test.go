@@ -0,0 +1,239 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	tsuruHTTP "github.com/tsuru/tsuru-client/tsuru/http"
+	"github.com/tsuru/tsuru/cmd"
+	"github.com/tsuru/tsuru/cmd/cmdtest"
+	tsuruIo "github.com/tsuru/tsuru/io"
+	"gopkg.in/check.v1"
+)
+
+const appMovePools = `[
+	{"Name": "poolA", "Provisioner": "kubernetes", "Allowed": {"team": ["myteam"]}},
+	{"Name": "poolB", "Provisioner": "kubernetes", "Allowed": {"team": ["myteam"]}},
+	{"Name": "poolC", "Provisioner": "kubernetes", "Allowed": {"team": ["otherteam"]}},
+	{"Name": "poolD", "Provisioner": "docker", "Public": true}
+]`
+
+func appMoveUpdatePool(req *http.Request) string {
+	if req.Method != http.MethodPut || !strings.HasSuffix(req.URL.Path, "/apps/myapp") {
+		return ""
+	}
+	data, _ := io.ReadAll(req.Body)
+	var body map[string]interface{}
+	json.Unmarshal(data, &body)
+	pool, _ := body["pool"].(string)
+	return pool
+}
+
+func (s *S) TestAppMoveInfo(c *check.C) {
+	c.Assert((&AppMove{}).Info(), check.NotNil)
+}
+
+func (s *S) TestAppMove(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	msg, _ := json.Marshal(tsuruIo.SimpleJsonMessage{Message: "rescheduling units\n"})
+	var moved bool
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: string(msg), Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					if appMoveUpdatePool(req) == "poolB" {
+						moved = true
+					}
+					return moved && req.Method == http.MethodPut
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: `{"name": "myapp", "pool": "poolA", "teamowner": "myteam", "units": [{"ID": "myapp-web-1", "Status": "started", "Ready": true}]}`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/apps/myapp")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: appMovePools, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/pools")
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppMove{}
+	command.Flags().Parse(true, []string{"-a", "myapp", "--to", "poolB", "--wait"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(moved, check.Equals, true)
+	expected := `Moving app "myapp" from pool "poolA" to pool "poolB"...
+rescheduling units
+Waiting for units of app "myapp": 1/1 healthy.
+App "myapp" successfully moved to pool "poolB".
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestAppMoveValidation(c *check.C) {
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: `{"name": "myapp", "pool": "poolA", "teamowner": "myteam"}`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/apps/myapp")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: appMovePools, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/pools")
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	tests := []struct {
+		to  string
+		err string
+	}{
+		{to: "poolA", err: `app "myapp" is already in pool "poolA"`},
+		{to: "poolX", err: `pool "poolX" not found`},
+		{to: "poolC", err: `team "myteam" is not allowed to use pool "poolC"`},
+		{to: "poolD", err: `pool "poolD" uses the "docker" provisioner, but app "myapp" is running on the "kubernetes" provisioner`},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+		command := AppMove{}
+		command.Flags().Parse(true, []string{"-a", "myapp", "--to", tt.to})
+		err := command.Run(&context)
+		c.Assert(err, check.ErrorMatches, tt.err)
+	}
+}
+
+func (s *S) TestAppMoveRollbackOnFailure(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	var moved, rolledBack bool
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: "no nodes available", Status: http.StatusInternalServerError},
+				CondFunc: func(req *http.Request) bool {
+					pool := appMoveUpdatePool(req)
+					rolledBack = pool == "poolA"
+					if pool == "poolB" {
+						moved = true
+					}
+					return pool == "poolB"
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return rolledBack
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: `{"name": "myapp", "pool": "poolB", "teamowner": "myteam"}`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return moved && req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/apps/myapp")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: `{"name": "myapp", "pool": "poolA", "teamowner": "myteam"}`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/apps/myapp")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: appMovePools, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/pools")
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppMove{}
+	command.Flags().Parse(true, []string{"-a", "myapp", "--to", "poolB"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `(?s)app "myapp" was kept in pool "poolA": .*no nodes available.*`)
+	c.Assert(rolledBack, check.Equals, true)
+	c.Assert(stderr.String(), check.Matches, `(?s).*Moving app "myapp" back to pool "poolA"\.\.\.\n`)
+}
+
+func (s *S) TestAppMoveNoRollbackWhenPoolUnchanged(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	var rolledBack bool
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: "pool not allowed", Status: http.StatusBadRequest},
+				CondFunc: func(req *http.Request) bool {
+					pool := appMoveUpdatePool(req)
+					if pool == "poolA" {
+						rolledBack = true
+					}
+					return pool != ""
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: `{"name": "myapp", "pool": "poolA", "teamowner": "myteam"}`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/apps/myapp")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: appMovePools, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/pools")
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppMove{}
+	command.Flags().Parse(true, []string{"-a", "myapp", "--to", "poolB"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `(?s)app "myapp" was kept in pool "poolA": .*pool not allowed.*`)
+	c.Assert(rolledBack, check.Equals, false)
+	c.Assert(stderr.String(), check.Not(check.Matches), `(?s).*back to pool.*`)
+}
+
+func (s *S) TestAppMoveDryRun(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	var updates int
+	s.setupFakeTransport(transportFunc(func(req *http.Request) (*http.Response, error) {
+		if appMoveUpdatePool(req) != "" {
+			updates++
+			return nil, tsuruHTTP.ErrDryRun
+		}
+		var body string
+		switch {
+		case strings.HasSuffix(req.URL.Path, "/apps/myapp"):
+			body = `{"name": "myapp", "pool": "poolA", "teamowner": "myteam"}`
+		case strings.HasSuffix(req.URL.Path, "/pools"):
+			body = appMovePools
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}))
+	command := AppMove{}
+	command.Flags().Parse(true, []string{"-a", "myapp", "--to", "poolB"})
+	err := command.Run(&context)
+	c.Assert(tsuruHTTP.UnwrapErr(err), check.Equals, tsuruHTTP.ErrDryRun)
+	c.Assert(updates, check.Equals, 1)
+	c.Assert(stderr.String(), check.Equals, "")
+}
@@ -0,0 +1,189 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/ghodss/yaml"
+	"github.com/tsuru/gnuflag"
+	"github.com/tsuru/tsuru-client/tsuru/formatter"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// outputFlag implements the --output flag for list commands, selecting
+// whether the result is printed as a table, the default, as JSON or as YAML.
+// Both JSON and YAML are rendered from the same data, so their fields match.
+type outputFlag struct {
+	output   string
+	template string
+	columns  string
+}
+
+func (o *outputFlag) addOutputFlag(fs *gnuflag.FlagSet) {
+	fs.StringVar(&o.output, "output", outputTable, "The output format: table, json or yaml")
+}
+
+// format returns the selected output format. The --json flag, kept by the
+// commands that supported it before --output, is the same as --output json.
+func (o *outputFlag) format(json bool) (string, error) {
+	switch o.output {
+	case "", outputTable:
+		if json {
+			return outputJSON, nil
+		}
+		return outputTable, nil
+	case outputJSON, outputYAML:
+		if json && o.output != outputJSON {
+			return "", errors.New("the --json flag can't be used with --output " + o.output)
+		}
+		return o.output, nil
+	}
+	return "", fmt.Errorf("invalid output format %q, supported formats are table, json and yaml", o.output)
+}
+
+// renderOutput writes data to w in the given format, which must be json or
+// yaml.
+func renderOutput(w io.Writer, format string, data interface{}) error {
+	if format != outputYAML {
+		return formatter.JSON(w, data)
+	}
+	b, err := yaml.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// addFormatFlag adds the --format flag, which renders each listed item with a
+// Go template.
+func (o *outputFlag) addFormatFlag(fs *gnuflag.FlagSet) {
+	fs.StringVar(&o.template, "format", "", "Render each item with the given Go template, like '{{.Name}}'")
+}
+
+// parseTemplate returns the template given by --format, or nil when the flag
+// isn't set. The template replaces the table, so it can't be used with other
+// output formats.
+func (o *outputFlag) parseTemplate(output string) (*template.Template, error) {
+	if o.template == "" {
+		return nil, nil
+	}
+	if output != outputTable {
+		return nil, fmt.Errorf("the --format flag can't be used with --output %s", output)
+	}
+	t, err := template.New("format").Funcs(template.FuncMap{"join": strings.Join}).Parse(o.template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --format template: %w", err)
+	}
+	return t, nil
+}
+
+// renderTemplate writes each item rendered with the template in its own line.
+// The template gets a pointer to the item, so its methods can be used too.
+func renderTemplate[T any](w io.Writer, t *template.Template, items []T) error {
+	for i := range items {
+		if err := t.Execute(w, &items[i]); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tableColumn is a column of the table of a list command, which can be chosen
+// by name with --columns.
+type tableColumn[T any] struct {
+	name   string
+	header string
+	value  func(item *T) string
+}
+
+// addColumnsFlag adds the --columns flag, which selects and orders the
+// columns of the table.
+func (o *outputFlag) addColumnsFlag(fs *gnuflag.FlagSet) {
+	fs.StringVar(&o.columns, "columns", "", "Comma-separated list of the columns displayed in the table, in order, like 'name,pool'")
+}
+
+// checkColumns returns an error if --columns is used with an output that
+// isn't a table.
+func (o *outputFlag) checkColumns(output string) error {
+	if o.columns == "" {
+		return nil
+	}
+	if output != outputTable {
+		return fmt.Errorf("the --columns flag can't be used with --output %s", output)
+	}
+	if o.template != "" {
+		return errors.New("the --columns flag can't be used with --format")
+	}
+	return nil
+}
+
+// selectColumns returns the columns named in spec, in the given order, or the
+// columns named in defaults when spec is empty.
+func selectColumns[T any](spec string, columns []tableColumn[T], defaults []string) ([]tableColumn[T], error) {
+	names := defaults
+	if spec != "" {
+		names = nil
+		for _, name := range strings.Split(spec, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			return nil, errors.New("the --columns flag requires at least one column")
+		}
+	}
+	valid := make([]string, len(columns))
+	byName := make(map[string]tableColumn[T], len(columns))
+	for i, column := range columns {
+		valid[i] = column.name
+		byName[column.name] = column
+	}
+	selected := make([]tableColumn[T], 0, len(names))
+	seen := map[string]bool{}
+	for _, name := range names {
+		column, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q, valid columns are: %s", name, strings.Join(valid, ", "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("column %q given more than once", name)
+		}
+		seen[name] = true
+		selected = append(selected, column)
+	}
+	return selected, nil
+}
+
+// columnHeaders returns the headers of the columns.
+func columnHeaders[T any](columns []tableColumn[T]) []string {
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = column.header
+	}
+	return headers
+}
+
+// columnValues returns the row of item in the table with the columns.
+func columnValues[T any](columns []tableColumn[T], item *T) []string {
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		row[i] = column.value(item)
+	}
+	return row
+}
@@ -5,6 +5,7 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/tsuru/gnuflag"
 	"github.com/tsuru/go-tsuruclient/pkg/config"
+	"github.com/tsuru/go-tsuruclient/pkg/tsuru"
 	"github.com/tsuru/tablecli"
 	tsuruHTTP "github.com/tsuru/tsuru-client/tsuru/http"
 	"github.com/tsuru/tsuru/cmd"
@@ -724,3 +726,194 @@ func (c *RoleUpdate) Run(context *cmd.Context) error {
 	context.Stdout.Write([]byte("Role successfully updated\n"))
 	return nil
 }
+
+// permRequirement is a permission required by a command, or by one of its
+// flags when flag is not empty.
+type permRequirement struct {
+	flag    string
+	schemes []*permTypes.PermissionScheme
+}
+
+// commandPermissions maps commands to the permissions checked by the tsuru
+// API when running them. It must be kept in sync with the API handlers.
+var commandPermissions = map[string][]permRequirement{
+	"app-create":  {{schemes: []*permTypes.PermissionScheme{permission.PermAppCreate}}},
+	"app-remove":  {{schemes: []*permTypes.PermissionScheme{permission.PermAppDelete}}},
+	"app-info":    {{schemes: []*permTypes.PermissionScheme{permission.PermAppReadInfo}}},
+	"app-deploy":  {{schemes: []*permTypes.PermissionScheme{permission.PermAppDeploy}}},
+	"app-run":     {{schemes: []*permTypes.PermissionScheme{permission.PermAppRun}}},
+	"app-log":     {{schemes: []*permTypes.PermissionScheme{permission.PermAppReadLog}}},
+	"app-restart": {{schemes: []*permTypes.PermissionScheme{permission.PermAppUpdateRestart}}},
+	"app-start":   {{schemes: []*permTypes.PermissionScheme{permission.PermAppUpdateStart}}},
+	"app-stop":    {{schemes: []*permTypes.PermissionScheme{permission.PermAppUpdateStop}}},
+	"app-grant":   {{schemes: []*permTypes.PermissionScheme{permission.PermAppUpdateGrant}}},
+	"app-revoke":  {{schemes: []*permTypes.PermissionScheme{permission.PermAppUpdateRevoke}}},
+	"app-update": {
+		{flag: "--description", schemes: []*permTypes.PermissionScheme{permission.PermAppUpdateDescription}},
+		{flag: "--tag", schemes: []*permTypes.PermissionScheme{permission.PermAppUpdateTags}},
+		{flag: "--plan", schemes: []*permTypes.PermissionScheme{permission.PermAppUpdatePlan}},
+		{flag: "--cpu, --cpu-burst-factor, --memory", schemes: []*permTypes.PermissionScheme{permission.PermAppUpdatePlanoverride}},
+		{flag: "--pool", schemes: []*permTypes.PermissionScheme{permission.PermAppUpdatePool}},
+		{flag: "--team-owner", schemes: []*permTypes.PermissionScheme{permission.PermAppUpdateTeamowner}},
+		{flag: "--platform", schemes: []*permTypes.PermissionScheme{permission.PermAppUpdatePlatform, permission.PermAppUpdateImageReset}},
+		{flag: "--image-reset", schemes: []*permTypes.PermissionScheme{permission.PermAppUpdateImageReset}},
+	},
+	"app-process-update":      {{schemes: []*permTypes.PermissionScheme{permission.PermAppUpdateProcesses}}},
+	"app-deploy-rollback":     {{schemes: []*permTypes.PermissionScheme{permission.PermAppUpdateDeployRollback}}},
+	"unit-add":                {{schemes: []*permTypes.PermissionScheme{permission.PermAppUpdateUnitAdd}}},
+	"unit-remove":             {{schemes: []*permTypes.PermissionScheme{permission.PermAppUpdateUnitRemove}}},
+	"unit-kill":               {{schemes: []*permTypes.PermissionScheme{permission.PermAppUpdateUnitKill}}},
+	"env-get":                 {{schemes: []*permTypes.PermissionScheme{permission.PermAppReadEnv}}},
+	"env-set":                 {{schemes: []*permTypes.PermissionScheme{permission.PermAppUpdateEnvSet}}},
+	"env-unset":               {{schemes: []*permTypes.PermissionScheme{permission.PermAppUpdateEnvUnset}}},
+	"cname-add":               {{schemes: []*permTypes.PermissionScheme{permission.PermAppUpdateCnameAdd}}},
+	"cname-remove":            {{schemes: []*permTypes.PermissionScheme{permission.PermAppUpdateCnameRemove}}},
+	"service-instance-bind":   {{schemes: []*permTypes.PermissionScheme{permission.PermAppUpdateBind, permission.PermServiceInstanceUpdateBind}}},
+	"service-instance-unbind": {{schemes: []*permTypes.PermissionScheme{permission.PermAppUpdateUnbind, permission.PermServiceInstanceUpdateUnbind}}},
+}
+
+type PermissionExplain struct{}
+
+func (c *PermissionExplain) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "permission-explain",
+		Usage: "permission explain <command>",
+		Desc: `Shows the permissions required to run a command. For commands like app-update,
+where the tsuru API checks a different permission for each field being changed,
+the permission required by each flag is shown.
+
+The command name can be written with dashes or spaces, like "app-update" or
+"app update".`,
+		MinArgs: 1,
+	}
+}
+
+func (c *PermissionExplain) Run(context *cmd.Context) error {
+	name := strings.Join(context.Args, "-")
+	requirements, ok := commandPermissions[name]
+	if !ok {
+		return fmt.Errorf("no permission information for command %q", name)
+	}
+	t := tablecli.NewTable()
+	t.Headers = tablecli.Row{"Flag", "Permission", "Contexts"}
+	for _, req := range requirements {
+		flag := req.flag
+		if flag == "" {
+			flag = "-"
+		}
+		var names, contexts []string
+		for _, scheme := range req.schemes {
+			names = append(names, scheme.FullName())
+			contexts = append(contexts, permContexts(scheme))
+		}
+		t.AddRow(tablecli.Row{flag, strings.Join(names, "\n"), strings.Join(contexts, "\n")})
+	}
+	fmt.Fprintf(context.Stdout, "Permissions required by %s:\n", name)
+	fmt.Fprint(context.Stdout, t.String())
+	return nil
+}
+
+func permContexts(scheme *permTypes.PermissionScheme) string {
+	var contexts []string
+	for _, ctx := range scheme.AllowedContexts() {
+		contexts = append(contexts, string(ctx))
+	}
+	return strings.Join(contexts, ", ")
+}
+
+// permContext is a context in which a permission is checked, like the app
+// itself or one of its teams.
+type permContext struct {
+	ctxType permTypes.ContextType
+	value   string
+}
+
+// appPermContexts returns the contexts used by the tsuru API to check
+// permissions on an app.
+func appPermContexts(a *app) []permContext {
+	contexts := []permContext{{ctxType: permTypes.CtxApp, value: a.Name}}
+	teams := a.Teams
+	if a.TeamOwner != "" && !sliceContains(teams, a.TeamOwner) {
+		teams = append([]string{a.TeamOwner}, teams...)
+	}
+	for _, team := range teams {
+		contexts = append(contexts, permContext{ctxType: permTypes.CtxTeam, value: team})
+	}
+	if a.Pool != "" {
+		contexts = append(contexts, permContext{ctxType: permTypes.CtxPool, value: a.Pool})
+	}
+	return contexts
+}
+
+// userHasPermission reports whether any of the user permissions grants the
+// scheme in one of the contexts, the same way the tsuru API checks it.
+func userHasPermission(userPerms []tsuru.PermissionUser, scheme *permTypes.PermissionScheme, contexts []permContext) bool {
+	name := scheme.FullName()
+	for _, perm := range userPerms {
+		if perm.Name != "" && perm.Name != name && !strings.HasPrefix(name, perm.Name+".") {
+			continue
+		}
+		if perm.Contexttype == string(permTypes.CtxGlobal) {
+			return true
+		}
+		for _, ctx := range contexts {
+			if perm.Contexttype == string(ctx.ctxType) && perm.Contextvalue == ctx.value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// appOperations are the operations displayed by app-info --my-perms, with the
+// command that performs each of them.
+var appOperations = []struct {
+	name    string
+	command string
+}{
+	{name: "deploy", command: "app-deploy"},
+	{name: "env-get", command: "env-get"},
+	{name: "env-set", command: "env-set"},
+	{name: "scale up", command: "unit-add"},
+	{name: "scale down", command: "unit-remove"},
+	{name: "restart", command: "app-restart"},
+	{name: "run", command: "app-run"},
+	{name: "log", command: "app-log"},
+	{name: "delete", command: "app-remove"},
+}
+
+type appOperationPermission struct {
+	Operation  string `json:"operation"`
+	Permission string `json:"permission"`
+	Allowed    bool   `json:"allowed"`
+}
+
+// appOperationPermissions evaluates the permissions of the current user for
+// the common operations on an app.
+func appOperationPermissions(a *app) ([]appOperationPermission, error) {
+	apiClient, err := tsuruHTTP.TsuruClientFromEnvironment()
+	if err != nil {
+		return nil, err
+	}
+	user, _, err := apiClient.UserApi.UserGet(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	contexts := appPermContexts(a)
+	var result []appOperationPermission
+	for _, op := range appOperations {
+		schemes := commandPermissions[op.command][0].schemes
+		allowed := true
+		var names []string
+		for _, scheme := range schemes {
+			names = append(names, scheme.FullName())
+			allowed = allowed && userHasPermission(user.Permissions, scheme, contexts)
+		}
+		result = append(result, appOperationPermission{
+			Operation:  op.name,
+			Permission: strings.Join(names, ", "),
+			Allowed:    allowed,
+		})
+	}
+	return result, nil
+}
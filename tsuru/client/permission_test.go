@@ -6,13 +6,16 @@ package client
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"reflect"
 	"sort"
 	"strings"
 
+	"github.com/tsuru/go-tsuruclient/pkg/tsuru"
 	"github.com/tsuru/tsuru/cmd"
 	"github.com/tsuru/tsuru/cmd/cmdtest"
+	"github.com/tsuru/tsuru/permission"
 	"gopkg.in/check.v1"
 )
 
@@ -598,3 +601,125 @@ func (s *S) TestRoleUpdateWithInvalidContent(c *check.C) {
 	c.Assert(stdout.String(), check.Equals, "")
 	c.Assert(stderr.String(), check.Equals, "Failed to update role\n")
 }
+
+func (s *S) TestPermissionExplainInfo(c *check.C) {
+	c.Assert((&PermissionExplain{}).Info(), check.NotNil)
+}
+
+func (s *S) TestPermissionExplain(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"app", "update"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	command := PermissionExplain{}
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Matches, `(?s)Permissions required by app-update:\n.*`)
+	c.Assert(stdout.String(), check.Matches, `(?s).*\| --plan +\| app\.update\.plan +\| global, app, team, pool \|.*`)
+	c.Assert(stdout.String(), check.Matches, `(?s).*\| --platform +\| app\.update\.platform +\| global, app, team, pool \|\n\| +\| app\.update\.image-reset +\| global, app, team, pool \|.*`)
+}
+
+func (s *S) TestPermissionExplainSingleCommand(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"env-set"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	command := PermissionExplain{}
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	expected := `Permissions required by env-set:
++------+--------------------+-------------------------+
+| Flag | Permission         | Contexts                |
++------+--------------------+-------------------------+
+| -    | app.update.env.set | global, app, team, pool |
++------+--------------------+-------------------------+
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestPermissionExplainUnknownCommand(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"team-create"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	command := PermissionExplain{}
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `no permission information for command "team-create"`)
+}
+
+func (s *S) TestUserHasPermission(c *check.C) {
+	a := &app{Name: "myapp", TeamOwner: "myteam", Teams: []string{"otherteam"}, Pool: "mypool"}
+	contexts := appPermContexts(a)
+	tests := []struct {
+		perms    []tsuru.PermissionUser
+		expected bool
+	}{
+		{[]tsuru.PermissionUser{{Name: "", Contexttype: "global"}}, true},
+		{[]tsuru.PermissionUser{{Name: "app.deploy", Contexttype: "team", Contextvalue: "myteam"}}, true},
+		{[]tsuru.PermissionUser{{Name: "app", Contexttype: "pool", Contextvalue: "mypool"}}, true},
+		{[]tsuru.PermissionUser{{Name: "app.deploy", Contexttype: "app", Contextvalue: "myapp"}}, true},
+		{[]tsuru.PermissionUser{{Name: "app.deploy", Contexttype: "team", Contextvalue: "anotherteam"}}, false},
+		{[]tsuru.PermissionUser{{Name: "app.update", Contexttype: "global"}}, false},
+		{[]tsuru.PermissionUser{{Name: "app.dep", Contexttype: "global"}}, false},
+		{nil, false},
+	}
+	for i, tt := range tests {
+		c.Check(userHasPermission(tt.perms, permission.PermAppDeploy, contexts), check.Equals, tt.expected, check.Commentf("test %d", i))
+	}
+}
+
+func (s *S) TestAppInfoMyPerms(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	result := `{"name":"app1","teamowner":"myteam","pool":"mypool","platform":"php","teams":["myteam"],"owner":"myapp_owner"}`
+	user := `{"email":"me@example.com","permissions":[
+	{"name":"app.deploy","contexttype":"team","contextvalue":"myteam"},
+	{"name":"app.update.env","contexttype":"pool","contextvalue":"mypool"},
+	{"name":"app.delete","contexttype":"team","contextvalue":"otherteam"}
+]}`
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: user, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return strings.HasSuffix(req.URL.Path, "/users/info")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: result, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return strings.HasSuffix(req.URL.Path, "/apps/app1")
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppInfo{}
+	command.Flags().Parse(true, []string{"--app", "app1", "--my-perms", "--json"})
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	var a app
+	err = json.Unmarshal(stdout.Bytes(), &a)
+	c.Assert(err, check.IsNil)
+	allowed := map[string]bool{}
+	for _, p := range a.MyPermissions {
+		allowed[p.Operation] = p.Allowed
+	}
+	c.Assert(allowed, check.DeepEquals, map[string]bool{
+		"deploy":     true,
+		"env-get":    false,
+		"env-set":    true,
+		"scale up":   false,
+		"scale down": false,
+		"restart":    false,
+		"run":        false,
+		"log":        false,
+		"delete":     false,
+	})
+}
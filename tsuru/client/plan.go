@@ -11,13 +11,17 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/tsuru/gnuflag"
 	"github.com/tsuru/go-tsuruclient/pkg/config"
 	"github.com/tsuru/tablecli"
+	tsuruClientApp "github.com/tsuru/tsuru-client/tsuru/app"
+	"github.com/tsuru/tsuru-client/tsuru/formatter"
 	tsuruHTTP "github.com/tsuru/tsuru-client/tsuru/http"
 	"github.com/tsuru/tsuru/cmd"
 	apptypes "github.com/tsuru/tsuru/types/app"
+	eventTypes "github.com/tsuru/tsuru/types/event"
 	"k8s.io/apimachinery/pkg/api/resource"
 )
 
@@ -267,34 +271,173 @@ func displayCPUBurst(currentCPU int, burst float64) string {
 }
 
 func (c *PlanList) Run(context *cmd.Context) error {
-	url, err := config.GetURL("/plans")
+	plans, err := listPlans()
 	if err != nil {
 		return err
 	}
+	if plans == nil {
+		fmt.Fprintln(context.Stdout, "No plans available.")
+		return nil
+	}
+
+	if c.k8sFriendly {
+		fmt.Fprintf(context.Stdout, "%s", renderPlansK8SFriendly(plans, c.showMaxBurstAllowed))
+	} else {
+		fmt.Fprintf(context.Stdout, "%s", renderPlans(plans, renderPlansOpts{isBytes: c.bytes, showDefaultColumn: true, showMaxBurstAllowed: c.showMaxBurstAllowed}))
+	}
+
+	return nil
+}
+
+// listPlans returns the plans available in the API, or nil when there are no
+// plans.
+func listPlans() ([]apptypes.Plan, error) {
+	url, err := config.GetURL("/plans")
+	if err != nil {
+		return nil, err
+	}
 	request, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	var plans []apptypes.Plan
-	resp, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	resp, err := tsuruHTTP.DoWithRetries(request)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode == http.StatusNoContent {
-		fmt.Fprintln(context.Stdout, "No plans available.")
-		return nil
+		return nil, nil
 	}
+	plans := []apptypes.Plan{}
 	err = json.NewDecoder(resp.Body).Decode(&plans)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return plans, nil
+}
 
-	if c.k8sFriendly {
-		fmt.Fprintf(context.Stdout, "%s", renderPlansK8SFriendly(plans, c.showMaxBurstAllowed))
-	} else {
-		fmt.Fprintf(context.Stdout, "%s", renderPlans(plans, renderPlansOpts{isBytes: c.bytes, showDefaultColumn: true, showMaxBurstAllowed: c.showMaxBurstAllowed}))
+type AppPlanHistory struct {
+	tsuruClientApp.AppNameMixIn
+}
+
+func (c *AppPlanHistory) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "app-plan-history",
+		Usage: "app plan history [-a/--app appname]",
+		Desc: `Shows the timeline of plan changes of an application, with the date of
+each transition and the user who made it. Only successful plan changes are
+listed.`,
+		MinArgs: 0,
+		MaxArgs: 0,
 	}
+}
 
+type planTransition struct {
+	date  time.Time
+	owner string
+	from  string
+	to    string
+}
+
+func (c *AppPlanHistory) Run(context *cmd.Context) error {
+	appName, err := c.AppNameByFlag()
+	if err != nil {
+		return err
+	}
+	filter := eventFilter{kindNames: cmd.StringSliceFlag{"app.update.plan"}}
+	filter.filter.Target.Type = eventTypes.TargetTypeApp
+	filter.filter.Target.Value = appName
+	qs, err := filter.queryString()
+	if err != nil {
+		return err
+	}
+	u, err := config.GetURLVersion("1.1", fmt.Sprintf("/events?%s", qs.Encode()))
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	var evts []eventTypes.EventData
+	if response.StatusCode != http.StatusNoContent {
+		err = json.NewDecoder(response.Body).Decode(&evts)
+		if err != nil {
+			return err
+		}
+	}
+	sort.Slice(evts, func(i, j int) bool {
+		return evts[i].StartTime.Before(evts[j].StartTime)
+	})
+	var transitions []planTransition
+	previous := "-"
+	for _, evt := range evts {
+		if evt.Running || evt.Error != "" {
+			continue
+		}
+		plan, err := eventPlanName(evt.UniqueID.Hex())
+		if err != nil {
+			return err
+		}
+		if plan == "" {
+			continue
+		}
+		transitions = append(transitions, planTransition{
+			date:  evt.StartTime,
+			owner: evt.Owner.Name,
+			from:  previous,
+			to:    plan,
+		})
+		previous = plan
+	}
+	if len(transitions) == 0 {
+		fmt.Fprintf(context.Stdout, "No plan changes found for app %q.\n", appName)
+		return nil
+	}
+	table := tablecli.NewTable()
+	table.Headers = tablecli.Row{"Date", "From", "To", "Owner"}
+	for _, t := range transitions {
+		table.AddRow(tablecli.Row{formatter.FormatDate(t.date), t.from, t.to, t.owner})
+	}
+	fmt.Fprint(context.Stdout, table.String())
 	return nil
 }
+
+// eventPlanName returns the plan requested in the update event, looking for
+// the plan field in the form data recorded when the event started.
+func eventPlanName(eventID string) (string, error) {
+	u, err := config.GetURLVersion("1.1", fmt.Sprintf("/events/%s", eventID))
+	if err != nil {
+		return "", err
+	}
+	request, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return "", err
+	}
+	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	var evt eventTypes.EventInfo
+	err = json.NewDecoder(response.Body).Decode(&evt)
+	if err != nil {
+		return "", err
+	}
+	fields, _ := evt.CustomData.Start.([]interface{})
+	for _, f := range fields {
+		field, _ := f.(map[string]interface{})
+		name, _ := field["name"].(string)
+		switch strings.ToLower(name) {
+		case "plan", "plan.name":
+			value, _ := field["value"].(string)
+			return value, nil
+		}
+	}
+	return "", nil
+}
@@ -8,7 +8,9 @@ import (
 	"bytes"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/tsuru/tsuru-client/tsuru/formatter"
 	"github.com/tsuru/tsuru/cmd"
 	"github.com/tsuru/tsuru/cmd/cmdtest"
 	appTypes "github.com/tsuru/tsuru/types/app"
@@ -387,3 +389,69 @@ func (s *S) TestRenderProcessPlan(c *check.C) {
 		c.Assert(cc.expectedResult, check.Equals, output)
 	}
 }
+
+func (s *S) TestAppPlanHistoryInfo(c *check.C) {
+	c.Assert((&AppPlanHistory{}).Info(), check.NotNil)
+}
+
+func (s *S) TestAppPlanHistory(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	old := formatter.LocalTZ
+	formatter.LocalTZ = time.UTC
+	defer func() {
+		formatter.LocalTZ = old
+	}()
+	events := `[
+	{"UniqueID": "578e3908413daf5fd9891aac", "StartTime": "2024-03-02T10:00:00Z", "Owner": {"Name": "bob@example.com"}},
+	{"UniqueID": "578e3908413daf5fd9891aab", "StartTime": "2024-03-01T10:00:00Z", "Owner": {"Name": "alice@example.com"}},
+	{"UniqueID": "578e3908413daf5fd9891aad", "StartTime": "2024-03-03T10:00:00Z", "Owner": {"Name": "bob@example.com"}, "Error": "plan not found"}
+]`
+	infos := map[string]string{
+		"578e3908413daf5fd9891aab": `{"CustomData": {"Start": [{"name": ":app", "value": "myapp"}, {"name": "plan", "value": "small"}]}}`,
+		"578e3908413daf5fd9891aac": `{"CustomData": {"Start": [{"name": "plan", "value": "large"}]}}`,
+	}
+	var transports []cmdtest.ConditionalTransport
+	transports = append(transports, cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: events, Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return strings.HasSuffix(req.URL.Path, "/events") &&
+				req.URL.Query().Get("kindname") == "app.update.plan" &&
+				req.URL.Query().Get("target.type") == "app" &&
+				req.URL.Query().Get("target.value") == "myapp"
+		},
+	})
+	for id, info := range infos {
+		id := id
+		transports = append(transports, cmdtest.ConditionalTransport{
+			Transport: cmdtest.Transport{Message: info, Status: http.StatusOK},
+			CondFunc: func(req *http.Request) bool {
+				return strings.HasSuffix(req.URL.Path, "/events/"+id)
+			},
+		})
+	}
+	s.setupFakeTransport(&cmdtest.AnyConditionalTransport{ConditionalTransports: transports})
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	command := AppPlanHistory{}
+	command.Flags().Parse(true, []string{"-a", "myapp"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	expected := `+---------------------+-------+-------+-------------------+
+| Date                | From  | To    | Owner             |
++---------------------+-------+-------+-------------------+
+| 01 Mar 24 10:00 UTC | -     | small | alice@example.com |
+| 02 Mar 24 10:00 UTC | small | large | bob@example.com   |
++---------------------+-------+-------+-------------------+
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestAppPlanHistoryNoChanges(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	s.setupFakeTransport(&cmdtest.Transport{Status: http.StatusNoContent})
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	command := AppPlanHistory{}
+	command.Flags().Parse(true, []string{"-a", "myapp"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "No plan changes found for app \"myapp\".\n")
+}
@@ -0,0 +1,191 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tsuru/gnuflag"
+	"github.com/tsuru/tablecli"
+	tsuruClientApp "github.com/tsuru/tsuru-client/tsuru/app"
+	"github.com/tsuru/tsuru-client/tsuru/formatter"
+	tsuruapp "github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/cmd"
+	"github.com/tsuru/tsuru/event"
+	eventTypes "github.com/tsuru/tsuru/types/event"
+)
+
+type AppPlatformCheck struct {
+	tsuruClientApp.AppNameMixIn
+	fs *gnuflag.FlagSet
+}
+
+func (c *AppPlatformCheck) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "app-platform-check",
+		Usage: "app platform check [-a/--app appname]",
+		Desc: `Checks whether all units of an application run a version built with the
+latest image of its platform. It's useful after a platform upgrade, to find
+units that survived a deploy and still run a previous image.
+
+The API doesn't tell which platform image a version was built with, so the
+version of each unit is compared with the deploy that built it: versions
+deployed before the last successful create or update of the platform were
+built with a previous image. Versions deployed from an image don't use the
+platform at all and aren't reported. The command exits with an error if any
+unit doesn't run the latest platform image.
+
+Apps pinned to a version of their platform don't follow its latest image, so
+they're not checked. When the events of the platform can't be read, the
+versions can't be compared with the platform image and only the units without
+a known deploy are reported.`,
+		MinArgs: 0,
+		MaxArgs: 0,
+	}
+}
+
+func (c *AppPlatformCheck) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = c.AppNameMixIn.Flags()
+	}
+	return c.fs
+}
+
+func (c *AppPlatformCheck) Run(context *cmd.Context) error {
+	appName, err := c.AppNameByFlag()
+	if err != nil {
+		return err
+	}
+	a, err := getApp(appName)
+	if err != nil {
+		return err
+	}
+	platform, version, _ := strings.Cut(a.Platform, ":")
+	if version != "" && version != "latest" {
+		fmt.Fprintf(context.Stdout, "App %q is pinned to version %s of platform %q, it doesn't use the latest platform image.\n", appName, version, platform)
+		return nil
+	}
+	var units []unit
+	for _, u := range a.Units {
+		if u.ID == "" {
+			continue
+		}
+		if u.Version == 0 {
+			return fmt.Errorf("the provisioner of app %q does not expose the version of its units", appName)
+		}
+		units = append(units, u)
+	}
+	if len(units) == 0 {
+		return fmt.Errorf("app %q has no units", appName)
+	}
+	updatedAt, err := platformUpdateTime(platform)
+	if err != nil {
+		fmt.Fprintf(context.Stderr, "WARNING: %v, the units won't be checked against the latest platform image.\n", err)
+	}
+	deploys, err := versionDeploys(appName)
+	if err != nil {
+		return err
+	}
+	type straggler struct {
+		unit
+		deployed string
+		reason   string
+	}
+	var stragglers []straggler
+	for _, u := range units {
+		d, ok := deploys[u.Version]
+		switch {
+		case !ok:
+			stragglers = append(stragglers, straggler{unit: u, reason: "deploy not found"})
+		case d.Origin == "image":
+		case d.Timestamp.Before(updatedAt):
+			stragglers = append(stragglers, straggler{unit: u, deployed: formatter.FormatDate(d.Timestamp), reason: "built with a previous image"})
+		}
+	}
+	if updatedAt.IsZero() {
+		fmt.Fprintf(context.Stdout, "Platform: %s (update time unknown)\n", platform)
+	} else {
+		fmt.Fprintf(context.Stdout, "Platform: %s (updated at %s)\n", platform, formatter.FormatDate(updatedAt))
+	}
+	if len(stragglers) == 0 {
+		if updatedAt.IsZero() {
+			fmt.Fprintf(context.Stdout, "All %d units run a known deploy.\n", len(units))
+		} else {
+			fmt.Fprintf(context.Stdout, "All %d units run the latest platform image.\n", len(units))
+		}
+		return nil
+	}
+	sort.Slice(stragglers, func(i, j int) bool {
+		return stragglers[i].ID < stragglers[j].ID
+	})
+	table := tablecli.NewTable()
+	table.Headers = tablecli.Row{"Unit", "Process", "Version", "Deployed", "Reason"}
+	for _, s := range stragglers {
+		table.AddRow(tablecli.Row{s.ID, s.ProcessName, strconv.Itoa(s.Version), s.deployed, s.reason})
+	}
+	fmt.Fprintln(context.Stdout, "Units not running the latest platform image:")
+	fmt.Fprint(context.Stdout, table.String())
+	return fmt.Errorf("%d of %d units are not running the latest image of platform %q", len(stragglers), len(units), platform)
+}
+
+// platformUpdateTime returns when the last successful create or update of the
+// platform finished, which is when its current image was built.
+func platformUpdateTime(platform string) (time.Time, error) {
+	filter := eventFilter{
+		filter: event.Filter{
+			Target: eventTypes.Target{Type: eventTypes.TargetTypePlatform, Value: platform},
+		},
+		kindNames: cmd.StringSliceFlag{"platform.create", "platform.update"},
+	}
+	evts, err := listEvents(&filter)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var updatedAt time.Time
+	for _, evt := range evts {
+		if evt.Running || evt.Error != "" {
+			continue
+		}
+		if evt.EndTime.After(updatedAt) {
+			updatedAt = evt.EndTime
+		}
+	}
+	if updatedAt.IsZero() {
+		return time.Time{}, fmt.Errorf("unable to find when the image of platform %q was built, its events may have expired", platform)
+	}
+	return updatedAt, nil
+}
+
+// versionDeploysPageSize is the number of deploys requested at a time by
+// versionDeploys.
+var versionDeploysPageSize = 100
+
+// versionDeploys returns the successful deploy that built each version of the
+// app. Rollbacks reuse the version of a previous deploy, so they're ignored.
+// Deploys are requested in pages, until the API returns a partial one.
+func versionDeploys(appName string) (map[int]tsuruapp.DeployData, error) {
+	deploys := map[int]tsuruapp.DeployData{}
+	for skip := 0; ; skip += versionDeploysPageSize {
+		list, err := listAppDeploys(appName, skip, versionDeploysPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range list {
+			if d.Version == 0 || d.Error != "" || d.Origin == "rollback" {
+				continue
+			}
+			if prev, ok := deploys[d.Version]; !ok || d.Timestamp.Before(prev.Timestamp) {
+				deploys[d.Version] = d
+			}
+		}
+		if len(list) < versionDeploysPageSize {
+			return deploys, nil
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tsuru/tsuru-client/tsuru/formatter"
+	"github.com/tsuru/tsuru/cmd"
+	"github.com/tsuru/tsuru/cmd/cmdtest"
+	"gopkg.in/check.v1"
+)
+
+func platformCheckTransport(appInfo, events, deploys string) *cmdtest.MultiConditionalTransport {
+	return &cmdtest.MultiConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: appInfo, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return strings.HasSuffix(req.URL.Path, "/apps/myapp")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: events, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					q := req.URL.Query()
+					return strings.HasSuffix(req.URL.Path, "/events") &&
+						q.Get("target.type") == "platform" && q.Get("target.value") == "python" &&
+						strings.Join(q["kindname"], ",") == "platform.create,platform.update"
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: deploys, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return strings.HasSuffix(req.URL.Path, "/deploys") && req.URL.Query().Get("app") == "myapp"
+				},
+			},
+		},
+	}
+}
+
+const platformCheckEvents = `[
+	{"StartTime": "2026-10-01T10:00:00Z", "EndTime": "2026-10-01T10:05:00Z", "Kind": {"Name": "platform.update"}},
+	{"StartTime": "2026-10-02T10:00:00Z", "EndTime": "2026-10-02T10:05:00Z", "Kind": {"Name": "platform.update"}, "Error": "build failed"},
+	{"StartTime": "2026-09-01T10:00:00Z", "EndTime": "2026-09-01T10:05:00Z", "Kind": {"Name": "platform.create"}}
+]`
+
+func (s *S) TestAppPlatformCheckInfo(c *check.C) {
+	c.Assert((&AppPlatformCheck{}).Info(), check.NotNil)
+}
+
+func (s *S) TestAppPlatformCheck(c *check.C) {
+	oldTZ := formatter.LocalTZ
+	defer func() { formatter.LocalTZ = oldTZ }()
+	formatter.LocalTZ = time.UTC
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	result := `{"name": "myapp", "platform": "python", "units": [
+	{"ID": "myapp-web-1", "ProcessName": "web", "Version": 3, "Status": "started"},
+	{"ID": "myapp-worker-1", "ProcessName": "worker", "Version": 3, "Status": "started"}
+]}`
+	deploys := `[
+	{"Version": 3, "Origin": "rollback", "Timestamp": "2026-10-04T10:00:00Z"},
+	{"Version": 3, "Origin": "git", "Timestamp": "2026-10-03T10:00:00Z"},
+	{"Version": 2, "Origin": "git", "Timestamp": "2026-09-20T10:00:00Z"}
+]`
+	s.setupFakeTransport(platformCheckTransport(result, platformCheckEvents, deploys))
+	command := AppPlatformCheck{}
+	command.Flags().Parse(true, []string{"-a", "myapp"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "Platform: python (updated at 01 Oct 26 10:05 UTC)\nAll 2 units run the latest platform image.\n")
+}
+
+func (s *S) TestAppPlatformCheckStragglers(c *check.C) {
+	oldTZ := formatter.LocalTZ
+	defer func() { formatter.LocalTZ = oldTZ }()
+	formatter.LocalTZ = time.UTC
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	result := `{"name": "myapp", "platform": "python", "units": [
+	{"ID": "myapp-web-1", "ProcessName": "web", "Version": 3, "Status": "started"},
+	{"ID": "myapp-web-2", "ProcessName": "web", "Version": 2, "Status": "started"},
+	{"ID": "myapp-web-3", "ProcessName": "web", "Version": 4, "Status": "started"},
+	{"ID": "myapp-web-4", "ProcessName": "web", "Version": 1, "Status": "started"}
+]}`
+	deploys := `[
+	{"Version": 4, "Origin": "image", "Timestamp": "2026-10-05T10:00:00Z"},
+	{"Version": 3, "Origin": "git", "Timestamp": "2026-10-03T10:00:00Z"},
+	{"Version": 2, "Origin": "app-deploy", "Timestamp": "2026-09-20T10:00:00Z"}
+]`
+	s.setupFakeTransport(platformCheckTransport(result, platformCheckEvents, deploys))
+	command := AppPlatformCheck{}
+	command.Flags().Parse(true, []string{"-a", "myapp"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `2 of 4 units are not running the latest image of platform "python"`)
+	expected := `Platform: python (updated at 01 Oct 26 10:05 UTC)
+Units not running the latest platform image:
++-------------+---------+---------+---------------------+-----------------------------+
+| Unit        | Process | Version | Deployed            | Reason                      |
++-------------+---------+---------+---------------------+-----------------------------+
+| myapp-web-2 | web     | 2       | 20 Sep 26 10:00 UTC | built with a previous image |
+| myapp-web-4 | web     | 1       |                     | deploy not found            |
++-------------+---------+---------+---------------------+-----------------------------+
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestAppPlatformCheckNoPlatformEvents(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	result := `{"name": "myapp", "platform": "python", "units": [
+	{"ID": "myapp-web-1", "ProcessName": "web", "Version": 3, "Status": "started"}
+]}`
+	deploys := `[{"Version": 3, "Origin": "git", "Timestamp": "2026-10-03T10:00:00Z"}]`
+	s.setupFakeTransport(platformCheckTransport(result, `[]`, deploys))
+	command := AppPlatformCheck{}
+	command.Flags().Parse(true, []string{"-a", "myapp"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "Platform: python (update time unknown)\nAll 1 units run a known deploy.\n")
+	c.Assert(stderr.String(), check.Equals, `WARNING: unable to find when the image of platform "python" was built, its events may have expired, the units won't be checked against the latest platform image.`+"\n")
+}
+
+func (s *S) TestAppPlatformCheckPlatformEventsForbidden(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	result := `{"name": "myapp", "platform": "python", "units": [
+	{"ID": "myapp-web-1", "ProcessName": "web", "Version": 3, "Status": "started"},
+	{"ID": "myapp-web-2", "ProcessName": "web", "Version": 1, "Status": "started"}
+]}`
+	deploys := `[{"Version": 3, "Origin": "git", "Timestamp": "2026-10-03T10:00:00Z"}]`
+	trans := platformCheckTransport(result, "", deploys)
+	trans.ConditionalTransports[1].Transport = cmdtest.Transport{Message: "forbidden", Status: http.StatusForbidden}
+	s.setupFakeTransport(trans)
+	command := AppPlatformCheck{}
+	command.Flags().Parse(true, []string{"-a", "myapp"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `1 of 2 units are not running the latest image of platform "python"`)
+	c.Assert(stderr.String(), check.Matches, `(?s)WARNING: .*forbidden.*, the units won't be checked against the latest platform image\.\n`)
+	c.Assert(stdout.String(), check.Matches, `(?s)Platform: python \(update time unknown\)\n.*myapp-web-2 .*deploy not found.*`)
+}
+
+func (s *S) TestAppPlatformCheckPinnedVersion(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	result := `{"name": "myapp", "platform": "python:3", "units": [
+	{"ID": "myapp-web-1", "ProcessName": "web", "Version": 3, "Status": "started"}
+]}`
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: result, Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return strings.HasSuffix(req.URL.Path, "/apps/myapp")
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppPlatformCheck{}
+	command.Flags().Parse(true, []string{"-a", "myapp"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, `App "myapp" is pinned to version 3 of platform "python", it doesn't use the latest platform image.`+"\n")
+}
+
+func (s *S) TestAppPlatformCheckPaginatesDeploys(c *check.C) {
+	oldTZ := formatter.LocalTZ
+	oldPageSize := versionDeploysPageSize
+	defer func() {
+		formatter.LocalTZ = oldTZ
+		versionDeploysPageSize = oldPageSize
+	}()
+	formatter.LocalTZ = time.UTC
+	versionDeploysPageSize = 2
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	result := `{"name": "myapp", "platform": "python", "units": [
+	{"ID": "myapp-web-1", "ProcessName": "web", "Version": 4, "Status": "started"},
+	{"ID": "myapp-web-2", "ProcessName": "web", "Version": 1, "Status": "started"}
+]}`
+	pages := []string{
+		`[{"Version": 5, "Origin": "git", "Timestamp": "2026-10-06T10:00:00Z"}, {"Version": 4, "Origin": "git", "Timestamp": "2026-10-05T10:00:00Z"}]`,
+		`[{"Version": 3, "Origin": "git", "Timestamp": "2026-10-03T10:00:00Z"}, {"Version": 2, "Origin": "git", "Timestamp": "2026-10-02T10:00:00Z"}]`,
+		`[{"Version": 1, "Origin": "git", "Timestamp": "2026-09-20T10:00:00Z"}]`,
+	}
+	var requested []string
+	trans := platformCheckTransport(result, platformCheckEvents, "")
+	trans.ConditionalTransports = trans.ConditionalTransports[:2]
+	for _, page := range pages {
+		trans.ConditionalTransports = append(trans.ConditionalTransports, cmdtest.ConditionalTransport{
+			Transport: cmdtest.Transport{Message: page, Status: http.StatusOK},
+			CondFunc: func(req *http.Request) bool {
+				q := req.URL.Query()
+				requested = append(requested, q.Get("skip"))
+				return strings.HasSuffix(req.URL.Path, "/deploys") && q.Get("app") == "myapp" && q.Get("limit") == "2"
+			},
+		})
+	}
+	s.setupFakeTransport(trans)
+	command := AppPlatformCheck{}
+	command.Flags().Parse(true, []string{"-a", "myapp"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `1 of 2 units are not running the latest image of platform "python"`)
+	c.Assert(requested, check.DeepEquals, []string{"0", "2", "4"})
+	c.Assert(stdout.String(), check.Matches, `(?s).*myapp-web-2 .*20 Sep 26 10:00 UTC.*built with a previous image.*`)
+}
+
+func (s *S) TestAppPlatformCheckNoVersions(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	result := `{"name": "myapp", "platform": "python", "units": [{"ID": "myapp-web-1", "ProcessName": "web"}]}`
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := AppPlatformCheck{}
+	command.Flags().Parse(true, []string{"-a", "myapp"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `the provisioner of app "myapp" does not expose the version of its units`)
+}
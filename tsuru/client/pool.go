@@ -6,10 +6,14 @@ package client
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/mitchellh/go-wordwrap"
 	"github.com/tsuru/gnuflag"
@@ -18,18 +22,22 @@ import (
 	"github.com/tsuru/tsuru-client/tsuru/formatter"
 	tsuruHTTP "github.com/tsuru/tsuru-client/tsuru/http"
 	"github.com/tsuru/tsuru/cmd"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
 )
 
 type poolFilter struct {
-	name string
-	team string
+	name        string
+	team        string
+	provisioner string
 }
 
 type PoolList struct {
+	outputFlag
 	fs         *gnuflag.FlagSet
 	filter     poolFilter
 	simplified bool
 	json       bool
+	withUsage  bool
 }
 
 type Pool struct {
@@ -76,41 +84,58 @@ func (c *PoolList) Flags() *gnuflag.FlagSet {
 		c.fs.StringVar(&c.filter.name, "n", "", "Filter pools by name")
 		c.fs.StringVar(&c.filter.team, "team", "", "Filter pools by team ")
 		c.fs.StringVar(&c.filter.team, "t", "", "Filter pools by team")
+		c.fs.StringVar(&c.filter.provisioner, "provisioner", "", "Filter pools by provisioner, accepts a comma-separated list")
 		c.fs.BoolVar(&c.simplified, "q", false, "Display only pools name")
 		c.fs.BoolVar(&c.json, "json", false, "Display in JSON format")
-
+		c.fs.BoolVar(&c.withUsage, "with-usage", false, "Display how many apps and units are in each pool")
+		c.addOutputFlag(c.fs)
+		c.addFormatFlag(c.fs)
+		c.addColumnsFlag(c.fs)
 	}
 	return c.fs
 }
 
 func (pl *PoolList) Run(context *cmd.Context) error {
-	url, err := config.GetURL("/pools")
+	output, err := pl.format(pl.json)
 	if err != nil {
 		return err
 	}
-	request, err := http.NewRequest("GET", url, nil)
+	tmpl, err := pl.parseTemplate(output)
 	if err != nil {
 		return err
 	}
-	resp, err := tsuruHTTP.AuthenticatedClient.Do(request)
-	if err != nil {
+	if tmpl != nil && (pl.simplified || pl.withUsage) {
+		return errors.New("the --format flag can't be used with -q or --with-usage")
+	}
+	if err = pl.checkColumns(output); err != nil {
 		return err
 	}
-	t := tablecli.Table{Headers: tablecli.Row([]string{"Pool", "Kind", "Provisioner", "Teams", "Routers"}), LineSeparator: true}
-	if resp.StatusCode == http.StatusNoContent {
-		context.Stdout.Write(t.Bytes())
-		return nil
+	usage := map[string]poolUsage{}
+	defaults := []string{"name", "kind", "provisioner", "teams", "routers"}
+	if pl.withUsage {
+		defaults = append(defaults, "apps", "units")
 	}
-	defer resp.Body.Close()
-	var pools []Pool
-	err = json.NewDecoder(resp.Body).Decode(&pools)
+	columns, err := selectColumns(pl.columns, poolColumns(usage), defaults)
 	if err != nil {
 		return err
 	}
+	pools, err := listPools()
+	if err != nil {
+		return err
+	}
+	t := tablecli.Table{Headers: tablecli.Row(columnHeaders(columns)), LineSeparator: true}
 	sort.Sort(poolEntriesList(pools))
 
 	pools = pl.clientSideFilter(pools)
 
+	if len(pools) == 0 && pl.filter.provisioner != "" {
+		fmt.Fprintf(context.Stderr, "no pools found for provisioner %s\n", pl.filter.provisioner)
+		if output != outputTable {
+			return renderOutput(context.Stdout, output, pools)
+		}
+		return nil
+	}
+
 	if pl.simplified {
 		for _, v := range pools {
 			fmt.Fprintln(context.Stdout, v.Name)
@@ -118,28 +143,127 @@ func (pl *PoolList) Run(context *cmd.Context) error {
 		return nil
 	}
 
-	if pl.json {
-		return formatter.JSON(context.Stdout, pools)
+	if output != outputTable {
+		return renderOutput(context.Stdout, output, pools)
 	}
 
-	for _, pool := range pools {
-		teams := ""
-		if !pool.Public && !pool.Default {
-			teams = strings.Join(pool.Allowed["team"], ", ")
-		}
-		routers := strings.Join(pool.Allowed["router"], ", ")
-		t.AddRow(tablecli.Row([]string{
-			pool.Name,
-			pool.Kind(),
-			pool.GetProvisioner(),
-			wordwrap.WrapString(teams, 30),
-			wordwrap.WrapString(routers, 30),
-		}))
+	if tmpl != nil {
+		return renderTemplate(context.Stdout, tmpl, pools)
+	}
+
+	if pl.withUsage || hasUsageColumn(columns) {
+		counted, err := poolsUsage(pools)
+		if err != nil {
+			return err
+		}
+		for name, u := range counted {
+			usage[name] = u
+		}
+	}
+	for i := range pools {
+		t.AddRow(tablecli.Row(columnValues(columns, &pools[i])))
 	}
 	context.Stdout.Write(t.Bytes())
 	return nil
 }
 
+// poolColumns returns the columns of the pool-list table. The apps and units
+// columns read the usage of the pools, which is filled before the rows are
+// built.
+func poolColumns(usage map[string]poolUsage) []tableColumn[Pool] {
+	return []tableColumn[Pool]{
+		{name: "name", header: "Pool", value: func(p *Pool) string { return p.Name }},
+		{name: "kind", header: "Kind", value: func(p *Pool) string { return p.Kind() }},
+		{name: "provisioner", header: "Provisioner", value: func(p *Pool) string { return p.GetProvisioner() }},
+		{name: "teams", header: "Teams", value: func(p *Pool) string {
+			if p.Public || p.Default {
+				return ""
+			}
+			return wordwrap.WrapString(strings.Join(p.Allowed["team"], ", "), 30)
+		}},
+		{name: "routers", header: "Routers", value: func(p *Pool) string {
+			return wordwrap.WrapString(strings.Join(p.Allowed["router"], ", "), 30)
+		}},
+		{name: "apps", header: "Apps", value: func(p *Pool) string { return strconv.Itoa(usage[p.Name].apps) }},
+		{name: "units", header: "Units", value: func(p *Pool) string { return strconv.Itoa(usage[p.Name].units) }},
+	}
+}
+
+func hasUsageColumn(columns []tableColumn[Pool]) bool {
+	for _, column := range columns {
+		if column.name == "apps" || column.name == "units" {
+			return true
+		}
+	}
+	return false
+}
+
+// poolUsageConcurrency is how many pools have their apps listed at the same
+// time by pool-list --with-usage.
+const poolUsageConcurrency = 10
+
+type poolUsage struct {
+	apps  int
+	units int
+}
+
+// poolsUsage counts the apps and units in each pool, listing the apps of the
+// pools concurrently.
+func poolsUsage(pools []Pool) (map[string]poolUsage, error) {
+	var (
+		mu       sync.Mutex
+		firstErr error
+	)
+	usage := make(map[string]poolUsage, len(pools))
+	forEachConcurrently(len(pools), poolUsageConcurrency, func(i int) bool {
+		name := pools[i].Name
+		apps, err := listApps(url.Values{"pool": []string{name}})
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("unable to list the apps in pool %q: %w", name, err)
+			}
+			return false
+		}
+		u := poolUsage{apps: len(apps)}
+		for _, a := range apps {
+			u.units += len(a.Units)
+		}
+		usage[name] = u
+		return true
+	})
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return usage, nil
+}
+
+func listPools() ([]Pool, error) {
+	url, err := config.GetURL("/pools")
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := tsuruHTTP.DoCached(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+	var pools []Pool
+	err = json.NewDecoder(resp.Body).Decode(&pools)
+	if err != nil {
+		return nil, err
+	}
+	return pools, nil
+}
+
 func (c *PoolList) clientSideFilter(pools []Pool) []Pool {
 	result := make([]Pool, 0, len(pools))
 
@@ -153,6 +277,10 @@ func (c *PoolList) clientSideFilter(pools []Pool) []Pool {
 			insert = false
 		}
 
+		if c.filter.provisioner != "" && !matchesProvisioner(pool.GetProvisioner(), c.filter.provisioner) {
+			insert = false
+		}
+
 		if insert {
 			result = append(result, pool)
 		}
@@ -161,6 +289,15 @@ func (c *PoolList) clientSideFilter(pools []Pool) []Pool {
 	return result
 }
 
+func matchesProvisioner(provisioner, filter string) bool {
+	for _, p := range strings.Split(filter, ",") {
+		if strings.EqualFold(strings.TrimSpace(p), provisioner) {
+			return true
+		}
+	}
+	return false
+}
+
 func sliceContains(s []string, d string) bool {
 	for _, i := range s {
 		if i == d {
@@ -173,9 +310,354 @@ func sliceContains(s []string, d string) bool {
 
 func (PoolList) Info() *cmd.Info {
 	return &cmd.Info{
-		Name:    "pool-list",
-		Usage:   "pool-list",
-		Desc:    "List all pools available for deploy.",
+		Name:  "pool-list",
+		Usage: "pool-list [-n/--name name] [-t/--team team] [--provisioner provisioner[,provisioner...]] [--with-usage] [-q] [--json] [--output table|json|yaml] [--format template] [--columns column[,column...]]",
+		Desc: `List all pools available for deploy.
+
+The [[--provisioner]] flag filters pools by provisioner, case-insensitively. It
+accepts a comma-separated list, like "docker,kubernetes".
+
+The [[--output]] flag prints the pools as JSON or YAML instead of a table,
+sorted the same way as the table. [[--json]] is the same as [[--output json]].
+An empty list is printed as [].
+
+The [[--format]] flag renders each pool with the given Go template instead of
+the table, like [[--format '{{.Name}} {{.GetProvisioner}}']]. The template
+gets the fields of the pool, like Name, Public, Default, Provisioner and
+Allowed, and the Kind and GetProvisioner methods. The join function joins a
+list, like [[{{join (index .Allowed "team") ","}}]].
+
+The [[--with-usage]] flag adds the "Apps" and "Units" columns to the table,
+with how many apps and units are in each pool. It lists the apps of each pool,
+so it's slower on large installations.
+
+The [[--columns]] flag selects which columns of the table are displayed, and in
+which order, like [[--columns name,kind,apps]]. The valid columns are name,
+kind, provisioner, teams, routers, apps and units. Choosing apps or units
+counts the apps and units of each pool, like [[--with-usage]].
+
+The list of pools is cached in [[$HOME/.tsuru/cache]] for 30 seconds, commands
+that change anything clear the cache. Use the global [[--no-cache]] flag or set
+the TSURU_NO_CACHE environment variable to always request the list.`,
 		MinArgs: 0,
 	}
 }
+
+type PoolInfo struct{}
+
+func (PoolInfo) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "pool-info",
+		Usage:   "pool-info <pool>",
+		Desc:    "Shows information about a single pool.",
+		MinArgs: 1,
+		MaxArgs: 1,
+	}
+}
+
+func (PoolInfo) Run(context *cmd.Context) error {
+	poolName := context.Args[0]
+	pools, err := listPools()
+	if err != nil {
+		return err
+	}
+	var pool *Pool
+	for i := range pools {
+		if pools[i].Name == poolName {
+			pool = &pools[i]
+			break
+		}
+	}
+	if pool == nil {
+		return fmt.Errorf("pool %q not found", poolName)
+	}
+	kind := pool.Kind()
+	if kind == "" {
+		kind = "-"
+	}
+	teams := "-"
+	if len(pool.Allowed["team"]) > 0 {
+		teams = strings.Join(pool.Allowed["team"], ", ")
+	}
+	fmt.Fprintf(context.Stdout, "Name: %s\n", pool.Name)
+	fmt.Fprintf(context.Stdout, "Kind: %s\n", kind)
+	fmt.Fprintf(context.Stdout, "Provisioner: %s\n", pool.GetProvisioner())
+	fmt.Fprintf(context.Stdout, "Default: %t\n", pool.Default)
+	fmt.Fprintf(context.Stdout, "Public: %t\n", pool.Public)
+	fmt.Fprintf(context.Stdout, "Teams: %s\n", teams)
+	return nil
+}
+
+type PoolGrant struct {
+	cmd.ConfirmationCommand
+}
+
+func (c *PoolGrant) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "pool-grant",
+		Usage: "pool grant <pool> <teamname> [-y/--assume-yes]",
+		Desc: `Allows a team to access all applications running in a pool, which is useful
+when a team joins a product made of many apps.
+
+The number of affected apps is displayed and must be confirmed before
+proceeding, unless [[--assume-yes]] is used. A failure to grant access to an
+app doesn't stop the command, the result for each app is displayed at the
+end.`,
+		MinArgs: 2,
+		MaxArgs: 2,
+	}
+}
+
+func (c *PoolGrant) Run(context *cmd.Context) error {
+	poolName, teamName := context.Args[0], context.Args[1]
+	apps, err := listApps(url.Values{"pool": []string{poolName}})
+	if err != nil {
+		return err
+	}
+	if len(apps) == 0 {
+		fmt.Fprintf(context.Stdout, "Pool %q has no apps.\n", poolName)
+		return nil
+	}
+	sort.Slice(apps, func(i, j int) bool {
+		return apps[i].Name < apps[j].Name
+	})
+	if !c.Confirm(context, fmt.Sprintf("Are you sure you want to grant team %q access to %d apps in pool %q?", teamName, len(apps), poolName)) {
+		return nil
+	}
+	table := tablecli.NewTable()
+	table.Headers = tablecli.Row{"App", "Result"}
+	failures := 0
+	for _, a := range apps {
+		result, err := grantPoolAppAccess(&a, teamName)
+		if err != nil {
+			failures++
+			result = fmt.Sprintf("failed: %v", err)
+		}
+		table.AddRow(tablecli.Row{a.Name, result})
+	}
+	fmt.Fprint(context.Stdout, table.String())
+	if failures > 0 {
+		return fmt.Errorf("failed to grant access to %d of %d apps", failures, len(apps))
+	}
+	return nil
+}
+
+// grantPoolAppAccess grants the team access to the app, returning the result
+// displayed by pool-grant.
+func grantPoolAppAccess(a *app, teamName string) (string, error) {
+	if a.TeamOwner == teamName || sliceContains(a.Teams, teamName) {
+		return "already had", nil
+	}
+	err := grantAppAccess(a.Name, teamName)
+	if err == nil {
+		return "granted", nil
+	}
+	err = tsuruHTTP.UnwrapErr(err)
+	if httpErr, ok := err.(*tsuruErrors.HTTP); ok && httpErr.Code == http.StatusConflict {
+		return "already had", nil
+	}
+	return "", err
+}
+
+type PoolRestart struct {
+	cmd.ConfirmationCommand
+	fs              *gnuflag.FlagSet
+	concurrency     int
+	continueOnError bool
+}
+
+func (c *PoolRestart) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "pool-restart",
+		Usage: "pool restart <pool> [--concurrency N] [--continue-on-error] [-y/--assume-yes]",
+		Desc: `Restarts all applications running in a pool, which is useful during incident
+response.
+
+The apps are restarted one at a time by default, use [[--concurrency]] to
+restart more apps at the same time. A line is displayed as each app finishes.
+The command stops on the first failure, letting the restarts already running
+finish, unless [[--continue-on-error]] is used.
+
+The number of affected apps is displayed and must be confirmed before
+proceeding, unless [[--assume-yes]] is used.`,
+		MinArgs: 1,
+		MaxArgs: 1,
+	}
+}
+
+func (c *PoolRestart) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = mergeFlagSet(gnuflag.NewFlagSet("pool-restart", gnuflag.ExitOnError), c.ConfirmationCommand.Flags())
+		c.fs.IntVar(&c.concurrency, "concurrency", 1, "How many apps are restarted at the same time")
+		c.fs.BoolVar(&c.continueOnError, "continue-on-error", false, "Keep restarting the remaining apps when an app fails to restart")
+	}
+	return c.fs
+}
+
+func (c *PoolRestart) Run(context *cmd.Context) error {
+	batch := poolBatch{
+		concurrency:     c.concurrency,
+		continueOnError: c.continueOnError,
+		verb:            "restart",
+		past:            "restarted",
+	}
+	return batch.run(context, context.Args[0], &c.ConfirmationCommand, restartPoolApp)
+}
+
+// poolBatch runs an action, like restarting, on all the apps of a pool, with
+// at most concurrency apps at a time. A line is displayed as each app
+// finishes. Unless continueOnError is set, it stops on the first failure,
+// letting the actions already running finish.
+type poolBatch struct {
+	concurrency     int
+	continueOnError bool
+	// verb and past name the action in the messages, like "restart" and
+	// "restarted".
+	verb string
+	past string
+}
+
+// run lists the apps of the pool and runs the action on them. When confirm is
+// not nil, the number of affected apps must be confirmed first.
+func (b *poolBatch) run(context *cmd.Context, poolName string, confirm *cmd.ConfirmationCommand, action func(appName string) error) error {
+	if b.concurrency <= 0 {
+		return errors.New("the concurrency must be greater than zero")
+	}
+	apps, err := listApps(url.Values{"pool": []string{poolName}})
+	if err != nil {
+		return err
+	}
+	if len(apps) == 0 {
+		fmt.Fprintf(context.Stdout, "Pool %q has no apps.\n", poolName)
+		return nil
+	}
+	sort.Slice(apps, func(i, j int) bool {
+		return apps[i].Name < apps[j].Name
+	})
+	if confirm != nil && !confirm.Confirm(context, fmt.Sprintf("Are you sure you want to %s %d apps in pool %q?", b.verb, len(apps), poolName)) {
+		return nil
+	}
+	var (
+		mu       sync.Mutex
+		done     int
+		failures int
+	)
+	forEachConcurrently(len(apps), b.concurrency, func(i int) bool {
+		appName := apps[i].Name
+		err := action(appName)
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		if err != nil {
+			failures++
+			fmt.Fprintf(context.Stdout, "[%d/%d] %s: failed: %v\n", done, len(apps), appName, tsuruHTTP.UnwrapErr(err))
+			return b.continueOnError
+		}
+		fmt.Fprintf(context.Stdout, "[%d/%d] %s: %s\n", done, len(apps), appName, b.past)
+		return true
+	})
+	if failures == 0 {
+		fmt.Fprintf(context.Stdout, "All %d apps in pool %q were %s.\n", len(apps), poolName, b.past)
+		return nil
+	}
+	if done < len(apps) {
+		fmt.Fprintf(context.Stderr, "Stopped after the first failure, %d apps were not %s. Use --continue-on-error to %s all apps.\n", len(apps)-done, b.past, b.verb)
+	}
+	return fmt.Errorf("failed to %s %d of %d apps", b.verb, failures, len(apps))
+}
+
+func restartPoolApp(appName string) error {
+	return runAppAction(appName, "restart", "", "")
+}
+
+type PoolUnits struct {
+	fs     *gnuflag.FlagSet
+	sortBy string
+	json   bool
+}
+
+type poolUnit struct {
+	App    string
+	Unit   string
+	Status string
+	Node   string
+}
+
+func (c *PoolUnits) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "pool-units",
+		Usage: "pool units <pool> [--sort node] [--json]",
+		Desc: `Lists the units of all applications running in a pool, with the node where
+each unit is running. This is useful to plan node drains and to correlate
+failures of different apps running on the same node.
+
+Units are sorted by app by default, use [[--sort node]] to group them by node.`,
+		MinArgs: 1,
+		MaxArgs: 1,
+	}
+}
+
+func (c *PoolUnits) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = gnuflag.NewFlagSet("pool-units", gnuflag.ExitOnError)
+		c.fs.StringVar(&c.sortBy, "sort", "app", "Sort units by the given field, \"app\" or \"node\"")
+		c.fs.BoolVar(&c.json, "json", false, "Display units in JSON format")
+	}
+	return c.fs
+}
+
+func (c *PoolUnits) Run(context *cmd.Context) error {
+	if c.sortBy != "app" && c.sortBy != "node" {
+		return fmt.Errorf("invalid sort option %q, supported values are \"app\" and \"node\"", c.sortBy)
+	}
+	poolName := context.Args[0]
+	apps, err := listApps(url.Values{"pool": []string{poolName}})
+	if err != nil {
+		return err
+	}
+	units := poolUnits(apps)
+	if c.sortBy == "node" {
+		sort.SliceStable(units, func(i, j int) bool {
+			return units[i].Node < units[j].Node
+		})
+	}
+	if c.json {
+		return formatter.JSON(context.Stdout, units)
+	}
+	if len(units) == 0 {
+		fmt.Fprintf(context.Stdout, "Pool %q has no units.\n", poolName)
+		return nil
+	}
+	table := tablecli.NewTable()
+	table.Headers = tablecli.Row{"App", "Unit", "Status", "Node"}
+	for _, u := range units {
+		table.AddRow(tablecli.Row{u.App, u.Unit, u.Status, u.Node})
+	}
+	fmt.Fprint(context.Stdout, table.String())
+	return nil
+}
+
+// poolUnits returns the units of all apps, sorted by app and unit name.
+func poolUnits(apps []app) []poolUnit {
+	units := []poolUnit{}
+	for _, a := range apps {
+		for _, u := range a.Units {
+			if u.ID == "" {
+				continue
+			}
+			units = append(units, poolUnit{
+				App:    a.Name,
+				Unit:   u.ID,
+				Status: u.ReadyAndStatus(),
+				Node:   u.Host(),
+			})
+		}
+	}
+	sort.Slice(units, func(i, j int) bool {
+		if units[i].App != units[j].App {
+			return units[i].App < units[j].App
+		}
+		return units[i].Unit < units[j].Unit
+	})
+	return units
+}
@@ -6,7 +6,10 @@ package client
 
 import (
 	"bytes"
+	"encoding/json"
+	"io"
 	"net/http"
+	"strings"
 
 	"github.com/tsuru/tsuru/cmd"
 	"github.com/tsuru/tsuru/cmd/cmdtest"
@@ -60,3 +63,545 @@ func (s *S) TestPoolListRunNoContent(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Assert(stdout.String(), check.Equals, expected)
 }
+
+func poolUsageTransport(failing string) *cmdtest.AnyConditionalTransport {
+	apps := map[string]string{
+		"pool1": `[{"name": "app1", "units": [{"ID": "app1-1"}, {"ID": "app1-2"}]}, {"name": "app2", "units": [{"ID": "app2-1"}]}]`,
+		"pool2": `[{"name": "app3"}]`,
+	}
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: `[{"Name":"pool2"},{"Name":"pool1"},{"Name":"pool3"}]`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.URL.Path == "/1.0/pools"
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Status: http.StatusNoContent},
+				CondFunc: func(req *http.Request) bool {
+					return req.URL.Path == "/1.0/apps" && req.URL.Query().Get("pool") == "pool3"
+				},
+			},
+		},
+	}
+	for pool, result := range apps {
+		pool, transport := pool, cmdtest.Transport{Message: result, Status: http.StatusOK}
+		if pool == failing {
+			transport = cmdtest.Transport{Message: "internal error", Status: http.StatusInternalServerError}
+		}
+		trans.ConditionalTransports = append(trans.ConditionalTransports, cmdtest.ConditionalTransport{
+			Transport: transport,
+			CondFunc: func(req *http.Request) bool {
+				return req.URL.Path == "/1.0/apps" && req.URL.Query().Get("pool") == pool
+			},
+		})
+	}
+	return trans
+}
+
+func (s *S) TestPoolListRunWithUsage(c *check.C) {
+	var stdout bytes.Buffer
+	context := cmd.Context{Args: []string{}, Stdout: &stdout}
+	s.setupFakeTransport(poolUsageTransport(""))
+	command := PoolList{}
+	err := command.Flags().Parse(true, []string{"--with-usage"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	expected := `+-------+------+-------------+-------+---------+------+-------+
+| Pool  | Kind | Provisioner | Teams | Routers | Apps | Units |
++-------+------+-------------+-------+---------+------+-------+
+| pool1 |      | default     |       |         | 2    | 3     |
++-------+------+-------------+-------+---------+------+-------+
+| pool2 |      | default     |       |         | 1    | 0     |
++-------+------+-------------+-------+---------+------+-------+
+| pool3 |      | default     |       |         | 0    | 0     |
++-------+------+-------------+-------+---------+------+-------+
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestPoolListRunWithUsageError(c *check.C) {
+	var stdout bytes.Buffer
+	context := cmd.Context{Args: []string{}, Stdout: &stdout}
+	s.setupFakeTransport(poolUsageTransport("pool2"))
+	command := PoolList{}
+	err := command.Flags().Parse(true, []string{"--with-usage"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `unable to list the apps in pool "pool2": .*internal error`)
+	c.Assert(stdout.String(), check.Equals, "")
+}
+
+func (s *S) TestPoolListRunColumns(c *check.C) {
+	var stdout bytes.Buffer
+	context := cmd.Context{Args: []string{}, Stdout: &stdout}
+	s.setupFakeTransport(poolUsageTransport(""))
+	command := PoolList{}
+	err := command.Flags().Parse(true, []string{"--columns", "units,name"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	expected := `+-------+-------+
+| Units | Pool  |
++-------+-------+
+| 3     | pool1 |
++-------+-------+
+| 0     | pool2 |
++-------+-------+
+| 0     | pool3 |
++-------+-------+
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestPoolListRunInvalidColumns(c *check.C) {
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: "[]", Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			c.Errorf("unexpected request to %s", req.URL)
+			return false
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := PoolList{}
+	err := command.Flags().Parse(true, []string{"--columns", "name,owner"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&cmd.Context{Stdout: io.Discard})
+	c.Assert(err, check.ErrorMatches, `unknown column "owner", valid columns are: name, kind, provisioner, teams, routers, apps, units`)
+	command = PoolList{}
+	err = command.Flags().Parse(true, []string{"--columns", "name", "--json"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&cmd.Context{Stdout: io.Discard})
+	c.Assert(err, check.ErrorMatches, "the --columns flag can't be used with --output json")
+}
+
+func (s *S) TestPoolListRunFormat(c *check.C) {
+	var stdout bytes.Buffer
+	result := `[{"Name":"pool2","Provisioner":"kubernetes","Allowed":{"team":["team1","team2"]}},{"Name":"pool1","Public":true}]`
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := PoolList{}
+	err := command.Flags().Parse(true, []string{"--format", `{{.Name}} {{.Kind}} {{.GetProvisioner}} {{join (index .Allowed "team") ","}}`})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&cmd.Context{Stdout: &stdout})
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "pool2  kubernetes team1,team2\npool1 public default \n")
+}
+
+func (s *S) TestPoolListRunInvalidFormat(c *check.C) {
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: "[]", Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			c.Errorf("unexpected request to %s", req.URL)
+			return false
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := PoolList{}
+	err := command.Flags().Parse(true, []string{"--format", "{{.Name}"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&cmd.Context{Stdout: io.Discard})
+	c.Assert(err, check.ErrorMatches, "invalid --format template: .*")
+	command = PoolList{}
+	err = command.Flags().Parse(true, []string{"--format", "{{.Name}}", "--json"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&cmd.Context{Stdout: io.Discard})
+	c.Assert(err, check.ErrorMatches, "the --format flag can't be used with --output json")
+}
+
+func (s *S) TestPoolListRunJSON(c *check.C) {
+	var stdout bytes.Buffer
+	context := cmd.Context{Args: []string{}, Stdout: &stdout}
+	result := `[{"Name": "pool2", "Provisioner": "kubernetes", "Allowed": {"team": ["team1"]}}, {"Name": "pool1", "Public": true}]`
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := PoolList{}
+	command.Flags().Parse(true, []string{"--json"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	var pools []Pool
+	err = json.Unmarshal(stdout.Bytes(), &pools)
+	c.Assert(err, check.IsNil)
+	c.Assert(pools, check.DeepEquals, []Pool{
+		{Name: "pool2", Provisioner: "kubernetes", Allowed: map[string][]string{"team": {"team1"}}},
+		{Name: "pool1", Public: true},
+	})
+}
+
+func (s *S) TestPoolListRunYAML(c *check.C) {
+	var stdout bytes.Buffer
+	context := cmd.Context{Args: []string{}, Stdout: &stdout}
+	result := `[{"Name": "pool2", "Provisioner": "kubernetes", "Allowed": {"team": ["team1"]}}, {"Name": "pool1", "Public": true}]`
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := PoolList{}
+	command.Flags().Parse(true, []string{"--output", "yaml"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	expected := `- Allowed:
+    team:
+    - team1
+  Default: false
+  Name: pool2
+  Provisioner: kubernetes
+  Public: false
+- Allowed: null
+  Default: false
+  Name: pool1
+  Provisioner: ""
+  Public: true
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestPoolListRunJSONNoContent(c *check.C) {
+	var stdout bytes.Buffer
+	context := cmd.Context{Args: []string{}, Stdout: &stdout}
+	s.setupFakeTransport(&cmdtest.Transport{Status: http.StatusNoContent})
+	command := PoolList{}
+	command.Flags().Parse(true, []string{"--json"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "[]\n")
+}
+
+func (s *S) TestPoolListRunJSONFilteredEmpty(c *check.C) {
+	var stdout bytes.Buffer
+	context := cmd.Context{Args: []string{}, Stdout: &stdout}
+	result := `[{"Name": "pool1", "Public": true}]`
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := PoolList{}
+	command.Flags().Parse(true, []string{"--json", "-n", "other"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "[]\n")
+}
+
+func (s *S) TestPoolListRunFilterByProvisioner(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Args: []string{}, Stdout: &stdout, Stderr: &stderr}
+	result := `[{"Name": "pool1", "Provisioner": "kubernetes"}, {"Name": "pool2", "Provisioner": "docker"}, {"Name": "pool3", "Provisioner": "swarm"}, {"Name": "pool4"}]`
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := PoolList{}
+	command.Flags().Parse(true, []string{"--provisioner", "Kubernetes, DOCKER", "-q"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "pool1\npool2\n")
+	c.Assert(stderr.String(), check.Equals, "")
+}
+
+func (s *S) TestPoolListRunFilterByProvisionerNoMatch(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Args: []string{}, Stdout: &stdout, Stderr: &stderr}
+	result := `[{"Name": "pool1", "Provisioner": "kubernetes"}]`
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := PoolList{}
+	command.Flags().Parse(true, []string{"--provisioner", "docker"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "")
+	c.Assert(stderr.String(), check.Equals, "no pools found for provisioner docker\n")
+}
+
+func (s *S) TestPoolInfoInfo(c *check.C) {
+	c.Assert((&PoolInfo{}).Info(), check.NotNil)
+}
+
+func (s *S) TestPoolInfoRun(c *check.C) {
+	var stdout bytes.Buffer
+	context := cmd.Context{Args: []string{"pool2"}, Stdout: &stdout}
+	result := `[{"Name": "pool1", "Public": true}, {"Name": "pool2", "Provisioner": "kubernetes", "Allowed": {"team": ["team1", "team2"]}}]`
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := PoolInfo{}
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	expected := `Name: pool2
+Kind: -
+Provisioner: kubernetes
+Default: false
+Public: false
+Teams: team1, team2
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestPoolInfoRunPublic(c *check.C) {
+	var stdout bytes.Buffer
+	context := cmd.Context{Args: []string{"pool1"}, Stdout: &stdout}
+	result := `[{"Name": "pool1", "Public": true}]`
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := PoolInfo{}
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	expected := `Name: pool1
+Kind: public
+Provisioner: default
+Default: false
+Public: true
+Teams: -
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestPoolInfoRunNotFound(c *check.C) {
+	var stdout bytes.Buffer
+	context := cmd.Context{Args: []string{"pool3"}, Stdout: &stdout}
+	s.setupFakeTransport(&cmdtest.Transport{Message: `[{"Name": "pool1"}]`, Status: http.StatusOK})
+	command := PoolInfo{}
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `pool "pool3" not found`)
+}
+
+func (s *S) TestPoolGrantInfo(c *check.C) {
+	c.Assert((&PoolGrant{}).Info(), check.NotNil)
+}
+
+func (s *S) TestPoolGrant(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"pool1", "newteam"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Stdin:  strings.NewReader("y\n"),
+	}
+	apps := `[
+	{"name": "app2", "pool": "pool1", "teamowner": "team1"},
+	{"name": "app1", "pool": "pool1", "teamowner": "team1", "teams": ["team1", "newteam"]},
+	{"name": "app3", "pool": "pool1", "teamowner": "team1"},
+	{"name": "app4", "pool": "pool1", "teamowner": "team1"}
+]`
+	var granted []string
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: apps, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.URL.Path == "/1.0/apps" && req.URL.Query().Get("pool") == "pool1"
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					if req.Method != http.MethodPut || req.URL.Path != "/1.0/apps/app2/teams/newteam" {
+						return false
+					}
+					granted = append(granted, "app2")
+					return true
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: "team already has access to this app", Status: http.StatusConflict},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodPut && req.URL.Path == "/1.0/apps/app3/teams/newteam"
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: "app is locked", Status: http.StatusInternalServerError},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodPut && req.URL.Path == "/1.0/apps/app4/teams/newteam"
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := PoolGrant{}
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "failed to grant access to 1 of 4 apps")
+	c.Assert(granted, check.DeepEquals, []string{"app2"})
+	expected := `Are you sure you want to grant team "newteam" access to 4 apps in pool "pool1"? (y/n) +------+-----------------------+
+| App  | Result                |
++------+-----------------------+
+| app1 | already had           |
+| app2 | granted               |
+| app3 | already had           |
+| app4 | failed: app is locked |
++------+-----------------------+
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestPoolGrantNoApps(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"pool1", "newteam"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	s.setupFakeTransport(&cmdtest.Transport{Status: http.StatusNoContent})
+	command := PoolGrant{}
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "Pool \"pool1\" has no apps.\n")
+}
+
+func (s *S) TestPoolUnitsInfo(c *check.C) {
+	c.Assert((&PoolUnits{}).Info(), check.NotNil)
+}
+
+const poolUnitsResult = `[
+	{"name": "web", "units": [
+		{"ID": "web-2", "Status": "started", "Address": {"Host": "10.0.0.2:8080"}},
+		{"ID": "web-1", "Status": "error", "StatusReason": "OOMKilled", "Address": {"Host": "10.0.0.1:8080"}}
+	]},
+	{"name": "api", "units": [{"ID": "api-1", "Status": "started", "Ready": true, "Address": {"Host": "10.0.0.2:8888"}}]}
+]`
+
+func (s *S) TestPoolUnits(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr, Args: []string{"pool1"}}
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: poolUnitsResult, Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return strings.HasSuffix(req.URL.Path, "/apps") && req.URL.Query().Get("pool") == "pool1"
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := PoolUnits{}
+	command.Flags().Parse(true, []string{"--sort", "node"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	expected := `+-----+-------+-------------------+----------+
+| App | Unit  | Status            | Node     |
++-----+-------+-------------------+----------+
+| web | web-1 | error (OOMKilled) | 10.0.0.1 |
+| api | api-1 | ready             | 10.0.0.2 |
+| web | web-2 | started           | 10.0.0.2 |
++-----+-------+-------------------+----------+
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestPoolUnitsJSON(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr, Args: []string{"pool1"}}
+	s.setupFakeTransport(&cmdtest.Transport{Message: poolUnitsResult, Status: http.StatusOK})
+	command := PoolUnits{}
+	command.Flags().Parse(true, []string{"--json"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	var units []poolUnit
+	err = json.Unmarshal(stdout.Bytes(), &units)
+	c.Assert(err, check.IsNil)
+	c.Assert(units, check.DeepEquals, []poolUnit{
+		{App: "api", Unit: "api-1", Status: "ready", Node: "10.0.0.2"},
+		{App: "web", Unit: "web-1", Status: "error (OOMKilled)", Node: "10.0.0.1"},
+		{App: "web", Unit: "web-2", Status: "started", Node: "10.0.0.2"},
+	})
+}
+
+func (s *S) TestPoolUnitsInvalidSort(c *check.C) {
+	context := cmd.Context{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}, Args: []string{"pool1"}}
+	command := PoolUnits{}
+	command.Flags().Parse(true, []string{"--sort", "status"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `invalid sort option "status", supported values are "app" and "node"`)
+}
+
+func poolRestartTransport(failing string, restarted *[]string) *cmdtest.AnyConditionalTransport {
+	return poolActionTransport("restart", failing, restarted)
+}
+
+// poolActionTransport serves the apps of pool1 and records the apps in which
+// the action, like "restart", is requested, failing for the given app.
+func poolActionTransport(action, failing string, called *[]string) *cmdtest.AnyConditionalTransport {
+	apps := `[{"name": "app3", "pool": "pool1"}, {"name": "app1", "pool": "pool1"}, {"name": "app2", "pool": "pool1"}]`
+	return &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: apps, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.URL.Path == "/1.0/apps" && req.URL.Query().Get("pool") == "pool1"
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: "app is locked", Status: http.StatusInternalServerError},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodPost && req.URL.Path == "/1.0/apps/"+failing+"/"+action
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: `{"Message": "done\n"}`, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					if req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/"+action) {
+						return false
+					}
+					*called = append(*called, strings.Split(req.URL.Path, "/")[3])
+					return true
+				},
+			},
+		},
+	}
+}
+
+func (s *S) TestPoolRestartInfo(c *check.C) {
+	c.Assert((&PoolRestart{}).Info(), check.NotNil)
+}
+
+func (s *S) TestPoolRestart(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"pool1"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	var restarted []string
+	s.setupFakeTransport(poolRestartTransport("", &restarted))
+	command := PoolRestart{}
+	command.Flags().Parse(true, []string{"-y"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(restarted, check.DeepEquals, []string{"app1", "app2", "app3"})
+	c.Assert(stdout.String(), check.Equals, `[1/3] app1: restarted
+[2/3] app2: restarted
+[3/3] app3: restarted
+All 3 apps in pool "pool1" were restarted.
+`)
+}
+
+func (s *S) TestPoolRestartStopsOnFailure(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"pool1"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	var restarted []string
+	s.setupFakeTransport(poolRestartTransport("app2", &restarted))
+	command := PoolRestart{}
+	command.Flags().Parse(true, []string{"-y"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "failed to restart 1 of 3 apps")
+	c.Assert(restarted, check.DeepEquals, []string{"app1"})
+	c.Assert(stdout.String(), check.Equals, `[1/3] app1: restarted
+[2/3] app2: failed: app is locked
+`)
+	c.Assert(stderr.String(), check.Equals, "Stopped after the first failure, 1 apps were not restarted. Use --continue-on-error to restart all apps.\n")
+}
+
+func (s *S) TestPoolRestartContinueOnError(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"pool1"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	var restarted []string
+	s.setupFakeTransport(poolRestartTransport("app2", &restarted))
+	command := PoolRestart{}
+	command.Flags().Parse(true, []string{"-y", "--continue-on-error"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "failed to restart 1 of 3 apps")
+	c.Assert(restarted, check.DeepEquals, []string{"app1", "app3"})
+	c.Assert(stdout.String(), check.Equals, `[1/3] app1: restarted
+[2/3] app2: failed: app is locked
+[3/3] app3: restarted
+`)
+	c.Assert(stderr.String(), check.Equals, "")
+}
+
+func (s *S) TestPoolRestartInvalidConcurrency(c *check.C) {
+	context := cmd.Context{Args: []string{"pool1"}}
+	command := PoolRestart{}
+	command.Flags().Parse(true, []string{"-y", "--concurrency", "0"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "the concurrency must be greater than zero")
+}
@@ -0,0 +1,182 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tsuru/gnuflag"
+	tsuruClientApp "github.com/tsuru/tsuru-client/tsuru/app"
+	"github.com/tsuru/tsuru/cmd"
+)
+
+// pollSleep waits between the checks of pollApp.
+var pollSleep = time.Sleep
+
+// errPollTimeout is returned by pollApp when the app is not done within the
+// timeout.
+var errPollTimeout = errors.New("timeout waiting for the app")
+
+// pollApp gets the app every interval and calls check with it, until check
+// reports that it's done or returns an error. When the app is not done within
+// the timeout, errPollTimeout is returned, which callers replace with an error
+// describing the last check.
+func pollApp(appName string, interval, timeout time.Duration, check func(a *app) (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		a, err := getApp(appName)
+		if err != nil {
+			return err
+		}
+		done, err := check(a)
+		if done || err != nil {
+			return err
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return errPollTimeout
+		}
+		pollSleep(interval)
+	}
+}
+
+type AppRolloutWatch struct {
+	tsuruClientApp.AppNameMixIn
+	fs          *gnuflag.FlagSet
+	expected    int
+	maxFailures int
+	interval    time.Duration
+	timeout     time.Duration
+	noColor     bool
+}
+
+func (c *AppRolloutWatch) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "app-rollout-watch",
+		Usage: "app rollout watch [-a/--app appname] [--expected units] [--max-failures units] [--interval duration] [--timeout duration] [--no-color]",
+		Desc: `Watches the units of an application during a deploy, printing each status
+transition, like starting -> started, and how many units are started.
+
+The command finishes when all units are started. By default, the expected
+number of units is the number of units of the app when the command starts, use
+[[--expected]] to wait for a different number of units. The command fails when
+more than [[--max-failures]] units are in the error state, or when the units
+aren't started within the time defined by [[--timeout]].`,
+		MinArgs: 0,
+		MaxArgs: 0,
+	}
+}
+
+func (c *AppRolloutWatch) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = c.AppNameMixIn.Flags()
+		c.fs.IntVar(&c.expected, "expected", 0, "The number of units expected to be started")
+		c.fs.IntVar(&c.maxFailures, "max-failures", 0, "The number of units in the error state tolerated before failing")
+		c.fs.DurationVar(&c.interval, "interval", 2*time.Second, "How often the units are checked")
+		c.fs.DurationVar(&c.timeout, "timeout", 10*time.Minute, "How long to wait for the units to be started")
+		c.fs.BoolVar(&c.noColor, "no-color", false, "No colors in the output")
+	}
+	return c.fs
+}
+
+func (c *AppRolloutWatch) Run(context *cmd.Context) error {
+	appName, err := c.AppNameByFlag()
+	if err != nil {
+		return err
+	}
+	if c.expected < 0 || c.maxFailures < 0 {
+		return errors.New("the number of units must not be negative")
+	}
+	color := useColors(context.Stdout, c.noColor)
+	previous := map[string]string{}
+	lastSummary := ""
+	started := 0
+	err = pollApp(appName, c.interval, c.timeout, func(a *app) (bool, error) {
+		statuses := unitStatuses(a)
+		if c.expected == 0 {
+			c.expected = len(statuses)
+		}
+		printUnitTransitions(context.Stdout, previous, statuses, color)
+		previous = statuses
+		failed := 0
+		started = 0
+		for _, status := range statuses {
+			switch unitStatusName(status) {
+			case "started":
+				started++
+			case "error":
+				failed++
+			}
+		}
+		summary := fmt.Sprintf("%d/%d units started", started, c.expected)
+		if failed > 0 {
+			summary += fmt.Sprintf(", %d failed", failed)
+		}
+		if summary != lastSummary {
+			fmt.Fprintln(context.Stdout, summary+".")
+			lastSummary = summary
+		}
+		if failed > c.maxFailures {
+			return false, fmt.Errorf("%d units of app %q failed", failed, appName)
+		}
+		if c.expected > 0 && started >= c.expected && started == len(statuses) {
+			fmt.Fprintf(context.Stdout, "All %d units of app %q are started.\n", started, appName)
+			return true, nil
+		}
+		return false, nil
+	})
+	if err == errPollTimeout {
+		return fmt.Errorf("only %d of %d units of app %q are started after %s", started, c.expected, appName, c.timeout)
+	}
+	return err
+}
+
+// unitStatuses returns the status of each unit of the app, by unit name.
+func unitStatuses(a *app) map[string]string {
+	statuses := map[string]string{}
+	for _, u := range a.Units {
+		if u.ID != "" {
+			statuses[u.ID] = u.Status
+		}
+	}
+	return statuses
+}
+
+// unitStatusName returns the status without its reason, like "error" for
+// "error (CrashLoopBackOff)".
+func unitStatusName(status string) string {
+	return strings.SplitN(status, " ", 2)[0]
+}
+
+// printUnitTransitions prints the units that were added, removed or had their
+// status changed between two checks, sorted by unit name.
+func printUnitTransitions(w io.Writer, previous, current map[string]string, color bool) {
+	var names []string
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	for name := range current {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		before, existed := previous[name]
+		after, exists := current[name]
+		switch {
+		case !existed:
+			fmt.Fprintf(w, "%s: %s\n", name, colorUnitStatus(after, color))
+		case !exists:
+			fmt.Fprintf(w, "%s: removed\n", name)
+		case before != after:
+			fmt.Fprintf(w, "%s: %s -> %s\n", name, colorUnitStatus(before, color), colorUnitStatus(after, color))
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tsuru/tsuru/cmd"
+	"github.com/tsuru/tsuru/cmd/cmdtest"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) TestAppRolloutWatchInfo(c *check.C) {
+	c.Assert((&AppRolloutWatch{}).Info(), check.NotNil)
+}
+
+func rolloutTransport(results ...string) *cmdtest.MultiConditionalTransport {
+	trans := &cmdtest.MultiConditionalTransport{}
+	for _, result := range results {
+		trans.ConditionalTransports = append(trans.ConditionalTransports, cmdtest.ConditionalTransport{
+			Transport: cmdtest.Transport{Message: result, Status: http.StatusOK},
+			CondFunc: func(req *http.Request) bool {
+				return strings.HasSuffix(req.URL.Path, "/apps/myapp")
+			},
+		})
+	}
+	return trans
+}
+
+func (s *S) TestAppRolloutWatch(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	var sleeps int
+	oldSleep := pollSleep
+	defer func() { pollSleep = oldSleep }()
+	pollSleep = func(time.Duration) { sleeps++ }
+	s.setupFakeTransport(rolloutTransport(
+		`{"name": "myapp", "units": [{"ID": "myapp-web-1", "Status": "starting"}, {"ID": "myapp-web-2", "Status": "starting"}]}`,
+		`{"name": "myapp", "units": [{"ID": "myapp-web-1", "Status": "started"}, {"ID": "myapp-web-2", "Status": "starting"}]}`,
+		`{"name": "myapp", "units": [{"ID": "myapp-web-1", "Status": "started"}, {"ID": "myapp-web-2", "Status": "started"}]}`,
+	))
+	command := AppRolloutWatch{}
+	command.Flags().Parse(true, []string{"-a", "myapp"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(sleeps, check.Equals, 2)
+	expected := `myapp-web-1: starting
+myapp-web-2: starting
+0/2 units started.
+myapp-web-1: starting -> started
+1/2 units started.
+myapp-web-2: starting -> started
+2/2 units started.
+All 2 units of app "myapp" are started.
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestAppRolloutWatchFailures(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	oldSleep := pollSleep
+	defer func() { pollSleep = oldSleep }()
+	pollSleep = func(time.Duration) {}
+	s.setupFakeTransport(rolloutTransport(
+		`{"name": "myapp", "units": [{"ID": "myapp-web-1", "Status": "starting"}, {"ID": "myapp-web-2", "Status": "starting"}]}`,
+		`{"name": "myapp", "units": [{"ID": "myapp-web-1", "Status": "error (CrashLoopBackOff)"}, {"ID": "myapp-web-2", "Status": "starting"}]}`,
+	))
+	command := AppRolloutWatch{}
+	command.Flags().Parse(true, []string{"-a", "myapp", "--expected", "3"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `1 units of app "myapp" failed`)
+	expected := `myapp-web-1: starting
+myapp-web-2: starting
+0/3 units started.
+myapp-web-1: starting -> error (CrashLoopBackOff)
+0/3 units started, 1 failed.
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestAppRolloutWatchTimeout(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	s.setupFakeTransport(rolloutTransport(
+		`{"name": "myapp", "units": [{"ID": "myapp-web-1", "Status": "starting"}]}`,
+	))
+	command := AppRolloutWatch{}
+	command.Flags().Parse(true, []string{"-a", "myapp", "--timeout", "1s"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `only 0 of 1 units of app "myapp" are started after 1s`)
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -22,20 +23,31 @@ import (
 
 type AppRun struct {
 	tsuruClientApp.AppNameMixIn
-	fs       *gnuflag.FlagSet
-	once     bool
-	isolated bool
+	fs         *gnuflag.FlagSet
+	once       bool
+	isolated   bool
+	unbuffered bool
 }
 
 func (c *AppRun) Info() *cmd.Info {
 	desc := `Runs an arbitrary command in application's containers. The base directory for
 all commands is the root of the application.
 
-If you use the [[--once]] flag tsuru will run the command only in one unit.
-Otherwise, it will run the command in all units.`
+If you use the [[--once]] flag tsuru will run the command only in one unit,
+which is what tasks like database migrations need. Otherwise, it will run the
+command in all units. The unit, or the process of the units, where the command
+runs can't be chosen. To run a command in a given unit, open a shell in it with
+[[app shell <unit-id>]].
+
+When the command fails, app run fails too, exiting with the exit code of the
+command when the unit reports it, or with 1 otherwise, so it can be used in CI
+pipelines. When the command runs in all units, the first failure is reported.
+
+If you use the [[--unbuffered]] flag the command output is line buffered, using
+stdbuf when it's available in the unit, and shown as soon as it's received.`
 	return &cmd.Info{
 		Name:    "app-run",
-		Usage:   "app run <command> [commandarg1] [commandarg2] ... [commandargn] [-a/--app appname] [-o/--once] [-i/--isolated]",
+		Usage:   "app run <command> [commandarg1] [commandarg2] ... [commandargn] [-a/--app appname] [-o/--once] [-i/--isolated] [--unbuffered]",
 		Desc:    desc,
 		MinArgs: 1,
 	}
@@ -51,8 +63,14 @@ func (c *AppRun) Run(context *cmd.Context) error {
 	if err != nil {
 		return err
 	}
+	command := strings.Join(context.Args, " ")
+	if c.unbuffered {
+		command = unbufferedCommand(command)
+	}
+	// The run API only accepts these options, there's no way to select the
+	// unit or the process where the command runs.
 	v := url.Values{}
-	v.Set("command", strings.Join(context.Args, " "))
+	v.Set("command", command)
 	v.Set("once", strconv.FormatBool(c.once))
 	v.Set("isolated", strconv.FormatBool(c.isolated))
 	b := strings.NewReader(v.Encode())
@@ -61,16 +79,20 @@ func (c *AppRun) Run(context *cmd.Context) error {
 		return err
 	}
 	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	r, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	r, err := tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
 	if err != nil {
 		return err
 	}
 	defer r.Body.Close()
-	w := tsuruIo.NewStreamWriter(context.Stdout, &tsuruIo.SimpleJsonMessageFormatter{NoTimestamp: true})
+	stdout := context.Stdout
+	if c.unbuffered {
+		stdout = &flushWriter{w: stdout}
+	}
+	w := tsuruIo.NewStreamWriter(stdout, &tsuruIo.SimpleJsonMessageFormatter{NoTimestamp: true})
 	for n := int64(1); n > 0 && err == nil; n, err = io.Copy(w, r.Body) {
 	}
 	if err != nil {
-		return err
+		return remoteExitError(err)
 	}
 	unparsed := w.Remaining()
 	if len(unparsed) > 0 {
@@ -82,10 +104,73 @@ func (c *AppRun) Run(context *cmd.Context) error {
 func (c *AppRun) Flags() *gnuflag.FlagSet {
 	if c.fs == nil {
 		c.fs = c.AppNameMixIn.Flags()
-		c.fs.BoolVar(&c.once, "once", false, "Running only one unit")
-		c.fs.BoolVar(&c.once, "o", false, "Running only one unit")
+		c.fs.BoolVar(&c.once, "once", false, "Run the command in only one unit")
+		c.fs.BoolVar(&c.once, "o", false, "Run the command in only one unit")
 		c.fs.BoolVar(&c.isolated, "isolated", false, "Running in ephemeral container")
 		c.fs.BoolVar(&c.isolated, "i", false, "Running in ephemeral container")
+		c.fs.BoolVar(&c.unbuffered, "unbuffered", false, "Show the output of the command as soon as it's written")
 	}
 	return c.fs
 }
+
+// runExitCodeRE matches the exit code of the command in the errors reported
+// by the units, like "command terminated with exit code 2" or "exit status 2".
+var runExitCodeRE = regexp.MustCompile(`exit (?:code|status):? (\d+)`)
+
+// runExitError is a failure of the command run in the units, with the exit
+// code of the command, which the client exits with.
+type runExitError struct {
+	message string
+	code    int
+}
+
+func (e *runExitError) Error() string {
+	return e.message
+}
+
+// ExitCode returns the exit code of the command run in the units.
+func (e *runExitError) ExitCode() int {
+	return e.code
+}
+
+// remoteExitError returns err along with the exit code of the command, when
+// the error streamed by the API reports it.
+func remoteExitError(err error) error {
+	match := runExitCodeRE.FindStringSubmatch(err.Error())
+	if match == nil {
+		return err
+	}
+	code, convErr := strconv.Atoi(match[1])
+	if convErr != nil || code < 1 || code > 255 {
+		return err
+	}
+	return &runExitError{message: err.Error(), code: code}
+}
+
+// unbufferedCommand wraps command so its output is line buffered, using
+// stdbuf when it's available in the unit.
+func unbufferedCommand(command string) string {
+	quoted := shellQuote(command)
+	return fmt.Sprintf("if command -v stdbuf >/dev/null 2>&1; then exec stdbuf -oL -eL sh -c %s; else exec sh -c %s; fi", quoted, quoted)
+}
+
+type flusher interface {
+	Flush() error
+}
+
+// flushWriter flushes the underlying writer after each write, when it's
+// buffered.
+type flushWriter struct {
+	w io.Writer
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	if fl, ok := f.w.(flusher); ok {
+		err = fl.Flush()
+	}
+	return n, err
+}
@@ -7,6 +7,7 @@ package client
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -179,7 +180,112 @@ func (s *S) TestAppRunShouldReturnErrorWhenCommandGoWrong(c *check.C) {
 	c.Assert(err, check.ErrorMatches, "command doesn't exist.")
 }
 
+func (s *S) TestAppRunReturnsExitCodeOfTheCommand(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	output, err := json.Marshal(io.SimpleJsonMessage{Message: "running migrations\n"})
+	c.Assert(err, check.IsNil)
+	failure, err := json.Marshal(io.SimpleJsonMessage{Error: "command terminated with exit code 3"})
+	c.Assert(err, check.IsNil)
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{
+			Message: string(output) + "\n" + string(failure) + "\n",
+			Status:  http.StatusOK,
+		},
+		CondFunc: func(req *http.Request) bool {
+			return strings.HasSuffix(req.URL.Path, "/apps/bla/run") && req.FormValue("once") == "true"
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppRun{}
+	err = command.Flags().Parse(true, []string{"-a", "bla", "--once", "migrate"})
+	c.Assert(err, check.IsNil)
+	context.Args = command.Flags().Args()
+	err = command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "command terminated with exit code 3")
+	exitErr, ok := err.(*runExitError)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(exitErr.ExitCode(), check.Equals, 3)
+	c.Assert(stdout.String(), check.Equals, "running migrations\n")
+}
+
+func (s *S) TestRemoteExitError(c *check.C) {
+	tests := []struct {
+		message string
+		code    int
+	}{
+		{"command terminated with exit code 2", 2},
+		{"exit status 127", 127},
+		{"error running command: exit status: 1", 1},
+		{"command doesn't exist.", 0},
+		{"exit status 0", 0},
+		{"exit code 300", 0},
+	}
+	for _, tt := range tests {
+		err := remoteExitError(errors.New(tt.message))
+		c.Check(err, check.ErrorMatches, tt.message)
+		exitErr, ok := err.(*runExitError)
+		c.Check(ok, check.Equals, tt.code != 0, check.Commentf("%q", tt.message))
+		if ok {
+			c.Check(exitErr.ExitCode(), check.Equals, tt.code)
+		}
+	}
+}
+
 func (s *S) TestAppRunInfo(c *check.C) {
 	command := AppRun{}
 	c.Assert(command.Info(), check.NotNil)
 }
+
+func (s *S) TestAppRunFlagUnbuffered(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	expected := "processing item 1"
+	context := cmd.Context{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	msg := io.SimpleJsonMessage{Message: expected}
+	result, err := json.Marshal(msg)
+	c.Assert(err, check.IsNil)
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{
+			Message: string(result),
+			Status:  http.StatusOK,
+		},
+		CondFunc: func(req *http.Request) bool {
+			cmd := req.FormValue("command") == `if command -v stdbuf >/dev/null 2>&1; then exec stdbuf -oL -eL sh -c 'python worker.py'; else exec sh -c 'python worker.py'; fi`
+			path := strings.HasSuffix(req.URL.Path, "/apps/ble/run")
+			return path && cmd
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := AppRun{}
+	err = command.Flags().Parse(true, []string{"--app", "ble", "--unbuffered", "python", "worker.py"})
+	c.Assert(err, check.IsNil)
+	context.Args = command.Flags().Args()
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+type flushRecorder struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (r *flushRecorder) Flush() error {
+	r.flushes++
+	return nil
+}
+
+func (s *S) TestFlushWriter(c *check.C) {
+	var rec flushRecorder
+	w := &flushWriter{w: &rec}
+	w.Write([]byte("a"))
+	w.Write([]byte("b"))
+	c.Assert(rec.String(), check.Equals, "ab")
+	c.Assert(rec.flushes, check.Equals, 2)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/tsuru/go-tsuruclient/pkg/config"
 	"github.com/tsuru/go-tsuruclient/pkg/tsuru"
 	"github.com/tsuru/tablecli"
+	tsuruClientApp "github.com/tsuru/tsuru-client/tsuru/app"
 	"github.com/tsuru/tsuru-client/tsuru/formatter"
 	tsuruHTTP "github.com/tsuru/tsuru-client/tsuru/http"
 	"github.com/tsuru/tsuru/cmd"
@@ -112,7 +113,7 @@ func (s ServiceList) Run(ctx *cmd.Context) error {
 	if err != nil {
 		return err
 	}
-	resp, err := tsuruHTTP.AuthenticatedClient.Do(req)
+	resp, err := tsuruHTTP.DoWithRetries(req)
 	if err != nil {
 		return err
 	}
@@ -449,7 +450,7 @@ type ServiceInstanceBind struct {
 func (sb *ServiceInstanceBind) Run(ctx *cmd.Context) error {
 	ctx.RawOutput()
 
-	err := checkAppAndJobInputs(sb.appName, sb.jobName)
+	err := checkAppAndJobInputs(&sb.appName, sb.jobName)
 	if err != nil {
 		return err
 	}
@@ -465,22 +466,26 @@ func (sb *ServiceInstanceBind) Run(ctx *cmd.Context) error {
 		path = "/services/" + serviceName + "/instances/" + instanceName + "/jobs/" + sb.jobName
 	}
 
-	u, err := config.GetURLVersion(apiVersion, path)
+	resp, err := requestServiceInstanceBind(apiVersion, path, sb.noRestart)
 	if err != nil {
 		return err
 	}
+	return formatter.StreamJSONResponse(ctx.Stdout, resp)
+}
+
+func requestServiceInstanceBind(apiVersion, path string, noRestart bool) (*http.Response, error) {
+	u, err := config.GetURLVersion(apiVersion, path)
+	if err != nil {
+		return nil, err
+	}
 	v := url.Values{}
-	v.Set("noRestart", strconv.FormatBool(sb.noRestart))
+	v.Set("noRestart", strconv.FormatBool(noRestart))
 	request, err := http.NewRequest("PUT", u, strings.NewReader(v.Encode()))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	resp, err := tsuruHTTP.AuthenticatedClient.Do(request)
-	if err != nil {
-		return err
-	}
-	return formatter.StreamJSONResponse(ctx.Stdout, resp)
+	return tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
 }
 
 func (sb *ServiceInstanceBind) Info() *cmd.Info {
@@ -521,20 +526,23 @@ type ServiceInstanceUnbind struct {
 func (su *ServiceInstanceUnbind) Run(ctx *cmd.Context) error {
 	ctx.RawOutput()
 
-	err := checkAppAndJobInputs(su.appName, su.jobName)
+	err := checkAppAndJobInputs(&su.appName, su.jobName)
 	if err != nil {
 		return err
 	}
 
-	serviceName := ctx.Args[0]
-	instanceName := ctx.Args[1]
+	return unbindServiceInstance(ctx.Stdout, ctx.Args[0], ctx.Args[1], su.appName, su.jobName, su.noRestart, su.force)
+}
 
+// unbindServiceInstance unbinds the app or the job from the service instance,
+// streaming the output of the unbind to w.
+func unbindServiceInstance(w io.Writer, serviceName, instanceName, appName, jobName string, noRestart, force bool) error {
 	var path string
 	apiVersion := "1.13"
-	if su.appName != "" {
-		path = "/services/" + serviceName + "/instances/" + instanceName + "/apps/" + su.appName
+	if appName != "" {
+		path = "/services/" + serviceName + "/instances/" + instanceName + "/apps/" + appName
 	} else {
-		path = "/services/" + serviceName + "/instances/" + instanceName + "/jobs/" + su.jobName
+		path = "/services/" + serviceName + "/instances/" + instanceName + "/jobs/" + jobName
 	}
 
 	u, err := config.GetURLVersion(apiVersion, path)
@@ -546,14 +554,14 @@ func (su *ServiceInstanceUnbind) Run(ctx *cmd.Context) error {
 		return err
 	}
 	query := url.Values{}
-	query.Set("noRestart", strconv.FormatBool(su.noRestart))
-	query.Set("force", strconv.FormatBool(su.force))
+	query.Set("noRestart", strconv.FormatBool(noRestart))
+	query.Set("force", strconv.FormatBool(force))
 	request.URL.RawQuery = query.Encode()
-	resp, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	resp, err := tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
 	if err != nil {
 		return err
 	}
-	return formatter.StreamJSONResponse(ctx.Stdout, resp)
+	return formatter.StreamJSONResponse(w, resp)
 }
 
 func (su *ServiceInstanceUnbind) Info() *cmd.Info {
@@ -581,6 +589,94 @@ func (su *ServiceInstanceUnbind) Flags() *gnuflag.FlagSet {
 	return su.fs
 }
 
+type ServiceInstanceUnbindAll struct {
+	tsuruClientApp.AppNameMixIn
+	cmd.ConfirmationCommand
+	fs              *gnuflag.FlagSet
+	noRestart       bool
+	force           bool
+	continueOnError bool
+}
+
+func (c *ServiceInstanceUnbindAll) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "service-instance-unbind-all",
+		Usage: "service instance unbind all [-a/--app appname] [--no-restart] [--force] [--continue-on-error] [-y/--assume-yes]",
+		Desc: `Unbinds an application from all the service instances bound to it, which is
+useful before removing the application.
+
+The instances are unbound one at a time, displaying the output of each unbind.
+The command stops on the first failure, unless [[--continue-on-error]] is used.
+
+As in [[tsuru service-instance-unbind]], [[--force]] makes the tsuru server
+remove each bind even if the unbind API call to the service fails, which may
+leave resources behind in the service.
+
+The number of bound instances is displayed and must be confirmed before
+proceeding, unless [[--assume-yes]] is used.`,
+		MinArgs: 0,
+		MaxArgs: 0,
+	}
+}
+
+func (c *ServiceInstanceUnbindAll) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = mergeFlagSet(c.AppNameMixIn.Flags(), c.ConfirmationCommand.Flags())
+		c.fs.BoolVar(&c.noRestart, "no-restart", false, "Unbinds the application from the service instances without restarting it")
+		c.fs.BoolVar(&c.force, "force", false, "Forces each unbind even if the unbind API call to the service fails")
+		c.fs.BoolVar(&c.continueOnError, "continue-on-error", false, "Keep unbinding the remaining instances when an unbind fails")
+	}
+	return c.fs
+}
+
+func (c *ServiceInstanceUnbindAll) Run(ctx *cmd.Context) error {
+	ctx.RawOutput()
+	appName, err := c.AppNameByFlag()
+	if err != nil {
+		return err
+	}
+	a, err := getApp(appName)
+	if err != nil {
+		return err
+	}
+	binds := make([]tsuru.AppServiceInstanceBinds, len(a.ServiceInstanceBinds))
+	copy(binds, a.ServiceInstanceBinds)
+	if len(binds) == 0 {
+		fmt.Fprintf(ctx.Stdout, "App %q has no service instances bound.\n", appName)
+		return nil
+	}
+	sort.Slice(binds, func(i, j int) bool {
+		if binds[i].Service != binds[j].Service {
+			return binds[i].Service < binds[j].Service
+		}
+		return binds[i].Instance < binds[j].Instance
+	})
+	if !c.Confirm(ctx, fmt.Sprintf("Are you sure you want to unbind %d service instances from app %q?", len(binds), appName)) {
+		return nil
+	}
+	var failures int
+	for i, b := range binds {
+		fmt.Fprintf(ctx.Stdout, "[%d/%d] Unbinding %s/%s:\n", i+1, len(binds), b.Service, b.Instance)
+		err = unbindServiceInstance(ctx.Stdout, b.Service, b.Instance, appName, "", c.noRestart, c.force)
+		if err == nil {
+			continue
+		}
+		failures++
+		fmt.Fprintf(ctx.Stderr, "Failed to unbind %s/%s: %v\n", b.Service, b.Instance, tsuruHTTP.UnwrapErr(err))
+		if !c.continueOnError {
+			if remaining := len(binds) - i - 1; remaining > 0 {
+				fmt.Fprintf(ctx.Stderr, "Stopped after the first failure, %d service instances were not unbound. Use --continue-on-error to unbind the remaining instances.\n", remaining)
+			}
+			break
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("failed to unbind %d of %d service instances", failures, len(binds))
+	}
+	fmt.Fprintf(ctx.Stdout, "All %d service instances were unbound from app %q.\n", len(binds), appName)
+	return nil
+}
+
 type ServiceInstanceInfo struct {
 	fs   *gnuflag.FlagSet
 	json bool
@@ -1171,7 +1267,7 @@ func (c *ServiceInstanceRemove) Run(ctx *cmd.Context) error {
 	if err != nil {
 		return err
 	}
-	resp, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	resp, err := tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
 	if err != nil {
 		return err
 	}
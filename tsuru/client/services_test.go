@@ -567,6 +567,118 @@ func (s *S) TestServiceInstanceJobUnbindWithRequestFailure(c *check.C) {
 	c.Assert(tsuruHTTP.UnwrapErr(err).Error(), check.Equals, trans.Message)
 }
 
+func unbindAllTransport(failing string, unbound *[]string) *cmdtest.AnyConditionalTransport {
+	appInfo := `{"name":"pocket","serviceInstanceBinds":[{"service":"redisapi","instance":"myredis"},{"service":"mongodb","instance":"mymongo"},{"service":"mongodb","instance":"logs"}]}`
+	msg, _ := json.Marshal(tsuruIo.SimpleJsonMessage{Message: "unbound\n"})
+	return &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: appInfo, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodGet && req.URL.Path == "/1.0/apps/pocket"
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: "service is down", Status: http.StatusInternalServerError},
+				CondFunc: func(req *http.Request) bool {
+					return req.Method == http.MethodDelete && req.URL.Path == "/1.13/services/"+failing+"/apps/pocket"
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: string(msg), Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					if req.Method != http.MethodDelete {
+						return false
+					}
+					*unbound = append(*unbound, req.URL.Path+"?"+req.URL.RawQuery)
+					return true
+				},
+			},
+		},
+	}
+}
+
+func (s *S) TestServiceInstanceUnbindAll(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	var unbound []string
+	s.setupFakeTransport(unbindAllTransport("", &unbound))
+	command := ServiceInstanceUnbindAll{}
+	err := command.Flags().Parse(true, []string{"-a", "pocket", "--no-restart", "-y"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&cmd.Context{Stdout: &stdout, Stderr: &stderr})
+	c.Assert(err, check.IsNil)
+	c.Assert(unbound, check.DeepEquals, []string{
+		"/1.13/services/mongodb/instances/logs/apps/pocket?force=false&noRestart=true",
+		"/1.13/services/mongodb/instances/mymongo/apps/pocket?force=false&noRestart=true",
+		"/1.13/services/redisapi/instances/myredis/apps/pocket?force=false&noRestart=true",
+	})
+	expected := `[1/3] Unbinding mongodb/logs:
+unbound
+[2/3] Unbinding mongodb/mymongo:
+unbound
+[3/3] Unbinding redisapi/myredis:
+unbound
+All 3 service instances were unbound from app "pocket".
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestServiceInstanceUnbindAllStopsOnFailure(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	var unbound []string
+	s.setupFakeTransport(unbindAllTransport("mongodb/instances/logs", &unbound))
+	command := ServiceInstanceUnbindAll{}
+	err := command.Flags().Parse(true, []string{"-a", "pocket", "-y"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&cmd.Context{Stdout: &stdout, Stderr: &stderr})
+	c.Assert(err, check.ErrorMatches, "failed to unbind 1 of 3 service instances")
+	c.Assert(unbound, check.HasLen, 0)
+	c.Assert(stderr.String(), check.Equals, "Failed to unbind mongodb/logs: service is down\nStopped after the first failure, 2 service instances were not unbound. Use --continue-on-error to unbind the remaining instances.\n")
+}
+
+func (s *S) TestServiceInstanceUnbindAllContinueOnError(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	var unbound []string
+	s.setupFakeTransport(unbindAllTransport("mongodb/instances/logs", &unbound))
+	command := ServiceInstanceUnbindAll{}
+	err := command.Flags().Parse(true, []string{"-a", "pocket", "-y", "--continue-on-error"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&cmd.Context{Stdout: &stdout, Stderr: &stderr})
+	c.Assert(err, check.ErrorMatches, "failed to unbind 1 of 3 service instances")
+	c.Assert(unbound, check.DeepEquals, []string{
+		"/1.13/services/mongodb/instances/mymongo/apps/pocket?force=false&noRestart=false",
+		"/1.13/services/redisapi/instances/myredis/apps/pocket?force=false&noRestart=false",
+	})
+	c.Assert(stderr.String(), check.Equals, "Failed to unbind mongodb/logs: service is down\n")
+}
+
+func (s *S) TestServiceInstanceUnbindAllForce(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	var unbound []string
+	s.setupFakeTransport(unbindAllTransport("", &unbound))
+	command := ServiceInstanceUnbindAll{}
+	err := command.Flags().Parse(true, []string{"-a", "pocket", "-y", "--force"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&cmd.Context{Stdout: &stdout, Stderr: &stderr})
+	c.Assert(err, check.IsNil)
+	c.Assert(unbound, check.DeepEquals, []string{
+		"/1.13/services/mongodb/instances/logs/apps/pocket?force=true&noRestart=false",
+		"/1.13/services/mongodb/instances/mymongo/apps/pocket?force=true&noRestart=false",
+		"/1.13/services/redisapi/instances/myredis/apps/pocket?force=true&noRestart=false",
+	})
+}
+
+func (s *S) TestServiceInstanceUnbindAllNoBinds(c *check.C) {
+	var stdout bytes.Buffer
+	s.setupFakeTransport(&cmdtest.Transport{Message: `{"name":"pocket"}`, Status: http.StatusOK})
+	command := ServiceInstanceUnbindAll{}
+	err := command.Flags().Parse(true, []string{"-a", "pocket"})
+	c.Assert(err, check.IsNil)
+	err = command.Run(&cmd.Context{Stdout: &stdout})
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "App \"pocket\" has no service instances bound.\n")
+}
+
 func (s *S) TestServiceInstanceUnbindInfo(c *check.C) {
 	c.Assert((&ServiceInstanceUnbind{}).Info(), check.NotNil)
 }
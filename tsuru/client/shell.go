@@ -5,14 +5,19 @@
 package client
 
 import (
+	"bytes"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
+	"net"
 	"net/url"
 	"os"
 	"os/signal"
 	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"syscall"
 
 	"github.com/tsuru/gnuflag"
@@ -20,6 +25,7 @@ import (
 	tsuruClientApp "github.com/tsuru/tsuru-client/tsuru/app"
 	tsuruHTTP "github.com/tsuru/tsuru-client/tsuru/http"
 	"github.com/tsuru/tsuru/cmd"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
 	"golang.org/x/net/websocket"
 	terminal "golang.org/x/term"
 )
@@ -30,16 +36,18 @@ type ShellToContainerCmd struct {
 	tsuruClientApp.AppNameMixIn
 	isolated bool
 	debug    bool
+	unit     string
 	fs       *gnuflag.FlagSet
 }
 
 func (c *ShellToContainerCmd) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:  "app-shell",
-		Usage: "app-shell [unit-id] -a/--app <appname> [-i/--isolated]",
+		Usage: "app-shell [unit-id] -a/--app <appname> [-u/--unit <unit-id>] [-i/--isolated]",
 		Desc: `Opens a remote shell inside unit, using the API server as a proxy. You
 can access an app unit just giving app name, or specifying the id of the unit.
-You can get the ID of the unit using the app-info command.`,
+You can get the ID of the unit using the app-info command. When no unit is
+given, the first started unit of the app is used.`,
 		MinArgs: 0,
 	}
 }
@@ -52,6 +60,8 @@ func (c *ShellToContainerCmd) Flags() *gnuflag.FlagSet {
 		c.fs.BoolVar(&c.isolated, "i", false, help)
 		c.fs.BoolVar(&c.debug, "debug", false, "Enable debug mode")
 		c.fs.BoolVar(&c.debug, "d", false, "Enable debug mode")
+		c.fs.StringVar(&c.unit, "unit", "", "The ID of the unit to open the shell in")
+		c.fs.StringVar(&c.unit, "u", "", "The ID of the unit to open the shell in")
 	}
 	return c.fs
 }
@@ -65,17 +75,20 @@ func (c *ShellToContainerCmd) Run(context *cmd.Context) error {
 	if err != nil {
 		return err
 	}
-	appInfoURL, err := config.GetURL(fmt.Sprintf("/apps/%s", appName))
+	a, err := getApp(appName)
 	if err != nil {
 		return err
 	}
-	request, err := http.NewRequest("GET", appInfoURL, nil)
-	if err != nil {
-		return err
+	unitID := c.unit
+	if unitID == "" && len(context.Args) > 0 {
+		unitID = context.Args[0]
 	}
-	_, err = tsuruHTTP.AuthenticatedClient.Do(request)
-	if err != nil {
-		return err
+	if !c.isolated {
+		unitID, err = shellUnit(a, unitID)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(context.Stderr, "Opening a shell in unit %s.\n", unitID)
 	}
 	context.RawOutput()
 	var width, height int
@@ -85,7 +98,7 @@ func (c *ShellToContainerCmd) Run(context *cmd.Context) error {
 			width, height, _ = terminal.GetSize(fd)
 			oldState, terminalErr := terminal.MakeRaw(fd)
 			if terminalErr != nil {
-				return err
+				return terminalErr
 			}
 			defer terminal.Restore(fd, oldState)
 			sigChan := make(chan os.Signal, 2)
@@ -103,9 +116,9 @@ func (c *ShellToContainerCmd) Run(context *cmd.Context) error {
 	queryString.Set("debug", strconv.FormatBool(c.debug))
 	queryString.Set("width", strconv.Itoa(width))
 	queryString.Set("height", strconv.Itoa(height))
-	if len(context.Args) > 0 {
-		queryString.Set("unit", context.Args[0])
-		queryString.Set("container_id", context.Args[0])
+	if unitID != "" {
+		queryString.Set("unit", unitID)
+		queryString.Set("container_id", unitID)
 	}
 	if term := os.Getenv("TERM"); term != "" {
 		queryString.Set("term", term)
@@ -114,16 +127,7 @@ func (c *ShellToContainerCmd) Run(context *cmd.Context) error {
 	if err != nil {
 		return err
 	}
-	serverURL = httpRegexp.ReplaceAllString(serverURL, "ws")
-	wsConfig, err := websocket.NewConfig(serverURL, "ws://localhost")
-	if err != nil {
-		return err
-	}
-	var token string
-	if token, err = config.DefaultTokenProvider.Token(); err == nil {
-		wsConfig.Header.Set("Authorization", "bearer "+token)
-	}
-	conn, err := websocket.DialConfig(wsConfig)
+	conn, err := dialShell(serverURL)
 	if err != nil {
 		return err
 	}
@@ -142,3 +146,112 @@ func (c *ShellToContainerCmd) Run(context *cmd.Context) error {
 	close(errs)
 	return <-errs
 }
+
+// shellUnit returns the unit of the app to open the shell in: the given unit,
+// which must belong to the app, or the first started unit.
+func shellUnit(a *app, unitID string) (string, error) {
+	statuses := unitStatuses(a)
+	if unitID != "" {
+		if _, ok := statuses[unitID]; !ok {
+			return "", fmt.Errorf("unit %q not found in app %q", unitID, a.Name)
+		}
+		return unitID, nil
+	}
+	ids := make([]string, 0, len(statuses))
+	for id, status := range statuses {
+		if unitStatusName(status) == "started" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return "", fmt.Errorf("app %q has no started units, use --isolated to open a shell in a new unit", a.Name)
+	}
+	sort.Strings(ids)
+	return ids[0], nil
+}
+
+// dialShell opens the websocket connection of the shell. When the server
+// refuses the handshake, the returned error has the HTTP status of the
+// response.
+func dialShell(serverURL string) (*websocket.Conn, error) {
+	serverURL = httpRegexp.ReplaceAllString(serverURL, "ws")
+	wsConfig, err := websocket.NewConfig(serverURL, "ws://localhost")
+	if err != nil {
+		return nil, err
+	}
+	if token, err := config.DefaultTokenProvider.Token(); err == nil {
+		wsConfig.Header.Set("Authorization", "bearer "+token)
+	}
+	wsConfig.TlsConfig, err = tsuruHTTP.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	rawConn, err := dialShellConn(wsConfig)
+	if err != nil {
+		return nil, err
+	}
+	statusConn := &statusLineConn{Conn: rawConn}
+	conn, err := websocket.NewClient(wsConfig, statusConn)
+	if err != nil {
+		rawConn.Close()
+		if err == websocket.ErrBadStatus {
+			return nil, statusConn.httpError()
+		}
+		return nil, err
+	}
+	return conn, nil
+}
+
+func dialShellConn(wsConfig *websocket.Config) (net.Conn, error) {
+	host := wsConfig.Location.Host
+	if wsConfig.Location.Port() == "" {
+		port := "80"
+		if wsConfig.Location.Scheme == "wss" {
+			port = "443"
+		}
+		host = net.JoinHostPort(wsConfig.Location.Hostname(), port)
+	}
+	if wsConfig.Location.Scheme == "wss" {
+		return tls.Dial("tcp", host, wsConfig.TlsConfig)
+	}
+	return net.Dial("tcp", host)
+}
+
+// statusLineConn keeps the first line read from the connection, which is the
+// status line of the response to the websocket handshake.
+type statusLineConn struct {
+	net.Conn
+	line []byte
+	done bool
+}
+
+func (c *statusLineConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if !c.done {
+		c.line = append(c.line, p[:n]...)
+		if i := bytes.IndexByte(c.line, '\n'); i >= 0 {
+			c.line = c.line[:i]
+			c.done = true
+		}
+	}
+	return n, err
+}
+
+// httpError returns the HTTP status of the handshake response as an error,
+// like 403 Forbidden.
+func (c *statusLineConn) httpError() error {
+	// The status line is like "HTTP/1.1 403 Forbidden".
+	parts := strings.SplitN(strings.TrimSpace(string(c.line)), " ", 3)
+	code := 0
+	if len(parts) > 1 {
+		code, _ = strconv.Atoi(parts[1])
+	}
+	if code == 0 {
+		return errors.New("the server refused the interactive session")
+	}
+	status := strings.Join(parts[1:], " ")
+	return &tsuruErrors.HTTP{
+		Code:    code,
+		Message: fmt.Sprintf("the server refused the interactive session: %s", status),
+	}
+}
@@ -6,16 +6,25 @@ package client
 
 import (
 	"bytes"
+	"encoding/pem"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 
 	"github.com/tsuru/tsuru/cmd"
 	"github.com/tsuru/tsuru/cmd/cmdtest"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
 	"golang.org/x/net/websocket"
 	check "gopkg.in/check.v1"
 )
 
+const shellAppInfo = `{"name": "myapp", "units": [
+	{"ID": "myapp-web-2", "Status": "started"},
+	{"ID": "myapp-web-1", "Status": "started"},
+	{"ID": "myapp-web-0", "Status": "error"}
+]}`
+
 func buildHandler(content []byte) websocket.Handler {
 	return websocket.Handler(func(conn *websocket.Conn) {
 		conn.Write(content)
@@ -32,7 +41,7 @@ func (s *S) TestShellToContainerCmdInfo(c *check.C) {
 func (s *S) TestShellToContainerCmdRunWithApp(c *check.C) {
 	transport := cmdtest.ConditionalTransport{
 		Transport: cmdtest.Transport{
-			Message: "",
+			Message: shellAppInfo,
 			Status:  http.StatusOK,
 		},
 		CondFunc: func(req *http.Request) bool {
@@ -59,12 +68,13 @@ func (s *S) TestShellToContainerCmdRunWithApp(c *check.C) {
 	err = command.Run(&context)
 	c.Assert(err, check.IsNil)
 	c.Assert(stdout.String(), check.Equals, "hello my friend\nglad to see you here\n")
+	c.Assert(stderr.String(), check.Equals, "Opening a shell in unit myapp-web-1.\n")
 }
 
 func (s *S) TestShellToContainerWithUnit(c *check.C) {
 	transport := cmdtest.ConditionalTransport{
 		Transport: cmdtest.Transport{
-			Message: "",
+			Message: shellAppInfo,
 			Status:  http.StatusOK,
 		},
 		CondFunc: func(req *http.Request) bool {
@@ -80,7 +90,7 @@ func (s *S) TestShellToContainerWithUnit(c *check.C) {
 	defer os.Unsetenv("TSURU_TOKEN")
 	var stdout, stderr, stdin bytes.Buffer
 	context := cmd.Context{
-		Args:   []string{"containerid"},
+		Args:   []string{"myapp-web-2"},
 		Stdout: &stdout,
 		Stderr: &stderr,
 		Stdin:  &stdin,
@@ -93,6 +103,7 @@ func (s *S) TestShellToContainerWithUnit(c *check.C) {
 	err = command.Run(&context)
 	c.Assert(err, check.IsNil)
 	c.Assert(stdout.String(), check.Equals, "hello my friend\nglad to see you here\n")
+	c.Assert(stderr.String(), check.Equals, "Opening a shell in unit myapp-web-2.\n")
 }
 
 func (s *S) TestShellToContainerCmdConnectionRefused(c *check.C) {
@@ -152,3 +163,105 @@ func (s *S) TestShellToContainerSessionExpired(c *check.C) {
 	c.Assert(err, check.NotNil)
 	c.Assert(err, check.ErrorMatches, ".*Unauthorized")
 }
+
+func (s *S) TestShellToContainerUnitFlag(c *check.C) {
+	var requestedUnit string
+	server := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		requestedUnit = conn.Request().URL.Query().Get("unit")
+		conn.Close()
+	}))
+	defer server.Close()
+	os.Setenv("TSURU_TARGET", server.URL)
+	defer os.Unsetenv("TSURU_TARGET")
+	var stdout, stderr, stdin bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr, Stdin: &stdin}
+	var command ShellToContainerCmd
+	err := command.Flags().Parse(true, []string{"-a", "myapp", "--unit", "myapp-web-0"})
+	c.Assert(err, check.IsNil)
+	s.setupFakeTransport(&cmdtest.Transport{Message: shellAppInfo, Status: http.StatusOK})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(requestedUnit, check.Equals, "myapp-web-0")
+}
+
+func (s *S) TestShellToContainerUnknownUnit(c *check.C) {
+	var stdout, stderr, stdin bytes.Buffer
+	context := cmd.Context{Args: []string{"other-unit"}, Stdout: &stdout, Stderr: &stderr, Stdin: &stdin}
+	var command ShellToContainerCmd
+	err := command.Flags().Parse(true, []string{"-a", "myapp"})
+	c.Assert(err, check.IsNil)
+	s.setupFakeTransport(&cmdtest.Transport{Message: shellAppInfo, Status: http.StatusOK})
+	err = command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `unit "other-unit" not found in app "myapp"`)
+}
+
+func (s *S) TestShellToContainerNoStartedUnits(c *check.C) {
+	var stdout, stderr, stdin bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr, Stdin: &stdin}
+	var command ShellToContainerCmd
+	err := command.Flags().Parse(true, []string{"-a", "myapp"})
+	c.Assert(err, check.IsNil)
+	s.setupFakeTransport(&cmdtest.Transport{
+		Message: `{"name": "myapp", "units": [{"ID": "myapp-web-0", "Status": "error"}]}`,
+		Status:  http.StatusOK,
+	})
+	err = command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `app "myapp" has no started units, use --isolated to open a shell in a new unit`)
+}
+
+func (s *S) TestShellToContainerNotSupported(c *check.C) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	os.Setenv("TSURU_TARGET", server.URL)
+	defer os.Unsetenv("TSURU_TARGET")
+	var stdout, stderr, stdin bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr, Stdin: &stdin}
+	var command ShellToContainerCmd
+	err := command.Flags().Parse(true, []string{"-a", "myapp"})
+	c.Assert(err, check.IsNil)
+	s.setupFakeTransport(&cmdtest.Transport{Message: shellAppInfo, Status: http.StatusOK})
+	err = command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "the server refused the interactive session: 404 Not Found")
+}
+
+func (s *S) TestShellToContainerForbidden(c *check.C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer server.Close()
+	os.Setenv("TSURU_TARGET", server.URL)
+	defer os.Unsetenv("TSURU_TARGET")
+	var stdout, stderr, stdin bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr, Stdin: &stdin}
+	var command ShellToContainerCmd
+	err := command.Flags().Parse(true, []string{"-a", "myapp"})
+	c.Assert(err, check.IsNil)
+	s.setupFakeTransport(&cmdtest.Transport{Message: shellAppInfo, Status: http.StatusOK})
+	err = command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "the server refused the interactive session: 403 Forbidden")
+	httpErr, ok := err.(*tsuruErrors.HTTP)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(httpErr.Code, check.Equals, http.StatusForbidden)
+}
+
+func (s *S) TestShellToContainerCustomCA(c *check.C) {
+	server := httptest.NewTLSServer(buildHandler([]byte("hello")))
+	defer server.Close()
+	caFile := filepath.Join(c.MkDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	err := os.WriteFile(caFile, data, 0600)
+	c.Assert(err, check.IsNil)
+	os.Setenv("TSURU_CA_FILE", caFile)
+	defer os.Unsetenv("TSURU_CA_FILE")
+	os.Setenv("TSURU_TARGET", server.URL)
+	defer os.Unsetenv("TSURU_TARGET")
+	var stdout, stderr, stdin bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr, Stdin: &stdin}
+	var command ShellToContainerCmd
+	err = command.Flags().Parse(true, []string{"-a", "myapp"})
+	c.Assert(err, check.IsNil)
+	s.setupFakeTransport(&cmdtest.Transport{Message: shellAppInfo, Status: http.StatusOK})
+	err = command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "hello")
+}
@@ -5,6 +5,7 @@
 package client
 
 import (
+	"errors"
 	"net/http"
 	"os"
 	"testing"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/cezarsa/form"
 	"github.com/tsuru/go-tsuruclient/pkg/config"
+	tsuruClientApp "github.com/tsuru/tsuru-client/tsuru/app"
 	"github.com/tsuru/tsuru-client/tsuru/formatter"
 	tsuruHTTP "github.com/tsuru/tsuru-client/tsuru/http"
 	"gopkg.in/check.v1"
@@ -19,6 +21,7 @@ import (
 
 type S struct {
 	defaultLocation time.Location
+	gitRemoteURL    func() (string, error)
 	t               *testing.T
 }
 
@@ -30,18 +33,25 @@ func (s *S) SetUpSuite(c *check.C) {
 func (s *S) SetUpTest(c *check.C) {
 	os.Setenv("TSURU_TARGET", "http://localhost:8080")
 	os.Setenv("TSURU_TOKEN", "sometoken")
+	os.Setenv("TSURU_NO_CACHE", "true")
 	s.defaultLocation = *formatter.LocalTZ
 	location, err := time.LoadLocation("US/Central")
 	if err == nil {
 		formatter.LocalTZ = location
 	}
 	config.ResetFileSystem()
+	s.gitRemoteURL = tsuruClientApp.GitRemoteURL
+	tsuruClientApp.GitRemoteURL = func() (string, error) {
+		return "", errors.New("no such remote")
+	}
 }
 
 func (s *S) TearDownTest(c *check.C) {
 	os.Unsetenv("TSURU_TARGET")
 	os.Unsetenv("TSURU_TOKEN")
+	os.Unsetenv("TSURU_NO_CACHE")
 	formatter.LocalTZ = &s.defaultLocation
+	tsuruClientApp.GitRemoteURL = s.gitRemoteURL
 }
 
 var suite = &S{}
@@ -0,0 +1,125 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/tsuru/gnuflag"
+	"github.com/tsuru/go-tsuruclient/pkg/config"
+	"github.com/tsuru/tablecli"
+	tsuruHTTP "github.com/tsuru/tsuru-client/tsuru/http"
+	"github.com/tsuru/tsuru/cmd"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+)
+
+type AppSwap struct {
+	fs        *gnuflag.FlagSet
+	force     bool
+	cnameOnly bool
+}
+
+func (c *AppSwap) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "app-swap",
+		Usage: "app swap <app1-name> <app2-name> [-f/--force] [-c/--cname-only]",
+		Desc: `Swaps routing between two apps. This allows zero downtime and makes rollback
+as simple as swapping the applications back.
+
+The platform and the number of units of both apps are displayed side by side
+before swapping. Apps with a different platform or number of units are only
+swapped when [[--force]] is used.
+
+Use [[--cname-only]] to swap only the cnames of the apps, keeping their default
+addresses and units untouched. Both apps must already be routable, which
+means they must have been deployed and have units.`,
+		MinArgs: 2,
+		MaxArgs: 2,
+	}
+}
+
+func (c *AppSwap) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = gnuflag.NewFlagSet("", gnuflag.ExitOnError)
+		forceMessage := "Force swap among apps with different number of units or different platform"
+		c.fs.BoolVar(&c.force, "force", false, forceMessage)
+		c.fs.BoolVar(&c.force, "f", false, forceMessage)
+		cnameOnlyMessage := "Swap all cnames except the default cname"
+		c.fs.BoolVar(&c.cnameOnly, "cname-only", false, cnameOnlyMessage)
+		c.fs.BoolVar(&c.cnameOnly, "c", false, cnameOnlyMessage)
+	}
+	return c.fs
+}
+
+func (c *AppSwap) Run(context *cmd.Context) error {
+	app1, err := getApp(context.Args[0])
+	if err != nil {
+		return err
+	}
+	app2, err := getApp(context.Args[1])
+	if err != nil {
+		return err
+	}
+	units1, units2 := len(unitStatuses(app1)), len(unitStatuses(app2))
+	table := tablecli.NewTable()
+	table.Headers = tablecli.Row{"", app1.Name, app2.Name}
+	table.AddRow(tablecli.Row{"Platform", app1.Platform, app2.Platform})
+	table.AddRow(tablecli.Row{"Units", strconv.Itoa(units1), strconv.Itoa(units2)})
+	fmt.Fprint(context.Stdout, table.String())
+	if c.cnameOnly {
+		fmt.Fprintln(context.Stdout, "Only the cnames will be swapped, the default addresses of the apps are kept.")
+	} else {
+		fmt.Fprintln(context.Stdout, "The routes and cnames of the apps will be swapped.")
+	}
+	var mismatches []string
+	if app1.Platform != app2.Platform {
+		mismatches = append(mismatches, "platforms")
+	}
+	if units1 != units2 {
+		mismatches = append(mismatches, "numbers of units")
+	}
+	if len(mismatches) > 0 {
+		if !c.force {
+			return fmt.Errorf("the apps have different %s, use --force to swap them anyway", strings.Join(mismatches, " and "))
+		}
+		fmt.Fprintf(context.Stdout, "WARNING: the apps have different %s.\n", strings.Join(mismatches, " and "))
+	}
+	err = swapApps(app1.Name, app2.Name, c.force, c.cnameOnly)
+	if err != nil {
+		if httpErr, ok := tsuruHTTP.UnwrapErr(err).(*tsuruErrors.HTTP); ok && httpErr.Code == http.StatusPreconditionFailed {
+			return fmt.Errorf("the apps can't be swapped: %s", strings.TrimSpace(httpErr.Message))
+		}
+		return err
+	}
+	fmt.Fprintln(context.Stdout, "Apps successfully swapped!")
+	return nil
+}
+
+func swapApps(app1, app2 string, force, cnameOnly bool) error {
+	v := url.Values{}
+	v.Set("app1", app1)
+	v.Set("app2", app2)
+	v.Set("force", strconv.FormatBool(force))
+	v.Set("cnameOnly", strconv.FormatBool(cnameOnly))
+	u, err := config.GetURL("/swap")
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(http.MethodPost, u, strings.NewReader(v.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	return nil
+}
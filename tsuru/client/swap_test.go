@@ -0,0 +1,125 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/tsuru/tsuru/cmd"
+	"github.com/tsuru/tsuru/cmd/cmdtest"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) TestAppSwapInfo(c *check.C) {
+	c.Assert((&AppSwap{}).Info(), check.NotNil)
+}
+
+func swapTransport(app1, app2 string, swapped *string) *cmdtest.AnyConditionalTransport {
+	return &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: app1, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return strings.HasSuffix(req.URL.Path, "/apps/blue")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: app2, Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return strings.HasSuffix(req.URL.Path, "/apps/green")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					if req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/swap") {
+						return false
+					}
+					req.ParseForm()
+					*swapped = req.PostForm.Encode()
+					return true
+				},
+			},
+		},
+	}
+}
+
+func (s *S) TestAppSwap(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr, Args: []string{"blue", "green"}}
+	var swapped string
+	s.setupFakeTransport(swapTransport(
+		`{"name": "blue", "platform": "python", "units": [{"ID": "blue-1"}]}`,
+		`{"name": "green", "platform": "python", "units": [{"ID": "green-1"}]}`,
+		&swapped,
+	))
+	command := AppSwap{}
+	command.Flags().Parse(true, nil)
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(swapped, check.Equals, "app1=blue&app2=green&cnameOnly=false&force=false")
+	expected := `+----------+--------+--------+
+|          | blue   | green  |
++----------+--------+--------+
+| Platform | python | python |
+| Units    | 1      | 1      |
++----------+--------+--------+
+The routes and cnames of the apps will be swapped.
+Apps successfully swapped!
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestAppSwapMismatch(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr, Args: []string{"blue", "green"}}
+	var swapped string
+	s.setupFakeTransport(swapTransport(
+		`{"name": "blue", "platform": "python", "units": [{"ID": "blue-1"}, {"ID": "blue-2"}]}`,
+		`{"name": "green", "platform": "go", "units": [{"ID": "green-1"}]}`,
+		&swapped,
+	))
+	command := AppSwap{}
+	command.Flags().Parse(true, nil)
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, "the apps have different platforms and numbers of units, use --force to swap them anyway")
+	c.Assert(swapped, check.Equals, "")
+}
+
+func (s *S) TestAppSwapMismatchForce(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr, Args: []string{"blue", "green"}}
+	var swapped string
+	s.setupFakeTransport(swapTransport(
+		`{"name": "blue", "platform": "python", "units": [{"ID": "blue-1"}]}`,
+		`{"name": "green", "platform": "go", "units": [{"ID": "green-1"}]}`,
+		&swapped,
+	))
+	command := AppSwap{}
+	command.Flags().Parse(true, []string{"-f"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(swapped, check.Equals, "app1=blue&app2=green&cnameOnly=false&force=true")
+	c.Assert(stdout.String(), check.Matches, "(?s).*WARNING: the apps have different platforms.\nApps successfully swapped!\n")
+}
+
+func (s *S) TestAppSwapCNameOnly(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr, Args: []string{"blue", "green"}}
+	var swapped string
+	s.setupFakeTransport(swapTransport(
+		`{"name": "blue", "platform": "python", "units": [{"ID": "blue-1"}]}`,
+		`{"name": "green", "platform": "python", "units": [{"ID": "green-1"}]}`,
+		&swapped,
+	))
+	command := AppSwap{}
+	command.Flags().Parse(true, []string{"--cname-only"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(swapped, check.Equals, "app1=blue&app2=green&cnameOnly=true&force=false")
+	c.Assert(stdout.String(), check.Matches, "(?s).*Only the cnames will be swapped, the default addresses of the apps are kept.\nApps successfully swapped!\n")
+}
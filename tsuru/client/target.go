@@ -0,0 +1,191 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/tsuru/gnuflag"
+	"github.com/tsuru/go-tsuruclient/pkg/config"
+	tsuruHTTP "github.com/tsuru/tsuru-client/tsuru/http"
+	"github.com/tsuru/tsuru/cmd"
+)
+
+type TargetSwitch struct {
+	skipValidation bool
+	fs             *gnuflag.FlagSet
+}
+
+func (c *TargetSwitch) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "target-switch",
+		Usage:   "target switch <label> [--skip-validation]",
+		MinArgs: 1,
+		MaxArgs: 1,
+		Desc: `Change current target, checking that it responds first
+
+The target with the given label must respond to an authenticated request
+before it becomes the current target. Unlike target set, the current target is
+kept when the new one is unreachable or the session in it is invalid, so
+commands don't end up running against an unexpected tsuru server. The request
+is authenticated with the token saved by login for the new target, or the one
+in TSURU_TOKEN, the token of the current target is never sent to it. Use
+[[--skip-validation]] to switch without the check, for instance before logging
+in to the new target.
+
+Use target list to see the configured targets, with the current one marked.`,
+	}
+}
+
+func (c *TargetSwitch) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = gnuflag.NewFlagSet("target-switch", gnuflag.ExitOnError)
+		c.fs.BoolVar(&c.skipValidation, "skip-validation", false, "Switch without checking that the target responds")
+	}
+	return c.fs
+}
+
+func (c *TargetSwitch) Run(ctx *cmd.Context) error {
+	label := strings.TrimSpace(ctx.Args[0])
+	exists, err := config.CheckIfTargetLabelExists(label)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("target %q not found, use target list to see the available targets", label)
+	}
+	target, err := targetURL(label)
+	if err != nil {
+		return err
+	}
+	if !c.skipValidation {
+		if err = pingTarget(label); err != nil {
+			return fmt.Errorf("target %s -> %s didn't respond, keeping the current target: %w", label, target, err)
+		}
+	}
+	if err = config.WriteTarget(target); err != nil {
+		return err
+	}
+	fmt.Fprintf(ctx.Stdout, "New target is %s -> %s\n", label, target)
+	if os.Getenv("TSURU_TARGET") != "" {
+		fmt.Fprintln(ctx.Stderr, "Warning: the TSURU_TARGET environment variable is set and overrides the current target.")
+	}
+	return nil
+}
+
+// targetURL returns the address of the target with the given label, as
+// stored in the targets file.
+func targetURL(label string) (string, error) {
+	f, err := config.Filesystem().Open(config.JoinWithUserDir(".tsuru", "targets"))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		parts := strings.Split(strings.TrimSpace(line), "\t")
+		if len(parts) == 2 && parts[0] == label {
+			return parts[1], nil
+		}
+	}
+	return "", fmt.Errorf("target %q not found, use target list to see the available targets", label)
+}
+
+// pingTarget sends a lightweight authenticated request to the target with
+// the given label. The TSURU_TARGET variable is used to point the request to
+// it without touching the current target. The request is sent without the
+// token of the current target, see setTargetToken.
+func pingTarget(label string) error {
+	previous, hadPrevious := os.LookupEnv("TSURU_TARGET")
+	os.Setenv("TSURU_TARGET", label)
+	defer func() {
+		if hadPrevious {
+			os.Setenv("TSURU_TARGET", previous)
+		} else {
+			os.Unsetenv("TSURU_TARGET")
+		}
+	}()
+	u, err := config.GetURL("/users/info")
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	if err = setTargetToken(request, label); err != nil {
+		return err
+	}
+	response, err := tsuruHTTP.UnauthenticatedClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return errors.New(http.StatusText(response.StatusCode))
+	}
+	return nil
+}
+
+// setTargetToken authenticates the request with the token in the TSURU_TOKEN
+// variable or, without it, with the token saved by login for the target with
+// the given label. The token of the current target isn't used, as it must not
+// be sent to another server, so no token is set when the target has none.
+func setTargetToken(request *http.Request, label string) error {
+	if token := config.ReadTeamToken(); token != "" {
+		request.Header.Set("Authorization", "bearer "+token)
+		return nil
+	}
+	data, err := readUserFile(".tsuru", "token-v2.d", label+".json")
+	if err != nil {
+		return err
+	}
+	if data != nil {
+		var tokenV2 config.TokenV2
+		if err = json.Unmarshal(data, &tokenV2); err != nil {
+			return err
+		}
+		if tokenV2.Scheme == "oidc" && tokenV2.OAuth2Config != nil {
+			token, err := tokenV2.OAuth2Config.TokenSource(context.Background(), tokenV2.OAuth2Token).Token()
+			if err != nil {
+				return err
+			}
+			token.SetAuthHeader(request)
+			return nil
+		}
+	}
+	data, err = readUserFile(".tsuru", "token.d", label)
+	if err != nil {
+		return err
+	}
+	if token := strings.TrimSpace(string(data)); token != "" {
+		request.Header.Set("Authorization", "bearer "+token)
+	}
+	return nil
+}
+
+// readUserFile returns the contents of the file in the user directory, or
+// nil if it doesn't exist.
+func readUserFile(path ...string) ([]byte, error) {
+	f, err := config.Filesystem().Open(config.JoinWithUserDir(path...))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
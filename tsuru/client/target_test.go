@@ -0,0 +1,170 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/tsuru/go-tsuruclient/pkg/config"
+	tsuruHTTP "github.com/tsuru/tsuru-client/tsuru/http"
+	"github.com/tsuru/tsuru/cmd"
+	"github.com/tsuru/tsuru/cmd/cmdtest"
+	"github.com/tsuru/tsuru/fs/fstest"
+	check "gopkg.in/check.v1"
+)
+
+func setupTargets(c *check.C) *fstest.RecordingFs {
+	rfs := &fstest.RecordingFs{}
+	config.SetFileSystem(rfs)
+	f, err := rfs.Create(config.JoinWithUserDir(".tsuru", "targets"))
+	c.Assert(err, check.IsNil)
+	f.Write([]byte("staging\thttp://staging.example.com\nprod\thttps://prod.example.com\n"))
+	f.Close()
+	f, err = rfs.Create(config.JoinWithUserDir(".tsuru", "target"))
+	c.Assert(err, check.IsNil)
+	f.Write([]byte("http://staging.example.com"))
+	f.Close()
+	return rfs
+}
+
+func readCurrentTarget(c *check.C, rfs *fstest.RecordingFs) string {
+	f, err := rfs.Open(config.JoinWithUserDir(".tsuru", "target"))
+	c.Assert(err, check.IsNil)
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	c.Assert(err, check.IsNil)
+	return string(data)
+}
+
+// setupPingTransport sends the requests of pingTarget to rt, returning a
+// function that restores the client.
+func setupPingTransport(rt http.RoundTripper) func() {
+	old := tsuruHTTP.UnauthenticatedClient
+	tsuruHTTP.UnauthenticatedClient = &http.Client{Transport: rt}
+	return func() { tsuruHTTP.UnauthenticatedClient = old }
+}
+
+func writeUserFile(c *check.C, rfs *fstest.RecordingFs, content string, path ...string) {
+	f, err := rfs.Create(config.JoinWithUserDir(path...))
+	c.Assert(err, check.IsNil)
+	f.Write([]byte(content))
+	f.Close()
+}
+
+func (s *S) TestTargetSwitchInfo(c *check.C) {
+	c.Assert((&TargetSwitch{}).Info(), check.NotNil)
+}
+
+func (s *S) TestTargetSwitch(c *check.C) {
+	os.Unsetenv("TSURU_TARGET")
+	os.Unsetenv("TSURU_TOKEN")
+	rfs := setupTargets(c)
+	writeUserFile(c, rfs, "staging-token", ".tsuru", "token")
+	writeUserFile(c, rfs, "prod-token", ".tsuru", "token.d", "prod")
+	var pinged bool
+	defer setupPingTransport(&cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: `{"Email":"me@example.com"}`, Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			pinged = req.URL.String() == "https://prod.example.com/1.0/users/info" &&
+				req.Header.Get("Authorization") == "bearer prod-token"
+			return pinged
+		},
+	})()
+	var stdout, stderr bytes.Buffer
+	ctx := cmd.Context{Args: []string{"prod"}, Stdout: &stdout, Stderr: &stderr}
+	command := TargetSwitch{}
+	command.Flags().Parse(true, nil)
+	err := command.Run(&ctx)
+	c.Assert(err, check.IsNil)
+	c.Assert(pinged, check.Equals, true)
+	c.Assert(stdout.String(), check.Equals, "New target is prod -> https://prod.example.com\n")
+	c.Assert(stderr.String(), check.Equals, "")
+	c.Assert(readCurrentTarget(c, rfs), check.Equals, "https://prod.example.com")
+	_, set := os.LookupEnv("TSURU_TARGET")
+	c.Assert(set, check.Equals, false)
+}
+
+func (s *S) TestTargetSwitchDoesNotSendCurrentToken(c *check.C) {
+	os.Unsetenv("TSURU_TARGET")
+	os.Unsetenv("TSURU_TOKEN")
+	rfs := setupTargets(c)
+	writeUserFile(c, rfs, "staging-token", ".tsuru", "token")
+	writeUserFile(c, rfs, "staging-token", ".tsuru", "token.d", "staging")
+	var authorization []string
+	defer setupPingTransport(&cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: "unauthorized", Status: http.StatusUnauthorized},
+		CondFunc: func(req *http.Request) bool {
+			authorization = req.Header["Authorization"]
+			return true
+		},
+	})()
+	ctx := cmd.Context{Args: []string{"prod"}, Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	command := TargetSwitch{}
+	command.Flags().Parse(true, nil)
+	err := command.Run(&ctx)
+	c.Assert(err, check.ErrorMatches, `target prod -> https://prod.example.com didn't respond, keeping the current target: Unauthorized`)
+	c.Assert(authorization, check.IsNil)
+	c.Assert(readCurrentTarget(c, rfs), check.Equals, "http://staging.example.com")
+}
+
+func (s *S) TestTargetSwitchTeamToken(c *check.C) {
+	os.Unsetenv("TSURU_TARGET")
+	setupTargets(c)
+	var authorization string
+	defer setupPingTransport(&cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: `{"Email":"me@example.com"}`, Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			authorization = req.Header.Get("Authorization")
+			return true
+		},
+	})()
+	ctx := cmd.Context{Args: []string{"prod"}, Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	command := TargetSwitch{}
+	command.Flags().Parse(true, nil)
+	err := command.Run(&ctx)
+	c.Assert(err, check.IsNil)
+	c.Assert(authorization, check.Equals, "bearer sometoken")
+}
+
+func (s *S) TestTargetSwitchUnreachableKeepsCurrentTarget(c *check.C) {
+	os.Unsetenv("TSURU_TARGET")
+	rfs := setupTargets(c)
+	defer setupPingTransport(&cmdtest.Transport{Message: "unavailable", Status: http.StatusServiceUnavailable})()
+	var stdout, stderr bytes.Buffer
+	ctx := cmd.Context{Args: []string{"prod"}, Stdout: &stdout, Stderr: &stderr}
+	command := TargetSwitch{}
+	command.Flags().Parse(true, nil)
+	err := command.Run(&ctx)
+	c.Assert(err, check.ErrorMatches, `target prod -> https://prod.example.com didn't respond, keeping the current target: Service Unavailable`)
+	c.Assert(stdout.String(), check.Equals, "")
+	c.Assert(readCurrentTarget(c, rfs), check.Equals, "http://staging.example.com")
+}
+
+func (s *S) TestTargetSwitchSkipValidation(c *check.C) {
+	rfs := setupTargets(c)
+	defer setupPingTransport(&cmdtest.Transport{Message: "unavailable", Status: http.StatusServiceUnavailable})()
+	var stdout, stderr bytes.Buffer
+	ctx := cmd.Context{Args: []string{"prod"}, Stdout: &stdout, Stderr: &stderr}
+	command := TargetSwitch{}
+	command.Flags().Parse(true, []string{"--skip-validation"})
+	err := command.Run(&ctx)
+	c.Assert(err, check.IsNil)
+	c.Assert(readCurrentTarget(c, rfs), check.Equals, "https://prod.example.com")
+	c.Assert(stderr.String(), check.Equals, "Warning: the TSURU_TARGET environment variable is set and overrides the current target.\n")
+	c.Assert(os.Getenv("TSURU_TARGET"), check.Equals, "http://localhost:8080")
+}
+
+func (s *S) TestTargetSwitchNotFound(c *check.C) {
+	setupTargets(c)
+	var stdout, stderr bytes.Buffer
+	ctx := cmd.Context{Args: []string{"dev"}, Stdout: &stdout, Stderr: &stderr}
+	command := TargetSwitch{}
+	command.Flags().Parse(true, nil)
+	err := command.Run(&ctx)
+	c.Assert(err, check.ErrorMatches, `target "dev" not found, use target list to see the available targets`)
+}
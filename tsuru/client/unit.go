@@ -12,7 +12,10 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/tsuru/gnuflag"
 	"github.com/tsuru/go-tsuruclient/pkg/config"
@@ -22,19 +25,43 @@ import (
 	"github.com/tsuru/tsuru/cmd"
 )
 
+var (
+	unitAddSleep     = time.Sleep
+	unitWaitInterval = 5 * time.Second
+)
+
 type UnitAdd struct {
 	tsuruClientApp.AppNameMixIn
-	fs      *gnuflag.FlagSet
-	process string
-	version string
+	ciOutput
+	fs          *gnuflag.FlagSet
+	process     string
+	version     string
+	batch       int
+	delay       time.Duration
+	waitTimeout time.Duration
+	verbose     bool
 }
 
 func (c *UnitAdd) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:  "unit-add",
-		Usage: "unit add <# of units> [-a/--app appname] [-p/--process processname] [--version version]",
+		Usage: "unit add <# of units> [-a/--app appname] [-p/--process processname] [--version version] [--batch size [--delay duration]] [--wait-timeout duration] [--verbose] [--ci/--quiet]",
 		Desc: `Adds new units to a process of an application. You need to have access to the
-app to be able to add new units to it.`,
+app to be able to add new units to it.
+
+By default all units are added at once. The [[--batch]] flag adds the units in
+waves of the given size, waiting for the time defined by the [[--delay]] flag
+between waves, which avoids overloading the cluster when adding many units:
+
+    tsuru unit add 50 -a myapp --batch 5 --delay 10s
+
+The [[--wait-timeout]] flag makes the command wait, after the units are added,
+until the app reports the new number of units, failing if it doesn't happen
+within the given duration.
+
+When the output is a terminal, the progress messages sent while the units are
+created are shown as a progress bar with the number of units ready. Use
+[[--verbose]] to see all the messages instead.`,
 		MinArgs: 1,
 	}
 }
@@ -45,6 +72,11 @@ func (c *UnitAdd) Flags() *gnuflag.FlagSet {
 		c.fs.StringVar(&c.process, "process", "", "Process name")
 		c.fs.StringVar(&c.process, "p", "", "Process name")
 		c.fs.StringVar(&c.version, "version", "", "Version number")
+		c.fs.IntVar(&c.batch, "batch", 0, "Add the units in waves of the given size")
+		c.fs.DurationVar(&c.delay, "delay", 0, "Time to wait between waves of units when using --batch")
+		c.fs.DurationVar(&c.waitTimeout, "wait-timeout", 0, "How long to wait for the app to report the new number of units")
+		c.fs.BoolVar(&c.verbose, "verbose", false, "Show all the messages sent by the API instead of a progress bar")
+		c.addFlags(c.fs)
 	}
 	return c.fs
 }
@@ -55,12 +87,73 @@ func (c *UnitAdd) Run(context *cmd.Context) error {
 	if err != nil {
 		return err
 	}
+	var units, before int
+	if c.waitTimeout > 0 {
+		units, err = strconv.Atoi(context.Args[0])
+		if err != nil || units <= 0 {
+			return fmt.Errorf("invalid number of units: %q", context.Args[0])
+		}
+		before, err = processUnitCount(appName, c.process)
+		if err != nil {
+			return err
+		}
+	}
+	err = c.add(context, appName)
+	if err != nil {
+		return err
+	}
+	if c.waitTimeout > 0 {
+		err = waitUnitCount(c.progressWriter(context.Stdout), appName, c.process, before+units, c.waitTimeout)
+		if err != nil {
+			return err
+		}
+	}
+	// In CI mode, OK is only written once the units are added and, with
+	// --wait-timeout, reported by the app.
+	if c.ci {
+		fmt.Fprintln(context.Stdout, "OK")
+	}
+	return nil
+}
+
+func (c *UnitAdd) add(context *cmd.Context, appName string) error {
+	if c.batch == 0 {
+		return c.addUnits(context.Stdout, appName, context.Args[0])
+	}
+	if c.batch < 0 {
+		return errors.New("the batch size must be a positive number")
+	}
+	total, err := strconv.Atoi(context.Args[0])
+	if err != nil || total <= 0 {
+		return fmt.Errorf("invalid number of units: %q", context.Args[0])
+	}
+	w := c.progressWriter(context.Stdout)
+	for added := 0; added < total; {
+		n := c.batch
+		if total-added < n {
+			n = total - added
+		}
+		if added > 0 && c.delay > 0 {
+			fmt.Fprintf(w, "Waiting %s before the next wave...\n", c.delay)
+			unitAddSleep(c.delay)
+		}
+		fmt.Fprintf(w, "Adding units %d-%d of %d...\n", added+1, added+n, total)
+		err = c.addUnits(w, appName, strconv.Itoa(n))
+		if err != nil {
+			return fmt.Errorf("%d of %d units were added: %w", added, total, err)
+		}
+		added += n
+	}
+	return nil
+}
+
+func (c *UnitAdd) addUnits(w io.Writer, appName, units string) error {
 	u, err := config.GetURL(fmt.Sprintf("/apps/%s/units", appName))
 	if err != nil {
 		return err
 	}
 	val := url.Values{}
-	val.Add("units", context.Args[0])
+	val.Add("units", units)
 	val.Add("process", c.process)
 	val.Set("version", c.version)
 	request, err := http.NewRequest("PUT", u, bytes.NewBufferString(val.Encode()))
@@ -68,27 +161,147 @@ func (c *UnitAdd) Run(context *cmd.Context) error {
 		return err
 	}
 	request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	response, err := tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
 	if err != nil {
 		return err
 	}
 	defer response.Body.Close()
-	return formatter.StreamJSONResponse(context.Stdout, response)
+	if !c.ci && !c.verbose && isTerminalWriter(w) {
+		progress := &unitProgress{w: w}
+		err = formatter.StreamJSONResponse(progress, response)
+		progress.Close()
+		return err
+	}
+	return formatter.StreamJSONResponse(c.progressWriter(w), response)
+}
+
+const unitProgressWidth = 30
+
+var reUnitProgress = regexp.MustCompile(`(\d+) of (\d+) new units (created|ready)`)
+
+// unitProgress replaces the "N of M new units ready" messages streamed while
+// units are added with a progress bar, redrawn in place. Other messages are
+// written as they are, above the bar.
+type unitProgress struct {
+	w       io.Writer
+	pending []byte
+	bar     string
+}
+
+func (p *unitProgress) Write(data []byte) (int, error) {
+	p.pending = append(p.pending, data...)
+	for {
+		i := bytes.IndexByte(p.pending, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(p.pending[:i])
+		p.pending = p.pending[i+1:]
+		p.writeLine(line)
+	}
+	return len(data), nil
+}
+
+func (p *unitProgress) writeLine(line string) {
+	if m := reUnitProgress.FindStringSubmatch(line); m != nil {
+		done, _ := strconv.Atoi(m[1])
+		total, _ := strconv.Atoi(m[2])
+		p.bar = unitProgressBar(m[3], done, total)
+		fmt.Fprintf(p.w, "\r%s", p.bar)
+		return
+	}
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if p.bar != "" {
+		fmt.Fprint(p.w, "\r\033[K")
+	}
+	fmt.Fprintln(p.w, line)
+	if p.bar != "" {
+		fmt.Fprint(p.w, p.bar)
+	}
+}
+
+// Close writes any incomplete message and ends the line of the bar.
+func (p *unitProgress) Close() {
+	if len(p.pending) > 0 {
+		p.writeLine(string(p.pending))
+		p.pending = nil
+	}
+	if p.bar != "" {
+		fmt.Fprintln(p.w)
+	}
+}
+
+func unitProgressBar(state string, done, total int) string {
+	filled := 0
+	if total > 0 {
+		filled = done * unitProgressWidth / total
+	}
+	if filled > unitProgressWidth {
+		filled = unitProgressWidth
+	}
+	bar := strings.Repeat("#", filled) + strings.Repeat("-", unitProgressWidth-filled)
+	return fmt.Sprintf("Units %-7s [%s] %d/%d", state, bar, done, total)
+}
+
+// processUnitCount returns the number of units of a process of the app, or of
+// all processes if process is empty.
+func processUnitCount(appName, process string) (int, error) {
+	a, err := getApp(appName)
+	if err != nil {
+		return 0, err
+	}
+	return appUnitCount(a, process), nil
+}
+
+func appUnitCount(a *app, process string) int {
+	count := 0
+	for _, u := range a.Units {
+		if u.ID != "" && (process == "" || u.ProcessName == process) {
+			count++
+		}
+	}
+	return count
+}
+
+// waitUnitCount polls the app until the process has the expected number of
+// units, or the timeout expires.
+func waitUnitCount(w io.Writer, appName, process string, expected int, timeout time.Duration) error {
+	count := 0
+	err := pollApp(appName, unitWaitInterval, timeout, func(a *app) (bool, error) {
+		count = appUnitCount(a, process)
+		if count == expected {
+			return true, nil
+		}
+		fmt.Fprintf(w, "Waiting for app %q to have %d units, currently %d...\n", appName, expected, count)
+		return false, nil
+	})
+	if err == errPollTimeout {
+		return fmt.Errorf("app %q has %d units after %s, expected %d", appName, count, timeout, expected)
+	}
+	return err
 }
 
 type UnitRemove struct {
 	tsuruClientApp.AppNameMixIn
-	fs      *gnuflag.FlagSet
-	process string
-	version string
+	ciOutput
+	fs          *gnuflag.FlagSet
+	process     string
+	version     string
+	waitTimeout time.Duration
 }
 
 func (c *UnitRemove) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:  "unit-remove",
-		Usage: "unit remove <# of units> [-a/--app appname] [-p/-process processname] [--version version]",
+		Usage: "unit remove <# of units> [-a/--app appname] [-p/-process processname] [--version version] [--wait-timeout duration] [--ci/--quiet]",
 		Desc: `Removes units from a process of an application. You need to have access to the
-app to be able to remove units from it.`,
+app to be able to remove units from it.
+
+The [[--wait-timeout]] flag makes the command wait, after the units are
+removed, until the app reports the new number of units, failing if it doesn't
+happen within the given duration.`,
 		MinArgs: 1,
 	}
 }
@@ -99,6 +312,8 @@ func (c *UnitRemove) Flags() *gnuflag.FlagSet {
 		c.fs.StringVar(&c.process, "process", "", "Process name")
 		c.fs.StringVar(&c.process, "p", "", "Process name")
 		c.fs.StringVar(&c.version, "version", "", "Version number")
+		c.fs.DurationVar(&c.waitTimeout, "wait-timeout", 0, "How long to wait for the app to report the new number of units")
+		c.addFlags(c.fs)
 	}
 	return c.fs
 }
@@ -109,6 +324,17 @@ func (c *UnitRemove) Run(context *cmd.Context) error {
 	if err != nil {
 		return err
 	}
+	var units, before int
+	if c.waitTimeout > 0 {
+		units, err = strconv.Atoi(context.Args[0])
+		if err != nil || units <= 0 {
+			return fmt.Errorf("invalid number of units: %q", context.Args[0])
+		}
+		before, err = processUnitCount(appName, c.process)
+		if err != nil {
+			return err
+		}
+	}
 	val := url.Values{}
 	val.Add("units", context.Args[0])
 	val.Add("process", c.process)
@@ -121,11 +347,28 @@ func (c *UnitRemove) Run(context *cmd.Context) error {
 	if err != nil {
 		return err
 	}
-	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	response, err := tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
 	if err != nil {
 		return err
 	}
-	return formatter.StreamJSONResponse(context.Stdout, response)
+	err = formatter.StreamJSONResponse(c.progressWriter(context.Stdout), response)
+	if err != nil {
+		return err
+	}
+	if c.waitTimeout > 0 {
+		expected := before - units
+		if expected < 0 {
+			expected = 0
+		}
+		err = waitUnitCount(c.progressWriter(context.Stdout), appName, c.process, expected, c.waitTimeout)
+		if err != nil {
+			return err
+		}
+	}
+	if c.ci {
+		fmt.Fprintln(context.Stdout, "OK")
+	}
+	return nil
 }
 
 type UnitKill struct {
@@ -182,7 +425,7 @@ func (c *UnitKill) Run(context *cmd.Context) error {
 	if err != nil {
 		return err
 	}
-	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	response, err := tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
 	if err != nil {
 		return err
 	}
@@ -303,7 +546,7 @@ func (c *UnitSet) Run(context *cmd.Context) error {
 		}
 
 		request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-		response, err := tsuruHTTP.AuthenticatedClient.Do(request)
+		response, err := tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
 		if err != nil {
 			return err
 		}
@@ -328,7 +571,7 @@ func (c *UnitSet) Run(context *cmd.Context) error {
 			return err
 		}
 
-		response, err := tsuruHTTP.AuthenticatedClient.Do(request)
+		response, err := tsuruHTTP.AuthenticatedClient.Do(tsuruHTTP.Streaming(request))
 		if err != nil {
 			return err
 		}
@@ -7,9 +7,12 @@ package client
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"net/http"
 	"strings"
+	"time"
 
+	tsuruClientApp "github.com/tsuru/tsuru-client/tsuru/app"
 	tsuruHTTP "github.com/tsuru/tsuru-client/tsuru/http"
 	"github.com/tsuru/tsuru/cmd"
 	"github.com/tsuru/tsuru/cmd/cmdtest"
@@ -96,6 +99,121 @@ func (s *S) TestUnitAddFailure(c *check.C) {
 	c.Assert(err.Error(), check.Equals, "errored msg")
 }
 
+func (s *S) TestUnitAddBatch(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"12"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	var sleeps []time.Duration
+	oldSleep := unitAddSleep
+	defer func() { unitAddSleep = oldSleep }()
+	unitAddSleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	msg, _ := json.Marshal(tsuruIo.SimpleJsonMessage{Message: "-- added units --\n"})
+	var waves []string
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: string(msg), Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			waves = append(waves, req.FormValue("units"))
+			return strings.HasSuffix(req.URL.Path, "/apps/radio/units") && req.Method == "PUT"
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := UnitAdd{}
+	command.Flags().Parse(true, []string{"-a", "radio", "--batch", "5", "--delay", "10s"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(waves, check.DeepEquals, []string{"5", "5", "2"})
+	c.Assert(sleeps, check.DeepEquals, []time.Duration{10 * time.Second, 10 * time.Second})
+	expected := `Adding units 1-5 of 12...
+-- added units --
+Waiting 10s before the next wave...
+Adding units 6-10 of 12...
+-- added units --
+Waiting 10s before the next wave...
+Adding units 11-12 of 12...
+-- added units --
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestUnitAddBatchInvalidUnits(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"many"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	command := UnitAdd{}
+	command.Flags().Parse(true, []string{"-a", "radio", "--batch", "5"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `invalid number of units: "many"`)
+}
+
+func unitAddStream(c *check.C, messages ...string) string {
+	var result []byte
+	for _, m := range messages {
+		data, err := json.Marshal(tsuruIo.SimpleJsonMessage{Message: m})
+		c.Assert(err, check.IsNil)
+		result = append(result, data...)
+		result = append(result, '\n')
+	}
+	return string(result)
+}
+
+func (s *S) TestUnitAddProgressBar(c *check.C) {
+	old := isTerminalWriter
+	defer func() { isTerminalWriter = old }()
+	isTerminalWriter = func(io.Writer) bool { return true }
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"2"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	result := unitAddStream(c,
+		"\n---- Updating units [web] ----\n",
+		" ---> 1 of 4 new units created\n",
+		" ---> 2 of 4 new units ready\n",
+		" ---> Waiting for the rollout\n",
+		" ---> 4 of 4 new units ready\n",
+		" ---> Done updating units\n",
+	)
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := UnitAdd{}
+	command.Flags().Parse(true, []string{"-a", "radio"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "---- Updating units [web] ----\n"+
+		"\rUnits created [#######-----------------------] 1/4"+
+		"\rUnits ready   [###############---------------] 2/4"+
+		"\r\033[K ---> Waiting for the rollout\n"+
+		"Units ready   [###############---------------] 2/4"+
+		"\rUnits ready   [##############################] 4/4"+
+		"\r\033[K ---> Done updating units\n"+
+		"Units ready   [##############################] 4/4\n")
+}
+
+func (s *S) TestUnitAddProgressBarVerbose(c *check.C) {
+	old := isTerminalWriter
+	defer func() { isTerminalWriter = old }()
+	isTerminalWriter = func(io.Writer) bool { return true }
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"2"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	result := unitAddStream(c, " ---> 1 of 2 new units ready\n", " ---> 2 of 2 new units ready\n")
+	s.setupFakeTransport(&cmdtest.Transport{Message: result, Status: http.StatusOK})
+	command := UnitAdd{}
+	command.Flags().Parse(true, []string{"-a", "radio", "--verbose"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, " ---> 1 of 2 new units ready\n ---> 2 of 2 new units ready\n")
+}
+
 func (s *S) TestUnitAddInfo(c *check.C) {
 	c.Assert((&UnitAdd{}).Info(), check.NotNil)
 }
@@ -134,6 +252,110 @@ func (s *S) TestUnitRemove(c *check.C) {
 	c.Assert(stdout.String(), check.Equals, "-- removed unit --")
 }
 
+func (s *S) TestUnitAddWaitTimeout(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"2"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	oldSleep := pollSleep
+	defer func() { pollSleep = oldSleep }()
+	pollSleep = func(time.Duration) {}
+	oneWebUnit := `{"name": "radio", "units": [{"ID": "radio-web-1", "ProcessName": "web"}, {"ID": "radio-worker-1", "ProcessName": "worker"}]}`
+	threeWebUnits := `{"name": "radio", "units": [{"ID": "radio-web-1", "ProcessName": "web"}, {"ID": "radio-web-2", "ProcessName": "web"}, {"ID": "radio-web-3", "ProcessName": "web"}]}`
+	isGetApp := func(req *http.Request) bool {
+		return strings.HasSuffix(req.URL.Path, "/apps/radio") && req.Method == http.MethodGet
+	}
+	msg, _ := json.Marshal(tsuruIo.SimpleJsonMessage{Message: "-- added units --\n"})
+	trans := &cmdtest.MultiConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{Transport: cmdtest.Transport{Message: oneWebUnit, Status: http.StatusOK}, CondFunc: isGetApp},
+			{
+				Transport: cmdtest.Transport{Message: string(msg), Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return strings.HasSuffix(req.URL.Path, "/apps/radio/units") && req.Method == http.MethodPut
+				},
+			},
+			{Transport: cmdtest.Transport{Message: oneWebUnit, Status: http.StatusOK}, CondFunc: isGetApp},
+			{Transport: cmdtest.Transport{Message: threeWebUnits, Status: http.StatusOK}, CondFunc: isGetApp},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := UnitAdd{}
+	command.Flags().Parse(true, []string{"-a", "radio", "-p", "web", "--wait-timeout", "1m"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	expected := `-- added units --
+Waiting for app "radio" to have 3 units, currently 1...
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestUnitAddWaitTimeoutCI(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"1"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	oneUnit := `{"name": "radio", "units": [{"ID": "radio-web-1", "ProcessName": "web"}]}`
+	isGetApp := func(req *http.Request) bool {
+		return strings.HasSuffix(req.URL.Path, "/apps/radio") && req.Method == http.MethodGet
+	}
+	msg, _ := json.Marshal(tsuruIo.SimpleJsonMessage{Message: "-- added units --\n"})
+	trans := &cmdtest.MultiConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{Transport: cmdtest.Transport{Message: oneUnit, Status: http.StatusOK}, CondFunc: isGetApp},
+			{
+				Transport: cmdtest.Transport{Message: string(msg), Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return strings.HasSuffix(req.URL.Path, "/apps/radio/units") && req.Method == http.MethodPut
+				},
+			},
+			{Transport: cmdtest.Transport{Message: oneUnit, Status: http.StatusOK}, CondFunc: isGetApp},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := UnitAdd{}
+	command.Flags().Parse(true, []string{"-a", "radio", "--ci", "--wait-timeout", "1s"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `app "radio" has 1 units after 1s, expected 2`)
+	c.Assert(stdout.String(), check.Equals, "")
+}
+
+func (s *S) TestUnitRemoveWaitTimeoutExpired(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Args:   []string{"1"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	twoUnits := `{"name": "vapor", "units": [{"ID": "vapor-web-1", "ProcessName": "web"}, {"ID": "vapor-web-2", "ProcessName": "web"}]}`
+	isGetApp := func(req *http.Request) bool {
+		return strings.HasSuffix(req.URL.Path, "/apps/vapor") && req.Method == http.MethodGet
+	}
+	msg, _ := json.Marshal(tsuruIo.SimpleJsonMessage{Message: "-- removed unit --\n"})
+	trans := &cmdtest.MultiConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{Transport: cmdtest.Transport{Message: twoUnits, Status: http.StatusOK}, CondFunc: isGetApp},
+			{
+				Transport: cmdtest.Transport{Message: string(msg), Status: http.StatusOK},
+				CondFunc: func(req *http.Request) bool {
+					return strings.HasSuffix(req.URL.Path, "/apps/vapor/units") && req.Method == http.MethodDelete
+				},
+			},
+			{Transport: cmdtest.Transport{Message: twoUnits, Status: http.StatusOK}, CondFunc: isGetApp},
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := UnitRemove{}
+	command.Flags().Parse(true, []string{"-a", "vapor", "--ci", "--wait-timeout", "1s"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `app "vapor" has 2 units after 1s, expected 1`)
+	c.Assert(stdout.String(), check.Equals, "")
+}
+
 func (s *S) TestUnitRemoveFailure(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	context := cmd.Context{
@@ -503,6 +725,24 @@ func (s *S) TestUnitKill(c *check.C) {
 	c.Assert(err, check.IsNil)
 }
 
+func (s *S) TestUnitKillGuessesAppName(c *check.C) {
+	tsuruClientApp.GitRemoteURL = func() (string, error) {
+		return "git@tsuru.example.com:app1.git", nil
+	}
+	context := cmd.Context{Args: []string{"unit1"}, Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	trans := &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Status: http.StatusOK},
+		CondFunc: func(req *http.Request) bool {
+			return req.Method == http.MethodDelete && strings.HasSuffix(req.URL.Path, "/apps/app1/units/unit1")
+		},
+	}
+	s.setupFakeTransport(trans)
+	command := UnitKill{}
+	command.Flags().Parse(true, []string{"-f"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+}
+
 func (s *S) TestUnitKillMissingUnit(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	context := cmd.Context{
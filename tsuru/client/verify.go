@@ -0,0 +1,185 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tsuru/gnuflag"
+	"github.com/tsuru/go-tsuruclient/pkg/config"
+	tsuruClientApp "github.com/tsuru/tsuru-client/tsuru/app"
+	tsuruHTTP "github.com/tsuru/tsuru-client/tsuru/http"
+	"github.com/tsuru/tsuru/cmd"
+)
+
+var (
+	verifyClient        = &http.Client{Timeout: 10 * time.Second}
+	verifyRetryInterval = 5 * time.Second
+)
+
+type AppVerify struct {
+	tsuruClientApp.AppNameMixIn
+	fs     *gnuflag.FlagSet
+	path   string
+	expect int
+	grace  time.Duration
+}
+
+func (c *AppVerify) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "app-verify",
+		Usage: "app verify [-a/--app appname] [--path path] [--expect status] [--grace duration]",
+		Desc: `Verifies that an application is reachable after a deploy, probing each of
+its external addresses and cnames and asserting the returned HTTP status.
+
+Cnames with a certificate are probed over HTTPS, all other addresses over HTTP.
+Failed probes are retried until the grace period, defined by the [[--grace]]
+flag, expires. The command exits with an error if any address does not return
+the status defined by the [[--expect]] flag.`,
+		MinArgs: 0,
+		MaxArgs: 0,
+	}
+}
+
+func (c *AppVerify) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = c.AppNameMixIn.Flags()
+		c.fs.StringVar(&c.path, "path", "/", "The path requested on each address")
+		c.fs.IntVar(&c.expect, "expect", http.StatusOK, "The expected HTTP status code")
+		c.fs.DurationVar(&c.grace, "grace", time.Minute, "How long failed probes are retried")
+	}
+	return c.fs
+}
+
+func (c *AppVerify) Run(context *cmd.Context) error {
+	appName, err := c.AppNameByFlag()
+	if err != nil {
+		return err
+	}
+	a, err := getApp(appName)
+	if err != nil {
+		return err
+	}
+	tlsCNames, err := appTLSCNames(appName)
+	if err != nil {
+		return err
+	}
+	path := c.path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	pending := verifyTargets(a, tlsCNames, path)
+	if len(pending) == 0 {
+		return fmt.Errorf("app %q has no external addresses to verify", appName)
+	}
+	results := map[string]string{}
+	deadline := time.Now().Add(c.grace)
+	for {
+		var failed []string
+		for _, target := range pending {
+			status, probeErr := probeAddress(target)
+			if probeErr == nil && status == c.expect {
+				fmt.Fprintf(context.Stdout, "OK: %s (%d)\n", target, status)
+				delete(results, target)
+				continue
+			}
+			if probeErr != nil {
+				results[target] = probeErr.Error()
+			} else {
+				results[target] = fmt.Sprintf("expected status %d, got %d", c.expect, status)
+			}
+			failed = append(failed, target)
+		}
+		pending = failed
+		if len(pending) == 0 || time.Now().Add(verifyRetryInterval).After(deadline) {
+			break
+		}
+		time.Sleep(verifyRetryInterval)
+	}
+	if len(pending) > 0 {
+		for _, target := range pending {
+			fmt.Fprintf(context.Stderr, "FAIL: %s: %s\n", target, results[target])
+		}
+		return fmt.Errorf("verification failed for %d address(es)", len(pending))
+	}
+	return nil
+}
+
+func verifyTargets(a *app, tlsCNames map[string]bool, path string) []string {
+	var hosts []string
+	hosts = append(hosts, a.CName...)
+	if len(a.Routers) == 0 {
+		hosts = append(hosts, a.IP)
+	}
+	for _, r := range a.Routers {
+		if len(r.Addresses) > 0 {
+			hosts = append(hosts, r.Addresses...)
+		} else {
+			hosts = append(hosts, r.Address)
+		}
+	}
+	seen := map[string]bool{}
+	var targets []string
+	for _, host := range hosts {
+		if host == "" || seen[host] {
+			continue
+		}
+		seen[host] = true
+		scheme := "http"
+		if tlsCNames[host] {
+			scheme = "https"
+		}
+		targets = append(targets, fmt.Sprintf("%s://%s%s", scheme, host, path))
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+func appTLSCNames(appName string) (map[string]bool, error) {
+	u, err := config.GetURLVersion("1.24", fmt.Sprintf("/apps/%s/certificate", appName))
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := tsuruHTTP.AuthenticatedClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	result := map[string]bool{}
+	if response.StatusCode == http.StatusNoContent {
+		return result, nil
+	}
+	var appCerts appCertificate
+	err = json.NewDecoder(response.Body).Decode(&appCerts)
+	if err != nil {
+		return nil, err
+	}
+	for _, routerCerts := range appCerts.RouterCertificates {
+		for cname, cnameCert := range routerCerts.CNameCertificates {
+			if cnameCert.Certificate != "" {
+				result[cname] = true
+			}
+		}
+	}
+	return result, nil
+}
+
+func probeAddress(target string) (int, error) {
+	response, err := verifyClient.Get(target)
+	if err != nil {
+		return 0, err
+	}
+	response.Body.Close()
+	return response.StatusCode, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/tsuru/tsuru/cmd"
+	"github.com/tsuru/tsuru/cmd/cmdtest"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) setupVerifyTransport(appHost string) {
+	trans := &cmdtest.AnyConditionalTransport{
+		ConditionalTransports: []cmdtest.ConditionalTransport{
+			{
+				Transport: cmdtest.Transport{Message: `{"routers":{}}`, Status: http.StatusOK},
+				CondFunc: func(r *http.Request) bool {
+					return strings.HasSuffix(r.URL.Path, "/apps/myapp/certificate")
+				},
+			},
+			{
+				Transport: cmdtest.Transport{Message: fmt.Sprintf(`{"name":"myapp","ip":%q}`, appHost), Status: http.StatusOK},
+				CondFunc: func(r *http.Request) bool {
+					return strings.HasSuffix(r.URL.Path, "/apps/myapp")
+				},
+			},
+		},
+	}
+	s.setupFakeTransport(trans)
+}
+
+func (s *S) TestAppVerify(c *check.C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.URL.Path, check.Equals, "/healthz")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	s.setupVerifyTransport(host)
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	command := AppVerify{}
+	command.Flags().Parse(true, []string{"-a", "myapp", "--path", "/healthz"})
+	err := command.Run(&context)
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, fmt.Sprintf("OK: http://%s/healthz (200)\n", host))
+}
+
+func (s *S) TestAppVerifyUnexpectedStatus(c *check.C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	s.setupVerifyTransport(host)
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, Stderr: &stderr}
+	command := AppVerify{}
+	command.Flags().Parse(true, []string{"-a", "myapp", "--grace", "0s"})
+	err := command.Run(&context)
+	c.Assert(err, check.ErrorMatches, `verification failed for 1 address\(es\)`)
+	c.Assert(stderr.String(), check.Equals, fmt.Sprintf("FAIL: http://%s/: expected status 200, got 503\n", host))
+}
+
+func (s *S) TestVerifyTargets(c *check.C) {
+	a := &app{IP: "myapp.tsuru.io", CName: []string{"www.example.com", "api.example.com"}}
+	targets := verifyTargets(a, map[string]bool{"www.example.com": true}, "/")
+	c.Assert(targets, check.DeepEquals, []string{
+		"http://api.example.com/",
+		"http://myapp.tsuru.io/",
+		"https://www.example.com/",
+	})
+}
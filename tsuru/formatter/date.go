@@ -37,6 +37,23 @@ func FormatDuration(duration *time.Duration) string {
 	return fmt.Sprintf("%02d:%02d", minutes, seconds)
 }
 
+// FormatRelative returns how long before now the date is, like "2s ago" or
+// "3h ago", using the largest unit that fits.
+func FormatRelative(date, now time.Time) string {
+	elapsed := now.Sub(date)
+	switch {
+	case elapsed < time.Second:
+		return "now"
+	case elapsed < time.Minute:
+		return fmt.Sprintf("%ds ago", elapsed/time.Second)
+	case elapsed < time.Hour:
+		return fmt.Sprintf("%dm ago", elapsed/time.Minute)
+	case elapsed < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", elapsed/time.Hour)
+	}
+	return fmt.Sprintf("%dd ago", elapsed/(24*time.Hour))
+}
+
 func FormatDateAndDuration(date time.Time, duration *time.Duration) string {
 	return fmt.Sprintf("%s (%s)", FormatDate(date), FormatDuration(duration))
 }
@@ -33,3 +33,13 @@ func (s *S) TestFormatDateAndDuration(c *check.C) {
 	c.Assert(FormatDateAndDuration(parsedTs, &duration), check.Equals, "16 Feb 18 05:03 CST (02:03)")
 	c.Assert(FormatDateAndDuration(parsedTs, nil), check.Equals, "16 Feb 18 05:03 CST (…)")
 }
+
+func (s *S) TestFormatRelative(c *check.C) {
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	c.Assert(FormatRelative(now, now), check.Equals, "now")
+	c.Assert(FormatRelative(now.Add(time.Minute), now), check.Equals, "now")
+	c.Assert(FormatRelative(now.Add(-2*time.Second), now), check.Equals, "2s ago")
+	c.Assert(FormatRelative(now.Add(-90*time.Second), now), check.Equals, "1m ago")
+	c.Assert(FormatRelative(now.Add(-3*time.Hour), now), check.Equals, "3h ago")
+	c.Assert(FormatRelative(now.Add(-50*time.Hour), now), check.Equals, "2d ago")
+}
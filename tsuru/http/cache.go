@@ -0,0 +1,130 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/tsuru/go-tsuruclient/pkg/config"
+)
+
+// CacheTTL is how long the responses stored by DoCached are reused.
+const CacheTTL = 30 * time.Second
+
+var cacheNow = time.Now
+
+type cacheEntry struct {
+	Time   time.Time `json:"time"`
+	Status int       `json:"status"`
+	Body   []byte    `json:"body"`
+}
+
+// IsCacheDisabled reports whether the response cache is disabled, through the
+// global --no-cache flag or the TSURU_NO_CACHE environment variable.
+func IsCacheDisabled() bool {
+	v, _ := strconv.ParseBool(os.Getenv("TSURU_NO_CACHE"))
+	return v
+}
+
+func cacheDir() string {
+	return config.JoinWithUserDir(".tsuru", "cache", "responses")
+}
+
+// cachePath returns the path of the cached response of the request. The key
+// is the full URL and the token used for the request, so each target and each
+// user, like a team token set in TSURU_TOKEN, has its own entries.
+func cachePath(req *http.Request) string {
+	token, _ := config.DefaultTokenProvider.Token()
+	h := fnv.New64a()
+	io.WriteString(h, req.URL.String())
+	io.WriteString(h, "\n")
+	io.WriteString(h, token)
+	return fmt.Sprintf("%s/%x", cacheDir(), h.Sum64())
+}
+
+// DoCached sends the GET request with DoWithRetries, reusing a response stored
+// on disk for up to CacheTTL. It's meant for read-only lists that are
+// requested often, like the pools and the apps. The cache is cleared by any
+// successful mutating request, see InvalidateCache.
+func DoCached(req *http.Request) (*http.Response, error) {
+	if IsCacheDisabled() || req.Method != http.MethodGet {
+		return DoWithRetries(req)
+	}
+	path := cachePath(req)
+	if entry, ok := readCacheEntry(path); ok {
+		return cachedResponse(req, entry), nil
+	}
+	resp, err := DoWithRetries(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	entry := cacheEntry{Time: cacheNow(), Status: resp.StatusCode, Body: body}
+	writeCacheEntry(path, entry)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+func cachedResponse(req *http.Request, entry cacheEntry) *http.Response {
+	return &http.Response{
+		StatusCode: entry.Status,
+		Status:     fmt.Sprintf("%d %s", entry.Status, http.StatusText(entry.Status)),
+		Header:     http.Header{},
+		Body:       io.NopCloser(bytes.NewReader(entry.Body)),
+		Request:    req,
+	}
+}
+
+func readCacheEntry(path string) (cacheEntry, bool) {
+	var entry cacheEntry
+	f, err := config.Filesystem().Open(path)
+	if err != nil {
+		return entry, false
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(&entry); err != nil {
+		return entry, false
+	}
+	age := cacheNow().Sub(entry.Time)
+	if age < 0 || age > CacheTTL {
+		return entry, false
+	}
+	return entry, true
+}
+
+// writeCacheEntry stores the response. Failing to write it is not an error,
+// the request is sent again next time.
+func writeCacheEntry(path string, entry cacheEntry) {
+	if err := config.Filesystem().MkdirAll(cacheDir(), 0700); err != nil {
+		return
+	}
+	f, err := config.Filesystem().OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	json.NewEncoder(f).Encode(entry)
+}
+
+// InvalidateCache removes all the responses stored by DoCached. It's called
+// after each successful mutating request, as it may change the cached lists.
+func InvalidateCache() {
+	config.Filesystem().RemoveAll(cacheDir())
+}
@@ -0,0 +1,124 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/tsuru/go-tsuruclient/pkg/config"
+	"github.com/tsuru/tsuru/cmd/cmdtest"
+	"github.com/tsuru/tsuru/fs/fstest"
+	check "gopkg.in/check.v1"
+)
+
+func setupCache(rt http.RoundTripper, now *time.Time) func() {
+	oldClient, oldNow := AuthenticatedClient, cacheNow
+	AuthenticatedClient = NewTerminalClient(TerminalClientOptions{RoundTripper: rt, ClientVersion: "dev"})
+	cacheNow = func() time.Time { return *now }
+	config.SetFileSystem(&fstest.RecordingFs{})
+	return func() {
+		AuthenticatedClient, cacheNow = oldClient, oldNow
+		config.ResetFileSystem()
+	}
+}
+
+func countingTransport(requests *int, message string, status int) http.RoundTripper {
+	return &cmdtest.ConditionalTransport{
+		Transport: cmdtest.Transport{Message: message, Status: status},
+		CondFunc: func(req *http.Request) bool {
+			if req.Method == http.MethodGet {
+				*requests++
+			}
+			return true
+		},
+	}
+}
+
+func doCachedBody(c *check.C, url string) string {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	c.Assert(err, check.IsNil)
+	resp, err := DoCached(req)
+	c.Assert(err, check.IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, check.Equals, http.StatusOK)
+	body, err := io.ReadAll(resp.Body)
+	c.Assert(err, check.IsNil)
+	return string(body)
+}
+
+func (s *S) TestDoCached(c *check.C) {
+	now := time.Now()
+	var requests int
+	defer setupCache(countingTransport(&requests, `[{"Name":"pool1"}]`, http.StatusOK), &now)()
+	c.Assert(doCachedBody(c, "http://localhost/1.0/pools"), check.Equals, `[{"Name":"pool1"}]`)
+	c.Assert(doCachedBody(c, "http://localhost/1.0/pools"), check.Equals, `[{"Name":"pool1"}]`)
+	c.Assert(requests, check.Equals, 1)
+	c.Assert(doCachedBody(c, "http://otherhost/1.0/pools"), check.Equals, `[{"Name":"pool1"}]`)
+	c.Assert(requests, check.Equals, 2)
+	now = now.Add(CacheTTL + time.Second)
+	c.Assert(doCachedBody(c, "http://localhost/1.0/pools"), check.Equals, `[{"Name":"pool1"}]`)
+	c.Assert(requests, check.Equals, 3)
+}
+
+func (s *S) TestDoCachedPerToken(c *check.C) {
+	now := time.Now()
+	var requests int
+	defer setupCache(countingTransport(&requests, `[{"Name":"app1"}]`, http.StatusOK), &now)()
+	os.Setenv("TSURU_TOKEN", "token1")
+	defer os.Unsetenv("TSURU_TOKEN")
+	doCachedBody(c, "http://localhost/1.0/apps")
+	doCachedBody(c, "http://localhost/1.0/apps")
+	c.Assert(requests, check.Equals, 1)
+	os.Setenv("TSURU_TOKEN", "token2")
+	doCachedBody(c, "http://localhost/1.0/apps")
+	c.Assert(requests, check.Equals, 2)
+}
+
+func (s *S) TestDoCachedDisabled(c *check.C) {
+	now := time.Now()
+	var requests int
+	defer setupCache(countingTransport(&requests, `[]`, http.StatusOK), &now)()
+	os.Setenv("TSURU_NO_CACHE", "true")
+	defer os.Unsetenv("TSURU_NO_CACHE")
+	doCachedBody(c, "http://localhost/1.0/apps")
+	doCachedBody(c, "http://localhost/1.0/apps")
+	c.Assert(requests, check.Equals, 2)
+}
+
+func (s *S) TestDoCachedDoesNotStoreErrors(c *check.C) {
+	now := time.Now()
+	var requests int
+	defer setupCache(countingTransport(&requests, "internal error", http.StatusInternalServerError), &now)()
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodGet, "http://localhost/1.0/apps", nil)
+		c.Assert(err, check.IsNil)
+		_, err = DoCached(req)
+		c.Assert(err, check.ErrorMatches, ".*internal error")
+	}
+	c.Assert(requests, check.Equals, 2)
+}
+
+func (s *S) TestMutatingRequestInvalidatesCache(c *check.C) {
+	now := time.Now()
+	var requests int
+	defer setupCache(countingTransport(&requests, `[]`, http.StatusOK), &now)()
+	rfs := &fstest.RecordingFs{}
+	config.SetFileSystem(rfs)
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/1.0/apps", nil)
+	c.Assert(err, check.IsNil)
+	resp, err := AuthenticatedClient.Do(req)
+	c.Assert(err, check.IsNil)
+	resp.Body.Close()
+	c.Assert(rfs.HasAction("removeall "+cacheDir()), check.Equals, false)
+	req, err = http.NewRequest(http.MethodPost, "http://localhost/1.0/apps", nil)
+	c.Assert(err, check.IsNil)
+	resp, err = AuthenticatedClient.Do(req)
+	c.Assert(err, check.IsNil)
+	resp.Body.Close()
+	c.Assert(rfs.HasAction("removeall "+cacheDir()), check.Equals, true)
+}
@@ -0,0 +1,106 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// ErrDryRun is returned instead of sending mutating requests when the dry run
+// mode is enabled.
+var ErrDryRun = errors.New("dry run mode enabled, the request was not sent")
+
+// IsDryRun reports whether the dry run mode is enabled, through the global
+// --dry-run flag or the TSURU_DRY_RUN environment variable.
+func IsDryRun() bool {
+	v, _ := strconv.ParseBool(os.Getenv("TSURU_DRY_RUN"))
+	return v
+}
+
+func isMutatingRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// dumpDryRunRequest writes the method, path and body of the request to w,
+// replacing the values of fields that may hold secrets, like passwords,
+// tokens and environment variable values.
+func dumpDryRunRequest(w io.Writer, req *http.Request) error {
+	fmt.Fprintf(w, "%s %s\n", req.Method, req.URL.Path)
+	query := req.URL.Query()
+	if len(query) > 0 {
+		fmt.Fprintln(w, "Query:")
+		writeRedactedValues(w, query)
+	}
+	if req.Body == nil {
+		return nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return err
+	}
+	if len(body) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w, "Body:")
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return err
+		}
+		writeRedactedValues(w, values)
+	case "application/json":
+		var data interface{}
+		if err := json.Unmarshal(body, &data); err != nil {
+			return err
+		}
+		redacted, err := json.MarshalIndent(Redact(data), "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", redacted)
+	default:
+		fmt.Fprintf(w, "<%d bytes of %s>\n", len(body), mediaTypeOrDefault(mediaType))
+	}
+	return nil
+}
+
+func mediaTypeOrDefault(mediaType string) string {
+	if mediaType == "" {
+		return "data"
+	}
+	return mediaType
+}
+
+func writeRedactedValues(w io.Writer, values url.Values) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range values[k] {
+			if IsSecretKey(k) {
+				v = RedactedValue
+			}
+			fmt.Fprintf(w, "  %s=%s\n", k, v)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// eventIDHeader is the header in which the API returns the id of the event
+// created by a request. Only some handlers, like the deploy ones, set it.
+const eventIDHeader = "X-Tsuru-Eventid"
+
+var sentRequests struct {
+	sync.Mutex
+	mutating bool
+	eventIDs []string
+}
+
+// IsShowEvent reports whether the ids of the events created by the command
+// should be displayed, through the global --show-event flag or the
+// TSURU_SHOW_EVENT environment variable.
+func IsShowEvent() bool {
+	v, _ := strconv.ParseBool(os.Getenv("TSURU_SHOW_EVENT"))
+	return v
+}
+
+func recordEvent(req *http.Request, response *http.Response) {
+	if !isMutatingRequest(req) {
+		return
+	}
+	sentRequests.Lock()
+	defer sentRequests.Unlock()
+	sentRequests.mutating = true
+	id := response.Header.Get(eventIDHeader)
+	if id == "" {
+		return
+	}
+	for _, seen := range sentRequests.eventIDs {
+		if seen == id {
+			return
+		}
+	}
+	sentRequests.eventIDs = append(sentRequests.eventIDs, id)
+}
+
+// SentEvents returns the ids of the events returned by the API for the
+// requests sent so far, and whether any of these requests changed something
+// in the API.
+func SentEvents() (eventIDs []string, mutating bool) {
+	sentRequests.Lock()
+	defer sentRequests.Unlock()
+	return append([]string(nil), sentRequests.eventIDs...), sentRequests.mutating
+}
+
+// ResetSentEvents forgets the requests sent so far.
+func ResetSentEvents() {
+	sentRequests.Lock()
+	defer sentRequests.Unlock()
+	sentRequests.mutating = false
+	sentRequests.eventIDs = nil
+}
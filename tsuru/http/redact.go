@@ -0,0 +1,58 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import "regexp"
+
+// RedactedValue replaces the values of secrets in the output of commands.
+const RedactedValue = "*****"
+
+var (
+	reSecretKey = regexp.MustCompile(`(?i)(passw(or)?d|secret|token|credential|api_?key|private_?key|^envs\.\d+\.value$)`)
+	reEnvsKey   = regexp.MustCompile(`(?i)^envs$`)
+)
+
+// IsSecretKey reports whether the value of a field, like a form field or a
+// JSON key, may hold a secret, like a password, a token or the value of an
+// environment variable.
+func IsSecretKey(key string) bool {
+	return reSecretKey.MatchString(key)
+}
+
+// Redact returns a copy of data, as decoded from JSON, with the values of
+// secret keys redacted. Besides regular maps, it handles the {"name": ...,
+// "value": ...} pairs used to store the form of a request and the environment
+// variables of apps: the value is redacted when the name is a secret key, when
+// the variable is private, or when the pair is in a list of environment
+// variables.
+func Redact(data any) any {
+	return redact(data, false)
+}
+
+func redact(data any, inEnvs bool) any {
+	switch v := data.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		name, _ := v["name"].(string)
+		public, hasPublic := v["public"].(bool)
+		private, _ := v["private"].(bool)
+		secretValue := inEnvs || private || (hasPublic && !public) || IsSecretKey(name)
+		for key, value := range v {
+			if IsSecretKey(key) || (key == "value" && secretValue) {
+				result[key] = RedactedValue
+				continue
+			}
+			result[key] = redact(value, reEnvsKey.MatchString(key))
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = redact(item, inEnvs)
+		}
+		return result
+	}
+	return data
+}
@@ -0,0 +1,50 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import check "gopkg.in/check.v1"
+
+func (s *S) TestIsSecretKey(c *check.C) {
+	for _, key := range []string{"password", "passwd", "api_token", "client_secret", "credentials", "apikey", "private_key", "Envs.0.Value"} {
+		c.Check(IsSecretKey(key), check.Equals, true, check.Commentf("key %q", key))
+	}
+	for _, key := range []string{"name", "email", "value", "Envs.0.Name", "keyword"} {
+		c.Check(IsSecretKey(key), check.Equals, false, check.Commentf("key %q", key))
+	}
+}
+
+func (s *S) TestRedact(c *check.C) {
+	data := map[string]any{
+		"form": []any{
+			map[string]any{"name": ":app", "value": "myapp"},
+			map[string]any{"name": "Envs.0.Value", "value": "s3cr3t"},
+			map[string]any{"name": "api_token", "value": "abc"},
+		},
+		"envs": []any{
+			map[string]any{"name": "DEBUG", "value": "1"},
+		},
+		"vars": []any{
+			map[string]any{"name": "A", "value": "1", "public": true},
+			map[string]any{"name": "B", "value": "2", "public": false},
+		},
+		"nested": map[string]any{"password": "123"},
+	}
+	c.Assert(Redact(data), check.DeepEquals, map[string]any{
+		"form": []any{
+			map[string]any{"name": ":app", "value": "myapp"},
+			map[string]any{"name": "Envs.0.Value", "value": "*****"},
+			map[string]any{"name": "api_token", "value": "*****"},
+		},
+		"envs": []any{
+			map[string]any{"name": "DEBUG", "value": "*****"},
+		},
+		"vars": []any{
+			map[string]any{"name": "A", "value": "1", "public": true},
+			map[string]any{"name": "B", "value": "*****", "public": false},
+		},
+		"nested": map[string]any{"password": "*****"},
+	})
+	c.Assert(data["nested"], check.DeepEquals, map[string]any{"password": "123"})
+}
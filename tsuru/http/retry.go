@@ -0,0 +1,73 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	tsuruerr "github.com/tsuru/tsuru/errors"
+)
+
+const defaultRetryDelay = time.Second
+
+var retrySleep = time.Sleep
+
+// Retries returns how many times read-only requests sent through
+// DoWithRetries are retried, set by the global --retries flag or the
+// TSURU_RETRIES environment variable. It defaults to no retries.
+func Retries() int {
+	n, err := strconv.Atoi(os.Getenv("TSURU_RETRIES"))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// RetryDelay returns the delay before the first retry, set by the
+// TSURU_RETRY_DELAY environment variable, like "500ms". The delay doubles
+// after each retry.
+func RetryDelay() time.Duration {
+	d, err := time.ParseDuration(os.Getenv("TSURU_RETRY_DELAY"))
+	if err != nil || d < 0 {
+		return defaultRetryDelay
+	}
+	return d
+}
+
+// DoWithRetries sends the request using the authenticated client, retrying it
+// on transient failures, like a 503 response or a connection reset. Only
+// read-only requests are retried, mutating requests are sent once.
+func DoWithRetries(req *http.Request) (*http.Response, error) {
+	retries := Retries()
+	if retries == 0 || isMutatingRequest(req) {
+		return AuthenticatedClient.Do(req)
+	}
+	delay := RetryDelay()
+	for attempt := 0; ; attempt++ {
+		resp, err := AuthenticatedClient.Do(req)
+		if err == nil || attempt >= retries || !isTransientError(err) {
+			return resp, err
+		}
+		retrySleep(delay << attempt)
+	}
+}
+
+func isTransientError(err error) bool {
+	if errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	if httpErr, ok := UnwrapErr(err).(*tsuruerr.HTTP); ok {
+		switch httpErr.Code {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/tsuru/tsuru/cmd/cmdtest"
+	check "gopkg.in/check.v1"
+)
+
+type resetTransport struct {
+	calls int
+}
+
+func (t *resetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.calls++
+	return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+}
+
+func statusTransports(statuses ...int) *cmdtest.MultiConditionalTransport {
+	trans := &cmdtest.MultiConditionalTransport{}
+	for _, status := range statuses {
+		trans.ConditionalTransports = append(trans.ConditionalTransports, cmdtest.ConditionalTransport{
+			Transport: cmdtest.Transport{Message: http.StatusText(status), Status: status},
+			CondFunc:  func(*http.Request) bool { return true },
+		})
+	}
+	return trans
+}
+
+func setupRetries(rt http.RoundTripper) (*[]time.Duration, func()) {
+	oldClient, oldSleep := AuthenticatedClient, retrySleep
+	AuthenticatedClient = NewTerminalClient(TerminalClientOptions{RoundTripper: rt, ClientVersion: "dev"})
+	var sleeps []time.Duration
+	retrySleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	os.Setenv("TSURU_RETRIES", "3")
+	os.Setenv("TSURU_RETRY_DELAY", "100ms")
+	return &sleeps, func() {
+		AuthenticatedClient, retrySleep = oldClient, oldSleep
+		os.Unsetenv("TSURU_RETRIES")
+		os.Unsetenv("TSURU_RETRY_DELAY")
+	}
+}
+
+func (s *S) TestDoWithRetries(c *check.C) {
+	sleeps, restore := setupRetries(statusTransports(http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK))
+	defer restore()
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/apps", nil)
+	c.Assert(err, check.IsNil)
+	resp, err := DoWithRetries(req)
+	c.Assert(err, check.IsNil)
+	c.Assert(resp.StatusCode, check.Equals, http.StatusOK)
+	c.Assert(*sleeps, check.DeepEquals, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond})
+}
+
+func (s *S) TestDoWithRetriesGivesUp(c *check.C) {
+	trans := &resetTransport{}
+	sleeps, restore := setupRetries(trans)
+	defer restore()
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/apps", nil)
+	c.Assert(err, check.IsNil)
+	_, err = DoWithRetries(req)
+	c.Assert(err, check.NotNil)
+	c.Assert(trans.calls, check.Equals, 4)
+	c.Assert(*sleeps, check.HasLen, 3)
+}
+
+func (s *S) TestDoWithRetriesNonTransientError(c *check.C) {
+	sleeps, restore := setupRetries(statusTransports(http.StatusNotFound))
+	defer restore()
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/apps/myapp", nil)
+	c.Assert(err, check.IsNil)
+	_, err = DoWithRetries(req)
+	c.Assert(err, check.ErrorMatches, ".*Not Found.*")
+	c.Assert(*sleeps, check.HasLen, 0)
+}
+
+func (s *S) TestDoWithRetriesNeverRetriesWrites(c *check.C) {
+	trans := &resetTransport{}
+	sleeps, restore := setupRetries(trans)
+	defer restore()
+	req, err := http.NewRequest(http.MethodPost, "http://localhost/apps", nil)
+	c.Assert(err, check.IsNil)
+	_, err = DoWithRetries(req)
+	c.Assert(err, check.NotNil)
+	c.Assert(trans.calls, check.Equals, 1)
+	c.Assert(*sleeps, check.HasLen, 0)
+}
+
+func (s *S) TestRetriesFromEnvironment(c *check.C) {
+	c.Assert(Retries(), check.Equals, 0)
+	c.Assert(RetryDelay(), check.Equals, time.Second)
+	os.Setenv("TSURU_RETRIES", "-1")
+	os.Setenv("TSURU_RETRY_DELAY", "2s")
+	defer os.Unsetenv("TSURU_RETRIES")
+	defer os.Unsetenv("TSURU_RETRY_DELAY")
+	c.Assert(Retries(), check.Equals, 0)
+	c.Assert(RetryDelay(), check.Equals, 2*time.Second)
+}
@@ -0,0 +1,118 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultTimeout = 2 * time.Minute
+
+type streamingKey struct{}
+
+// Timeout returns the deadline of each request sent to the tsuru API, set by
+// the global --timeout flag or the TSURU_TIMEOUT environment variable, like
+// "30s". A zero timeout disables the deadline.
+func Timeout() time.Duration {
+	value := os.Getenv("TSURU_TIMEOUT")
+	if value == "" {
+		return defaultTimeout
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return defaultTimeout
+	}
+	return d
+}
+
+// Streaming marks the request as streaming, like following logs, deploying
+// or adding units. Streaming requests are exempt from the timeout, as they
+// may take any time to finish.
+func Streaming(req *http.Request) *http.Request {
+	return req.WithContext(StreamingContext(req.Context()))
+}
+
+// StreamingContext returns a context that marks the requests sent with it as
+// streaming, for the requests sent through the go-tsuruclient API client,
+// like updating an app, which restarts it.
+func StreamingContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamingKey{}, true)
+}
+
+func isStreaming(req *http.Request) bool {
+	streaming, _ := req.Context().Value(streamingKey{}).(bool)
+	return streaming
+}
+
+// withTimeout sends the request through roundTripper with the deadline
+// returned by Timeout. The deadline covers reading the response body.
+func withTimeout(roundTripper http.RoundTripper, req *http.Request) (*http.Response, error) {
+	timeout := Timeout()
+	if timeout == 0 || isStreaming(req) {
+		return roundTripper.RoundTrip(req)
+	}
+	ctx, cancel := context.WithCancel(req.Context())
+	var timedOut atomic.Bool
+	timer := time.AfterFunc(timeout, func() {
+		timedOut.Store(true)
+		cancel()
+	})
+	response, err := roundTripper.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		timer.Stop()
+		cancel()
+		if timedOut.Load() {
+			return nil, &timeoutError{timeout: timeout}
+		}
+		return nil, err
+	}
+	response.Body = &cancelBody{
+		ReadCloser: response.Body,
+		timeout:    timeout,
+		timedOut:   &timedOut,
+		cancel: func() {
+			timer.Stop()
+			cancel()
+		},
+	}
+	return response, nil
+}
+
+// cancelBody releases the request context once the response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	timeout  time.Duration
+	timedOut *atomic.Bool
+	cancel   func()
+	once     sync.Once
+}
+
+func (b *cancelBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF && b.timedOut.Load() {
+		err = &timeoutError{timeout: b.timeout}
+	}
+	return n, err
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.cancel)
+	return err
+}
+
+type timeoutError struct {
+	timeout time.Duration
+}
+
+func (e *timeoutError) Error() string {
+	return "request timed out after " + e.timeout.String() + ", use --timeout to wait longer"
+}
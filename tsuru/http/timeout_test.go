@@ -0,0 +1,90 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	check "gopkg.in/check.v1"
+)
+
+// slowTransport responds after delay, unless the request is canceled before.
+type slowTransport struct {
+	delay time.Duration
+}
+
+func (t *slowTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	select {
+	case <-time.After(t.delay):
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+}
+
+func (s *S) TestTimeout(c *check.C) {
+	defer os.Unsetenv("TSURU_TIMEOUT")
+	os.Unsetenv("TSURU_TIMEOUT")
+	c.Assert(Timeout(), check.Equals, 2*time.Minute)
+	os.Setenv("TSURU_TIMEOUT", "30s")
+	c.Assert(Timeout(), check.Equals, 30*time.Second)
+	os.Setenv("TSURU_TIMEOUT", "0")
+	c.Assert(Timeout(), check.Equals, time.Duration(0))
+	os.Setenv("TSURU_TIMEOUT", "soon")
+	c.Assert(Timeout(), check.Equals, 2*time.Minute)
+}
+
+func (s *S) TestRequestTimeout(c *check.C) {
+	os.Setenv("TSURU_TIMEOUT", "50ms")
+	defer os.Unsetenv("TSURU_TIMEOUT")
+	client := NewTerminalClient(TerminalClientOptions{RoundTripper: &slowTransport{delay: time.Minute}, ClientVersion: "dev"})
+	req, err := http.NewRequest(http.MethodPost, "http://localhost/swap", nil)
+	c.Assert(err, check.IsNil)
+	_, err = client.Do(req)
+	c.Assert(err, check.ErrorMatches, `.*Failed to get a response from tsuru server .*: request timed out after 50ms, use --timeout to wait longer`)
+}
+
+func (s *S) TestRequestWithinTimeout(c *check.C) {
+	os.Setenv("TSURU_TIMEOUT", "1m")
+	defer os.Unsetenv("TSURU_TIMEOUT")
+	client := NewTerminalClient(TerminalClientOptions{RoundTripper: &slowTransport{}, ClientVersion: "dev"})
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/apps", nil)
+	c.Assert(err, check.IsNil)
+	resp, err := client.Do(req)
+	c.Assert(err, check.IsNil)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	c.Assert(err, check.IsNil)
+	c.Assert(string(body), check.Equals, "ok")
+}
+
+func (s *S) TestStreamingRequestIgnoresTimeout(c *check.C) {
+	os.Setenv("TSURU_TIMEOUT", "10ms")
+	defer os.Unsetenv("TSURU_TIMEOUT")
+	client := NewTerminalClient(TerminalClientOptions{RoundTripper: &slowTransport{delay: 100 * time.Millisecond}, ClientVersion: "dev"})
+	req, err := http.NewRequest(http.MethodPost, "http://localhost/apps/myapp/units", nil)
+	c.Assert(err, check.IsNil)
+	resp, err := client.Do(Streaming(req))
+	c.Assert(err, check.IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, check.Equals, http.StatusOK)
+}
+
+func (s *S) TestStreamingContextIgnoresTimeout(c *check.C) {
+	os.Setenv("TSURU_TIMEOUT", "10ms")
+	defer os.Unsetenv("TSURU_TIMEOUT")
+	client := NewTerminalClient(TerminalClientOptions{RoundTripper: &slowTransport{delay: 100 * time.Millisecond}, ClientVersion: "dev"})
+	req, err := http.NewRequestWithContext(StreamingContext(context.Background()), http.MethodPut, "http://localhost/apps/myapp", nil)
+	c.Assert(err, check.IsNil)
+	resp, err := client.Do(req)
+	c.Assert(err, check.IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, check.Equals, http.StatusOK)
+}
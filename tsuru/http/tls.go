@@ -0,0 +1,65 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// NewTransport returns the base transport for requests to the tsuru API. Like
+// http.DefaultTransport, it uses the proxy set by the HTTPS_PROXY, HTTP_PROXY
+// and NO_PROXY environment variables. When TSURU_CA_FILE points to a PEM
+// bundle, it trusts its certificates in addition to the system ones.
+func NewTransport() (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	tlsConfig, err := TLSConfig()
+	if err != nil || tlsConfig == nil {
+		return transport, err
+	}
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	transport.TLSClientConfig.RootCAs = tlsConfig.RootCAs
+	return transport, nil
+}
+
+// TLSConfig returns the TLS configuration for connections to the tsuru API
+// that don't use NewTransport, like the websocket of app-shell. It's nil when
+// TSURU_CA_FILE is not set, to use the system certificates.
+func TLSConfig() (*tls.Config, error) {
+	caFile := os.Getenv("TSURU_CA_FILE")
+	if caFile == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the CA file from TSURU_CA_FILE: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in the CA file %q", caFile)
+	}
+	return &tls.Config{RootCAs: pool}, nil
+}
+
+// ConfigureDefaultTransport replaces http.DefaultTransport, used by the
+// authenticated and unauthenticated clients, with the one returned by
+// NewTransport.
+func ConfigureDefaultTransport() error {
+	transport, err := NewTransport()
+	if err != nil {
+		return err
+	}
+	http.DefaultTransport = transport
+	defaultRoundTripper = transport
+	return nil
+}
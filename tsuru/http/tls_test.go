@@ -0,0 +1,81 @@
+// Copyright 2026 tsuru-client authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) TestNewTransportWithoutCAFile(c *check.C) {
+	os.Unsetenv("TSURU_CA_FILE")
+	transport, err := NewTransport()
+	c.Assert(err, check.IsNil)
+	if transport.TLSClientConfig != nil {
+		c.Assert(transport.TLSClientConfig.RootCAs, check.IsNil)
+	}
+}
+
+func (s *S) TestNewTransportWithCAFile(c *check.C) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	caFile := filepath.Join(c.MkDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	err := os.WriteFile(caFile, data, 0600)
+	c.Assert(err, check.IsNil)
+	transport, err := NewTransport()
+	c.Assert(err, check.IsNil)
+	_, err = (&http.Client{Transport: transport}).Get(server.URL)
+	c.Assert(err, check.ErrorMatches, ".*certificate.*")
+	os.Setenv("TSURU_CA_FILE", caFile)
+	defer os.Unsetenv("TSURU_CA_FILE")
+	transport, err = NewTransport()
+	c.Assert(err, check.IsNil)
+	c.Assert(transport.TLSClientConfig, check.NotNil)
+	c.Assert(transport.TLSClientConfig.RootCAs, check.NotNil)
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	c.Assert(err, check.IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, check.Equals, http.StatusOK)
+}
+
+func (s *S) TestNewTransportInvalidCAFile(c *check.C) {
+	caFile := filepath.Join(c.MkDir(), "ca.pem")
+	err := os.WriteFile(caFile, []byte("not a certificate"), 0600)
+	c.Assert(err, check.IsNil)
+	os.Setenv("TSURU_CA_FILE", caFile)
+	defer os.Unsetenv("TSURU_CA_FILE")
+	_, err = NewTransport()
+	c.Assert(err, check.ErrorMatches, `no certificates found in the CA file ".*ca.pem"`)
+	os.Setenv("TSURU_CA_FILE", filepath.Join(c.MkDir(), "missing.pem"))
+	_, err = NewTransport()
+	c.Assert(err, check.ErrorMatches, "unable to read the CA file from TSURU_CA_FILE: .*")
+}
+
+func (s *S) TestTLSConfig(c *check.C) {
+	os.Unsetenv("TSURU_CA_FILE")
+	config, err := TLSConfig()
+	c.Assert(err, check.IsNil)
+	c.Assert(config, check.IsNil)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	caFile := filepath.Join(c.MkDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	err = os.WriteFile(caFile, data, 0600)
+	c.Assert(err, check.IsNil)
+	os.Setenv("TSURU_CA_FILE", caFile)
+	defer os.Unsetenv("TSURU_CA_FILE")
+	config, err = TLSConfig()
+	c.Assert(err, check.IsNil)
+	conn, err := tls.Dial("tcp", server.Listener.Addr().String(), config)
+	c.Assert(err, check.IsNil)
+	conn.Close()
+}
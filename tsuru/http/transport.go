@@ -72,6 +72,14 @@ func (v *TerminalRoundTripper) RoundTrip(req *http.Request) (*http.Response, err
 	req.Header.Set("User-Agent", fmt.Sprintf("tsuru-client/%s", v.CurrentVersion))
 	req.Close = true
 
+	if IsDryRun() && isMutatingRequest(req) {
+		fmt.Fprintln(v.Stdout, "Dry run, the following request would be sent:")
+		if err := dumpDryRunRequest(v.Stdout, req); err != nil {
+			return nil, err
+		}
+		return nil, ErrDryRun
+	}
+
 	if verbosity >= TerminalClientOnlyRequest {
 		fmt.Fprintf(v.Stdout, "*************************** <Request uri=%q> **********************************\n", req.URL.RequestURI())
 		requestDump, err := httputil.DumpRequest(req, true)
@@ -85,7 +93,7 @@ func (v *TerminalRoundTripper) RoundTrip(req *http.Request) (*http.Response, err
 		fmt.Fprintf(v.Stdout, "*************************** </Request uri=%q> **********************************\n", req.URL.RequestURI())
 	}
 
-	response, err := roundTripper.RoundTrip(req)
+	response, err := withTimeout(roundTripper, req)
 	if verbosity >= TerminalClientVerbose && response != nil {
 		fmt.Fprintf(v.Stdout, "*************************** <Response uri=%q> **********************************\n", req.URL.RequestURI())
 		responseDump, errDump := httputil.DumpResponse(response, true)
@@ -119,13 +127,32 @@ func (v *TerminalRoundTripper) RoundTrip(req *http.Request) (*http.Response, err
 		if len(body) > 0 {
 			err.Message = string(body)
 		}
+		if IsRawError() {
+			fmt.Fprintf(v.Stderr, "Raw error response for %s %s:\nHTTP/%d.%d %s\n", req.Method, req.URL.RequestURI(), response.ProtoMajor, response.ProtoMinor, response.Status)
+			v.Stderr.Write(body)
+			if len(body) > 0 && body[len(body)-1] != '\n' {
+				fmt.Fprintln(v.Stderr)
+			}
+		}
 
 		return nil, err
 	}
 
+	if isMutatingRequest(req) {
+		InvalidateCache()
+	}
+	recordEvent(req, response)
 	return response, err
 }
 
+// IsRawError reports whether the raw error mode is enabled, through the global
+// --raw-error flag or the TSURU_RAW_ERROR environment variable. In this mode,
+// the full body of error responses is written to stderr.
+func IsRawError() bool {
+	v, _ := strconv.ParseBool(os.Getenv("TSURU_RAW_ERROR"))
+	return v
+}
+
 func detectClientError(err error) error {
 	if err == nil {
 		return nil
@@ -136,6 +163,8 @@ func detectClientError(err error) error {
 		switch e := e.(type) {
 		case *tsuruerr.HTTP:
 			return errors.Wrapf(e, "Error received from tsuru server (%s), %d", target, e.Code)
+		case *timeoutError:
+			return errors.Wrapf(e, "Failed to get a response from tsuru server (%s)", target)
 		case x509.UnknownAuthorityError:
 			return errors.Wrapf(e, "Failed to connect to tsuru server (%s)", target)
 		}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/tsuru/tsuru/cmd/cmdtest"
 	check "gopkg.in/check.v1"
@@ -69,3 +70,160 @@ func (s *S) TestVerboseRoundTripperDumpRequestResponse2(c *check.C) {
 		"*************************** </Response uri=\"/users\"> **********************************\n")
 
 }
+
+func (s *S) TestTerminalRoundTripperDryRun(c *check.C) {
+	os.Setenv("TSURU_DRY_RUN", "true")
+	defer os.Unsetenv("TSURU_DRY_RUN")
+	out := new(bytes.Buffer)
+	called := false
+	r := TerminalRoundTripper{
+		Stdout: out,
+		RoundTripper: &cmdtest.ConditionalTransport{
+			Transport: cmdtest.Transport{Status: http.StatusOK},
+			CondFunc: func(*http.Request) bool {
+				called = true
+				return true
+			},
+		},
+	}
+	body := strings.NewReader("Envs.0.Name=DATABASE_PASSWORD&Envs.0.Value=s3cr3t&NoRestart=false")
+	req, err := http.NewRequest(http.MethodPost, "http://localhost/1.0/apps/myapp/env", body)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	_, err = r.RoundTrip(req)
+	c.Assert(err, check.Equals, ErrDryRun)
+	c.Assert(called, check.Equals, false)
+	c.Assert(out.String(), check.Equals, `Dry run, the following request would be sent:
+POST /1.0/apps/myapp/env
+Body:
+  Envs.0.Name=DATABASE_PASSWORD
+  Envs.0.Value=*****
+  NoRestart=false
+`)
+}
+
+func (s *S) TestTerminalRoundTripperDryRunJSON(c *check.C) {
+	os.Setenv("TSURU_DRY_RUN", "true")
+	defer os.Unsetenv("TSURU_DRY_RUN")
+	out := new(bytes.Buffer)
+	r := TerminalRoundTripper{
+		Stdout:       out,
+		RoundTripper: &cmdtest.Transport{Status: http.StatusOK},
+	}
+	body := strings.NewReader(`{"email": "me@example.com", "password": "123456"}`)
+	req, err := http.NewRequest(http.MethodPost, "http://localhost/1.0/users", body)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/json")
+	_, err = r.RoundTrip(req)
+	c.Assert(err, check.Equals, ErrDryRun)
+	c.Assert(out.String(), check.Equals, `Dry run, the following request would be sent:
+POST /1.0/users
+Body:
+{
+  "email": "me@example.com",
+  "password": "*****"
+}
+`)
+}
+
+func (s *S) TestTerminalRoundTripperDryRunSendsReadOnlyRequests(c *check.C) {
+	os.Setenv("TSURU_DRY_RUN", "true")
+	defer os.Unsetenv("TSURU_DRY_RUN")
+	out := new(bytes.Buffer)
+	r := TerminalRoundTripper{
+		Stdout:       out,
+		RoundTripper: &cmdtest.Transport{Message: "Success!", Status: http.StatusOK},
+	}
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/1.0/apps", nil)
+	c.Assert(err, check.IsNil)
+	resp, err := r.RoundTrip(req)
+	c.Assert(err, check.IsNil)
+	c.Assert(resp.StatusCode, check.Equals, http.StatusOK)
+	c.Assert(out.String(), check.Equals, "")
+}
+
+func (s *S) TestTerminalRoundTripperRawError(c *check.C) {
+	os.Setenv("TSURU_RAW_ERROR", "true")
+	defer os.Unsetenv("TSURU_RAW_ERROR")
+	stderr := new(bytes.Buffer)
+	r := TerminalRoundTripper{
+		Stdout:         new(bytes.Buffer),
+		Stderr:         stderr,
+		CurrentVersion: "1.0.0",
+		RoundTripper: &cmdtest.Transport{
+			Message: `{"Message": "app not found", "trace": "abc"}`,
+			Status:  http.StatusNotFound,
+		},
+	}
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/apps/myapp", nil)
+	c.Assert(err, check.IsNil)
+	_, err = r.RoundTrip(req)
+	c.Assert(err, check.NotNil)
+	c.Assert(stderr.String(), check.Equals, "Raw error response for GET /apps/myapp:\n"+
+		"HTTP/0.0 404 Not Found\n"+
+		`{"Message": "app not found", "trace": "abc"}`+"\n")
+}
+
+func (s *S) TestTerminalRoundTripperNoRawError(c *check.C) {
+	stderr := new(bytes.Buffer)
+	r := TerminalRoundTripper{
+		Stdout:         new(bytes.Buffer),
+		Stderr:         stderr,
+		CurrentVersion: "1.0.0",
+		RoundTripper:   &cmdtest.Transport{Message: "not found", Status: http.StatusNotFound},
+	}
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/apps/myapp", nil)
+	c.Assert(err, check.IsNil)
+	_, err = r.RoundTrip(req)
+	c.Assert(err, check.NotNil)
+	c.Assert(stderr.String(), check.Equals, "")
+}
+
+func (s *S) TestTerminalRoundTripperRecordsEvents(c *check.C) {
+	ResetSentEvents()
+	defer ResetSentEvents()
+	r := TerminalRoundTripper{
+		Stdout:         new(bytes.Buffer),
+		Stderr:         new(bytes.Buffer),
+		CurrentVersion: "1.0.0",
+		RoundTripper: &cmdtest.Transport{
+			Message: "ok",
+			Status:  http.StatusOK,
+			Headers: map[string][]string{"X-Tsuru-Eventid": {"64f1a2"}},
+		},
+	}
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/1.0/apps/myapp", nil)
+	c.Assert(err, check.IsNil)
+	_, err = r.RoundTrip(req)
+	c.Assert(err, check.IsNil)
+	eventIDs, mutating := SentEvents()
+	c.Assert(eventIDs, check.HasLen, 0)
+	c.Assert(mutating, check.Equals, false)
+	for i := 0; i < 2; i++ {
+		req, err = http.NewRequest(http.MethodPost, "http://localhost/1.0/apps/myapp/restart", nil)
+		c.Assert(err, check.IsNil)
+		_, err = r.RoundTrip(req)
+		c.Assert(err, check.IsNil)
+	}
+	eventIDs, mutating = SentEvents()
+	c.Assert(eventIDs, check.DeepEquals, []string{"64f1a2"})
+	c.Assert(mutating, check.Equals, true)
+}
+
+func (s *S) TestTerminalRoundTripperRecordsRequestsWithoutEvent(c *check.C) {
+	ResetSentEvents()
+	defer ResetSentEvents()
+	r := TerminalRoundTripper{
+		Stdout:         new(bytes.Buffer),
+		Stderr:         new(bytes.Buffer),
+		CurrentVersion: "1.0.0",
+		RoundTripper:   &cmdtest.Transport{Message: "ok", Status: http.StatusOK},
+	}
+	req, err := http.NewRequest(http.MethodDelete, "http://localhost/1.0/apps/myapp", nil)
+	c.Assert(err, check.IsNil)
+	_, err = r.RoundTrip(req)
+	c.Assert(err, check.IsNil)
+	eventIDs, mutating := SentEvents()
+	c.Assert(eventIDs, check.HasLen, 0)
+	c.Assert(mutating, check.Equals, true)
+}
@@ -10,9 +10,13 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cezarsa/form"
 	"github.com/pkg/errors"
+	"github.com/tsuru/gnuflag"
 	goTsuruClient "github.com/tsuru/go-tsuruclient/pkg/client"
 	"github.com/tsuru/go-tsuruclient/pkg/config"
 
@@ -108,12 +112,17 @@ func buildManagerCustom(name string, stdout, stderr io.Writer) *cmd.Manager {
 	m.Register(&config.TargetAdd{})
 	m.Register(&config.TargetRemove{})
 	m.Register(&config.TargetSet{})
+	m.Register(&client.TargetSwitch{})
 	m.RegisterTopic("target", targetTopic)
 
 	m.Register(&client.AppRun{})
 	m.Register(&client.AppInfo{})
 	m.Register(&client.AppCreate{})
+	m.Register(&client.AppUse{})
+	m.Register(&client.AppBootstrap{})
 	m.Register(&client.AppRemove{})
+	m.Register(&client.AppUnlock{})
+	m.Register(&client.AppSwap{})
 	m.Register(&client.AppUpdate{})
 	m.Register(&client.AppProcessUpdate{})
 	m.Register(&client.UnitAdd{})
@@ -127,6 +136,10 @@ func buildManagerCustom(name string, stdout, stderr io.Writer) *cmd.Manager {
 	m.Register(&client.AppRestart{})
 	m.Register(&client.AppStart{})
 	m.Register(&client.AppStop{})
+	m.Register(&client.AppVerify{})
+	m.Register(&client.AppMetrics{})
+	m.Register(&client.AppMove{})
+	m.Register(&client.AppPlatformCheck{})
 	m.Register(&client.Init{})
 	m.Register(&client.CertificateSet{})
 	m.Register(&client.CertificateUnset{})
@@ -135,9 +148,15 @@ func buildManagerCustom(name string, stdout, stderr io.Writer) *cmd.Manager {
 	m.Register(&client.CertificateIssuerUnset{})
 	m.Register(&client.CnameAdd{})
 	m.Register(&client.CnameRemove{})
+	m.Register(&client.CnameBackup{})
+	m.Register(&client.CnameRestore{})
+	m.Register(&client.CnameConflicts{})
 	m.Register(&client.EnvGet{})
 	m.Register(&client.EnvSet{})
 	m.Register(&client.EnvUnset{})
+	m.Register(&client.EnvDiff{})
+	m.Register(&client.EnvAudit{})
+	m.Register(&client.EnvEffective{})
 	m.RegisterTopic("service", `A service is a well-defined API that tsuru communicates with to provide extra functionality for applications.
 Examples of services are MySQL, Redis, MongoDB, etc. tsuru has built-in services, but it is easy to create and add new services to tsuru.
 Services aren’t managed by tsuru, but by their creators.`)
@@ -151,6 +170,7 @@ Services aren’t managed by tsuru, but by their creators.`)
 	m.Register(&client.ServiceInstanceRevoke{})
 	m.Register(&client.ServiceInstanceBind{})
 	m.Register(&client.ServiceInstanceUnbind{})
+	m.Register(&client.ServiceInstanceUnbindAll{})
 
 	m.RegisterTopic("platform", `A platform is a well-defined pack with installed dependencies for a language or framework that a group of applications will need. A platform might be a container template (Docker image).`)
 	m.Register(&admin.PlatformList{})
@@ -176,6 +196,7 @@ Services aren’t managed by tsuru, but by their creators.`)
 	m.Register(&client.AppDeploy{})
 	m.Register(&client.AppBuild{})
 	m.Register(&client.PlanList{})
+	m.Register(&client.AppPlanHistory{})
 	m.Register(&client.UserCreate{})
 	m.Register(&client.ResetPassword{})
 	m.Register(&client.UserRemove{})
@@ -191,12 +212,19 @@ Services aren’t managed by tsuru, but by their creators.`)
 	m.Register(&client.ShowAPIToken{})
 	m.Register(&client.RegenerateAPIToken{})
 	m.Register(&client.AppDeployList{})
+	m.Register(&client.AppDeployInfo{})
+	m.Register(&client.AppRolloutWatch{})
 	m.Register(&client.AppDeployRollback{})
 	m.Register(&client.AppDeployRollbackUpdate{})
 	m.Register(&client.AppDeployRebuild{})
 	m.Register(&client.ShellToContainerCmd{})
 	m.Register(&client.PoolList{})
+	m.Register(&client.PoolGrant{})
+	m.Register(&client.PoolUnits{})
+	m.Register(&client.PoolRestart{})
+	m.Register(&client.PoolInfo{})
 	m.Register(&client.PermissionList{})
+	m.Register(&client.PermissionExplain{})
 	m.Register(&client.RoleAdd{})
 	m.Register(&client.RoleUpdate{})
 	m.Register(&client.RoleRemove{})
@@ -211,6 +239,8 @@ Services aren’t managed by tsuru, but by their creators.`)
 	m.Register(&client.RoleDefaultRemove{})
 	m.Register(&admin.AddPoolToSchedulerCmd{})
 	m.Register(&client.EventList{})
+	m.Register(&client.EventsList{})
+	m.Register(&client.AppEvents{})
 	m.Register(&client.EventInfo{})
 	m.Register(&client.EventCancel{})
 	m.Register(&client.RoutersList{})
@@ -284,6 +314,15 @@ Services aren’t managed by tsuru, but by their creators.`)
 	registerExtraCommands(m)
 	m.RetryHook = retryHook
 	m.AfterFlagParseHook = initAuthorization
+	if tsuruHTTP.IsDryRun() {
+		wrapDryRun(m)
+	}
+	if os.Getenv("TSURU_ERROR_FORMAT") == "json" {
+		wrapJSONErrors(m, stderr, retryHook)
+	}
+	if tsuruHTTP.IsShowEvent() {
+		wrapShowEvent(m, name, stderr)
+	}
 	return m
 }
 
@@ -296,12 +335,57 @@ func registerExtraCommands(m *cmd.Manager) {
 func recoverCmdPanicExitError() {
 	if r := recover(); r != nil {
 		if e, ok := r.(*cmd.PanicExitError); ok {
-			os.Exit(e.Code)
+			os.Exit(exitCode(e.Code, commandError))
 		}
 		panic(r)
 	}
 }
 
+// Exit codes used when a command fails with an error from the tsuru API, so
+// scripts can tell the failures apart without parsing stderr. Commands that
+// run a command in the units, like app-run, exit with the exit code of that
+// command instead, which may be one of these codes too. Other errors keep the
+// exit code 1.
+const (
+	exitCodeNotFound     = 4 // 404 Not Found
+	exitCodeUnauthorized = 5 // 401 Unauthorized and 403 Forbidden
+	exitCodeConflict     = 6 // 409 Conflict
+	exitCodeServerError  = 7 // 5xx server errors
+)
+
+// commandError is the error returned by the last command run, used to choose
+// the exit code of the client.
+var commandError error
+
+// exitCode returns the exit code of the client, given the code the manager
+// exits with and the error of the command. Commands that run a command in the
+// units, like app-run, exit with the exit code of that command. Other errors
+// keep the code of the manager.
+func exitCode(code int, err error) int {
+	if code == 0 || err == nil {
+		return code
+	}
+	var exitCoder interface{ ExitCode() int }
+	if errors.As(err, &exitCoder) {
+		return exitCoder.ExitCode()
+	}
+	httpErr, ok := tsuruHTTP.UnwrapErr(err).(*tsuruErrors.HTTP)
+	if !ok {
+		return code
+	}
+	switch status := httpErr.StatusCode(); {
+	case status == http.StatusNotFound:
+		return exitCodeNotFound
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return exitCodeUnauthorized
+	case status == http.StatusConflict:
+		return exitCodeConflict
+	case status >= http.StatusInternalServerError:
+		return exitCodeServerError
+	}
+	return code
+}
+
 func main() {
 	defer recoverCmdPanicExitError()
 	defer config.SaveChangesWithTimeout()
@@ -311,12 +395,283 @@ func main() {
 
 	name := cmd.ExtractProgramName(os.Args[0])
 
+	args := extractGlobalFlags(os.Args[1:])
 	m := buildManager(name)
-	m.Run(os.Args[1:])
+	recordCommandErrors(m)
+	m.Run(args)
+}
+
+type globalFlag struct {
+	env string
+	// commands lists the commands that have a flag with the same name, for
+	// them the global flag is only recognized before the command name.
+	commands []string
+}
+
+func (f globalFlag) definedBy(command string) bool {
+	for _, name := range f.commands {
+		if command == name || strings.HasPrefix(command, name+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// globalFlags are the flags accepted by every command, with the environment
+// variable each of them enables.
+var globalFlags = map[string]globalFlag{
+	"--dry-run":    {env: "TSURU_DRY_RUN"},
+	"--raw-error":  {env: "TSURU_RAW_ERROR"},
+	"--no-cache":   {env: "TSURU_NO_CACHE", commands: []string{"service-broker-update"}},
+	"--show-event": {env: "TSURU_SHOW_EVENT"},
+}
+
+type globalValueFlag struct {
+	globalFlag
+	valid func(value string) bool
+}
+
+// globalValueFlags are the global flags that take a value, like "--retries 3"
+// or "--retries=3", with the environment variable that holds it. Invalid
+// values are left for the command to report.
+var globalValueFlags = map[string]globalValueFlag{
+	"--retries": {globalFlag: globalFlag{env: "TSURU_RETRIES"}, valid: func(value string) bool {
+		_, err := strconv.ParseUint(value, 10, 32)
+		return err == nil
+	}},
+	"--error-format": {globalFlag: globalFlag{env: "TSURU_ERROR_FORMAT"}, valid: func(value string) bool {
+		return value == "text" || value == "json"
+	}},
+	"--timeout": {globalFlag: globalFlag{env: "TSURU_TIMEOUT", commands: []string{"app-move", "app-rollout-watch"}}, valid: func(value string) bool {
+		d, err := time.ParseDuration(value)
+		return err == nil && d >= 0
+	}},
+	// --target accepts a target label or an API address. Labels resolve to
+	// their address, and the token saved for them by login, the same way
+	// TSURU_TARGET does.
+	"--target": {globalFlag: globalFlag{env: "TSURU_TARGET", commands: []string{"event-list", "events-list", "event-block-add"}}, valid: func(value string) bool {
+		return value != "" && !strings.HasPrefix(value, "-")
+	}},
+}
+
+// extractGlobalFlags removes the global flags, like --dry-run, from args and
+// enables them through their environment variables. Arguments after "--" are
+// left untouched.
+func extractGlobalFlags(args []string) []string {
+	result := make([]string, 0, len(args))
+	var command []string
+	inCommand := true
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(result, args[i:]...)
+		}
+		if flag, ok := globalFlags[arg]; ok && !flag.definedBy(strings.Join(command, "-")) {
+			os.Setenv(flag.env, "true")
+			continue
+		}
+		name, value, hasValue := strings.Cut(arg, "=")
+		if flag, ok := globalValueFlags[name]; ok && !flag.definedBy(strings.Join(command, "-")) {
+			if !hasValue && i+1 < len(args) {
+				value = args[i+1]
+			}
+			if flag.valid(value) {
+				os.Setenv(flag.env, value)
+				if !hasValue {
+					i++
+				}
+				continue
+			}
+		}
+		if inCommand && strings.HasPrefix(arg, "-") {
+			inCommand = false
+		} else if inCommand {
+			command = append(command, arg)
+		}
+		result = append(result, arg)
+	}
+	return result
+}
+
+// genericErrorCode is the code of errors that don't come from an HTTP
+// response in the JSON error format.
+const genericErrorCode = 1
+
+type jsonError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// jsonErrorCommand reports the errors of the command as JSON, in the format
+// enabled by --error-format json. The error is written to stderr and
+// cmd.ErrAbortCommand is returned, so the manager still exits with an error
+// status without printing it again.
+type jsonErrorCommand struct {
+	cmd.Command
+	stderr io.Writer
+	retry  func(err error) bool
+}
+
+func (c *jsonErrorCommand) Run(context *cmd.Context) error {
+	err := c.Command.Run(context)
+	if err != nil && c.retry(err) {
+		err = c.Command.Run(context)
+	}
+	if err == nil || err == cmd.ErrAbortCommand {
+		return err
+	}
+	commandError = err
+	writeJSONError(c.stderr, err)
+	return cmd.ErrAbortCommand
+}
+
+// errorRecorderCommand keeps the error of the command in commandError.
+// Errors reported as JSON are kept by jsonErrorCommand, which returns
+// cmd.ErrAbortCommand in their place.
+type errorRecorderCommand struct {
+	cmd.Command
+}
+
+func (c *errorRecorderCommand) Run(context *cmd.Context) error {
+	err := c.Command.Run(context)
+	if err != cmd.ErrAbortCommand {
+		commandError = err
+	}
+	return err
+}
+
+// dryRunCommand makes the command succeed when it stops at the first request
+// that would change something, as --dry-run doesn't send these requests, so
+// scripts can tell a dry run apart from a failure.
+type dryRunCommand struct {
+	cmd.Command
+}
+
+func (c *dryRunCommand) Run(context *cmd.Context) error {
+	err := c.Command.Run(context)
+	if err != nil && tsuruHTTP.UnwrapErr(err) == tsuruHTTP.ErrDryRun {
+		return nil
+	}
+	return err
+}
+
+// showEventCommand writes to stderr the ids of the events created by a
+// successful command, as enabled by --show-event. Commands that only read
+// from the API don't create events and write nothing.
+type showEventCommand struct {
+	cmd.Command
+	name   string
+	stderr io.Writer
+}
+
+func (c *showEventCommand) Run(context *cmd.Context) error {
+	tsuruHTTP.ResetSentEvents()
+	err := c.Command.Run(context)
+	if err != nil {
+		return err
+	}
+	eventIDs, mutating := tsuruHTTP.SentEvents()
+	for _, id := range eventIDs {
+		fmt.Fprintf(c.stderr, "Event: %s (use \"%s event info %s\" to inspect it)\n", id, c.name, id)
+	}
+	if len(eventIDs) == 0 && mutating {
+		fmt.Fprintf(c.stderr, "The tsuru API didn't return the event of this command, use \"%s event list\" to find it.\n", c.name)
+	}
+	return nil
+}
+
+type flaggedCommand struct {
+	cmd.Command
+	flagged cmd.FlaggedCommand
+}
+
+func (c *flaggedCommand) Flags() *gnuflag.FlagSet {
+	return c.flagged.Flags()
+}
+
+type cancelableCommand struct {
+	*flaggedCommand
+	cancelable cmd.Cancelable
+}
+
+func (c *cancelableCommand) Cancel(context cmd.Context) error {
+	return c.cancelable.Cancel(context)
+}
+
+// wrapCommands replaces the commands of the manager by the ones returned by
+// wrap, keeping the flags and the cancellation of each command. Deprecated
+// names are kept as such, with the command they run wrapped.
+func wrapCommands(m *cmd.Manager, wrap func(command cmd.Command) cmd.Command) {
+	for name, command := range m.Commands {
+		if deprecated, ok := command.(*cmd.DeprecatedCommand); ok {
+			deprecated.Command = wrapCommand(deprecated.Command, wrap)
+			continue
+		}
+		m.Commands[name] = wrapCommand(command, wrap)
+	}
+}
+
+func wrapCommand(command cmd.Command, wrap func(command cmd.Command) cmd.Command) cmd.Command {
+	wrapped := wrap(command)
+	flagged, ok := command.(cmd.FlaggedCommand)
+	if !ok {
+		return wrapped
+	}
+	withFlags := &flaggedCommand{Command: wrapped, flagged: flagged}
+	if cancelable, ok := command.(cmd.Cancelable); ok {
+		return &cancelableCommand{flaggedCommand: withFlags, cancelable: cancelable}
+	}
+	return withFlags
+}
+
+// wrapDryRun makes all commands of the manager succeed when they are stopped
+// by the dry run mode.
+func wrapDryRun(m *cmd.Manager) {
+	wrapCommands(m, func(command cmd.Command) cmd.Command {
+		return &dryRunCommand{Command: command}
+	})
+}
+
+// wrapJSONErrors makes all commands of the manager report their errors as
+// JSON.
+func wrapJSONErrors(m *cmd.Manager, stderr io.Writer, retry func(err error) bool) {
+	wrapCommands(m, func(command cmd.Command) cmd.Command {
+		return &jsonErrorCommand{Command: command, stderr: stderr, retry: retry}
+	})
+}
+
+// wrapShowEvent makes all commands of the manager display the ids of the
+// events they create.
+func wrapShowEvent(m *cmd.Manager, name string, stderr io.Writer) {
+	wrapCommands(m, func(command cmd.Command) cmd.Command {
+		return &showEventCommand{Command: command, name: name, stderr: stderr}
+	})
+}
+
+// recordCommandErrors makes all commands of the manager keep their errors in
+// commandError, to choose the exit code of the client.
+func recordCommandErrors(m *cmd.Manager) {
+	wrapCommands(m, func(command cmd.Command) cmd.Command {
+		return &errorRecorderCommand{Command: command}
+	})
+}
+
+func writeJSONError(w io.Writer, err error) {
+	result := jsonError{Code: genericErrorCode, Message: err.Error()}
+	if httpErr, ok := tsuruHTTP.UnwrapErr(err).(*tsuruErrors.HTTP); ok {
+		result = jsonError{Code: httpErr.Code, Message: httpErr.Message}
+	}
+	result.Message = strings.TrimSpace(result.Message)
+	data, _ := json.Marshal(result)
+	fmt.Fprintf(w, "%s\n", data)
 }
 
 func initAuthorization() {
 	name := cmd.ExtractProgramName(os.Args[0])
+	if err := tsuruHTTP.ConfigureDefaultTransport(); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not configure the HTTP transport: %s\n", err)
+		os.Exit(1)
+	}
 	roundTripper, tokenProvider, err := goTsuruClient.RoundTripperAndTokenProvider()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Could not read token V2: %q\n", err.Error())
@@ -6,7 +6,9 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -22,6 +24,8 @@ import (
 	"github.com/tsuru/tsuru-client/tsuru/client"
 	tsuruHTTP "github.com/tsuru/tsuru-client/tsuru/http"
 	"github.com/tsuru/tsuru/cmd"
+	"github.com/tsuru/tsuru/cmd/cmdtest"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
 	"github.com/tsuru/tsuru/exec/exectest"
 )
 
@@ -455,6 +459,7 @@ The following commands are available in the "target" topic:
   target list          Displays the list of targets, marking the current
   target remove        Remove a target from target-list (tsuru server)
   target set           Change current target (tsuru server)
+  target switch        Change current target, checking that it responds first
 
 Use tsuru help <commandname> to get more information about a command.
 `, targetTopic)
@@ -597,3 +602,314 @@ func (s *S) TestVersionAPIInvalidURL(c *check.C) {
 
 	c.Assert(stdout, check.Matches, "Client version: dev.\n")
 }
+
+func (s *S) TestExtractDryRunFlag(c *check.C) {
+	defer os.Unsetenv("TSURU_DRY_RUN")
+	args := extractGlobalFlags([]string{"--dry-run", "env-set", "-a", "myapp", "FOO=bar"})
+	c.Assert(args, check.DeepEquals, []string{"env-set", "-a", "myapp", "FOO=bar"})
+	c.Assert(os.Getenv("TSURU_DRY_RUN"), check.Equals, "true")
+}
+
+func (s *S) TestExtractGlobalFlagsRawError(c *check.C) {
+	defer os.Unsetenv("TSURU_RAW_ERROR")
+	args := extractGlobalFlags([]string{"app-info", "--raw-error", "-a", "myapp"})
+	c.Assert(args, check.DeepEquals, []string{"app-info", "-a", "myapp"})
+	c.Assert(os.Getenv("TSURU_RAW_ERROR"), check.Equals, "true")
+}
+
+func (s *S) TestExtractGlobalFlagsRetries(c *check.C) {
+	defer os.Unsetenv("TSURU_RETRIES")
+	args := extractGlobalFlags([]string{"app-list", "--retries", "3", "-q"})
+	c.Assert(args, check.DeepEquals, []string{"app-list", "-q"})
+	c.Assert(os.Getenv("TSURU_RETRIES"), check.Equals, "3")
+	args = extractGlobalFlags([]string{"--retries=5", "pool-list"})
+	c.Assert(args, check.DeepEquals, []string{"pool-list"})
+	c.Assert(os.Getenv("TSURU_RETRIES"), check.Equals, "5")
+}
+
+func (s *S) TestExtractGlobalFlagsInvalidRetries(c *check.C) {
+	defer os.Unsetenv("TSURU_RETRIES")
+	args := extractGlobalFlags([]string{"app-list", "--retries", "many"})
+	c.Assert(args, check.DeepEquals, []string{"app-list", "--retries", "many"})
+	c.Assert(os.Getenv("TSURU_RETRIES"), check.Equals, "")
+}
+
+func (s *S) TestExtractDryRunFlagAfterDoubleDash(c *check.C) {
+	defer os.Unsetenv("TSURU_DRY_RUN")
+	args := extractGlobalFlags([]string{"app-run", "-a", "myapp", "--", "migrate", "--dry-run"})
+	c.Assert(args, check.DeepEquals, []string{"app-run", "-a", "myapp", "--", "migrate", "--dry-run"})
+	c.Assert(os.Getenv("TSURU_DRY_RUN"), check.Equals, "")
+}
+
+func (s *S) TestExtractGlobalFlagsErrorFormat(c *check.C) {
+	defer os.Unsetenv("TSURU_ERROR_FORMAT")
+	args := extractGlobalFlags([]string{"app-info", "--error-format", "json", "-a", "myapp"})
+	c.Assert(args, check.DeepEquals, []string{"app-info", "-a", "myapp"})
+	c.Assert(os.Getenv("TSURU_ERROR_FORMAT"), check.Equals, "json")
+	os.Unsetenv("TSURU_ERROR_FORMAT")
+	args = extractGlobalFlags([]string{"app-info", "--error-format=xml"})
+	c.Assert(args, check.DeepEquals, []string{"app-info", "--error-format=xml"})
+	c.Assert(os.Getenv("TSURU_ERROR_FORMAT"), check.Equals, "")
+}
+
+func (s *S) TestExitCode(c *check.C) {
+	tests := []struct {
+		code     int
+		err      error
+		expected int
+	}{
+		{0, nil, 0},
+		{1, nil, 1},
+		{2, errors.New("flag provided but not defined"), 2},
+		{1, errors.New("something went wrong"), 1},
+		{1, &tsuruErrors.HTTP{Code: http.StatusNotFound, Message: "App not found"}, exitCodeNotFound},
+		{1, &tsuruErrors.HTTP{Code: http.StatusUnauthorized}, exitCodeUnauthorized},
+		{1, &tsuruErrors.HTTP{Code: http.StatusForbidden}, exitCodeUnauthorized},
+		{1, &tsuruErrors.HTTP{Code: http.StatusConflict}, exitCodeConflict},
+		{1, &tsuruErrors.HTTP{Code: http.StatusBadGateway}, exitCodeServerError},
+		{1, &tsuruErrors.HTTP{Code: http.StatusBadRequest}, 1},
+		{1, fmt.Errorf("unable to get the app: %w", &tsuruErrors.HTTP{Code: http.StatusNotFound}), exitCodeNotFound},
+		{1, &remoteCommandError{code: 3}, 3},
+		{1, &remoteCommandError{code: exitCodeNotFound}, exitCodeNotFound},
+		{1, fmt.Errorf("unable to run the command: %w", &remoteCommandError{code: 3}), 3},
+		{0, &remoteCommandError{code: 3}, 0},
+	}
+	for _, tt := range tests {
+		c.Check(exitCode(tt.code, tt.err), check.Equals, tt.expected, check.Commentf("error %v", tt.err))
+	}
+}
+
+// remoteCommandError is an error with the exit code of a command run in the
+// units, like the ones returned by app-run.
+type remoteCommandError struct {
+	code int
+}
+
+func (e *remoteCommandError) Error() string {
+	return fmt.Sprintf("command terminated with exit code %d", e.code)
+}
+
+func (e *remoteCommandError) ExitCode() int {
+	return e.code
+}
+
+type failingCommand struct {
+	err error
+}
+
+func (c *failingCommand) Info() *cmd.Info {
+	return &cmd.Info{Name: "fail"}
+}
+
+func (c *failingCommand) Run(context *cmd.Context) error {
+	return c.err
+}
+
+func (s *S) TestRecordCommandErrors(c *check.C) {
+	defer func() { commandError = nil }()
+	runErr := &remoteCommandError{code: 3}
+	m := cmd.NewManagerPanicExiter("tsuru", &bytes.Buffer{}, &bytes.Buffer{}, os.Stdin, nil)
+	m.Register(&failingCommand{err: runErr})
+	m.RegisterDeprecated(&client.MetadataGet{}, "app-metadata-get")
+	recordCommandErrors(m)
+	err := m.Commands["fail"].Run(&cmd.Context{})
+	c.Assert(err, check.Equals, runErr)
+	c.Assert(commandError, check.Equals, runErr)
+	c.Assert(exitCode(1, commandError), check.Equals, 3)
+	_, ok := m.Commands["app-metadata-get"].(*cmd.DeprecatedCommand)
+	c.Assert(ok, check.Equals, true)
+	_, ok = m.Commands["app-metadata-get"].(cmd.FlaggedCommand)
+	c.Assert(ok, check.Equals, true)
+	_, ok = m.Commands["metadata-get"].(cmd.FlaggedCommand)
+	c.Assert(ok, check.Equals, true)
+}
+
+func (s *S) TestJSONErrorCommand(c *check.C) {
+	var stderr bytes.Buffer
+	noRetry := func(error) bool { return false }
+	command := &jsonErrorCommand{
+		Command: &failingCommand{err: &tsuruErrors.HTTP{Code: http.StatusForbidden, Message: "You don't have permission to do this action\n"}},
+		stderr:  &stderr,
+		retry:   noRetry,
+	}
+	err := command.Run(&cmd.Context{})
+	c.Assert(err, check.Equals, cmd.ErrAbortCommand)
+	c.Assert(stderr.String(), check.Equals, `{"code":403,"message":"You don't have permission to do this action"}`+"\n")
+	stderr.Reset()
+	command.Command = &failingCommand{err: fmt.Errorf("app %q not found", "myapp")}
+	err = command.Run(&cmd.Context{})
+	c.Assert(err, check.Equals, cmd.ErrAbortCommand)
+	c.Assert(stderr.String(), check.Equals, `{"code":1,"message":"app \"myapp\" not found"}`+"\n")
+	stderr.Reset()
+	command.Command = &failingCommand{}
+	err = command.Run(&cmd.Context{})
+	c.Assert(err, check.IsNil)
+	c.Assert(stderr.String(), check.Equals, "")
+}
+
+func (s *S) TestJSONErrorFormatKeepsCommandInterfaces(c *check.C) {
+	os.Setenv("TSURU_ERROR_FORMAT", "json")
+	defer os.Unsetenv("TSURU_ERROR_FORMAT")
+	manager = buildManager("tsuru")
+	_, ok := manager.Commands["version"].(cmd.FlaggedCommand)
+	c.Assert(ok, check.Equals, false)
+	_, ok = manager.Commands["app-info"].(cmd.FlaggedCommand)
+	c.Assert(ok, check.Equals, true)
+	_, ok = manager.Commands["app-info"].(cmd.Cancelable)
+	c.Assert(ok, check.Equals, false)
+	_, ok = manager.Commands["app-deploy"].(cmd.Cancelable)
+	c.Assert(ok, check.Equals, true)
+}
+
+func (s *S) TestRecordCommandErrorsWithJSONErrors(c *check.C) {
+	defer func() { commandError = nil }()
+	httpErr := &tsuruErrors.HTTP{Code: http.StatusForbidden, Message: "Forbidden"}
+	var stderr bytes.Buffer
+	m := cmd.NewManagerPanicExiter("tsuru", &bytes.Buffer{}, &stderr, os.Stdin, nil)
+	m.Register(&failingCommand{err: httpErr})
+	wrapJSONErrors(m, &stderr, func(error) bool { return false })
+	recordCommandErrors(m)
+	err := m.Commands["fail"].Run(&cmd.Context{})
+	c.Assert(err, check.Equals, cmd.ErrAbortCommand)
+	c.Assert(commandError, check.Equals, httpErr)
+	c.Assert(exitCode(1, commandError), check.Equals, exitCodeUnauthorized)
+}
+
+func (s *S) TestExtractGlobalFlagsTarget(c *check.C) {
+	defer os.Setenv("TSURU_TARGET", "http://localhost:8080")
+	os.Unsetenv("TSURU_TARGET")
+	args := extractGlobalFlags([]string{"app-list", "--target", "prod", "-q"})
+	c.Assert(args, check.DeepEquals, []string{"app-list", "-q"})
+	c.Assert(os.Getenv("TSURU_TARGET"), check.Equals, "prod")
+	args = extractGlobalFlags([]string{"--target=https://tsuru.example.com", "pool-list"})
+	c.Assert(args, check.DeepEquals, []string{"pool-list"})
+	c.Assert(os.Getenv("TSURU_TARGET"), check.Equals, "https://tsuru.example.com")
+}
+
+func (s *S) TestExtractGlobalFlagsTargetCommandFlag(c *check.C) {
+	defer os.Setenv("TSURU_TARGET", "http://localhost:8080")
+	os.Unsetenv("TSURU_TARGET")
+	args := extractGlobalFlags([]string{"event-list", "--target", "app"})
+	c.Assert(args, check.DeepEquals, []string{"event-list", "--target", "app"})
+	c.Assert(os.Getenv("TSURU_TARGET"), check.Equals, "")
+	args = extractGlobalFlags([]string{"--target", "staging", "event", "list", "--target", "app"})
+	c.Assert(args, check.DeepEquals, []string{"event", "list", "--target", "app"})
+	c.Assert(os.Getenv("TSURU_TARGET"), check.Equals, "staging")
+}
+
+func (s *S) TestExtractGlobalFlagsTimeout(c *check.C) {
+	defer os.Unsetenv("TSURU_TIMEOUT")
+	args := extractGlobalFlags([]string{"app-swap", "app1", "app2", "--timeout", "30s"})
+	c.Assert(args, check.DeepEquals, []string{"app-swap", "app1", "app2"})
+	c.Assert(os.Getenv("TSURU_TIMEOUT"), check.Equals, "30s")
+	args = extractGlobalFlags([]string{"app-list", "--timeout", "soon"})
+	c.Assert(args, check.DeepEquals, []string{"app-list", "--timeout", "soon"})
+	c.Assert(os.Getenv("TSURU_TIMEOUT"), check.Equals, "30s")
+}
+
+func (s *S) TestExtractGlobalFlagsTimeoutCommandFlag(c *check.C) {
+	defer os.Unsetenv("TSURU_TIMEOUT")
+	args := extractGlobalFlags([]string{"app", "move", "-a", "myapp", "--to", "pool2", "--timeout", "5m"})
+	c.Assert(args, check.DeepEquals, []string{"app", "move", "-a", "myapp", "--to", "pool2", "--timeout", "5m"})
+	c.Assert(os.Getenv("TSURU_TIMEOUT"), check.Equals, "")
+	args = extractGlobalFlags([]string{"--timeout=1m", "app-rollout-watch", "-a", "myapp", "--timeout", "20m"})
+	c.Assert(args, check.DeepEquals, []string{"app-rollout-watch", "-a", "myapp", "--timeout", "20m"})
+	c.Assert(os.Getenv("TSURU_TIMEOUT"), check.Equals, "1m")
+}
+
+func (s *S) TestExtractGlobalFlagsNoCache(c *check.C) {
+	defer os.Unsetenv("TSURU_NO_CACHE")
+	args := extractGlobalFlags([]string{"pool-list", "--no-cache"})
+	c.Assert(args, check.DeepEquals, []string{"pool-list"})
+	c.Assert(os.Getenv("TSURU_NO_CACHE"), check.Equals, "true")
+}
+
+func (s *S) TestExtractGlobalFlagsNoCacheDefinedByCommand(c *check.C) {
+	defer os.Unsetenv("TSURU_NO_CACHE")
+	args := extractGlobalFlags([]string{"service", "broker", "update", "mybroker", "http://broker", "--no-cache"})
+	c.Assert(args, check.DeepEquals, []string{"service", "broker", "update", "mybroker", "http://broker", "--no-cache"})
+	c.Assert(os.Getenv("TSURU_NO_CACHE"), check.Equals, "")
+	args = extractGlobalFlags([]string{"--no-cache", "service-broker-update", "mybroker", "http://broker"})
+	c.Assert(args, check.DeepEquals, []string{"service-broker-update", "mybroker", "http://broker"})
+	c.Assert(os.Getenv("TSURU_NO_CACHE"), check.Equals, "true")
+}
+
+func (s *S) TestExtractGlobalFlagsShowEvent(c *check.C) {
+	defer os.Unsetenv("TSURU_SHOW_EVENT")
+	args := extractGlobalFlags([]string{"app-restart", "-a", "myapp", "--show-event"})
+	c.Assert(args, check.DeepEquals, []string{"app-restart", "-a", "myapp"})
+	c.Assert(os.Getenv("TSURU_SHOW_EVENT"), check.Equals, "true")
+}
+
+// requestCommand sends a request with the given method through the client
+// transport, answered with the given event id.
+type requestCommand struct {
+	method  string
+	eventID string
+}
+
+func (c *requestCommand) Info() *cmd.Info {
+	return &cmd.Info{Name: "request"}
+}
+
+func (c *requestCommand) Run(context *cmd.Context) error {
+	transport := &cmdtest.Transport{Message: "ok", Status: http.StatusOK, Headers: map[string][]string{}}
+	if c.eventID != "" {
+		transport.Headers["X-Tsuru-Eventid"] = []string{c.eventID}
+	}
+	r := tsuruHTTP.TerminalRoundTripper{
+		RoundTripper:   transport,
+		Stdout:         context.Stdout,
+		Stderr:         context.Stderr,
+		CurrentVersion: "dev",
+	}
+	req, err := http.NewRequest(c.method, "http://localhost/1.0/apps/myapp", nil)
+	if err != nil {
+		return err
+	}
+	_, err = r.RoundTrip(req)
+	return err
+}
+
+func (s *S) TestShowEventCommand(c *check.C) {
+	defer tsuruHTTP.ResetSentEvents()
+	var stderr bytes.Buffer
+	command := &showEventCommand{
+		Command: &requestCommand{method: http.MethodPost, eventID: "64f1a2"},
+		name:    "tsuru",
+		stderr:  &stderr,
+	}
+	err := command.Run(&cmd.Context{Stdout: io.Discard, Stderr: io.Discard})
+	c.Assert(err, check.IsNil)
+	c.Assert(stderr.String(), check.Equals, "Event: 64f1a2 (use \"tsuru event info 64f1a2\" to inspect it)\n")
+	stderr.Reset()
+	command.Command = &requestCommand{method: http.MethodPost}
+	err = command.Run(&cmd.Context{Stdout: io.Discard, Stderr: io.Discard})
+	c.Assert(err, check.IsNil)
+	c.Assert(stderr.String(), check.Equals, "The tsuru API didn't return the event of this command, use \"tsuru event list\" to find it.\n")
+	stderr.Reset()
+	command.Command = &requestCommand{method: http.MethodGet, eventID: "64f1a2"}
+	err = command.Run(&cmd.Context{Stdout: io.Discard, Stderr: io.Discard})
+	c.Assert(err, check.IsNil)
+	c.Assert(stderr.String(), check.Equals, "")
+	command.Command = &failingCommand{err: errors.New("app not found")}
+	err = command.Run(&cmd.Context{Stdout: io.Discard, Stderr: io.Discard})
+	c.Assert(err, check.ErrorMatches, "app not found")
+	c.Assert(stderr.String(), check.Equals, "")
+}
+
+func (s *S) TestDryRunCommand(c *check.C) {
+	os.Setenv("TSURU_DRY_RUN", "true")
+	defer os.Unsetenv("TSURU_DRY_RUN")
+	var stdout bytes.Buffer
+	command := &dryRunCommand{Command: &requestCommand{method: http.MethodPost}}
+	err := command.Run(&cmd.Context{Stdout: &stdout, Stderr: io.Discard})
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Matches, "(?s)Dry run, the following request would be sent:\nPOST /1.0/apps/myapp\n.*")
+	command.Command = &failingCommand{err: fmt.Errorf("unable to update the app: %w", tsuruHTTP.ErrDryRun)}
+	err = command.Run(&cmd.Context{Stdout: io.Discard, Stderr: io.Discard})
+	c.Assert(err, check.IsNil)
+	command.Command = &failingCommand{err: errors.New("app not found")}
+	err = command.Run(&cmd.Context{Stdout: io.Discard, Stderr: io.Discard})
+	c.Assert(err, check.ErrorMatches, "app not found")
+}